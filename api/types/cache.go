@@ -1,5 +1,11 @@
 package types
 
+import (
+	"time"
+
+	"github.com/rulego/rulego/api/types/metrics"
+)
+
 // Cache defines the interface for cache storage
 // Provides key-value based storage and retrieval functionality with expiration support
 // Implementation classes must ensure thread safety
@@ -44,4 +50,126 @@ type Cache interface {
 	// Returns:
 	//   - map[string]interface{}: map of matching key-value pairs
 	GetByPrefix(prefix string) map[string]interface{}
+
+	// Incr atomically increments the integer value of a key by delta
+	// Parameters:
+	//   - key: cache key (string)
+	//   - delta: increment, may be negative (int64)
+	//   - ttl: time-to-live applied only when the key is created, empty means never expire
+	// Returns:
+	//   - int64: value after the increment
+	//   - error: ErrCacheWrongType if the existing value is not an integer
+	// Note: A missing or expired key is treated as 0, the ttl of an existing key is kept
+	Incr(key string, delta int64, ttl string) (int64, error)
+	// Decr atomically decrements the integer value of a key by delta
+	// Same as Incr(key, -delta, ttl)
+	Decr(key string, delta int64, ttl string) (int64, error)
+	// CompareAndSet atomically sets the value of a key only if its current value equals expected
+	// Parameters:
+	//   - key: cache key (string)
+	//   - expected: expected current value, nil means the key must not exist
+	//   - value: new value to store
+	//   - ttl: time-to-live of the new value, empty means never expire
+	// Returns:
+	//   - bool: true if the value was set
+	//   - error: returns error if ttl format is invalid
+	// Note: Values are equal if they are deeply equal or have the same string form,
+	// so 1 and "1" are equal. This keeps the result the same for caches storing strings
+	CompareAndSet(key string, expected, value interface{}, ttl string) (bool, error)
+	// SetIfAbsent atomically stores a key-value pair only if the key does not exist or has expired
+	// Returns:
+	//   - bool: true if the value was set
+	//   - error: returns error if ttl format is invalid
+	SetIfAbsent(key string, value interface{}, ttl string) (bool, error)
+	// Expire updates the time-to-live of an existing key
+	// Parameters:
+	//   - key: cache key (string)
+	//   - ttl: new time-to-live, empty or 0 removes the expiration
+	// Returns:
+	//   - bool: false if the key does not exist
+	//   - error: returns error if ttl format is invalid
+	Expire(key string, ttl string) (bool, error)
+	// TTL returns the remaining time-to-live of a key
+	// Returns:
+	//   - time.Duration: remaining time-to-live, 0 if the key never expires
+	//   - bool: false if the key does not exist
+	TTL(key string) (time.Duration, bool)
+
+	// LPush inserts values at the head of the list stored at key, creating the list if needed
+	// Returns the length of the list after the push, or ErrCacheWrongType if the key holds another type
+	LPush(key string, values ...interface{}) (int64, error)
+	// RPush appends values to the tail of the list stored at key, creating the list if needed
+	// Returns the length of the list after the push, or ErrCacheWrongType if the key holds another type
+	RPush(key string, values ...interface{}) (int64, error)
+	// LPop removes and returns the first element of the list, nil if the list is empty
+	// The key is deleted when its last element is removed
+	LPop(key string) (interface{}, error)
+	// RPop removes and returns the last element of the list, nil if the list is empty
+	// The key is deleted when its last element is removed
+	RPop(key string) (interface{}, error)
+	// LRange returns the elements of the list between start and stop, both inclusive
+	// Negative indexes count from the tail, -1 is the last element
+	LRange(key string, start, stop int64) ([]interface{}, error)
+	// LLen returns the length of the list, 0 if the key does not exist
+	LLen(key string) (int64, error)
+
+	// SAdd adds members to the set stored at key, creating the set if needed
+	// Returns the number of members that were added
+	SAdd(key string, members ...string) (int64, error)
+	// SRem removes members from the set stored at key
+	// Returns the number of members that were removed, the key is deleted when the set becomes empty
+	SRem(key string, members ...string) (int64, error)
+	// SMembers returns all members of the set, in no particular order
+	SMembers(key string) ([]string, error)
+	// SIsMember reports whether member belongs to the set stored at key
+	SIsMember(key string, member string) (bool, error)
+
+	// HSet sets a field of the hash stored at key, creating the hash if needed
+	HSet(key string, field string, value interface{}) error
+	// HGet returns the value of a field of the hash, nil if the key or field does not exist
+	HGet(key string, field string) (interface{}, error)
+	// HDel removes fields from the hash stored at key
+	// Returns the number of fields that were removed, the key is deleted when the hash becomes empty
+	HDel(key string, fields ...string) (int64, error)
+	// HGetAll returns all fields and values of the hash
+	HGetAll(key string) (map[string]interface{}, error)
+}
+
+// Cache event types
+const (
+	// CacheEventExpired is published when an item is removed because its ttl has expired
+	CacheEventExpired = "expired"
+	// CacheEventDeleted is published when an item is removed by Delete or DeleteByPrefix
+	CacheEventDeleted = "deleted"
+	// CacheEventEvicted is published when an item is removed because a size limit of the cache was exceeded
+	CacheEventEvicted = "evicted"
+)
+
+// CacheEvent describes an item removed from a cache
+type CacheEvent struct {
+	// Type is the event type, CacheEventExpired, CacheEventDeleted or CacheEventEvicted
+	Type string
+	// Key is the full key of the removed item
+	Key string
+	// Value is the value of the removed item
+	Value interface{}
+	// Ts is the event time in milliseconds
+	Ts int64
+}
+
+// CacheNotifier is an optional interface implemented by caches that can publish
+// expiry, delete and eviction events. Listeners are called synchronously after the item has
+// been removed and without holding any cache lock, so they must not block for long.
+type CacheNotifier interface {
+	// AddListener registers a listener and returns an id used to remove it
+	AddListener(listener func(event CacheEvent)) string
+	// RemoveListener removes the listener with the given id
+	RemoveListener(id string)
+}
+
+// CacheMetricsProvider is an optional interface implemented by caches that collect
+// hit, miss and eviction statistics
+type CacheMetricsProvider interface {
+	// Metrics returns the metrics of the cache
+	Metrics() *metrics.CacheMetrics
 }
@@ -54,4 +54,6 @@ var (
 	// ErrConcurrencyLimitReached is the error returned when the concurrency limit has been reached
 	ErrConcurrencyLimitReached = errors.New("concurrency limit reached")
 	ErrCacheNotInitialized     = errors.New("cache not initialized")
+	// ErrCacheWrongType is the error returned when an operation is applied to a cache value of the wrong type
+	ErrCacheWrongType = errors.New("cache value is of the wrong type")
 )
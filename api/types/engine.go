@@ -80,6 +80,9 @@ type RuleEngine interface {
 	RootRuleContext() RuleContext
 	// GetMetrics returns the metrics of the RuleEngine.
 	GetMetrics() *metrics.EngineMetrics
+	// GetCacheMetrics returns the metrics of the cache used by the RuleEngine,
+	// nil if the cache does not implement CacheMetricsProvider.
+	GetCacheMetrics() *metrics.CacheMetrics
 }
 
 // RuleEnginePool is an interface for a pool of rule engines.
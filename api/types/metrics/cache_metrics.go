@@ -0,0 +1,79 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"sync/atomic"
+)
+
+// CacheMetrics holds metrics for a cache.
+type CacheMetrics struct {
+	Entries   int64 // Number of entries currently stored, including expired entries not yet collected
+	Bytes     int64 // Estimated size of the stored entries in bytes, 0 if size accounting is disabled
+	Hits      int64 // Total number of lookups that found a live entry
+	Misses    int64 // Total number of lookups that found no live entry
+	Evictions int64 // Total number of entries evicted because a size limit was exceeded
+}
+
+// NewCacheMetrics creates a new instance of CacheMetrics.
+func NewCacheMetrics() *CacheMetrics {
+	return &CacheMetrics{}
+}
+
+// SetEntries sets the number of entries.
+func (m *CacheMetrics) SetEntries(n int64) {
+	atomic.StoreInt64(&m.Entries, n)
+}
+
+// SetBytes sets the estimated size of the entries.
+func (m *CacheMetrics) SetBytes(n int64) {
+	atomic.StoreInt64(&m.Bytes, n)
+}
+
+// IncrementHits increases the count of hits.
+func (m *CacheMetrics) IncrementHits() {
+	atomic.AddInt64(&m.Hits, 1)
+}
+
+// IncrementMisses increases the count of misses.
+func (m *CacheMetrics) IncrementMisses() {
+	atomic.AddInt64(&m.Misses, 1)
+}
+
+// IncrementEvictions increases the count of evictions.
+func (m *CacheMetrics) IncrementEvictions() {
+	atomic.AddInt64(&m.Evictions, 1)
+}
+
+// Get returns a copy of the current metrics.
+func (m *CacheMetrics) Get() CacheMetrics {
+	return CacheMetrics{
+		Entries:   atomic.LoadInt64(&m.Entries),
+		Bytes:     atomic.LoadInt64(&m.Bytes),
+		Hits:      atomic.LoadInt64(&m.Hits),
+		Misses:    atomic.LoadInt64(&m.Misses),
+		Evictions: atomic.LoadInt64(&m.Evictions),
+	}
+}
+
+// Reset resets the hit, miss and eviction counters to zero.
+// Entries and Bytes describe the current content and are not reset.
+func (m *CacheMetrics) Reset() {
+	atomic.StoreInt64(&m.Hits, 0)
+	atomic.StoreInt64(&m.Misses, 0)
+	atomic.StoreInt64(&m.Evictions, 0)
+}
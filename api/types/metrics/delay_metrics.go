@@ -0,0 +1,88 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metrics
+
+import (
+	"sync/atomic"
+)
+
+// DelayMetrics holds metrics for a persistent delay queue.
+type DelayMetrics struct {
+	Pending   int64 // Number of messages currently waiting to fire
+	Scheduled int64 // Total number of messages scheduled
+	Fired     int64 // Total number of messages fired
+	Cancelled int64 // Total number of messages cancelled
+	Rejected  int64 // Total number of messages rejected because the backlog is full
+	Recovered int64 // Total number of messages recovered from the store
+}
+
+// NewDelayMetrics creates a new instance of DelayMetrics.
+func NewDelayMetrics() *DelayMetrics {
+	return &DelayMetrics{}
+}
+
+// SetPending sets the number of pending messages.
+func (m *DelayMetrics) SetPending(n int64) {
+	atomic.StoreInt64(&m.Pending, n)
+}
+
+// IncrementScheduled increases the count of scheduled messages.
+func (m *DelayMetrics) IncrementScheduled() {
+	atomic.AddInt64(&m.Scheduled, 1)
+}
+
+// IncrementFired increases the count of fired messages.
+func (m *DelayMetrics) IncrementFired() {
+	atomic.AddInt64(&m.Fired, 1)
+}
+
+// AddCancelled increases the count of cancelled messages.
+func (m *DelayMetrics) AddCancelled(n int64) {
+	atomic.AddInt64(&m.Cancelled, n)
+}
+
+// IncrementRejected increases the count of rejected messages.
+func (m *DelayMetrics) IncrementRejected() {
+	atomic.AddInt64(&m.Rejected, 1)
+}
+
+// AddRecovered increases the count of recovered messages.
+func (m *DelayMetrics) AddRecovered(n int64) {
+	atomic.AddInt64(&m.Recovered, n)
+}
+
+// Get returns a copy of the current metrics.
+func (m *DelayMetrics) Get() DelayMetrics {
+	return DelayMetrics{
+		Pending:   atomic.LoadInt64(&m.Pending),
+		Scheduled: atomic.LoadInt64(&m.Scheduled),
+		Fired:     atomic.LoadInt64(&m.Fired),
+		Cancelled: atomic.LoadInt64(&m.Cancelled),
+		Rejected:  atomic.LoadInt64(&m.Rejected),
+		Recovered: atomic.LoadInt64(&m.Recovered),
+	}
+}
+
+// Reset resets all metrics to zero.
+func (m *DelayMetrics) Reset() {
+	atomic.StoreInt64(&m.Pending, 0)
+	atomic.StoreInt64(&m.Scheduled, 0)
+	atomic.StoreInt64(&m.Fired, 0)
+	atomic.StoreInt64(&m.Cancelled, 0)
+	atomic.StoreInt64(&m.Rejected, 0)
+	atomic.StoreInt64(&m.Recovered, 0)
+}
@@ -88,7 +88,9 @@ type PluginRegistry interface {
 type ComponentRegistry interface {
 	// Register adds a new component. If `node.Type()` already exists, it returns an 'already exists' error.
 	Register(node Node) error
-	// RegisterPlugin loads and registers a component from an external .so file using the plugin mechanism.
+	// RegisterPlugin loads and registers a component from an external .so file using the plugin mechanism,
+	// from a .wasm module using the pure Go WASM runtime (see components/wasm),
+	// or from an executable prefixed with rpc:// running as an out-of-process plugin (see components/rpcplugin).
 	// If `name` already exists or the component list provided by the plugin `node.Type()` exists, it returns an 'already exists' error.
 	RegisterPlugin(name string, file string) error
 	// Unregister removes a component or a batch of components by plugin name.
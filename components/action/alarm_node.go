@@ -0,0 +1,753 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+//规则链节点配置示例：
+//{
+//        "id": "s1",
+//        "type": "alarm",
+//        "name": "温度告警",
+//        "configuration": {
+//          "originator": "${metadata.deviceId}",
+//          "alarmType": "HighTemperature",
+//          "severity": "CRITICAL",
+//          "action": "auto",
+//          "raiseExpr": "msg.temperature > 50",
+//          "clearExpr": "msg.temperature < 45",
+//          "parentOriginator": "${metadata.gatewayId}"
+//        }
+//  }
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+	"github.com/gofrs/uuid/v5"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/json"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 告警关系类型，只有告警状态发生变化时才发送到 Created/Updated/Cleared 链
+const (
+	AlarmRelationCreated   = "Created"
+	AlarmRelationUpdated   = "Updated"
+	AlarmRelationCleared   = "Cleared"
+	AlarmRelationUnchanged = "Unchanged"
+)
+
+// 告警操作
+const (
+	// AlarmActionAuto 根据 raiseExpr/clearExpr 决定产生或者清除告警
+	AlarmActionAuto = "auto"
+	// AlarmActionRaise 产生告警，如果告警已经存在，更新告警级别
+	AlarmActionRaise = "raise"
+	// AlarmActionClear 清除告警
+	AlarmActionClear = "clear"
+	// AlarmActionAck 确认告警
+	AlarmActionAck = "ack"
+)
+
+// 告警状态
+const (
+	AlarmStatusActiveUnack  = "ACTIVE_UNACK"
+	AlarmStatusActiveAck    = "ACTIVE_ACK"
+	AlarmStatusClearedUnack = "CLEARED_UNACK"
+	AlarmStatusClearedAck   = "CLEARED_ACK"
+)
+
+// 告警消息元数据key
+const (
+	AlarmMetadataId         = "alarmId"
+	AlarmMetadataType       = "alarmType"
+	AlarmMetadataSeverity   = "alarmSeverity"
+	AlarmMetadataStatus     = "alarmStatus"
+	AlarmMetadataOriginator = "alarmOriginator"
+	AlarmMetadataPropagated = "alarmPropagated"
+)
+
+// AlarmSeverities 告警级别，从高到低
+var AlarmSeverities = []string{"CRITICAL", "MAJOR", "MINOR", "WARNING", "INDETERMINATE"}
+
+// AlarmStoreKeyPrefix 默认告警存储在缓存中的key前缀
+const AlarmStoreKeyPrefix = "alarm:"
+
+// alarmMaxRetries 多个节点实例并发修改同一个告警时的最大重试次数
+const alarmMaxRetries = 16
+
+// ErrAlarmConflict 并发修改告警冲突，超过最大重试次数
+var ErrAlarmConflict = errors.New("alarm was modified concurrently, retry limit exceeded")
+
+var alarmStores = struct {
+	sync.RWMutex
+	stores map[string]AlarmStore
+}{stores: make(map[string]AlarmStore)}
+
+// 注册节点
+func init() {
+	Registry.Add(&AlarmNode{})
+}
+
+// Alarm 告警
+type Alarm struct {
+	Id         string `json:"id"`
+	Originator string `json:"originator"`
+	Type       string `json:"type"`
+	Severity   string `json:"severity"`
+	//告警自身产生时的级别，不包含子告警传播的级别，只由子告警传播产生的告警为空
+	SelfSeverity string `json:"selfSeverity,omitempty"`
+	Status       string `json:"status"`
+	//告警开始时间
+	StartTs int64 `json:"startTs"`
+	//最后一次更新时间
+	EndTs   int64 `json:"endTs"`
+	AckTs   int64 `json:"ackTs,omitempty"`
+	ClearTs int64 `json:"clearTs,omitempty"`
+	//告警详情，最后一次产生告警的消息负荷
+	Details interface{} `json:"details,omitempty"`
+	//是否是子告警传播产生的告警
+	Propagated bool `json:"propagated,omitempty"`
+	//父告警key
+	Parent string `json:"parent,omitempty"`
+	//传播到该告警的子告警key列表
+	Children []string `json:"children,omitempty"`
+	//版本号，每次保存加1，用于并发修改检查
+	Version int64 `json:"version"`
+}
+
+// Key 告警key：originator:type
+func (a *Alarm) Key() string {
+	return AlarmKey(a.Originator, a.Type)
+}
+
+// IsActive 告警是否未清除
+func (a *Alarm) IsActive() bool {
+	return a.Status == AlarmStatusActiveUnack || a.Status == AlarmStatusActiveAck
+}
+
+// IsAcknowledged 告警是否已确认
+func (a *Alarm) IsAcknowledged() bool {
+	return a.Status == AlarmStatusActiveAck || a.Status == AlarmStatusClearedAck
+}
+
+// AlarmKey 告警key
+func AlarmKey(originator, alarmType string) string {
+	return originator + ":" + alarmType
+}
+
+// AlarmStore 告警存储，实现需要保证并发安全
+type AlarmStore interface {
+	// Get 获取告警，不存在返回nil
+	Get(key string) (*Alarm, error)
+	// CompareAndSave 存储中告警的版本号等于 version 时保存告警，version 为0表示告警不存在，
+	// 返回false表示告警已经被其他节点实例修改
+	CompareAndSave(alarm *Alarm, version int64) (bool, error)
+	// Delete 删除告警
+	Delete(key string) error
+}
+
+// RegisterAlarmStore 注册告警存储，节点通过 store 配置使用
+func RegisterAlarmStore(name string, store AlarmStore) {
+	alarmStores.Lock()
+	defer alarmStores.Unlock()
+	alarmStores.stores[name] = store
+}
+
+// UnregisterAlarmStore 删除告警存储
+func UnregisterAlarmStore(name string) {
+	alarmStores.Lock()
+	defer alarmStores.Unlock()
+	delete(alarmStores.stores, name)
+}
+
+func getAlarmStore(name string) (AlarmStore, bool) {
+	alarmStores.RLock()
+	defer alarmStores.RUnlock()
+	store, ok := alarmStores.stores[name]
+	return store, ok
+}
+
+// CacheAlarmStore 基于 types.Cache 的告警存储，告警以JSON格式保存，通过 CompareAndSet 实现并发修改检查
+type CacheAlarmStore struct {
+	Cache types.Cache
+	// ClearedTTL 已清除并且已确认的告警保留时间，为空则不过期
+	ClearedTTL string
+}
+
+// NewCacheAlarmStore 创建基于缓存的告警存储，已清除并且已确认的告警保留24小时
+func NewCacheAlarmStore(cache types.Cache) *CacheAlarmStore {
+	return &CacheAlarmStore{Cache: cache, ClearedTTL: "24h"}
+}
+
+func (s *CacheAlarmStore) Get(key string) (*Alarm, error) {
+	alarm, _, err := s.get(key)
+	return alarm, err
+}
+
+// get 获取告警以及缓存中的原始值
+func (s *CacheAlarmStore) get(key string) (*Alarm, interface{}, error) {
+	v := s.Cache.Get(AlarmStoreKeyPrefix + key)
+	if v == nil {
+		return nil, nil, nil
+	}
+	var alarm Alarm
+	if err := json.Unmarshal([]byte(str.ToString(v)), &alarm); err != nil {
+		return nil, v, err
+	}
+	return &alarm, v, nil
+}
+
+func (s *CacheAlarmStore) CompareAndSave(alarm *Alarm, version int64) (bool, error) {
+	current, raw, err := s.get(alarm.Key())
+	if err != nil {
+		return false, err
+	}
+	if (current == nil && version != 0) || (current != nil && current.Version != version) {
+		return false, nil
+	}
+	data, err := json.Marshal(alarm)
+	if err != nil {
+		return false, err
+	}
+	ttl := ""
+	if alarm.Status == AlarmStatusClearedAck && len(alarm.Children) == 0 {
+		ttl = s.ClearedTTL
+	}
+	//和读取时的原始值比较，期间被其他实例修改则失败
+	return s.Cache.CompareAndSet(AlarmStoreKeyPrefix+alarm.Key(), raw, string(data), ttl)
+}
+
+func (s *CacheAlarmStore) Delete(key string) error {
+	return s.Cache.Delete(AlarmStoreKeyPrefix + key)
+}
+
+// AlarmNodeConfiguration 节点配置
+type AlarmNodeConfiguration struct {
+	//告警发起者，通过 ${metadata.key} 从元数据变量中获取或者通过 ${msg.key} 从消息负荷中获取
+	Originator string
+	//告警类型，支持 ${} 变量
+	AlarmType string
+	//告警级别：CRITICAL/MAJOR/MINOR/WARNING/INDETERMINATE，支持 ${} 变量
+	Severity string
+	//告警操作：auto/raise/clear/ack，支持 ${} 变量
+	Action string
+	//auto 模式下产生告警的expr表达式，例如：msg.temperature > 50
+	RaiseExpr string
+	//auto 模式下清除告警的expr表达式，例如：msg.temperature < 45
+	ClearExpr string
+	//父告警发起者，不为空则把告警传播到父告警，例如：${metadata.gatewayId}
+	ParentOriginator string
+	//告警存储，通过 RegisterAlarmStore 注册。为空则使用全局缓存 types.Config.Cache
+	Store string
+}
+
+// AlarmNode 告警生命周期管理节点，按照发起者+告警类型管理告警的产生、级别更新、确认和清除，
+// 只有告警状态发生变化时发送到 `Created`/`Updated`/`Cleared` 链，否则发送到 `Unchanged` 链：
+//   - raise: 没有未清除的告警，产生新告警(Created)；告警已存在并且级别变化(Updated)
+//   - clear: 清除未清除的告警(Cleared)
+//   - ack: 确认未确认的告警(Updated)
+//
+// 输出消息负荷为告警JSON，并增加 alarmId/alarmType/alarmSeverity/alarmStatus/alarmOriginator 元数据。
+// 配置 parentOriginator 后，告警会传播到父发起者的同类型告警，父告警级别取自身级别和所有未清除子告警的最高级别，
+// 子告警级别变化或者清除时重新计算，只由子告警传播产生的父告警在所有子告警清除后清除，
+// 父告警的状态变化以独立的消息发送，元数据 alarmPropagated=true。
+// 告警通过版本号进行并发修改检查，共享同一个存储的多个节点实例可以同时修改同一个告警。
+type AlarmNode struct {
+	//节点配置
+	Config           AlarmNodeConfiguration
+	store            AlarmStore
+	raiseProgram     *vm.Program
+	clearProgram     *vm.Program
+	originatorTmpl   str.Template
+	alarmTypeTmpl    str.Template
+	severityTmpl     str.Template
+	actionTmpl       str.Template
+	parentOriginator str.Template
+	mu               sync.RWMutex
+}
+
+// Type 组件类型
+func (x *AlarmNode) Type() string {
+	return "alarm"
+}
+
+func (x *AlarmNode) New() types.Node {
+	return &AlarmNode{Config: AlarmNodeConfiguration{
+		Originator: "${metadata.deviceId}",
+		AlarmType:  "Alarm",
+		Severity:   "CRITICAL",
+		Action:     AlarmActionRaise,
+	}}
+}
+
+// Init 初始化
+func (x *AlarmNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(x.Config.Originator) == "" {
+		return errors.New("originator can not be empty")
+	}
+	if strings.TrimSpace(x.Config.AlarmType) == "" {
+		return errors.New("alarmType can not be empty")
+	}
+	if x.Config.Action == "" {
+		x.Config.Action = AlarmActionRaise
+	}
+	//常量告警级别在初始化时校验，清除和确认告警不使用告警级别
+	if !str.CheckHasVar(x.Config.Severity) && x.Config.Action != AlarmActionClear && x.Config.Action != AlarmActionAck {
+		if !str.Contains(AlarmSeverities, strings.ToUpper(x.Config.Severity)) {
+			return fmt.Errorf("invalid alarm severity:%s, must be one of %s", x.Config.Severity, strings.Join(AlarmSeverities, "/"))
+		}
+	}
+	x.store = nil
+	if x.Config.Store == "" {
+		if ruleConfig.Cache != nil {
+			x.store = NewCacheAlarmStore(ruleConfig.Cache)
+		}
+	} else if store, ok := getAlarmStore(x.Config.Store); ok {
+		x.store = store
+	} else {
+		return fmt.Errorf("alarm store:%s not found", x.Config.Store)
+	}
+	if x.Config.Action == AlarmActionAuto && x.Config.RaiseExpr == "" && x.Config.ClearExpr == "" {
+		return errors.New("raiseExpr and clearExpr can not both be empty in auto mode")
+	}
+	if x.Config.RaiseExpr != "" {
+		if x.raiseProgram, err = expr.Compile(x.Config.RaiseExpr, expr.AllowUndefinedVariables()); err != nil {
+			return err
+		}
+	}
+	if x.Config.ClearExpr != "" {
+		if x.clearProgram, err = expr.Compile(x.Config.ClearExpr, expr.AllowUndefinedVariables()); err != nil {
+			return err
+		}
+	}
+	x.originatorTmpl = str.NewTemplate(x.Config.Originator)
+	x.alarmTypeTmpl = str.NewTemplate(x.Config.AlarmType)
+	x.severityTmpl = str.NewTemplate(x.Config.Severity)
+	x.actionTmpl = str.NewTemplate(x.Config.Action)
+	x.parentOriginator = str.NewTemplate(x.Config.ParentOriginator)
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *AlarmNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	action := x.actionTmpl.Execute(evn)
+	if action == AlarmActionAuto {
+		var err error
+		if action, err = x.evalAction(ctx, msg); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	originator := x.originatorTmpl.Execute(evn)
+	alarmType := x.alarmTypeTmpl.Execute(evn)
+	now := time.Now().UnixMilli()
+
+	store, err := x.getStore(ctx)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	var severity, parent string
+	if action == AlarmActionRaise {
+		severity = strings.ToUpper(x.severityTmpl.Execute(evn))
+		if !str.Contains(AlarmSeverities, severity) {
+			ctx.TellFailure(msg, fmt.Errorf("invalid alarm severity:%s, must be one of %s", severity, strings.Join(AlarmSeverities, "/")))
+			return
+		}
+		if x.Config.ParentOriginator != "" {
+			if parentOriginator := x.parentOriginator.Execute(evn); parentOriginator != "" {
+				parent = AlarmKey(parentOriginator, alarmType)
+			}
+		}
+	}
+	var relationType string
+	var alarm *Alarm
+	for i := 0; ; i++ {
+		if i >= alarmMaxRetries {
+			err = ErrAlarmConflict
+			break
+		}
+		var current *Alarm
+		if current, err = store.Get(AlarmKey(originator, alarmType)); err != nil {
+			break
+		}
+		var version int64
+		if current != nil {
+			version = current.Version
+		}
+		switch action {
+		case AlarmActionRaise:
+			effective := severity
+			if current != nil && len(current.Children) > 0 {
+				childrenSeverity, err := x.childrenSeverity(store, current, nil)
+				if err != nil {
+					ctx.TellFailure(msg, err)
+					return
+				}
+				effective = highestSeverity(severity, childrenSeverity)
+			}
+			alarm, relationType = x.raise(current, originator, alarmType, severity, effective, parent, alarmDetails(msg), now)
+		case AlarmActionClear:
+			alarm, relationType = x.clear(current, now)
+		case AlarmActionAck:
+			alarm, relationType = x.ack(current, now)
+		case "":
+			//auto 模式下两个条件都不满足
+			alarm, relationType = current, AlarmRelationUnchanged
+		default:
+			ctx.TellFailure(msg, fmt.Errorf("unsupported alarm action:%s", action))
+			return
+		}
+		if relationType == AlarmRelationUnchanged {
+			break
+		}
+		var ok bool
+		if ok, err = x.save(store, alarm, version); err != nil || ok {
+			break
+		}
+	}
+	var propagated []alarmTransition
+	if err == nil && relationType != AlarmRelationUnchanged {
+		propagated, err = x.propagate(store, alarm, now)
+	}
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	if alarm == nil {
+		ctx.TellNext(msg, relationType)
+		return
+	}
+	ctx.TellNext(alarmMsg(msg, alarm, false), relationType)
+	for _, item := range propagated {
+		ctx.TellNext(alarmMsg(msg, item.alarm, true), item.relationType)
+	}
+}
+
+// Destroy 销毁
+func (x *AlarmNode) Destroy() {
+}
+
+// getStore 获取告警存储，没有配置时使用规则上下文的全局缓存
+func (x *AlarmNode) getStore(ctx types.RuleContext) (AlarmStore, error) {
+	x.mu.RLock()
+	store := x.store
+	x.mu.RUnlock()
+	if store != nil {
+		return store, nil
+	}
+	cache := ctx.GlobalCache()
+	if cache == nil {
+		return nil, types.ErrCacheNotInitialized
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.store == nil {
+		x.store = NewCacheAlarmStore(cache)
+	}
+	return x.store, nil
+}
+
+// Def 组件定义
+func (x *AlarmNode) Def() types.ComponentForm {
+	relationTypes := []string{AlarmRelationCreated, AlarmRelationUpdated, AlarmRelationCleared, AlarmRelationUnchanged, types.Failure}
+	return types.ComponentForm{
+		Type:          x.Type(),
+		RelationTypes: &relationTypes,
+	}
+}
+
+// alarmTransition 父告警的状态变化
+type alarmTransition struct {
+	alarm        *Alarm
+	relationType string
+}
+
+// evalAction auto 模式根据表达式决定操作，清除条件优先
+func (x *AlarmNode) evalAction(ctx types.RuleContext, msg types.RuleMsg) (string, error) {
+	evn := base.NodeUtils.GetEvn(ctx, msg)
+	if x.clearProgram != nil {
+		if out, err := vm.Run(x.clearProgram, evn); err != nil {
+			return "", err
+		} else if ok, _ := out.(bool); ok {
+			return AlarmActionClear, nil
+		}
+	}
+	if x.raiseProgram != nil {
+		if out, err := vm.Run(x.raiseProgram, evn); err != nil {
+			return "", err
+		} else if ok, _ := out.(bool); ok {
+			return AlarmActionRaise, nil
+		}
+	}
+	return "", nil
+}
+
+// raise 产生告警，severity 为告警自身级别，effective 为包含子告警的最终级别
+func (x *AlarmNode) raise(current *Alarm, originator, alarmType, severity, effective, parent string, details interface{}, now int64) (*Alarm, string) {
+	if current == nil || !current.IsActive() {
+		alarm := &Alarm{
+			Id:           newAlarmId(),
+			Originator:   originator,
+			Type:         alarmType,
+			Severity:     effective,
+			SelfSeverity: severity,
+			Status:       AlarmStatusActiveUnack,
+			StartTs:      now,
+			EndTs:        now,
+			Details:      details,
+			Parent:       parent,
+		}
+		//保留传播到该告警的子告警
+		if current != nil {
+			alarm.Children = current.Children
+		}
+		return alarm, AlarmRelationCreated
+	}
+	current.EndTs = now
+	current.Details = details
+	current.SelfSeverity = severity
+	if parent != "" {
+		current.Parent = parent
+	}
+	if current.Severity != effective {
+		current.Severity = effective
+		return current, AlarmRelationUpdated
+	}
+	return current, AlarmRelationUnchanged
+}
+
+func (x *AlarmNode) clear(current *Alarm, now int64) (*Alarm, string) {
+	if current == nil || !current.IsActive() {
+		return current, AlarmRelationUnchanged
+	}
+	current.ClearTs = now
+	current.EndTs = now
+	if current.IsAcknowledged() {
+		current.Status = AlarmStatusClearedAck
+	} else {
+		current.Status = AlarmStatusClearedUnack
+	}
+	return current, AlarmRelationCleared
+}
+
+func (x *AlarmNode) ack(current *Alarm, now int64) (*Alarm, string) {
+	if current == nil || current.IsAcknowledged() {
+		return current, AlarmRelationUnchanged
+	}
+	current.AckTs = now
+	if current.IsActive() {
+		current.Status = AlarmStatusActiveAck
+	} else {
+		current.Status = AlarmStatusClearedAck
+	}
+	return current, AlarmRelationUpdated
+}
+
+// save 告警版本号等于 version 时保存告警，返回false表示告警已经被修改
+func (x *AlarmNode) save(store AlarmStore, alarm *Alarm, version int64) (bool, error) {
+	alarm.Version = version + 1
+	return store.CompareAndSave(alarm, version)
+}
+
+// propagate 把子告警的变化传播到父告警，父告警被并发修改时重新读取后重试
+func (x *AlarmNode) propagate(store AlarmStore, child *Alarm, now int64) ([]alarmTransition, error) {
+	if child.Parent == "" {
+		return nil, nil
+	}
+	for i := 0; i < alarmMaxRetries; i++ {
+		parent, err := store.Get(child.Parent)
+		if err != nil {
+			return nil, err
+		}
+		var version int64
+		if parent != nil {
+			version = parent.Version
+		}
+		parent, relationType, err := x.propagateTo(store, parent, child, now)
+		if err != nil || parent == nil {
+			return nil, err
+		}
+		if ok, err := x.save(store, parent, version); err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+		if relationType == "" {
+			return nil, nil
+		}
+		return []alarmTransition{{alarm: parent, relationType: relationType}}, nil
+	}
+	return nil, ErrAlarmConflict
+}
+
+// propagateTo 计算子告警变化后的父告警，不需要保存返回nil
+func (x *AlarmNode) propagateTo(store AlarmStore, parent *Alarm, child *Alarm, now int64) (*Alarm, string, error) {
+	childKey := child.Key()
+	var relationType string
+	if child.IsActive() {
+		if parent == nil || !parent.IsActive() {
+			originator := strings.TrimSuffix(child.Parent, ":"+child.Type)
+			var children []string
+			if parent != nil {
+				children = parent.Children
+			}
+			parent = &Alarm{
+				Id:         newAlarmId(),
+				Originator: originator,
+				Type:       child.Type,
+				Severity:   child.Severity,
+				Status:     AlarmStatusActiveUnack,
+				StartTs:    now,
+				EndTs:      now,
+				Propagated: true,
+				Children:   children,
+			}
+			relationType = AlarmRelationCreated
+		}
+		if !containsString(parent.Children, childKey) {
+			parent.Children = append(parent.Children, childKey)
+		}
+	} else {
+		if parent == nil || !containsString(parent.Children, childKey) {
+			return nil, "", nil
+		}
+		parent.Children = removeString(parent.Children, childKey)
+		if len(parent.Children) == 0 && parent.IsActive() && parent.Propagated && parent.SelfSeverity == "" {
+			parent, relationType = x.clear(parent, now)
+			return parent, relationType, nil
+		}
+	}
+	if !parent.IsActive() {
+		return parent, relationType, nil
+	}
+	//按照自身级别和未清除的子告警重新计算父告警级别
+	childrenSeverity, err := x.childrenSeverity(store, parent, child)
+	if err != nil {
+		return nil, "", err
+	}
+	if severity := highestSeverity(parent.SelfSeverity, childrenSeverity); severity != "" && severity != parent.Severity {
+		parent.Severity = severity
+		parent.EndTs = now
+		if relationType == "" {
+			relationType = AlarmRelationUpdated
+		}
+	}
+	return parent, relationType, nil
+}
+
+// childrenSeverity 未清除的子告警中的最高级别，child 为刚修改的子告警，使用内存中的值
+func (x *AlarmNode) childrenSeverity(store AlarmStore, parent *Alarm, child *Alarm) (string, error) {
+	var severity string
+	for _, key := range parent.Children {
+		item := child
+		if item == nil || item.Key() != key {
+			var err error
+			if item, err = store.Get(key); err != nil {
+				return "", err
+			}
+		}
+		if item != nil && item.IsActive() {
+			severity = highestSeverity(severity, item.Severity)
+		}
+	}
+	return severity, nil
+}
+
+// alarmMsg 告警消息
+func alarmMsg(msg types.RuleMsg, alarm *Alarm, propagated bool) types.RuleMsg {
+	out := msg.Copy()
+	if data, err := json.Marshal(alarm); err == nil {
+		out.DataType = types.JSON
+		out.SetData(string(data))
+	}
+	out.Metadata.PutValue(AlarmMetadataId, alarm.Id)
+	out.Metadata.PutValue(AlarmMetadataType, alarm.Type)
+	out.Metadata.PutValue(AlarmMetadataSeverity, alarm.Severity)
+	out.Metadata.PutValue(AlarmMetadataStatus, alarm.Status)
+	out.Metadata.PutValue(AlarmMetadataOriginator, alarm.Originator)
+	if propagated {
+		out.Metadata.PutValue(AlarmMetadataPropagated, "true")
+	}
+	return out
+}
+
+// alarmDetails JSON类型的消息负荷转换成对象，其他类型作为字符串
+func alarmDetails(msg types.RuleMsg) interface{} {
+	if msg.DataType == types.JSON {
+		if data, err := msg.GetDataAsJson(); err == nil {
+			return data
+		}
+	}
+	return msg.GetData()
+}
+
+// severityRank 告警级别排序，越小级别越高，未知级别排在最后
+func severityRank(severity string) int {
+	for i, item := range AlarmSeverities {
+		if item == severity {
+			return i
+		}
+	}
+	return len(AlarmSeverities)
+}
+
+// highestSeverity 返回级别较高的一个，忽略空级别
+func highestSeverity(a, b string) string {
+	if a == "" {
+		return b
+	}
+	if b == "" || severityRank(a) <= severityRank(b) {
+		return a
+	}
+	return b
+}
+
+func newAlarmId() string {
+	id, _ := uuid.NewV4()
+	return id.String()
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(list []string, s string) []string {
+	var result []string
+	for _, item := range list {
+		if item != s {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,379 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/cache"
+	"github.com/rulego/rulego/utils/reflect"
+)
+
+// memoryAlarmStore 测试用告警存储
+type memoryAlarmStore struct {
+	mu     sync.Mutex
+	alarms map[string]Alarm
+}
+
+func (s *memoryAlarmStore) Get(key string) (*Alarm, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if alarm, ok := s.alarms[key]; ok {
+		return &alarm, nil
+	}
+	return nil, nil
+}
+
+func (s *memoryAlarmStore) CompareAndSave(alarm *Alarm, version int64) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, ok := s.alarms[alarm.Key()]; (ok && current.Version != version) || (!ok && version != 0) {
+		return false, nil
+	}
+	s.alarms[alarm.Key()] = *alarm
+	return true, nil
+}
+
+func (s *memoryAlarmStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.alarms, key)
+	return nil
+}
+
+func newAlarmMsg(deviceId, gatewayId, data string) types.RuleMsg {
+	metadata := types.NewMetadata()
+	metadata.PutValue("deviceId", deviceId)
+	if gatewayId != "" {
+		metadata.PutValue("gatewayId", gatewayId)
+	}
+	return types.NewMsg(0, "TELEMETRY", types.JSON, metadata, data)
+}
+
+func TestAlarmNode(t *testing.T) {
+	var targetNodeType = "alarm"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &AlarmNode{}, types.Configuration{
+			"originator": "${metadata.deviceId}",
+			"alarmType":  "Alarm",
+			"severity":   "CRITICAL",
+			"action":     AlarmActionRaise,
+		}, Registry)
+		form := reflect.GetComponentForm(&AlarmNode{})
+		assert.Equal(t, []string{AlarmRelationCreated, AlarmRelationUpdated, AlarmRelationCleared, AlarmRelationUnchanged, types.Failure}, *form.RelationTypes)
+	})
+
+	t.Run("InitNode", func(t *testing.T) {
+		test.NodeInit(t, targetNodeType, types.Configuration{
+			"alarmType": "HighTemperature",
+			"action":    AlarmActionAuto,
+			"raiseExpr": "msg.temperature > 50",
+		}, types.Configuration{
+			"alarmType": "HighTemperature",
+			"action":    AlarmActionAuto,
+			"raiseExpr": "msg.temperature > 50",
+		}, Registry)
+	})
+
+	t.Run("InitError", func(t *testing.T) {
+		for _, config := range []types.Configuration{
+			{"originator": ""},
+			{"alarmType": ""},
+			{"action": AlarmActionAuto},
+			{"action": AlarmActionAuto, "raiseExpr": "msg.temperature >"},
+			{"store": "notFound"},
+			{"severity": "HIGH"},
+		} {
+			_, err := test.CreateAndInitNode(targetNodeType, config, Registry)
+			assert.NotNil(t, err)
+		}
+	})
+
+	t.Run("InvalidSeverity", func(t *testing.T) {
+		config := types.NewConfig(types.WithCache(cache.NewMemoryCache(time.Minute)))
+		node := test.InitNodeByConfig(config, targetNodeType, types.Configuration{
+			"alarmType": "HighTemperature",
+			"severity":  "${metadata.severity}",
+		}, Registry)
+		assert.NotNil(t, node)
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(config, collector.callback)
+		msg := newAlarmMsg("d1", "", `{"temperature":60}`)
+		msg.Metadata.PutValue("severity", "high")
+		node.OnMsg(ctx, msg)
+		results := collector.get()
+		assert.Equal(t, 1, len(results))
+		assert.Equal(t, types.Failure, results[0].relationType)
+		assert.Equal(t, "invalid alarm severity:HIGH, must be one of CRITICAL/MAJOR/MINOR/WARNING/INDETERMINATE", results[0].err.Error())
+		alarm, err := NewCacheAlarmStore(config.Cache).Get(AlarmKey("d1", "HighTemperature"))
+		assert.Nil(t, err)
+		assert.Nil(t, alarm)
+	})
+
+	t.Run("Lifecycle", func(t *testing.T) {
+		config := types.NewConfig(types.WithCache(cache.NewMemoryCache(time.Minute)))
+		newNode := func(action, severity string) types.Node {
+			node := test.InitNodeByConfig(config, targetNodeType, types.Configuration{
+				"alarmType": "HighTemperature",
+				"severity":  severity,
+				"action":    action,
+			}, Registry)
+			assert.NotNil(t, node)
+			return node
+		}
+		raiseMajor := newNode(AlarmActionRaise, "MAJOR")
+		raiseCritical := newNode(AlarmActionRaise, "${metadata.severity}")
+		clear := newNode(AlarmActionClear, "")
+		ack := newNode(AlarmActionAck, "")
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(config, collector.callback)
+
+		raiseMajor.OnMsg(ctx, newAlarmMsg("d1", "", `{"temperature":60}`))
+		raiseMajor.OnMsg(ctx, newAlarmMsg("d1", "", `{"temperature":61}`))
+		msg := newAlarmMsg("d1", "", `{"temperature":80}`)
+		msg.Metadata.PutValue("severity", "critical")
+		raiseCritical.OnMsg(ctx, msg)
+		ack.OnMsg(ctx, newAlarmMsg("d1", "", `{}`))
+		ack.OnMsg(ctx, newAlarmMsg("d1", "", `{}`))
+		clear.OnMsg(ctx, newAlarmMsg("d1", "", `{}`))
+		clear.OnMsg(ctx, newAlarmMsg("d1", "", `{}`))
+		//清除后重新产生告警
+		raiseMajor.OnMsg(ctx, newAlarmMsg("d1", "", `{"temperature":70}`))
+
+		results := collector.get()
+		var relationTypes []string
+		for _, item := range results {
+			relationTypes = append(relationTypes, item.relationType)
+		}
+		assert.Equal(t, []string{
+			AlarmRelationCreated, AlarmRelationUnchanged, AlarmRelationUpdated,
+			AlarmRelationUpdated, AlarmRelationUnchanged,
+			AlarmRelationCleared, AlarmRelationUnchanged,
+			AlarmRelationCreated,
+		}, relationTypes)
+
+		first := results[0]
+		assert.Equal(t, "d1", first.msg.Metadata.GetValue(AlarmMetadataOriginator))
+		assert.Equal(t, "HighTemperature", first.msg.Metadata.GetValue(AlarmMetadataType))
+		assert.Equal(t, "MAJOR", first.msg.Metadata.GetValue(AlarmMetadataSeverity))
+		assert.Equal(t, AlarmStatusActiveUnack, first.data["status"])
+		assert.Equal(t, float64(60), first.data["details"].(map[string]interface{})["temperature"])
+
+		assert.Equal(t, "CRITICAL", results[2].data["severity"])
+		assert.Equal(t, first.data["id"], results[2].data["id"])
+		assert.Equal(t, AlarmStatusActiveAck, results[3].data["status"])
+		assert.Equal(t, AlarmStatusClearedAck, results[5].data["status"])
+		assert.True(t, results[7].data["id"] != first.data["id"])
+
+		//已清除并且已确认的告警被新告警覆盖，新告警保存在全局缓存
+		alarm, err := NewCacheAlarmStore(config.Cache).Get(AlarmKey("d1", "HighTemperature"))
+		assert.Nil(t, err)
+		assert.Equal(t, results[7].data["id"], alarm.Id)
+	})
+
+	t.Run("Auto", func(t *testing.T) {
+		store := &memoryAlarmStore{alarms: map[string]Alarm{}}
+		RegisterAlarmStore("test", store)
+		defer UnregisterAlarmStore("test")
+
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"alarmType": "HighTemperature",
+			"action":    AlarmActionAuto,
+			"raiseExpr": "msg.temperature > 50",
+			"clearExpr": "msg.temperature < 45",
+			"store":     "test",
+		}, Registry)
+		assert.Nil(t, err)
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		for _, data := range []string{`{"temperature":40}`, `{"temperature":55}`, `{"temperature":48}`, `{"temperature":56}`, `{"temperature":30}`} {
+			node.OnMsg(ctx, newAlarmMsg("d2", "", data))
+		}
+		var relationTypes []string
+		for _, item := range collector.get() {
+			relationTypes = append(relationTypes, item.relationType)
+		}
+		assert.Equal(t, []string{AlarmRelationUnchanged, AlarmRelationCreated, AlarmRelationUnchanged, AlarmRelationUnchanged, AlarmRelationCleared}, relationTypes)
+
+		alarm, _ := store.Get(AlarmKey("d2", "HighTemperature"))
+		assert.Equal(t, AlarmStatusClearedUnack, alarm.Status)
+	})
+
+	t.Run("Propagate", func(t *testing.T) {
+		store := &memoryAlarmStore{alarms: map[string]Alarm{}}
+		RegisterAlarmStore("propagate", store)
+		defer UnregisterAlarmStore("propagate")
+
+		newNode := func(action, severity string) types.Node {
+			node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+				"alarmType":        "Offline",
+				"severity":         severity,
+				"action":           action,
+				"parentOriginator": "${metadata.gatewayId}",
+				"store":            "propagate",
+			}, Registry)
+			assert.Nil(t, err)
+			return node
+		}
+		raiseMinor := newNode(AlarmActionRaise, "MINOR")
+		raiseMajor := newNode(AlarmActionRaise, "MAJOR")
+		clear := newNode(AlarmActionClear, "")
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+
+		raiseMinor.OnMsg(ctx, newAlarmMsg("d1", "g1", `{}`))
+		raiseMajor.OnMsg(ctx, newAlarmMsg("d2", "g1", `{}`))
+		clear.OnMsg(ctx, newAlarmMsg("d1", "", `{}`))
+		clear.OnMsg(ctx, newAlarmMsg("d2", "", `{}`))
+
+		type emitted struct {
+			originator   string
+			relationType string
+			propagated   string
+			severity     string
+		}
+		var actual []emitted
+		for _, item := range collector.get() {
+			actual = append(actual, emitted{
+				originator:   item.msg.Metadata.GetValue(AlarmMetadataOriginator),
+				relationType: item.relationType,
+				propagated:   item.msg.Metadata.GetValue(AlarmMetadataPropagated),
+				severity:     item.msg.Metadata.GetValue(AlarmMetadataSeverity),
+			})
+		}
+		assert.Equal(t, []emitted{
+			{"d1", AlarmRelationCreated, "", "MINOR"},
+			{"g1", AlarmRelationCreated, "true", "MINOR"},
+			{"d2", AlarmRelationCreated, "", "MAJOR"},
+			{"g1", AlarmRelationUpdated, "true", "MAJOR"},
+			{"d1", AlarmRelationCleared, "", "MINOR"},
+			{"d2", AlarmRelationCleared, "", "MAJOR"},
+			{"g1", AlarmRelationCleared, "true", "MAJOR"},
+		}, actual)
+	})
+
+	t.Run("PropagateSeverity", func(t *testing.T) {
+		store := &memoryAlarmStore{alarms: map[string]Alarm{}}
+		RegisterAlarmStore("propagateSeverity", store)
+		defer UnregisterAlarmStore("propagateSeverity")
+
+		newNode := func(action, parentOriginator string) types.Node {
+			node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+				"alarmType":        "Offline",
+				"severity":         "${metadata.severity}",
+				"action":           action,
+				"parentOriginator": parentOriginator,
+				"store":            "propagateSeverity",
+			}, Registry)
+			assert.Nil(t, err)
+			return node
+		}
+		raise := newNode(AlarmActionRaise, "${metadata.gatewayId}")
+		raiseGateway := newNode(AlarmActionRaise, "")
+		clear := newNode(AlarmActionClear, "")
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		onMsg := func(node types.Node, deviceId, gatewayId, severity string) {
+			msg := newAlarmMsg(deviceId, gatewayId, `{}`)
+			msg.Metadata.PutValue("severity", severity)
+			node.OnMsg(ctx, msg)
+		}
+		parentSeverity := func() string {
+			alarm, _ := store.Get(AlarmKey("g1", "Offline"))
+			return alarm.Severity
+		}
+
+		onMsg(raise, "d1", "g1", "CRITICAL")
+		onMsg(raise, "d2", "g1", "MINOR")
+		assert.Equal(t, "CRITICAL", parentSeverity())
+		//子告警降级，父告警级别重新计算
+		onMsg(raise, "d1", "g1", "MAJOR")
+		assert.Equal(t, "MAJOR", parentSeverity())
+		//级别最高的子告警清除，父告警取剩余子告警的最高级别
+		onMsg(clear, "d1", "", "")
+		assert.Equal(t, "MINOR", parentSeverity())
+
+		//父告警自身产生的级别参与计算，所有子告警清除后不清除父告警
+		onMsg(raiseGateway, "g1", "", "MAJOR")
+		assert.Equal(t, "MAJOR", parentSeverity())
+		onMsg(raise, "d2", "g1", "CRITICAL")
+		assert.Equal(t, "CRITICAL", parentSeverity())
+		onMsg(clear, "d2", "", "")
+		assert.Equal(t, "MAJOR", parentSeverity())
+		alarm, _ := store.Get(AlarmKey("g1", "Offline"))
+		assert.True(t, alarm.IsActive())
+
+		var parentRelations []string
+		for _, item := range collector.get() {
+			if item.msg.Metadata.GetValue(AlarmMetadataPropagated) == "true" {
+				parentRelations = append(parentRelations, item.relationType+":"+item.msg.Metadata.GetValue(AlarmMetadataSeverity))
+			}
+		}
+		assert.Equal(t, []string{
+			"Created:CRITICAL", "Updated:MAJOR", "Updated:MINOR", "Updated:CRITICAL", "Updated:MAJOR",
+		}, parentRelations)
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		//两个节点实例共享同一个缓存，并发修改同一个父告警
+		config := types.NewConfig(types.WithCache(cache.NewMemoryCache(time.Minute)))
+		var nodes []types.Node
+		for i := 0; i < 2; i++ {
+			node := test.InitNodeByConfig(config, targetNodeType, types.Configuration{
+				"alarmType":        "Offline",
+				"severity":         "MINOR",
+				"action":           AlarmActionRaise,
+				"parentOriginator": "${metadata.gatewayId}",
+			}, Registry)
+			assert.NotNil(t, node)
+			nodes = append(nodes, node)
+		}
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(config, collector.callback)
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				nodes[i%2].OnMsg(ctx, newAlarmMsg(fmt.Sprintf("d%d", i), "g1", `{}`))
+			}(i)
+		}
+		wg.Wait()
+		parent, err := NewCacheAlarmStore(config.Cache).Get(AlarmKey("g1", "Offline"))
+		assert.Nil(t, err)
+		assert.Equal(t, 20, len(parent.Children))
+		var created int
+		for _, item := range collector.get() {
+			assert.True(t, item.relationType != types.Failure)
+			if item.msg.Metadata.GetValue(AlarmMetadataOriginator) == "g1" && item.relationType == AlarmRelationCreated {
+				created++
+			}
+		}
+		assert.Equal(t, 1, created)
+	})
+}
@@ -0,0 +1,333 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+//规则链节点配置示例：
+//{
+//        "id": "s1",
+//        "type": "batch",
+//        "name": "批量写入",
+//        "configuration": {
+//          "key": "${metadata.table}",
+//          "maxCount": 100,
+//          "maxBytes": 1048576,
+//          "interval": "1s"
+//        }
+//  }
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/json"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 批量消息元数据key
+const (
+	// BatchMetadataKey 批次分组key
+	BatchMetadataKey = "batchKey"
+	// BatchMetadataSize 批次消息数量
+	BatchMetadataSize = "batchSize"
+	// BatchMetadataItems 批次中每条消息的元数据，JSON数组，和消息负荷数组下标一一对应
+	BatchMetadataItems = "batchMetadata"
+)
+
+// 注册节点
+func init() {
+	Registry.Add(&BatchNode{})
+}
+
+// batchInstances 批量节点实例，key为 规则链ID:节点ID。规则链重新加载时新实例先初始化，
+// 旧实例销毁时把未满的批次移交给新实例，避免通过可能已经销毁的下游节点发送
+var batchInstances = struct {
+	sync.Mutex
+	nodes map[string][]*BatchNode
+}{nodes: make(map[string][]*BatchNode)}
+
+// BatchNodeConfiguration 节点配置
+type BatchNodeConfiguration struct {
+	//分组key，通过 ${metadata.key} 从元数据变量中获取或者通过 ${msg.key} 从消息负荷中获取，为空则所有消息一个批次
+	Key string
+	//每批最大消息数量，达到后立即发送，默认100
+	MaxCount int
+	//每批最大字节数(消息负荷长度之和)，加入下一条消息会超过该值时先发送当前批次，0表示不限制
+	MaxBytes int
+	//批次第一条消息到达后最长等待时间，超时后发送，例如：1s。为空表示不限制
+	Interval string
+	//是否在 batchMetadata 元数据中输出每条消息的元数据
+	IncludeMetadata bool
+}
+
+// BatchNode 批量节点，按照key把消息累积成批次，达到数量、字节数或者时间限制时，
+// 把批次中所有消息负荷合并成一个JSON数组通过成功链路(`Success`)发送，JSON类型的消息负荷作为对象，其他类型作为字符串。
+// 输出消息的元数据和消息类型取自批次最后一条消息，并增加 batchKey/batchSize 元数据，
+// 如果开启 includeMetadata，batchMetadata 元数据为每条消息元数据组成的JSON数组。
+// 进入批次的消息不会继续往下传递。规则链重新加载时未满的批次移交给新的节点实例继续累积，保留原来的超时时间，
+// 节点销毁时发送所有未满的批次。
+type BatchNode struct {
+	//节点配置
+	Config   BatchNodeConfiguration
+	interval time.Duration
+	batches  map[string]*batch
+	mu       sync.Mutex
+	//规则链ID:节点ID，为空则不移交批次
+	namespace string
+	destroyed bool
+}
+
+// batch 一个批次
+type batch struct {
+	key   string
+	msgs  []types.RuleMsg
+	bytes int
+	//批次第一条消息到达时间
+	start time.Time
+	//最后一条消息的上下文，用于发送批次
+	ctx   types.RuleContext
+	timer *time.Timer
+}
+
+// Type 组件类型
+func (x *BatchNode) Type() string {
+	return "batch"
+}
+
+func (x *BatchNode) New() types.Node {
+	return &BatchNode{Config: BatchNodeConfiguration{MaxCount: 100, Interval: "1s", IncludeMetadata: true}}
+}
+
+// Init 初始化
+func (x *BatchNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.Config.MaxCount <= 0 {
+		x.Config.MaxCount = 100
+	}
+	if x.Config.MaxBytes < 0 {
+		x.Config.MaxBytes = 0
+	}
+	x.interval = 0
+	if x.Config.Interval != "" {
+		if x.interval, err = time.ParseDuration(x.Config.Interval); err != nil {
+			return err
+		} else if x.interval < 0 {
+			return fmt.Errorf("interval must not be negative")
+		}
+	}
+	x.batches = make(map[string]*batch)
+	if chainCtx := base.NodeUtils.GetChainCtx(configuration); chainCtx != nil {
+		x.namespace = chainCtx.GetNodeId().Id + ":" + base.NodeUtils.GetSelfDefinition(configuration).Id
+		batchInstances.Lock()
+		batchInstances.nodes[x.namespace] = append(batchInstances.nodes[x.namespace], x)
+		batchInstances.Unlock()
+	}
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *BatchNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	key := ""
+	if x.Config.Key != "" {
+		key = str.ExecuteTemplate(x.Config.Key, base.NodeUtils.GetEvnAndMetadata(ctx, msg))
+	}
+	size := len(msg.GetData())
+	var full []*batch
+
+	x.mu.Lock()
+	b := x.batches[key]
+	//加入该消息会超过字节数限制，先发送当前批次
+	if b != nil && x.Config.MaxBytes > 0 && b.bytes+size > x.Config.MaxBytes {
+		full = append(full, x.remove(b))
+		b = nil
+	}
+	if b == nil {
+		b = &batch{key: key, start: time.Now()}
+		x.batches[key] = b
+		x.startTimer(b, x.interval)
+	}
+	b.msgs = append(b.msgs, msg)
+	b.bytes += size
+	b.ctx = ctx
+	if len(b.msgs) >= x.Config.MaxCount || (x.Config.MaxBytes > 0 && b.bytes >= x.Config.MaxBytes) {
+		full = append(full, x.remove(b))
+	}
+	x.mu.Unlock()
+
+	for _, item := range full {
+		x.emit(item)
+	}
+}
+
+// Destroy 销毁，未满的批次移交给重新加载后的新实例，没有新实例则发送所有未满的批次
+func (x *BatchNode) Destroy() {
+	x.mu.Lock()
+	x.destroyed = true
+	var pending []*batch
+	for _, b := range x.batches {
+		pending = append(pending, x.remove(b))
+	}
+	x.mu.Unlock()
+	if successor := x.unregister(); successor != nil {
+		successor.adopt(pending)
+		return
+	}
+	for _, b := range pending {
+		x.emit(b)
+	}
+}
+
+// flushBatch 超时发送批次
+func (x *BatchNode) flushBatch(b *batch) {
+	x.mu.Lock()
+	if x.batches[b.key] != b {
+		x.mu.Unlock()
+		return
+	}
+	x.remove(b)
+	x.mu.Unlock()
+	x.emit(b)
+}
+
+// startTimer 批次超时发送，interval为0表示不限制
+func (x *BatchNode) startTimer(b *batch, d time.Duration) {
+	if x.interval <= 0 {
+		return
+	}
+	if d < 0 {
+		d = 0
+	}
+	b.timer = time.AfterFunc(d, func() {
+		x.flushBatch(b)
+	})
+}
+
+// unregister 从实例列表移除，返回最后初始化的相同节点的实例
+func (x *BatchNode) unregister() *BatchNode {
+	if x.namespace == "" {
+		return nil
+	}
+	batchInstances.Lock()
+	defer batchInstances.Unlock()
+	nodes := batchInstances.nodes[x.namespace]
+	for i, node := range nodes {
+		if node == x {
+			nodes = append(nodes[:i:i], nodes[i+1:]...)
+			break
+		}
+	}
+	if len(nodes) == 0 {
+		delete(batchInstances.nodes, x.namespace)
+		return nil
+	}
+	batchInstances.nodes[x.namespace] = nodes
+	return nodes[len(nodes)-1]
+}
+
+// adopt 接收旧实例移交的批次，按照新实例的配置继续累积，超时时间从批次第一条消息到达开始计算。
+// 旧批次的上下文在规则链重新加载后路由到新的下游节点
+func (x *BatchNode) adopt(pending []*batch) {
+	var full []*batch
+	x.mu.Lock()
+	if x.destroyed {
+		x.mu.Unlock()
+		for _, b := range pending {
+			x.emit(b)
+		}
+		return
+	}
+	for _, old := range pending {
+		b := x.batches[old.key]
+		if b == nil {
+			b = &batch{key: old.key, msgs: old.msgs, bytes: old.bytes, start: old.start, ctx: old.ctx}
+			x.batches[b.key] = b
+			x.startTimer(b, x.interval-time.Since(b.start))
+		} else {
+			//新实例已经收到消息，旧消息放在前面，使用新的上下文
+			b.msgs = append(old.msgs, b.msgs...)
+			b.bytes += old.bytes
+			b.start = old.start
+			if b.timer != nil {
+				b.timer.Stop()
+			}
+			x.startTimer(b, x.interval-time.Since(b.start))
+		}
+		if len(b.msgs) >= x.Config.MaxCount || (x.Config.MaxBytes > 0 && b.bytes >= x.Config.MaxBytes) {
+			full = append(full, x.remove(b))
+		}
+	}
+	x.mu.Unlock()
+	for _, b := range full {
+		x.emit(b)
+	}
+}
+
+func (x *BatchNode) remove(b *batch) *batch {
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	if x.batches[b.key] == b {
+		delete(x.batches, b.key)
+	}
+	return b
+}
+
+// emit 合并批次中的消息并发送
+func (x *BatchNode) emit(b *batch) {
+	if len(b.msgs) == 0 || b.ctx == nil {
+		return
+	}
+	items := make([]interface{}, 0, len(b.msgs))
+	var metadataList []map[string]string
+	for _, msg := range b.msgs {
+		if msg.DataType == types.JSON {
+			var v interface{}
+			if err := json.Unmarshal([]byte(msg.GetData()), &v); err == nil {
+				items = append(items, v)
+			} else {
+				items = append(items, msg.GetData())
+			}
+		} else {
+			items = append(items, msg.GetData())
+		}
+		if x.Config.IncludeMetadata {
+			metadataList = append(metadataList, msg.Metadata.Values())
+		}
+	}
+	last := b.msgs[len(b.msgs)-1]
+	metadata := last.Metadata.Copy()
+	metadata.PutValue(BatchMetadataKey, b.key)
+	metadata.PutValue(BatchMetadataSize, strconv.Itoa(len(b.msgs)))
+	if x.Config.IncludeMetadata {
+		if v, err := json.Marshal(metadataList); err == nil {
+			metadata.PutValue(BatchMetadataItems, string(v))
+		}
+	}
+	data, err := json.Marshal(items)
+	out := types.NewMsg(0, last.Type, types.JSON, metadata, string(data))
+	if err != nil {
+		b.ctx.TellFailure(out, err)
+	} else {
+		b.ctx.TellSuccess(out)
+	}
+}
@@ -0,0 +1,145 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/json"
+)
+
+func TestBatchNode(t *testing.T) {
+	var targetNodeType = "batch"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &BatchNode{}, types.Configuration{
+			"maxCount":        100,
+			"interval":        "1s",
+			"includeMetadata": true,
+		}, Registry)
+	})
+
+	t.Run("InitNode", func(t *testing.T) {
+		test.NodeInit(t, targetNodeType, types.Configuration{
+			"key":      "${metadata.table}",
+			"maxCount": 0,
+			"maxBytes": -1,
+			"interval": "",
+		}, types.Configuration{
+			"key":      "${metadata.table}",
+			"maxCount": 100,
+			"maxBytes": 0,
+			"interval": "",
+		}, Registry)
+		_, err := test.CreateAndInitNode(targetNodeType, types.Configuration{"interval": "abc"}, Registry)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("MaxCount", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"key":      "${metadata.deviceId}",
+			"maxCount": 3,
+			"interval": "",
+		}, Registry)
+		assert.Nil(t, err)
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		for i := 1; i <= 4; i++ {
+			node.OnMsg(ctx, newWindowMsg(0, "dev1", fmt.Sprintf(`{"seq":%d}`, i)))
+		}
+		node.OnMsg(ctx, types.NewMsg(0, "LOG", types.TEXT, types.BuildMetadata(map[string]string{"deviceId": "dev2"}), "hello"))
+		results := collector.get()
+		assert.Equal(t, 1, len(results))
+		assert.Equal(t, types.Success, results[0].relationType)
+		assert.Equal(t, `[{"seq":1},{"seq":2},{"seq":3}]`, results[0].msg.GetData())
+		assert.Equal(t, types.JSON, results[0].msg.DataType)
+		assert.Equal(t, "TELEMETRY", results[0].msg.Type)
+		assert.Equal(t, "dev1", results[0].msg.Metadata.GetValue(BatchMetadataKey))
+		assert.Equal(t, "3", results[0].msg.Metadata.GetValue(BatchMetadataSize))
+		var metadataList []map[string]string
+		assert.Nil(t, json.Unmarshal([]byte(results[0].msg.Metadata.GetValue(BatchMetadataItems)), &metadataList))
+		assert.Equal(t, 3, len(metadataList))
+		assert.Equal(t, "dev1", metadataList[2]["deviceId"])
+
+		//销毁时发送未满的批次
+		node.Destroy()
+		results = collector.get()
+		assert.Equal(t, 3, len(results))
+		var dev1, dev2 types.RuleMsg
+		for _, item := range results[1:] {
+			if item.msg.Metadata.GetValue(BatchMetadataKey) == "dev1" {
+				dev1 = item.msg
+			} else {
+				dev2 = item.msg
+			}
+		}
+		assert.Equal(t, `[{"seq":4}]`, dev1.GetData())
+		assert.Equal(t, `["hello"]`, dev2.GetData())
+		assert.Equal(t, "LOG", dev2.Type)
+	})
+
+	t.Run("MaxBytes", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"maxBytes":        20,
+			"interval":        "",
+			"includeMetadata": false,
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		//每条消息10个字节
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"seq":11}`))
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"seq":12}`))
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"seq":13}`))
+		//超过限制的单条消息单独发送
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"seq":14,"data":"abcdefghijk"}`))
+		results := collector.get()
+		assert.Equal(t, 3, len(results))
+		assert.Equal(t, `[{"seq":11},{"seq":12}]`, results[0].msg.GetData())
+		assert.Equal(t, `[{"seq":13}]`, results[1].msg.GetData())
+		assert.Equal(t, `[{"data":"abcdefghijk","seq":14}]`, results[2].msg.GetData())
+		assert.Equal(t, "", results[0].msg.Metadata.GetValue(BatchMetadataItems))
+	})
+
+	t.Run("Interval", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"interval": "100ms",
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"seq":1}`))
+		node.OnMsg(ctx, newWindowMsg(0, "dev2", `{"seq":2}`))
+		assert.Equal(t, 0, len(collector.get()))
+		results := collector.wait(t, 1, time.Second)
+		assert.Equal(t, `[{"seq":1},{"seq":2}]`, results[0].msg.GetData())
+
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"seq":3}`))
+		results = collector.wait(t, 2, time.Second)
+		assert.Equal(t, `[{"seq":3}]`, results[1].msg.GetData())
+	})
+}
@@ -0,0 +1,457 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+//规则链节点配置示例：
+//{
+//        "id": "s1",
+//        "type": "cep",
+//        "name": "复杂事件处理",
+//        "configuration": {
+//          "key": "${metadata.deviceId}",
+//          "patterns": [
+//            {
+//              "name": "doorLeftOpen",
+//              "steps": [
+//                {"name": "open", "expr": "msg.door == 'open'"},
+//                {"name": "close", "expr": "msg.door == 'close'", "not": true}
+//              ],
+//              "within": "5m"
+//            },
+//            {
+//              "name": "threeFailures",
+//              "steps": [
+//                {"name": "fail", "expr": "msg.status == 'fail'", "count": 3}
+//              ],
+//              "within": "60s"
+//            }
+//          ]
+//        }
+//  }
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/json"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 匹配结果消息元数据key
+const (
+	CepMetadataPattern = "cepPattern"
+	CepMetadataKey     = "cepKey"
+)
+
+// 注册节点
+func init() {
+	Registry.Add(&CepNode{})
+}
+
+// CepStep 模式中的一个步骤
+type CepStep struct {
+	//步骤名称
+	Name string
+	//匹配事件的expr表达式，可以使用 msg/metadata/msgType/ts 等变量，例如：msg.status == 'fail'
+	Expr string
+	//需要匹配的事件数量，默认1
+	Count int
+	//否定步骤：在该步骤期间不能出现匹配的事件，出现则放弃本次匹配。
+	//否定步骤不能是第一个步骤，如果是最后一个步骤，必须配置 within，超时后没有出现匹配事件视为匹配成功
+	Not bool
+}
+
+// CepPattern 事件模式，按照顺序匹配步骤(sequence)，两个步骤之间可以出现其他不相关的事件
+type CepPattern struct {
+	//模式名称
+	Name string
+	//匹配结果消息类型，默认使用模式名称
+	MsgType string
+	//步骤列表
+	Steps []CepStep
+	//从第一个事件开始，必须在该时间内完成匹配，例如：5m、60s。为空表示不限制
+	Within string
+}
+
+// CepNodeConfiguration 节点配置
+type CepNodeConfiguration struct {
+	//分组key，通过 ${metadata.key} 从元数据变量中获取或者通过 ${msg.key} 从消息负荷中获取，每个key单独匹配
+	Key string
+	//模式列表
+	Patterns []CepPattern
+	//每个key每个模式最多同时进行的匹配数量，超过后丢弃最早的匹配，默认100
+	MaxRuns int
+}
+
+// CepNode 复杂事件处理节点，按照key检测事件序列，支持：
+//   - sequence: 按照步骤顺序匹配
+//   - within: 限制整个匹配的时间范围
+//   - not: 在该步骤期间没有出现指定事件，例如：开门后5分钟内没有关门
+//   - count: 步骤需要匹配多个事件，例如：60秒内出现3次失败
+//
+// 匹配成功后生成新的消息通过成功链路(`Success`)发送，并清空该key该模式所有进行中的匹配：
+// {"pattern":"threeFailures","key":"dev1","start":1700000000000,"end":1700000030000,"events":[{"step":"fail","ts":1700000000000,"data":{}}]}
+// 结果消息的元数据取自最后一个事件，并增加 cepPattern/cepKey 元数据。
+// 输入的事件消息不会继续往下传递，表达式执行失败的消息通过失败链路路由。
+type CepNode struct {
+	//节点配置
+	Config   CepNodeConfiguration
+	patterns []*cepPattern
+	//进行中的匹配，key：模式下标+分组key
+	runs     map[cepRunKey][]*cepRun
+	mu       sync.Mutex
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+type cepPattern struct {
+	name    string
+	msgType string
+	steps   []cepStep
+	within  time.Duration
+}
+
+type cepStep struct {
+	name    string
+	program *vm.Program
+	count   int
+	not     bool
+}
+
+type cepRunKey struct {
+	pattern int
+	key     string
+}
+
+// cepRun 一个进行中的匹配
+type cepRun struct {
+	//当前步骤
+	step int
+	//当前步骤已匹配的事件数量
+	matched  int
+	start    time.Time
+	events   []cepEvent
+	lastMsg  types.RuleMsg
+	ctx      types.RuleContext
+	finished bool
+}
+
+// cepEvent 匹配的事件
+type cepEvent struct {
+	Step string      `json:"step"`
+	Ts   int64       `json:"ts"`
+	Data interface{} `json:"data"`
+}
+
+// cepMatch 匹配结果
+type cepMatch struct {
+	pattern *cepPattern
+	key     string
+	run     *cepRun
+	end     int64
+}
+
+// Type 组件类型
+func (x *CepNode) Type() string {
+	return "cep"
+}
+
+func (x *CepNode) New() types.Node {
+	return &CepNode{Config: CepNodeConfiguration{MaxRuns: 100}}
+}
+
+// Init 初始化
+func (x *CepNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	if err := maps.Map2Struct(configuration, &x.Config); err != nil {
+		return err
+	}
+	if x.Config.MaxRuns <= 0 {
+		x.Config.MaxRuns = 100
+	}
+	if len(x.Config.Patterns) == 0 {
+		return fmt.Errorf("patterns can not be empty")
+	}
+	x.patterns = nil
+	var minWithin time.Duration
+	for _, item := range x.Config.Patterns {
+		p, err := compileCepPattern(item)
+		if err != nil {
+			return err
+		}
+		x.patterns = append(x.patterns, p)
+		if p.within > 0 && (minWithin == 0 || p.within < minWithin) {
+			minWithin = p.within
+		}
+	}
+	x.runs = make(map[cepRunKey][]*cepRun)
+	if minWithin > 0 {
+		x.stop = make(chan struct{})
+		go x.sweep(minWithin)
+	}
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *CepNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	key := ""
+	if x.Config.Key != "" {
+		key = str.ExecuteTemplate(x.Config.Key, base.NodeUtils.GetEvnAndMetadata(ctx, msg))
+	}
+	evn := base.NodeUtils.GetEvn(ctx, msg)
+	//每个步骤的表达式结果
+	results := make([][]bool, len(x.patterns))
+	for i, p := range x.patterns {
+		results[i] = make([]bool, len(p.steps))
+		for j, step := range p.steps {
+			out, err := vm.Run(step.program, evn)
+			if err != nil {
+				ctx.TellFailure(msg, fmt.Errorf("pattern:%s step:%s error:%s", p.name, step.name, err.Error()))
+				return
+			}
+			results[i][j], _ = out.(bool)
+		}
+	}
+	event := cepEvent{Ts: msg.Ts, Data: cepEventData(msg)}
+	now := time.Now()
+
+	var matches []cepMatch
+	x.mu.Lock()
+	for i, p := range x.patterns {
+		if m, ok := x.advance(cepRunKey{pattern: i, key: key}, p, results[i], event, msg, ctx, now); ok {
+			matches = append(matches, m)
+		}
+	}
+	x.mu.Unlock()
+
+	x.emit(matches)
+}
+
+// Destroy 销毁，丢弃进行中的匹配
+func (x *CepNode) Destroy() {
+	if x.stop != nil {
+		x.stopOnce.Do(func() {
+			close(x.stop)
+		})
+	}
+}
+
+// advance 用事件推进该key该模式进行中的匹配，返回完成的匹配
+func (x *CepNode) advance(runKey cepRunKey, p *cepPattern, results []bool, event cepEvent, msg types.RuleMsg, ctx types.RuleContext, now time.Time) (cepMatch, bool) {
+	runs := x.runs[runKey]
+	//第一个步骤匹配，开始新的匹配
+	if results[0] {
+		runs = append(runs, &cepRun{start: now})
+		if len(runs) > x.Config.MaxRuns {
+			runs = runs[len(runs)-x.Config.MaxRuns:]
+		}
+	}
+	alive := runs[:0]
+	for _, run := range runs {
+		if p.within > 0 && now.Sub(run.start) >= p.within {
+			//已经超时，等待定时清理
+			alive = append(alive, run)
+			continue
+		}
+		if !run.accept(p, results, event, msg, ctx) {
+			continue
+		}
+		alive = append(alive, run)
+		if run.finished {
+			delete(x.runs, runKey)
+			return cepMatch{pattern: p, key: runKey.key, run: run, end: event.Ts}, true
+		}
+	}
+	if len(alive) == 0 {
+		delete(x.runs, runKey)
+	} else {
+		x.runs[runKey] = alive
+	}
+	return cepMatch{}, false
+}
+
+// accept 用事件推进匹配，返回false表示匹配失败需要放弃
+func (run *cepRun) accept(p *cepPattern, results []bool, event cepEvent, msg types.RuleMsg, ctx types.RuleContext) bool {
+	step := p.steps[run.step]
+	if step.not {
+		if results[run.step] {
+			return false
+		}
+		//否定步骤期间出现下一个步骤的事件，越过否定步骤
+		if run.step+1 < len(p.steps) && results[run.step+1] {
+			run.step++
+			step = p.steps[run.step]
+		} else {
+			return true
+		}
+	} else if !results[run.step] {
+		return true
+	}
+	event.Step = step.name
+	run.events = append(run.events, event)
+	run.lastMsg = msg
+	run.ctx = ctx
+	run.matched++
+	if run.matched >= step.count {
+		run.step++
+		run.matched = 0
+		if run.step == len(p.steps) {
+			run.finished = true
+		}
+	}
+	return true
+}
+
+// sweep 定时处理超时的匹配
+func (x *CepNode) sweep(minWithin time.Duration) {
+	interval := minWithin / 10
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	} else if interval > time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-x.stop:
+			return
+		case now := <-ticker.C:
+			x.emit(x.expire(now))
+		}
+	}
+}
+
+// expire 处理超时的匹配：停在最后一个否定步骤的匹配视为成功，其他的放弃
+func (x *CepNode) expire(now time.Time) []cepMatch {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	var matches []cepMatch
+	for runKey, runs := range x.runs {
+		p := x.patterns[runKey.pattern]
+		if p.within <= 0 {
+			continue
+		}
+		alive := runs[:0]
+		var matched *cepRun
+		for _, run := range runs {
+			if now.Sub(run.start) < p.within {
+				alive = append(alive, run)
+			} else if matched == nil && run.step == len(p.steps)-1 && p.steps[run.step].not {
+				matched = run
+			}
+		}
+		if matched != nil {
+			delete(x.runs, runKey)
+			matches = append(matches, cepMatch{pattern: p, key: runKey.key, run: matched, end: matched.start.Add(p.within).UnixMilli()})
+		} else if len(alive) == 0 {
+			delete(x.runs, runKey)
+		} else {
+			x.runs[runKey] = alive
+		}
+	}
+	return matches
+}
+
+// emit 发送匹配结果
+func (x *CepNode) emit(matches []cepMatch) {
+	for _, m := range matches {
+		run := m.run
+		if run.ctx == nil {
+			continue
+		}
+		result := map[string]interface{}{
+			"pattern": m.pattern.name,
+			"key":     m.key,
+			"start":   run.events[0].Ts,
+			"end":     m.end,
+			"events":  run.events,
+		}
+		metadata := run.lastMsg.Metadata.Copy()
+		metadata.PutValue(CepMetadataPattern, m.pattern.name)
+		metadata.PutValue(CepMetadataKey, m.key)
+		data, err := json.Marshal(result)
+		msg := types.NewMsg(0, m.pattern.msgType, types.JSON, metadata, string(data))
+		if err != nil {
+			run.ctx.TellFailure(msg, err)
+		} else {
+			run.ctx.TellSuccess(msg)
+		}
+	}
+}
+
+func compileCepPattern(item CepPattern) (*cepPattern, error) {
+	if strings.TrimSpace(item.Name) == "" {
+		return nil, fmt.Errorf("pattern name can not be empty")
+	}
+	if len(item.Steps) == 0 {
+		return nil, fmt.Errorf("pattern:%s steps can not be empty", item.Name)
+	}
+	p := &cepPattern{name: item.Name, msgType: item.MsgType}
+	if p.msgType == "" {
+		p.msgType = item.Name
+	}
+	if item.Within != "" {
+		d, err := time.ParseDuration(item.Within)
+		if err != nil {
+			return nil, fmt.Errorf("pattern:%s invalid within:%s", item.Name, item.Within)
+		}
+		p.within = d
+	}
+	for i, s := range item.Steps {
+		if strings.TrimSpace(s.Expr) == "" {
+			return nil, fmt.Errorf("pattern:%s step:%d expr can not be empty", item.Name, i)
+		}
+		program, err := expr.Compile(s.Expr, expr.AllowUndefinedVariables())
+		if err != nil {
+			return nil, fmt.Errorf("pattern:%s step:%d compile error:%s", item.Name, i, err.Error())
+		}
+		step := cepStep{name: s.Name, program: program, count: s.Count, not: s.Not}
+		if step.name == "" {
+			step.name = fmt.Sprintf("step%d", i)
+		}
+		if step.count <= 0 || step.not {
+			step.count = 1
+		}
+		if step.not && i == 0 {
+			return nil, fmt.Errorf("pattern:%s the first step can not be not", item.Name)
+		}
+		if step.not && i > 0 && item.Steps[i-1].Not {
+			return nil, fmt.Errorf("pattern:%s consecutive not steps are not supported", item.Name)
+		}
+		if step.not && i == len(item.Steps)-1 && p.within <= 0 {
+			return nil, fmt.Errorf("pattern:%s the last not step requires within", item.Name)
+		}
+		p.steps = append(p.steps, step)
+	}
+	return p, nil
+}
+
+// cepEventData JSON类型的消息负荷转换成对象，其他类型作为字符串
+func cepEventData(msg types.RuleMsg) interface{} {
+	if msg.DataType == types.JSON {
+		if data, err := msg.GetDataAsJson(); err == nil {
+			return data
+		}
+	}
+	return msg.GetData()
+}
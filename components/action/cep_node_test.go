@@ -0,0 +1,174 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestCepNode(t *testing.T) {
+	var targetNodeType = "cep"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &CepNode{}, types.Configuration{
+			"maxRuns": 100,
+		}, Registry)
+	})
+
+	t.Run("InitError", func(t *testing.T) {
+		step := func(expr string, not bool) map[string]interface{} {
+			return map[string]interface{}{"expr": expr, "not": not}
+		}
+		for _, patterns := range [][]interface{}{
+			nil,
+			{map[string]interface{}{"name": "", "steps": []interface{}{step("true", false)}}},
+			{map[string]interface{}{"name": "p1"}},
+			{map[string]interface{}{"name": "p1", "steps": []interface{}{step("", false)}}},
+			{map[string]interface{}{"name": "p1", "steps": []interface{}{step("msg.a ==", false)}}},
+			{map[string]interface{}{"name": "p1", "steps": []interface{}{step("true", true)}, "within": "1s"}},
+			{map[string]interface{}{"name": "p1", "steps": []interface{}{step("true", false), step("true", true)}}},
+			{map[string]interface{}{"name": "p1", "steps": []interface{}{step("true", false), step("true", true), step("true", true)}, "within": "1s"}},
+			{map[string]interface{}{"name": "p1", "steps": []interface{}{step("true", false)}, "within": "abc"}},
+		} {
+			_, err := test.CreateAndInitNode(targetNodeType, types.Configuration{"patterns": patterns}, Registry)
+			assert.NotNil(t, err)
+		}
+	})
+
+	t.Run("NotWithin", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"key": "${metadata.deviceId}",
+			"patterns": []interface{}{
+				map[string]interface{}{
+					"name":    "doorLeftOpen",
+					"msgType": "ALARM",
+					"steps": []interface{}{
+						map[string]interface{}{"name": "open", "expr": "msg.door == 'open'"},
+						map[string]interface{}{"name": "close", "expr": "msg.door == 'close'", "not": true},
+					},
+					"within": "200ms",
+				},
+			},
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		//dev1 开门后关门，不匹配
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"door":"open"}`))
+		node.OnMsg(ctx, newWindowMsg(0, "dev2", `{"door":"open"}`))
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"door":"close"}`))
+		node.OnMsg(ctx, newWindowMsg(0, "dev2", `{"temperature":20}`))
+		assert.Equal(t, 0, len(collector.get()))
+
+		collector.wait(t, 1, time.Second)
+		time.Sleep(time.Millisecond * 300)
+		results := collector.get()
+		assert.Equal(t, 1, len(results))
+		assert.Equal(t, types.Success, results[0].relationType)
+		assert.Equal(t, "ALARM", results[0].msg.Type)
+		assert.Equal(t, "doorLeftOpen", results[0].data["pattern"])
+		assert.Equal(t, "dev2", results[0].data["key"])
+		assert.Equal(t, "dev2", results[0].msg.Metadata.GetValue(CepMetadataKey))
+		assert.Equal(t, "doorLeftOpen", results[0].msg.Metadata.GetValue(CepMetadataPattern))
+		events := results[0].data["events"].([]interface{})
+		assert.Equal(t, 1, len(events))
+		assert.Equal(t, "open", events[0].(map[string]interface{})["step"])
+	})
+
+	t.Run("CountWithin", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"key": "${metadata.deviceId}",
+			"patterns": []interface{}{
+				map[string]interface{}{
+					"name": "threeFailures",
+					"steps": []interface{}{
+						map[string]interface{}{"name": "fail", "expr": "msg.status == 'fail'", "count": 3},
+					},
+					"within": "300ms",
+				},
+			},
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"status":"fail","seq":1}`))
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"status":"ok"}`))
+		node.OnMsg(ctx, newWindowMsg(0, "dev2", `{"status":"fail"}`))
+		time.Sleep(time.Millisecond * 200)
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"status":"fail","seq":2}`))
+		//第一次失败已经超过时间范围，从第二次失败开始重新计算
+		time.Sleep(time.Millisecond * 150)
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"status":"fail","seq":3}`))
+		assert.Equal(t, 0, len(collector.get()))
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"status":"fail","seq":4}`))
+		results := collector.get()
+		assert.Equal(t, 1, len(results))
+		events := results[0].data["events"].([]interface{})
+		assert.Equal(t, 3, len(events))
+		assert.Equal(t, float64(2), events[0].(map[string]interface{})["data"].(map[string]interface{})["seq"])
+		assert.Equal(t, float64(4), events[2].(map[string]interface{})["data"].(map[string]interface{})["seq"])
+		//匹配后清空进行中的匹配
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"status":"fail","seq":5}`))
+		assert.Equal(t, 1, len(collector.get()))
+	})
+
+	t.Run("Sequence", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"patterns": []interface{}{
+				map[string]interface{}{
+					"name": "loginWithoutMfa",
+					"steps": []interface{}{
+						map[string]interface{}{"name": "login", "expr": "msgType == 'LOGIN'"},
+						map[string]interface{}{"name": "mfa", "expr": "msgType == 'MFA'", "not": true},
+						map[string]interface{}{"name": "transfer", "expr": "msgType == 'TRANSFER' && msg.amount > 100"},
+					},
+				},
+			},
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		send := func(msgType, data string) {
+			node.OnMsg(ctx, types.NewMsg(0, msgType, types.JSON, types.NewMetadata(), data))
+		}
+		send("LOGIN", `{}`)
+		send("MFA", `{}`)
+		send("TRANSFER", `{"amount":200}`)
+		assert.Equal(t, 0, len(collector.get()))
+
+		send("LOGIN", `{}`)
+		send("TRANSFER", `{"amount":50}`)
+		send("TRANSFER", `{"amount":200}`)
+		results := collector.get()
+		assert.Equal(t, 1, len(results))
+		events := results[0].data["events"].([]interface{})
+		assert.Equal(t, 2, len(events))
+		assert.Equal(t, "login", events[0].(map[string]interface{})["step"])
+		assert.Equal(t, "transfer", events[1].(map[string]interface{})["step"])
+	})
+}
@@ -0,0 +1,190 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+//规则链节点配置示例：
+//{
+//        "id": "s1",
+//        "type": "debounce",
+//        "name": "防抖",
+//        "configuration": {
+//          "key": "${metadata.deviceId}",
+//          "wait": "500ms",
+//          "leading": false,
+//          "trailing": true,
+//          "emit": "last"
+//        }
+//  }
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+const (
+	// DebounceEmitLast 静默期结束时发送最后一条消息
+	DebounceEmitLast = "last"
+	// DebounceEmitFirst 静默期结束时发送第一条消息
+	DebounceEmitFirst = "first"
+)
+
+// 注册节点
+func init() {
+	Registry.Add(&DebounceNode{})
+}
+
+// DebounceNodeConfiguration 节点配置
+type DebounceNodeConfiguration struct {
+	//分组key，通过 ${metadata.key} 从元数据变量中获取或者通过 ${msg.key} 从消息负荷中获取，为空则所有消息一个分组
+	Key string
+	//静默期，超过该时间没有新消息则认为一批消息结束，例如：500ms、10s
+	Wait string
+	//是否在一批消息开始时立即发送第一条消息
+	Leading bool
+	//是否在静默期结束时发送消息
+	Trailing bool
+	//静默期结束时发送哪条消息：last/first，默认last
+	Emit string
+}
+
+// DebounceNode 防抖节点，同一个key的消息在静默期内不断到达时视为一批消息，每批消息只通过成功链路(`Success`)发送一次：
+//   - leading: 一批消息开始时立即发送第一条消息
+//   - trailing: 超过静默期没有新消息时，发送该批的最后一条(或者第一条)消息。
+//     如果同时开启leading，不会重复发送leading已经发送的消息
+//
+// 其他消息被吸收，不会继续往下传递。
+type DebounceNode struct {
+	//节点配置
+	Config    DebounceNodeConfiguration
+	wait      time.Duration
+	states    map[string]*debounceState
+	mu        sync.Mutex
+	destroyed bool
+}
+
+// debounceState 每个key的一批消息
+type debounceState struct {
+	msg   types.RuleMsg
+	ctx   types.RuleContext
+	count int
+	//最后一条消息到达时间
+	lastAt time.Time
+	timer  *time.Timer
+}
+
+// Type 组件类型
+func (x *DebounceNode) Type() string {
+	return "debounce"
+}
+
+func (x *DebounceNode) New() types.Node {
+	return &DebounceNode{Config: DebounceNodeConfiguration{Wait: "1s", Trailing: true, Emit: DebounceEmitLast}}
+}
+
+// Init 初始化
+func (x *DebounceNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.wait, err = time.ParseDuration(x.Config.Wait); err != nil {
+		return err
+	} else if x.wait <= 0 {
+		return fmt.Errorf("wait must be greater than 0")
+	}
+	if !x.Config.Leading && !x.Config.Trailing {
+		return fmt.Errorf("at least one of leading and trailing must be true")
+	}
+	if x.Config.Emit == "" {
+		x.Config.Emit = DebounceEmitLast
+	}
+	if x.Config.Emit != DebounceEmitLast && x.Config.Emit != DebounceEmitFirst {
+		return fmt.Errorf("unsupported emit:%s", x.Config.Emit)
+	}
+	x.states = make(map[string]*debounceState)
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *DebounceNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	key := ""
+	if x.Config.Key != "" {
+		key = str.ExecuteTemplate(x.Config.Key, base.NodeUtils.GetEvnAndMetadata(ctx, msg))
+	}
+	x.mu.Lock()
+	state, ok := x.states[key]
+	if ok {
+		state.count++
+		state.lastAt = time.Now()
+		if x.Config.Emit == DebounceEmitLast {
+			state.msg, state.ctx = msg, ctx
+		}
+		state.timer.Reset(x.wait)
+		x.mu.Unlock()
+		return
+	}
+	state = &debounceState{msg: msg, ctx: ctx, count: 1, lastAt: time.Now()}
+	state.timer = time.AfterFunc(x.wait, func() {
+		x.flush(key, state)
+	})
+	x.states[key] = state
+	x.mu.Unlock()
+
+	if x.Config.Leading {
+		ctx.TellSuccess(msg)
+	}
+}
+
+// Destroy 销毁，丢弃等待发送的消息
+func (x *DebounceNode) Destroy() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.destroyed = true
+	for _, state := range x.states {
+		state.timer.Stop()
+	}
+	x.states = make(map[string]*debounceState)
+}
+
+// flush 静默期结束
+func (x *DebounceNode) flush(key string, state *debounceState) {
+	x.mu.Lock()
+	if x.destroyed || x.states[key] != state {
+		x.mu.Unlock()
+		return
+	}
+	//定时器触发时刚好有新消息到达
+	if remaining := x.wait - time.Since(state.lastAt); remaining > 0 {
+		state.timer.Reset(remaining)
+		x.mu.Unlock()
+		return
+	}
+	delete(x.states, key)
+	msg, ctx, count := state.msg, state.ctx, state.count
+	x.mu.Unlock()
+
+	//leading已经发送过该消息
+	sent := x.Config.Leading && (count == 1 || x.Config.Emit == DebounceEmitFirst)
+	if x.Config.Trailing && !sent {
+		ctx.TellSuccess(msg)
+	}
+}
@@ -0,0 +1,153 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestDebounceNode(t *testing.T) {
+	var targetNodeType = "debounce"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &DebounceNode{}, types.Configuration{
+			"wait":     "1s",
+			"trailing": true,
+			"emit":     DebounceEmitLast,
+		}, Registry)
+	})
+
+	t.Run("InitNode", func(t *testing.T) {
+		test.NodeInit(t, targetNodeType, types.Configuration{
+			"key":     "${metadata.deviceId}",
+			"wait":    "10s",
+			"leading": true,
+			"emit":    "",
+		}, types.Configuration{
+			"key":     "${metadata.deviceId}",
+			"wait":    "10s",
+			"leading": true,
+			"emit":    DebounceEmitLast,
+		}, Registry)
+		for _, config := range []types.Configuration{
+			{"wait": "abc"},
+			{"wait": "-1s"},
+			{"leading": false, "trailing": false},
+			{"emit": "middle"},
+		} {
+			_, err := test.CreateAndInitNode(targetNodeType, config, Registry)
+			assert.NotNil(t, err)
+		}
+	})
+
+	sendBurst := func(node types.Node, ctx types.RuleContext, deviceId string, n int) {
+		for i := 1; i <= n; i++ {
+			node.OnMsg(ctx, newWindowMsg(0, deviceId, fmt.Sprintf(`{"seq":%d}`, i)))
+			time.Sleep(time.Millisecond * 20)
+		}
+	}
+
+	t.Run("Trailing", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"key":  "${metadata.deviceId}",
+			"wait": "100ms",
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		sendBurst(node, ctx, "dev1", 3)
+		sendBurst(node, ctx, "dev2", 1)
+		assert.Equal(t, 0, len(collector.get()))
+		collector.wait(t, 2, time.Second)
+		time.Sleep(time.Millisecond * 150)
+		results := collector.get()
+		assert.Equal(t, 2, len(results))
+		assert.Equal(t, "dev1", results[0].msg.Metadata.GetValue("deviceId"))
+		assert.Equal(t, float64(3), results[0].data["seq"])
+		assert.Equal(t, "dev2", results[1].msg.Metadata.GetValue("deviceId"))
+		assert.Equal(t, float64(1), results[1].data["seq"])
+	})
+
+	t.Run("TrailingFirst", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"wait": "100ms",
+			"emit": DebounceEmitFirst,
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		sendBurst(node, ctx, "dev1", 3)
+		results := collector.wait(t, 1, time.Second)
+		assert.Equal(t, float64(1), results[0].data["seq"])
+	})
+
+	t.Run("LeadingAndTrailing", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"wait":    "100ms",
+			"leading": true,
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		sendBurst(node, ctx, "dev1", 3)
+		results := collector.get()
+		assert.Equal(t, 1, len(results))
+		assert.Equal(t, float64(1), results[0].data["seq"])
+		results = collector.wait(t, 2, time.Second)
+		assert.Equal(t, float64(3), results[1].data["seq"])
+
+		//只有一条消息，不重复发送
+		sendBurst(node, ctx, "dev1", 1)
+		time.Sleep(time.Millisecond * 200)
+		results = collector.get()
+		assert.Equal(t, 3, len(results))
+		assert.Equal(t, float64(1), results[2].data["seq"])
+	})
+
+	t.Run("LeadingOnly", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"wait":     "100ms",
+			"leading":  true,
+			"trailing": false,
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		sendBurst(node, ctx, "dev1", 3)
+		time.Sleep(time.Millisecond * 200)
+		sendBurst(node, ctx, "dev1", 2)
+		time.Sleep(time.Millisecond * 200)
+		results := collector.get()
+		assert.Equal(t, 2, len(results))
+		assert.Equal(t, float64(1), results[0].data["seq"])
+		assert.Equal(t, float64(1), results[1].data["seq"])
+	})
+}
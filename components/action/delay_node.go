@@ -27,6 +27,18 @@ package action
 //          "maxPendingMsgs": 1000
 //        }
 //  }
+//持久化模式配置示例：
+//{
+//        "id": "s2",
+//        "type": "delay",
+//        "name": "定时消息",
+//        "configuration": {
+//          "persistent": true,
+//          "fireTimePattern": "${msg.fireAt}",
+//          "key": "${metadata.orderId}",
+//          "maxPendingMsgs": 10000
+//        }
+//  }
 import (
 	"fmt"
 	"github.com/rulego/rulego/api/types"
@@ -57,12 +69,26 @@ type DelayNodeConfiguration struct {
 	//true：周期内只保留一条消息，新的消息会覆盖之前的消息。直到队列里的消息被处理后，才会再次进入延迟队列。
 	//false：周期内保留所有消息，直到达到最大挂起消息限制后，才会进入失败链路。
 	Overwrite bool
+	//是否持久化挂起的消息，开启后挂起的消息保存到存储中，规则链重新加载或者重启后恢复，不再使用 TellSelf
+	//持久化模式下 overwrite 表示相同key的挂起消息被新消息覆盖
+	Persistent bool
+	//持久化存储，通过 RegisterDelayStore 注册。为空则使用全局缓存 types.Config.Cache。仅持久化模式有效
+	Store string
+	//通过 ${metadata.key} 从元数据变量中获取或者通过 ${msg.key} 从消息负荷中获取绝对触发时间，支持毫秒时间戳或者RFC3339格式，
+	//如果该值有值，优先于延迟时间。仅持久化模式有效
+	FireTimePattern string
+	//挂起消息的key，用于取消和覆盖，支持 ${} 变量，为空则使用消息ID。仅持久化模式有效
+	Key string
+	//取消消息类型，收到该类型的消息时取消相同key所有挂起的消息，默认：DELAY_CANCEL。仅持久化模式有效
+	CancelMsgType string
 }
 
 // DelayNode
 // 当消息的延迟期达到后，该消息将从挂起队列中删除，并通过成功链路(`Success`)路由到下一个节点。
 // 如果已经达到了最大挂起消息限制，则每个下一条消息都会通过失败链路(`Failure`)路由。
 // 如果overwrite为true，则消息会被覆盖，直到队列里的消息被处理后，才会再次进入延迟队列。
+// 如果persistent为true，挂起的消息保存到持久化存储中，节点重新初始化时恢复，到期后通过成功链路发送，
+// 收到 cancelMsgType 类型的消息时取消相同key所有挂起的消息，取消消息通过成功链路发送，元数据 delayCancelled 为取消的数量。
 type DelayNode struct {
 	//节点配置
 	Config DelayNodeConfiguration
@@ -72,6 +98,8 @@ type DelayNode struct {
 	LastPendingMsgId atomic.Value
 	//锁
 	mu sync.Mutex
+	//持久化模式的延迟队列
+	persistent *persistentDelay
 }
 
 // Type 组件类型
@@ -91,12 +119,22 @@ func (x *DelayNode) Init(ruleConfig types.Config, configuration types.Configurat
 		x.Config.MaxPendingMsgs = 1000
 	}
 	x.LastPendingMsgId.Store("")
-	return err
+	if err != nil {
+		return err
+	}
+	x.persistent = nil
+	if x.Config.Persistent {
+		return x.initPersistent(ruleConfig, configuration)
+	}
+	return nil
 }
 
 // OnMsg 处理消息
 func (x *DelayNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
-
+	if x.persistent != nil {
+		x.onPersistentMsg(ctx, msg)
+		return
+	}
 	if msg.Type == DelayNodeMsgType {
 		x.mu.Lock()
 		defer x.mu.Unlock()
@@ -148,7 +186,7 @@ func (x *DelayNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 			ackMsg.Type = DelayNodeMsgType
 			ctx.TellSelf(ackMsg, int64(periodInSeconds*1000))
 		} else {
-			ctx.TellFailure(msg, fmt.Errorf("max limit of pending messages"))
+			ctx.TellFailure(msg, ErrDelayMaxPending)
 		}
 	}
 
@@ -156,4 +194,7 @@ func (x *DelayNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
 
 // Destroy 销毁
 func (x *DelayNode) Destroy() {
+	if x.persistent != nil {
+		x.destroyPersistent()
+	}
 }
@@ -17,9 +17,13 @@
 package action
 
 import (
+	"fmt"
 	"github.com/rulego/rulego/api/types"
 	"github.com/rulego/rulego/test"
 	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/cache"
+	"github.com/rulego/rulego/utils/cache/bolt"
+	"path/filepath"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -175,3 +179,208 @@ func TestDelayNode(t *testing.T) {
 		})
 	})
 }
+
+func TestPersistentDelayNode(t *testing.T) {
+	var targetNodeType = "delay"
+	newMsg := func(msgType, orderId string, fireAt int64) types.RuleMsg {
+		metadata := types.NewMetadata()
+		metadata.PutValue("orderId", orderId)
+		return types.NewMsg(0, msgType, types.JSON, metadata, fmt.Sprintf(`{"fireAt":%d}`, fireAt))
+	}
+	newConfig := func(maxPendingMsgs int) types.Configuration {
+		return types.Configuration{
+			"persistent":                             true,
+			"fireTimePattern":                        "${msg.fireAt}",
+			"key":                                    "${metadata.orderId}",
+			"maxPendingMsgs":                         maxPendingMsgs,
+			types.NodeConfigurationKeySelfDefinition: types.RuleNode{Id: "s1"},
+		}
+	}
+
+	t.Run("InitError", func(t *testing.T) {
+		_, err := test.CreateAndInitNode(targetNodeType, types.Configuration{"persistent": true}, Registry)
+		assert.Equal(t, types.ErrCacheNotInitialized, err)
+		_, err = test.CreateAndInitNode(targetNodeType, types.Configuration{"persistent": true, "store": "notFound"}, Registry)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("FireAndCancel", func(t *testing.T) {
+		config := types.NewConfig(types.WithCache(cache.NewMemoryCache(time.Minute)))
+		node := test.InitNodeByConfig(config, targetNodeType, newConfig(2), Registry)
+		assert.NotNil(t, node)
+		delayNode := node.(*DelayNode)
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(config, collector.callback)
+		now := time.Now().UnixMilli()
+		node.OnMsg(ctx, newMsg("ORDER", "o1", now+200))
+		node.OnMsg(ctx, newMsg("ORDER", "o2", now+200))
+		//超过最大挂起数量
+		node.OnMsg(ctx, newMsg("ORDER", "o3", now+200))
+		//取消o2
+		node.OnMsg(ctx, newMsg(DelayCancelMsgType, "o2", 0))
+
+		results := collector.get()
+		assert.Equal(t, 2, len(results))
+		assert.Equal(t, types.Failure, results[0].relationType)
+		assert.Equal(t, ErrDelayMaxPending, results[0].err)
+		assert.Equal(t, types.Success, results[1].relationType)
+		assert.Equal(t, "1", results[1].msg.Metadata.GetValue(DelayMetadataCancelled))
+		assert.Equal(t, 1, len(config.Cache.GetByPrefix(DelayStoreKeyPrefix)))
+
+		results = collector.wait(t, 3, time.Second)
+		assert.Equal(t, types.Success, results[2].relationType)
+		assert.Equal(t, "o1", results[2].msg.Metadata.GetValue("orderId"))
+		assert.True(t, time.Now().UnixMilli() >= now+200)
+		assert.Equal(t, 0, len(config.Cache.GetByPrefix(DelayStoreKeyPrefix)))
+
+		m := delayNode.Metrics().Get()
+		assert.Equal(t, int64(0), m.Pending)
+		assert.Equal(t, int64(2), m.Scheduled)
+		assert.Equal(t, int64(1), m.Fired)
+		assert.Equal(t, int64(1), m.Cancelled)
+		assert.Equal(t, int64(1), m.Rejected)
+	})
+
+	t.Run("Overwrite", func(t *testing.T) {
+		config := types.NewConfig(types.WithCache(cache.NewMemoryCache(time.Minute)))
+		initConfig := newConfig(10)
+		initConfig["overwrite"] = true
+		node := test.InitNodeByConfig(config, targetNodeType, initConfig, Registry)
+		assert.NotNil(t, node)
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(config, collector.callback)
+		now := time.Now().UnixMilli()
+		node.OnMsg(ctx, newMsg("ORDER", "o1", now+100))
+		msg := newMsg("ORDER", "o1", now+5000)
+		msg.Metadata.PutValue("version", "2")
+		node.OnMsg(ctx, msg)
+
+		results := collector.wait(t, 1, time.Second)
+		assert.Equal(t, 1, len(results))
+		assert.Equal(t, "2", results[0].msg.Metadata.GetValue("version"))
+	})
+
+	t.Run("Recover", func(t *testing.T) {
+		config := types.NewConfig(types.WithCache(cache.NewMemoryCache(time.Minute)))
+		node1 := test.InitNodeByConfig(config, targetNodeType, newConfig(10), Registry)
+		assert.NotNil(t, node1)
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(config, collector.callback)
+		now := time.Now().UnixMilli()
+		node1.OnMsg(ctx, newMsg("ORDER", "o1", now+100))
+		node1.OnMsg(ctx, newMsg("ORDER", "o2", now+100))
+		//规则链重新加载，挂起的消息保留在存储中
+		node1.Destroy()
+
+		node2 := test.InitNodeByConfig(config, targetNodeType, newConfig(10), Registry)
+		assert.NotNil(t, node2)
+		assert.Equal(t, int64(2), node2.(*DelayNode).Metrics().Get().Recovered)
+
+		time.Sleep(time.Millisecond * 300)
+		//旧节点实例不会发送消息，新节点没有可用上下文，等待下一条消息
+		assert.Equal(t, 0, len(collector.get()))
+		orphans := func() int {
+			delayNode := node2.(*DelayNode)
+			delayNode.mu.Lock()
+			defer delayNode.mu.Unlock()
+			return len(delayNode.persistent.orphans)
+		}
+		assert.Equal(t, 2, orphans())
+
+		count, err := node2.(*DelayNode).Cancel("o2")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, count)
+		assert.Equal(t, 1, orphans())
+
+		node2.OnMsg(ctx, newMsg(DelayCancelMsgType, "o3", 0))
+		results := collector.wait(t, 2, time.Second)
+		assert.Equal(t, 0, orphans())
+		var orderIds []string
+		for _, item := range results {
+			orderIds = append(orderIds, item.msg.Metadata.GetValue("orderId"))
+		}
+		assert.Equal(t, []string{"o1", "o3"}, orderIds)
+		assert.Equal(t, 0, len(config.Cache.GetByPrefix(DelayStoreKeyPrefix)))
+	})
+
+	t.Run("Reload", func(t *testing.T) {
+		config := types.NewConfig(types.WithCache(cache.NewMemoryCache(time.Minute)))
+		node1 := test.InitNodeByConfig(config, targetNodeType, newConfig(10), Registry)
+		assert.NotNil(t, node1)
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(config, collector.callback)
+		now := time.Now().UnixMilli()
+		node1.OnMsg(ctx, newMsg("ORDER", "o1", now+200))
+		node1.OnMsg(ctx, newMsg("ORDER", "o2", now+600))
+
+		//重新加载时新节点实例先初始化，旧实例后销毁
+		node2 := test.InitNodeByConfig(config, targetNodeType, newConfig(10), Registry)
+		assert.NotNil(t, node2)
+		assert.Equal(t, int64(2), node2.(*DelayNode).Metrics().Get().Recovered)
+		//新旧实例同时存在期间旧实例收到的消息
+		node1.OnMsg(ctx, newMsg("ORDER", "o3", now+200))
+		node1.OnMsg(ctx, newMsg("ORDER", "o4", now+600))
+
+		//o1、o3由旧实例发送，新实例恢复的o1没有可用上下文
+		collector.wait(t, 2, time.Second)
+		time.Sleep(time.Millisecond * 100)
+		node1.Destroy()
+
+		//新实例收到消息后发送等待上下文的o1，已经被旧实例发送，不会重复发送
+		node2.OnMsg(ctx, newMsg(DelayCancelMsgType, "o5", 0))
+		collector.wait(t, 5, time.Second)
+		time.Sleep(time.Millisecond * 100)
+		results := collector.get()
+		counts := make(map[string]int)
+		for _, item := range results {
+			if item.msg.Type == "ORDER" {
+				counts[item.msg.Metadata.GetValue("orderId")]++
+			}
+		}
+		assert.Equal(t, map[string]int{"o1": 1, "o2": 1, "o3": 1, "o4": 1}, counts)
+		assert.Equal(t, 5, len(results))
+		assert.Equal(t, 0, len(config.Cache.GetByPrefix(DelayStoreKeyPrefix)))
+		node2.Destroy()
+	})
+
+	t.Run("RecoverFromDisk", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "cache.db")
+		boltCache, err := bolt.NewBoltCache(path, bolt.Config{})
+		assert.Nil(t, err)
+		config := types.NewConfig(types.WithCache(boltCache))
+		node1 := test.InitNodeByConfig(config, targetNodeType, newConfig(10), Registry)
+		assert.NotNil(t, node1)
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(config, collector.callback)
+		now := time.Now().UnixMilli()
+		node1.OnMsg(ctx, newMsg("ORDER", "o1", now+100))
+		node1.OnMsg(ctx, newMsg("ORDER", "o2", now+5000))
+		//进程重启，挂起的消息保留在磁盘中
+		node1.Destroy()
+		assert.Nil(t, boltCache.Close())
+
+		boltCache, err = bolt.NewBoltCache(path, bolt.Config{})
+		assert.Nil(t, err)
+		defer boltCache.Close()
+		config = types.NewConfig(types.WithCache(boltCache))
+		node2 := test.InitNodeByConfig(config, targetNodeType, newConfig(10), Registry)
+		assert.NotNil(t, node2)
+		assert.Equal(t, int64(2), node2.(*DelayNode).Metrics().Get().Recovered)
+
+		count, err := node2.(*DelayNode).Cancel("o2")
+		assert.Nil(t, err)
+		assert.Equal(t, 1, count)
+		time.Sleep(time.Millisecond * 200)
+		ctx = test.NewRuleContext(config, collector.callback)
+		node2.OnMsg(ctx, newMsg(DelayCancelMsgType, "o3", 0))
+		results := collector.wait(t, 2, time.Second)
+		assert.Equal(t, "o1", results[0].msg.Metadata.GetValue("orderId"))
+		assert.Equal(t, 0, len(boltCache.GetByPrefix(DelayStoreKeyPrefix)))
+		node2.Destroy()
+	})
+}
@@ -0,0 +1,507 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/metrics"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/json"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// DelayCancelMsgType 持久化模式下默认的取消消息类型
+const DelayCancelMsgType = "DELAY_CANCEL"
+
+// DelayMetadataCancelled 取消消息输出的被取消的挂起消息数量
+const DelayMetadataCancelled = "delayCancelled"
+
+// DelayStoreKeyPrefix 默认延迟消息存储在缓存中的key前缀
+const DelayStoreKeyPrefix = "delay:"
+
+// DelayClaimKeyPrefix 默认延迟消息发送标记在缓存中的key前缀
+const DelayClaimKeyPrefix = "delayClaim:"
+
+// delayClaimTTL 发送标记的过期时间
+const delayClaimTTL = "1h"
+
+// ErrDelayMaxPending 挂起消息数量达到上限
+var ErrDelayMaxPending = errors.New("max limit of pending messages")
+
+var delayStores = struct {
+	sync.RWMutex
+	stores map[string]DelayStore
+}{stores: make(map[string]DelayStore)}
+
+// delayInstances 持久化模式的节点实例，key为命名空间。节点重新加载时新实例先初始化，
+// 旧实例销毁时把挂起的消息移交给新实例
+var delayInstances = struct {
+	sync.Mutex
+	nodes map[string][]*DelayNode
+}{nodes: make(map[string][]*DelayNode)}
+
+// DelayItem 持久化的延迟消息
+type DelayItem struct {
+	Id string `json:"id"`
+	//取消key
+	Key string `json:"key"`
+	//触发时间，毫秒时间戳
+	FireAt int64         `json:"fireAt"`
+	Msg    types.RuleMsg `json:"msg"`
+}
+
+// DelayStore 延迟消息持久化存储，namespace 为 规则链ID:节点ID，实现需要保证并发安全
+type DelayStore interface {
+	// Save 保存延迟消息，相同ID覆盖
+	Save(namespace string, item DelayItem) error
+	// Delete 删除延迟消息
+	Delete(namespace string, id string) error
+	// Claim 删除延迟消息并返回是否由本次调用获得该消息，同一条消息只有一次调用返回true，
+	// 用于节点重新加载时新旧实例同时触发相同的消息只发送一次
+	Claim(namespace string, id string) (bool, error)
+	// List 获取命名空间下所有延迟消息
+	List(namespace string) ([]DelayItem, error)
+}
+
+// RegisterDelayStore 注册延迟消息存储，节点通过 store 配置使用
+func RegisterDelayStore(name string, store DelayStore) {
+	delayStores.Lock()
+	defer delayStores.Unlock()
+	delayStores.stores[name] = store
+}
+
+// UnregisterDelayStore 删除延迟消息存储
+func UnregisterDelayStore(name string) {
+	delayStores.Lock()
+	defer delayStores.Unlock()
+	delete(delayStores.stores, name)
+}
+
+func getDelayStore(name string) (DelayStore, bool) {
+	delayStores.RLock()
+	defer delayStores.RUnlock()
+	store, ok := delayStores.stores[name]
+	return store, ok
+}
+
+// CacheDelayStore 基于 types.Cache 的延迟消息存储，使用持久化的缓存实现(例如redis)可以在进程重启后恢复
+type CacheDelayStore struct {
+	Cache types.Cache
+}
+
+// NewCacheDelayStore 创建基于缓存的延迟消息存储
+func NewCacheDelayStore(cache types.Cache) *CacheDelayStore {
+	return &CacheDelayStore{Cache: cache}
+}
+
+func (s *CacheDelayStore) Save(namespace string, item DelayItem) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	return s.Cache.Set(s.prefix(namespace)+item.Id, string(data), "")
+}
+
+func (s *CacheDelayStore) Delete(namespace string, id string) error {
+	return s.Cache.Delete(s.prefix(namespace) + id)
+}
+
+func (s *CacheDelayStore) Claim(namespace string, id string) (bool, error) {
+	key := s.prefix(namespace) + id
+	if !s.Cache.Has(key) {
+		return false, nil
+	}
+	if ok, err := s.Cache.SetIfAbsent(DelayClaimKeyPrefix+namespace+":"+id, "1", delayClaimTTL); err != nil || !ok {
+		return false, err
+	}
+	return true, s.Cache.Delete(key)
+}
+
+func (s *CacheDelayStore) List(namespace string) ([]DelayItem, error) {
+	var items []DelayItem
+	for _, v := range s.Cache.GetByPrefix(s.prefix(namespace)) {
+		var item DelayItem
+		if err := json.Unmarshal([]byte(str.ToString(v)), &item); err != nil || item.Id == "" {
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (s *CacheDelayStore) prefix(namespace string) string {
+	return DelayStoreKeyPrefix + namespace + ":"
+}
+
+// delayEntry 挂起的延迟消息
+type delayEntry struct {
+	item DelayItem
+	//收到该消息的上下文，恢复的消息为nil
+	ctx   types.RuleContext
+	timer *time.Timer
+}
+
+// persistentDelay 持久化模式的延迟队列
+type persistentDelay struct {
+	store     DelayStore
+	namespace string
+	chainCtx  types.ChainCtx
+	nodeId    string
+	pending   map[string]*delayEntry
+	//已经到期但是没有可用上下文的消息ID，恢复的消息到期时没有可用上下文则加入该集合
+	orphans map[string]struct{}
+	//最后一条消息的上下文，用于发送恢复的消息
+	lastCtx   types.RuleContext
+	metrics   *metrics.DelayMetrics
+	destroyed bool
+}
+
+// initPersistent 初始化持久化模式，并从存储恢复挂起的消息
+func (x *DelayNode) initPersistent(ruleConfig types.Config, configuration types.Configuration) error {
+	var store DelayStore
+	if x.Config.Store == "" {
+		if ruleConfig.Cache == nil {
+			return types.ErrCacheNotInitialized
+		}
+		store = NewCacheDelayStore(ruleConfig.Cache)
+	} else if s, ok := getDelayStore(x.Config.Store); ok {
+		store = s
+	} else {
+		return fmt.Errorf("delay store:%s not found", x.Config.Store)
+	}
+	if x.Config.CancelMsgType == "" {
+		x.Config.CancelMsgType = DelayCancelMsgType
+	}
+	p := &persistentDelay{
+		store:   store,
+		nodeId:  base.NodeUtils.GetSelfDefinition(configuration).Id,
+		pending: make(map[string]*delayEntry),
+		orphans: make(map[string]struct{}),
+		metrics: metrics.NewDelayMetrics(),
+	}
+	var chainId string
+	if chainCtx := base.NodeUtils.GetChainCtx(configuration); chainCtx != nil {
+		p.chainCtx = chainCtx
+		chainId = chainCtx.GetNodeId().Id
+	}
+	p.namespace = chainId + ":" + p.nodeId
+	items, err := store.List(p.namespace)
+	if err != nil {
+		return err
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.persistent = p
+	for _, item := range items {
+		x.schedule(&delayEntry{item: item})
+	}
+	p.metrics.AddRecovered(int64(len(items)))
+	p.metrics.SetPending(int64(len(p.pending)))
+
+	delayInstances.Lock()
+	delayInstances.nodes[p.namespace] = append(delayInstances.nodes[p.namespace], x)
+	delayInstances.Unlock()
+	return nil
+}
+
+// Metrics 持久化模式的延迟队列指标，非持久化模式返回nil
+func (x *DelayNode) Metrics() *metrics.DelayMetrics {
+	if x.persistent == nil {
+		return nil
+	}
+	return x.persistent.metrics
+}
+
+// Cancel 取消指定key所有挂起的消息，返回取消的数量。仅持久化模式有效
+func (x *DelayNode) Cancel(key string) (int, error) {
+	p := x.persistent
+	if p == nil {
+		return 0, nil
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	count := 0
+	for id, entry := range p.pending {
+		if entry.item.Key != key {
+			continue
+		}
+		if err := p.store.Delete(p.namespace, id); err != nil {
+			return count, err
+		}
+		if entry.timer != nil {
+			entry.timer.Stop()
+		}
+		delete(p.pending, id)
+		delete(p.orphans, id)
+		count++
+	}
+	p.metrics.AddCancelled(int64(count))
+	p.metrics.SetPending(int64(len(p.pending)))
+	return count, nil
+}
+
+// onPersistentMsg 持久化模式处理消息
+func (x *DelayNode) onPersistentMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	p := x.persistent
+	evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	key := msg.Id
+	if x.Config.Key != "" {
+		key = str.ExecuteTemplate(x.Config.Key, evn)
+	}
+	x.mu.Lock()
+	p.lastCtx = ctx
+	x.mu.Unlock()
+	//发送等待上下文的恢复消息
+	x.fireOrphans()
+
+	if msg.Type == x.Config.CancelMsgType {
+		count, err := x.Cancel(key)
+		if err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		msg.Metadata.PutValue(DelayMetadataCancelled, strconv.Itoa(count))
+		ctx.TellSuccess(msg)
+		return
+	}
+
+	fireAt, err := x.fireAt(evn)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+
+	x.mu.Lock()
+	if x.Config.Overwrite {
+		//相同key的挂起消息被新消息覆盖，触发时间不变
+		for _, entry := range p.pending {
+			if entry.item.Key == key {
+				item := entry.item
+				item.Msg = msg
+				if err = p.store.Save(p.namespace, item); err == nil {
+					entry.item, entry.ctx = item, ctx
+				}
+				x.mu.Unlock()
+				if err != nil {
+					ctx.TellFailure(msg, err)
+				}
+				return
+			}
+		}
+	}
+	if len(p.pending) >= x.Config.MaxPendingMsgs {
+		x.mu.Unlock()
+		p.metrics.IncrementRejected()
+		ctx.TellFailure(msg, ErrDelayMaxPending)
+		return
+	}
+	id, _ := uuid.NewV4()
+	entry := &delayEntry{item: DelayItem{Id: id.String(), Key: key, FireAt: fireAt, Msg: msg}, ctx: ctx}
+	if err = p.store.Save(p.namespace, entry.item); err != nil {
+		x.mu.Unlock()
+		ctx.TellFailure(msg, err)
+		return
+	}
+	x.schedule(entry)
+	p.metrics.IncrementScheduled()
+	p.metrics.SetPending(int64(len(p.pending)))
+	x.mu.Unlock()
+}
+
+// fireAt 计算触发时间，优先使用绝对触发时间
+func (x *DelayNode) fireAt(evn map[string]interface{}) (int64, error) {
+	if x.Config.FireTimePattern != "" {
+		return parseWindowTime(str.ExecuteTemplate(x.Config.FireTimePattern, evn))
+	}
+	periodInSeconds := x.Config.PeriodInSeconds
+	if x.Config.PeriodInSecondsPattern != "" {
+		v, err := strconv.Atoi(str.ExecuteTemplate(x.Config.PeriodInSecondsPattern, evn))
+		if err != nil {
+			return 0, err
+		}
+		periodInSeconds = v
+	}
+	return time.Now().UnixMilli() + int64(periodInSeconds)*1000, nil
+}
+
+// schedule 加入挂起队列并启动定时器，调用方需要持有锁
+func (x *DelayNode) schedule(entry *delayEntry) {
+	p := x.persistent
+	p.pending[entry.item.Id] = entry
+	id := entry.item.Id
+	delay := time.Duration(entry.item.FireAt-time.Now().UnixMilli()) * time.Millisecond
+	if delay < 0 {
+		delay = 0
+	}
+	entry.timer = time.AfterFunc(delay, func() {
+		x.fire(id)
+	})
+}
+
+// fire 触发延迟消息
+func (x *DelayNode) fire(id string) {
+	p := x.persistent
+	x.mu.Lock()
+	entry, ok := p.pending[id]
+	if p.destroyed || !ok {
+		x.mu.Unlock()
+		return
+	}
+	entry.timer = nil
+	ctx, engine := x.emitter(entry)
+	if ctx == nil && engine == nil {
+		//没有可用的上下文，等待下一条消息到达后发送
+		p.orphans[id] = struct{}{}
+		x.mu.Unlock()
+		return
+	}
+	delete(p.pending, id)
+	claimed, err := p.store.Claim(p.namespace, id)
+	p.metrics.SetPending(int64(len(p.pending)))
+	x.mu.Unlock()
+	if err == nil && !claimed {
+		//已经被其他实例发送或者已取消
+		return
+	}
+
+	p.metrics.IncrementFired()
+	msg := entry.item.Msg
+	if ctx != nil {
+		if err != nil {
+			ctx.TellFailure(msg, err)
+		} else {
+			ctx.TellSuccess(msg)
+		}
+	} else {
+		//恢复的消息从当前节点的成功链路继续执行
+		engine.OnMsg(msg, types.WithTellNext(p.nodeId, types.Success))
+	}
+}
+
+// emitter 获取发送消息的上下文，恢复的消息优先使用规则引擎从当前节点重新执行，其次使用最后一条消息的上下文
+func (x *DelayNode) emitter(entry *delayEntry) (types.RuleContext, types.RuleEngine) {
+	p := x.persistent
+	if entry.ctx != nil {
+		return entry.ctx, nil
+	}
+	if p.chainCtx != nil {
+		if pool := p.chainCtx.GetRuleEnginePool(); pool != nil {
+			if engine, ok := pool.Get(p.chainCtx.GetNodeId().Id); ok && engine != nil {
+				return nil, engine
+			}
+		}
+	}
+	return p.lastCtx, nil
+}
+
+// fireOrphans 发送已经到期但是当时没有可用上下文的恢复消息
+func (x *DelayNode) fireOrphans() {
+	p := x.persistent
+	x.mu.Lock()
+	if len(p.orphans) == 0 {
+		x.mu.Unlock()
+		return
+	}
+	ids := make([]string, 0, len(p.orphans))
+	for id := range p.orphans {
+		ids = append(ids, id)
+	}
+	p.orphans = make(map[string]struct{})
+	x.mu.Unlock()
+	for _, id := range ids {
+		x.fire(id)
+	}
+}
+
+// destroyPersistent 停止定时器，挂起的消息保留在存储中，并移交给同一命名空间的新节点实例
+func (x *DelayNode) destroyPersistent() {
+	p := x.persistent
+	x.mu.Lock()
+	p.destroyed = true
+	entries := make([]*delayEntry, 0, len(p.pending))
+	for _, entry := range p.pending {
+		if entry.timer != nil {
+			entry.timer.Stop()
+		}
+		entries = append(entries, entry)
+	}
+	x.mu.Unlock()
+
+	var successor *DelayNode
+	delayInstances.Lock()
+	nodes := delayInstances.nodes[p.namespace]
+	for i, node := range nodes {
+		if node == x {
+			nodes = append(nodes[:i:i], nodes[i+1:]...)
+			break
+		}
+	}
+	if len(nodes) == 0 {
+		delete(delayInstances.nodes, p.namespace)
+	} else {
+		delayInstances.nodes[p.namespace] = nodes
+	}
+	//最后初始化的使用相同存储的实例
+	for i := len(nodes) - 1; i >= 0; i-- {
+		if sameDelayStore(nodes[i].persistent.store, p.store) {
+			successor = nodes[i]
+			break
+		}
+	}
+	delayInstances.Unlock()
+
+	if successor != nil {
+		successor.adopt(entries)
+	}
+}
+
+// adopt 接收旧实例移交的挂起消息，新实例初始化之后旧实例收到的消息不在初始化时的恢复列表中
+func (x *DelayNode) adopt(entries []*delayEntry) {
+	p := x.persistent
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if p.destroyed {
+		return
+	}
+	for _, entry := range entries {
+		if exist, ok := p.pending[entry.item.Id]; ok {
+			if exist.ctx == nil {
+				exist.ctx = entry.ctx
+			}
+			continue
+		}
+		x.schedule(&delayEntry{item: entry.item, ctx: entry.ctx})
+	}
+	p.metrics.SetPending(int64(len(p.pending)))
+}
+
+// sameDelayStore 是否是相同的存储，基于缓存的存储每次初始化都会创建，比较使用的缓存
+func sameDelayStore(a, b DelayStore) bool {
+	if ca, ok := a.(*CacheDelayStore); ok {
+		if cb, ok := b.(*CacheDelayStore); ok {
+			return ca.Cache == cb.Cache
+		}
+	}
+	return a == b
+}
@@ -18,14 +18,22 @@
 //
 // These components are designed to perform various actions within a rule chain, including:
 //
-// - DelayNode: Introduces a time delay in rule execution
+// - AlarmNode: Creates, updates, acknowledges and clears alarms per originator and alarm type
+// - BatchNode: Accumulates messages into JSON-array batches by count, bytes or time
+// - CepNode: Detects temporal event patterns (sequence, within, not, count) per key
+// - DelayNode: Introduces a time delay in rule execution, optionally persisted to a durable store
+// - DebounceNode: Emits one message per burst after a quiet period per key
 // - ExecCommandNode: Executes system commands
+// - FileWriteNode: Appends messages to local files as JSONL, CSV or raw text with rotation
 // - ForNode: Implements loop functionality for iterating over data
 // - FunctionsNode: Allows calling custom-defined functions
 // - GroupActionNode: Groups multiple nodes and executes them asynchronously
 // - IteratorNode: Iterates over data (deprecated, use ForNode instead)
 // - JoinNode: Merges results from multiple asynchronous nodes
 // - JsLogNode: Logs messages using JavaScript
+// - StateMachineNode: Tracks a per-entity finite state machine driven by expr transitions
+// - ThrottleNode: Limits the number of messages per interval per key
+// - WindowNode: Aggregates messages in tumbling, sliding or session windows
 //
 // Each component is registered with the Registry, allowing them to be used
 // within rule chains. These components can be configured and connected to create
@@ -0,0 +1,624 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+//规则链节点配置示例：
+//{
+//        "id": "s1",
+//        "type": "fileWrite",
+//        "name": "写入文件",
+//        "configuration": {
+//          "path": "/data/${metadata.deviceId}/${_date}.jsonl",
+//          "format": "jsonl",
+//          "maxSize": 104857600,
+//          "rotateInterval": "24h",
+//          "compress": true,
+//          "fsync": "1s"
+//        }
+//  }
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/el"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 文件格式
+const (
+	// FileWriteFormatJsonl 每条消息一行JSON
+	FileWriteFormatJsonl = "jsonl"
+	// FileWriteFormatCsv 每条消息一行或者多行CSV
+	FileWriteFormatCsv = "csv"
+	// FileWriteFormatRaw 原样写入消息负荷，不以换行符结尾则追加换行符
+	FileWriteFormatRaw = "raw"
+)
+
+// 刷盘策略
+const (
+	// FileWriteFsyncNone 不主动刷盘，由操作系统决定
+	FileWriteFsyncNone = "none"
+	// FileWriteFsyncAlways 每次写入后刷盘
+	FileWriteFsyncAlways = "always"
+)
+
+const (
+	// FileWriteKeyDate 路径模板中的日期变量，使用DateFormat格式化消息时间
+	FileWriteKeyDate = "_date"
+	// FileWriteKeyPath 写入的文件路径，输出到元数据
+	FileWriteKeyPath = "_filePath"
+)
+
+func init() {
+	Registry.Add(&FileWriteNode{})
+}
+
+// FileWriteNodeConfiguration 节点配置
+type FileWriteNodeConfiguration struct {
+	// Path 文件路径，可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
+	// 使用 ${_date} 读取按照DateFormat格式化的消息时间，例如：/data/${metadata.deviceId}/${_date}.jsonl
+	// 如果Path本身不包含..，替换后的路径也不允许包含..
+	Path string
+	// DateFormat ${_date} 的时间格式，默认：2006-01-02
+	DateFormat string
+	// Format 文件格式：jsonl、csv、raw，默认jsonl
+	Format string
+	// Envelope jsonl格式下，是否写入包含id、ts、type、metadata和data的完整消息，默认只写入消息负荷
+	Envelope bool
+	// Headers csv格式下，对象消息输出的列以及顺序，如果为空，则使用对象字段名按字母排序
+	Headers []string
+	// WriteHeader csv格式下，新文件是否先写入表头
+	WriteHeader bool
+	// Delimiter csv格式下，字段分隔符，默认","，制表符使用"\t"
+	Delimiter string
+	// MaxSize 文件最大字节数，写入后超过该值则先轮转，0表示不按大小轮转
+	MaxSize int64
+	// RotateInterval 按时间轮转的间隔，例如：1h、24h，文件的写入时间跨越间隔边界时轮转，为空表示不按时间轮转
+	RotateInterval string
+	// Compress 是否使用gzip压缩轮转后的文件
+	Compress bool
+	// Fsync 刷盘策略：none(不主动刷盘)、always(每次写入后刷盘)或者时间间隔(例如：1s，写入时距离上次刷盘超过该间隔则刷盘)，默认none
+	Fsync string
+	// MaxOpenFiles 节点最多打开的文件数量，超过则关闭最久没有写入的文件，默认16
+	MaxOpenFiles int
+}
+
+// FileWriteNode 把消息追加写入本地文件，支持jsonl、csv、raw格式
+// 支持按大小和时间轮转，轮转后的文件名在扩展名前添加时间，例如：data-20250101T000000.jsonl，可以选择gzip压缩
+// 相同路径的文件在所有节点实例之间共享同一个文件句柄，写入互斥
+// 写入成功后，元数据 _filePath 为写入的文件路径，转到Success链，否则转到Failure链
+type FileWriteNode struct {
+	//节点配置
+	Config       FileWriteNodeConfiguration
+	pathTemplate *el.MixedTemplate
+	//路径是否允许包含..
+	allowDotDot    bool
+	comma          rune
+	rotateInterval time.Duration
+	fsyncInterval  time.Duration
+	//节点打开的文件以及最后写入时间
+	files map[string]time.Time
+	mu    sync.Mutex
+}
+
+// Type 组件类型
+func (x *FileWriteNode) Type() string {
+	return "fileWrite"
+}
+
+func (x *FileWriteNode) New() types.Node {
+	return &FileWriteNode{Config: FileWriteNodeConfiguration{
+		DateFormat:   "2006-01-02",
+		Format:       FileWriteFormatJsonl,
+		Delimiter:    ",",
+		Fsync:        FileWriteFsyncNone,
+		MaxOpenFiles: 16,
+	}}
+}
+
+// Init 初始化
+func (x *FileWriteNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(x.Config.Path) == "" {
+		return errors.New("path can not be empty")
+	}
+	if x.pathTemplate, err = el.NewMixedTemplate(x.Config.Path); err != nil {
+		return err
+	}
+	x.allowDotDot = containsDotDot(x.Config.Path)
+	if x.Config.DateFormat == "" {
+		x.Config.DateFormat = "2006-01-02"
+	}
+	switch x.Config.Format {
+	case "":
+		x.Config.Format = FileWriteFormatJsonl
+	case FileWriteFormatJsonl, FileWriteFormatCsv, FileWriteFormatRaw:
+	default:
+		return fmt.Errorf("unsupported format:%s", x.Config.Format)
+	}
+	if x.comma, err = parseCsvDelimiter(x.Config.Delimiter); err != nil {
+		return err
+	}
+	x.rotateInterval = 0
+	if x.Config.RotateInterval != "" {
+		if x.rotateInterval, err = time.ParseDuration(x.Config.RotateInterval); err != nil {
+			return err
+		} else if x.rotateInterval <= 0 {
+			return errors.New("rotateInterval must be greater than 0")
+		}
+	}
+	x.fsyncInterval = 0
+	switch x.Config.Fsync {
+	case "", FileWriteFsyncNone, FileWriteFsyncAlways:
+	default:
+		if x.fsyncInterval, err = time.ParseDuration(x.Config.Fsync); err != nil {
+			return fmt.Errorf("invalid fsync:%s", x.Config.Fsync)
+		} else if x.fsyncInterval <= 0 {
+			return errors.New("fsync interval must be greater than 0")
+		}
+	}
+	if x.Config.MaxOpenFiles <= 0 {
+		x.Config.MaxOpenFiles = 16
+	}
+	x.files = make(map[string]time.Time)
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *FileWriteNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	now := time.Now()
+	ts := now
+	if msg.Ts > 0 {
+		ts = time.UnixMilli(msg.Ts)
+	}
+	evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	evn[FileWriteKeyDate] = ts.Format(x.Config.DateFormat)
+	path := x.pathTemplate.ExecuteAsString(evn)
+	if !x.allowDotDot && containsDotDot(path) {
+		ctx.TellFailure(msg, fmt.Errorf("invalid path:%s", path))
+		return
+	}
+	path = filepath.Clean(path)
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	f := x.acquire(path, now)
+	rotated, err := f.write(now, x, msg)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	if rotated != "" && x.Config.Compress {
+		//在文件锁外压缩，不阻塞其他写入
+		if err = gzipFile(rotated); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	msg.Metadata.PutValue(FileWriteKeyPath, path)
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁，释放节点打开的文件
+func (x *FileWriteNode) Destroy() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for path := range x.files {
+		releaseSharedFile(path)
+	}
+	x.files = make(map[string]time.Time)
+}
+
+// acquire 获取共享文件，节点打开的文件超过MaxOpenFiles则释放最久没有写入的文件
+func (x *FileWriteNode) acquire(path string, now time.Time) *sharedFile {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if _, ok := x.files[path]; ok {
+		x.files[path] = now
+		return getSharedFile(path, false)
+	}
+	if len(x.files) >= x.Config.MaxOpenFiles {
+		var oldestPath string
+		var oldest time.Time
+		for p, t := range x.files {
+			if oldestPath == "" || t.Before(oldest) {
+				oldestPath, oldest = p, t
+			}
+		}
+		delete(x.files, oldestPath)
+		releaseSharedFile(oldestPath)
+	}
+	x.files[path] = now
+	return getSharedFile(path, true)
+}
+
+// encode 把消息编码成写入的内容，header为csv格式的表头
+func (x *FileWriteNode) encode(msg types.RuleMsg) (data []byte, header []byte, err error) {
+	switch x.Config.Format {
+	case FileWriteFormatCsv:
+		return x.encodeCsv(msg)
+	case FileWriteFormatRaw:
+		data = []byte(msg.GetData())
+		if len(data) == 0 || data[len(data)-1] != '\n' {
+			data = append(data, '\n')
+		}
+		return data, nil, nil
+	default:
+		var buf bytes.Buffer
+		payload := []byte(msg.GetData())
+		if !json.Valid(payload) {
+			if payload, err = json.Marshal(msg.GetData()); err != nil {
+				return nil, nil, err
+			}
+		}
+		if x.Config.Envelope {
+			//消息负荷原样嵌入，保持字段顺序
+			buf.WriteString(`{"id":`)
+			id, _ := json.Marshal(msg.Id)
+			buf.Write(id)
+			buf.WriteString(fmt.Sprintf(`,"ts":%d,"type":`, msg.Ts))
+			msgType, _ := json.Marshal(msg.Type)
+			buf.Write(msgType)
+			buf.WriteString(`,"dataType":`)
+			dataType, _ := json.Marshal(string(msg.DataType))
+			buf.Write(dataType)
+			buf.WriteString(`,"metadata":`)
+			var metadata map[string]string
+			if msg.Metadata != nil {
+				metadata = msg.Metadata.Values()
+			}
+			if metadata == nil {
+				metadata = map[string]string{}
+			}
+			md, err := json.Marshal(metadata)
+			if err != nil {
+				return nil, nil, err
+			}
+			buf.Write(md)
+			buf.WriteString(`,"data":`)
+			if err = json.Compact(&buf, payload); err != nil {
+				return nil, nil, err
+			}
+			buf.WriteString("}")
+		} else if err = json.Compact(&buf, payload); err != nil {
+			return nil, nil, err
+		}
+		buf.WriteByte('\n')
+		return buf.Bytes(), nil, nil
+	}
+}
+
+// encodeCsv 消息负荷为JSON对象、数组或者对象数组，每个对象或者数组写入一行
+func (x *FileWriteNode) encodeCsv(msg types.RuleMsg) ([]byte, []byte, error) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(msg.GetData()), &data); err != nil {
+		return nil, nil, err
+	}
+	var rows []interface{}
+	switch v := data.(type) {
+	case map[string]interface{}:
+		rows = []interface{}{v}
+	case []interface{}:
+		if len(v) > 0 {
+			if _, ok := v[0].(map[string]interface{}); ok {
+				rows = v
+			} else if _, ok := v[0].([]interface{}); ok {
+				rows = v
+			} else {
+				rows = []interface{}{v}
+			}
+		}
+	default:
+		return nil, nil, errors.New("data must be a JSON array or object")
+	}
+	headers := x.Config.Headers
+	if len(headers) == 0 {
+		keys := make(map[string]struct{})
+		for _, item := range rows {
+			if row, ok := item.(map[string]interface{}); ok {
+				for k := range row {
+					keys[k] = struct{}{}
+				}
+			}
+		}
+		for k := range keys {
+			headers = append(headers, k)
+		}
+		sort.Strings(headers)
+	}
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Comma = x.comma
+	for _, item := range rows {
+		var record []string
+		switch row := item.(type) {
+		case map[string]interface{}:
+			record = make([]string, len(headers))
+			for i, h := range headers {
+				if v, ok := row[h]; ok && v != nil {
+					record[i] = str.ToString(v)
+				}
+			}
+		case []interface{}:
+			for _, v := range row {
+				if v == nil {
+					record = append(record, "")
+				} else {
+					record = append(record, str.ToString(v))
+				}
+			}
+		default:
+			record = []string{str.ToString(row)}
+		}
+		if err := writer.Write(record); err != nil {
+			return nil, nil, err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, nil, err
+	}
+	var header []byte
+	if x.Config.WriteHeader && len(headers) > 0 {
+		var headerBuf bytes.Buffer
+		headerWriter := csv.NewWriter(&headerBuf)
+		headerWriter.Comma = x.comma
+		if err := headerWriter.Write(headers); err != nil {
+			return nil, nil, err
+		}
+		headerWriter.Flush()
+		header = headerBuf.Bytes()
+	}
+	return buf.Bytes(), header, nil
+}
+
+// sharedFiles 所有节点实例共享的文件句柄，key为文件绝对路径
+var sharedFiles = struct {
+	sync.Mutex
+	files map[string]*sharedFile
+}{files: make(map[string]*sharedFile)}
+
+// sharedFile 共享的文件句柄，引用计数为0时关闭
+type sharedFile struct {
+	path string
+	file *os.File
+	size int64
+	//文件写入时间所在的轮转周期开始时间
+	period   time.Time
+	lastSync time.Time
+	refs     int
+	//已经从共享文件中移除，写入后关闭文件
+	removed bool
+	mu      sync.Mutex
+}
+
+// getSharedFile 获取共享文件，ref为true则增加引用计数
+func getSharedFile(path string, ref bool) *sharedFile {
+	sharedFiles.Lock()
+	defer sharedFiles.Unlock()
+	f, ok := sharedFiles.files[path]
+	if !ok {
+		f = &sharedFile{path: path}
+		sharedFiles.files[path] = f
+	}
+	if ref || f.refs == 0 {
+		f.refs++
+	}
+	return f
+}
+
+// releaseSharedFile 减少引用计数，为0时关闭文件
+func releaseSharedFile(path string) {
+	sharedFiles.Lock()
+	f, ok := sharedFiles.files[path]
+	if ok {
+		f.refs--
+		if f.refs > 0 {
+			ok = false
+		} else {
+			delete(sharedFiles.files, path)
+		}
+	}
+	sharedFiles.Unlock()
+	if ok {
+		f.mu.Lock()
+		f.removed = true
+		f.close()
+		f.mu.Unlock()
+	}
+}
+
+// write 写入消息，返回轮转后的文件路径
+func (f *sharedFile) write(now time.Time, x *FileWriteNode, msg types.RuleMsg) (string, error) {
+	data, header, err := x.encode(msg)
+	if err != nil {
+		return "", err
+	}
+	f.mu.Lock()
+	defer func() {
+		if f.removed {
+			f.close()
+		}
+		f.mu.Unlock()
+	}()
+	if err = f.open(x.rotateInterval); err != nil {
+		return "", err
+	}
+	var rotated string
+	if f.needRotate(now, x, int64(len(data))) {
+		if rotated, err = f.rotate(now, x.rotateInterval); err != nil {
+			return "", err
+		}
+	}
+	if f.size == 0 && len(header) > 0 {
+		data = append(header, data...)
+	}
+	n, err := f.file.Write(data)
+	f.size += int64(n)
+	if err != nil {
+		return rotated, err
+	}
+	if x.rotateInterval > 0 {
+		f.period = now.Truncate(x.rotateInterval)
+	}
+	if x.Config.Fsync == FileWriteFsyncAlways || (x.fsyncInterval > 0 && now.Sub(f.lastSync) >= x.fsyncInterval) {
+		f.lastSync = now
+		err = f.file.Sync()
+	}
+	return rotated, err
+}
+
+// open 打开文件，已有内容的文件使用修改时间确定轮转周期
+func (f *sharedFile) open(rotateInterval time.Duration) error {
+	if f.file != nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	f.period = time.Time{}
+	if f.size > 0 && rotateInterval > 0 {
+		f.period = info.ModTime().Truncate(rotateInterval)
+	}
+	return nil
+}
+
+// needRotate 写入后超过最大字节数或者跨越轮转周期
+func (f *sharedFile) needRotate(now time.Time, x *FileWriteNode, n int64) bool {
+	if f.size == 0 {
+		return false
+	}
+	if x.Config.MaxSize > 0 && f.size+n > x.Config.MaxSize {
+		return true
+	}
+	return x.rotateInterval > 0 && !f.period.IsZero() && !now.Truncate(x.rotateInterval).Equal(f.period)
+}
+
+// rotate 关闭当前文件并重命名，然后打开新文件
+func (f *sharedFile) rotate(now time.Time, rotateInterval time.Duration) (string, error) {
+	t := now
+	if !f.period.IsZero() {
+		t = f.period
+	}
+	f.close()
+	ext := filepath.Ext(f.path)
+	prefix := strings.TrimSuffix(f.path, ext) + "-" + t.Format("20060102T150405")
+	target := prefix + ext
+	for i := 1; fileExists(target) || fileExists(target+".gz"); i++ {
+		target = fmt.Sprintf("%s.%d%s", prefix, i, ext)
+	}
+	if err := os.Rename(f.path, target); err != nil {
+		return "", err
+	}
+	return target, f.open(rotateInterval)
+}
+
+func (f *sharedFile) close() {
+	if f.file != nil {
+		_ = f.file.Sync()
+		_ = f.file.Close()
+		f.file = nil
+	}
+}
+
+// gzipFile 压缩文件为.gz后删除原文件
+func gzipFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	writer := gzip.NewWriter(dst)
+	_, err = io.Copy(writer, src)
+	if err == nil {
+		err = writer.Close()
+	}
+	if err == nil {
+		err = dst.Sync()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path + ".gz")
+		return err
+	}
+	_ = src.Close()
+	return os.Remove(path)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// containsDotDot 路径是否包含..元素
+func containsDotDot(path string) bool {
+	for _, item := range strings.FieldsFunc(filepath.ToSlash(path), func(r rune) bool { return r == '/' }) {
+		if item == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCsvDelimiter 解析分隔符，默认逗号
+func parseCsvDelimiter(delimiter string) (rune, error) {
+	switch delimiter {
+	case "":
+		return ',', nil
+	case "\\t", "\t":
+		return '\t', nil
+	}
+	runes := []rune(delimiter)
+	if len(runes) != 1 || runes[0] == '"' || runes[0] == '\r' || runes[0] == '\n' {
+		return 0, fmt.Errorf("invalid delimiter: %s", delimiter)
+	}
+	return runes[0], nil
+}
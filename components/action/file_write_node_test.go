@@ -0,0 +1,261 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func readFileString(t *testing.T, path string) string {
+	data, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	return string(data)
+}
+
+// listFiles 目录下的文件名，按名称排序
+func listFiles(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestFileWriteNode(t *testing.T) {
+	var targetNodeType = "fileWrite"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &FileWriteNode{}, types.Configuration{
+			"dateFormat":   "2006-01-02",
+			"format":       FileWriteFormatJsonl,
+			"delimiter":    ",",
+			"fsync":        FileWriteFsyncNone,
+			"maxOpenFiles": 16,
+		}, Registry)
+	})
+
+	t.Run("InitNode", func(t *testing.T) {
+		for _, configuration := range []types.Configuration{
+			{},
+			{"path": "a.log", "format": "xml"},
+			{"path": "a.log", "delimiter": "ab"},
+			{"path": "a.log", "rotateInterval": "abc"},
+			{"path": "a.log", "rotateInterval": "-1s"},
+			{"path": "a.log", "fsync": "abc"},
+			{"path": "a.log", "fsync": "0s"},
+		} {
+			_, err := test.CreateAndInitNode(targetNodeType, configuration, Registry)
+			assert.NotNil(t, err)
+		}
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{"path": "a.log", "fsync": "1s"}, Registry)
+		assert.Nil(t, err)
+		assert.Equal(t, time.Second, node.(*FileWriteNode).fsyncInterval)
+	})
+
+	t.Run("Jsonl", func(t *testing.T) {
+		dir := t.TempDir()
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"path":  filepath.Join(dir, "${metadata.deviceId}", "${_date}-${msgType}.jsonl"),
+			"fsync": FileWriteFsyncAlways,
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		ts := time.Date(2025, 1, 2, 10, 0, 0, 0, time.Local).UnixMilli()
+		node.OnMsg(ctx, newWindowMsg(ts, "dev1", `{"temperature": 41,
+  "humidity": 30}`))
+		node.OnMsg(ctx, newWindowMsg(ts, "dev1", `not json`))
+		node.OnMsg(ctx, newWindowMsg(ts, "dev2", `[1,2]`))
+		//路径不允许跳出模板目录
+		node.OnMsg(ctx, newWindowMsg(ts, "../dev3", `{}`))
+
+		results := collector.get()
+		assert.Equal(t, 4, len(results))
+		path := filepath.Join(dir, "dev1", "2025-01-02-TELEMETRY.jsonl")
+		assert.Equal(t, types.Success, results[0].relationType)
+		assert.Equal(t, path, results[0].msg.Metadata.GetValue(FileWriteKeyPath))
+		assert.Equal(t, "{\"temperature\":41,\"humidity\":30}\n\"not json\"\n", readFileString(t, path))
+		assert.Equal(t, "[1,2]\n", readFileString(t, filepath.Join(dir, "dev2", "2025-01-02-TELEMETRY.jsonl")))
+		assert.Equal(t, types.Failure, results[3].relationType)
+		assert.Equal(t, []string{"dev1", "dev2"}, listFiles(t, dir))
+	})
+
+	t.Run("Envelope", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "msgs.jsonl")
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"path":     path,
+			"envelope": true,
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+		ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+			assert.Equal(t, types.Success, relationType)
+		})
+		msg := newWindowMsg(1000, "dev1", `{"a": "<b>"}`)
+		msg.Id = "id1"
+		node.OnMsg(ctx, msg)
+		assert.Equal(t, `{"id":"id1","ts":1000,"type":"TELEMETRY","dataType":"JSON","metadata":{"deviceId":"dev1"},"data":{"a":"<b>"}}`+"\n", readFileString(t, path))
+	})
+
+	t.Run("Csv", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "data.csv")
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"path":        path,
+			"format":      FileWriteFormatCsv,
+			"headers":     []string{"id", "name"},
+			"writeHeader": true,
+			"maxSize":     40,
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"id":1,"name":"a,b","other":true}`))
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `[{"id":2,"name":"c"},{"id":3}]`))
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `[4,"d"]`))
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `"abc"`))
+		assert.Equal(t, "id,name\n1,\"a,b\"\n2,c\n3,\n4,d\n", readFileString(t, path))
+		assert.Equal(t, types.Failure, collector.get()[3].relationType)
+
+		//超过最大字节数，轮转后新文件重新写入表头
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"id":5,"name":"long name for rotation test"}`))
+		assert.Equal(t, "id,name\n5,long name for rotation test\n", readFileString(t, path))
+		names := listFiles(t, filepath.Dir(path))
+		assert.Equal(t, 2, len(names))
+		assert.True(t, strings.HasPrefix(names[0], "data-") && strings.HasSuffix(names[0], ".csv"))
+		assert.Equal(t, "id,name\n1,\"a,b\"\n2,c\n3,\n4,d\n", readFileString(t, filepath.Join(filepath.Dir(path), names[0])))
+	})
+
+	t.Run("RawRotateCompress", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+		//上一个周期写入的文件，打开时按时间轮转
+		assert.Nil(t, os.WriteFile(path, []byte("old\n"), 0644))
+		old := time.Now().Add(-2 * time.Hour)
+		assert.Nil(t, os.Chtimes(path, old, old))
+
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"path":           path,
+			"format":         FileWriteFormatRaw,
+			"rotateInterval": "1h",
+			"compress":       true,
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", "line1"))
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", "line2\n"))
+		for _, result := range collector.get() {
+			assert.Equal(t, types.Success, result.relationType)
+		}
+		assert.Equal(t, "line1\nline2\n", readFileString(t, path))
+
+		names := listFiles(t, dir)
+		assert.Equal(t, 2, len(names))
+		rotated := "app-" + old.Truncate(time.Hour).Format("20060102T150405") + ".log.gz"
+		assert.Equal(t, rotated, names[0])
+		f, err := os.Open(filepath.Join(dir, rotated))
+		assert.Nil(t, err)
+		defer f.Close()
+		reader, err := gzip.NewReader(f)
+		assert.Nil(t, err)
+		data, err := io.ReadAll(reader)
+		assert.Nil(t, err)
+		assert.Equal(t, "old\n", string(data))
+	})
+
+	t.Run("SharedFile", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "shared.log")
+		configuration := types.Configuration{"path": path, "format": FileWriteFormatRaw}
+		node1, err := test.CreateAndInitNode(targetNodeType, configuration, Registry)
+		assert.Nil(t, err)
+		node2, err := test.CreateAndInitNode(targetNodeType, configuration, Registry)
+		assert.Nil(t, err)
+		ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+			assert.Equal(t, types.Success, relationType)
+		})
+
+		var wg sync.WaitGroup
+		for _, node := range []types.Node{node1, node2} {
+			wg.Add(1)
+			go func(node types.Node) {
+				defer wg.Done()
+				for i := 0; i < 100; i++ {
+					node.OnMsg(ctx, newWindowMsg(0, "dev1", "0123456789"))
+				}
+			}(node)
+		}
+		wg.Wait()
+		abs, _ := filepath.Abs(path)
+		sharedFiles.Lock()
+		f := sharedFiles.files[abs]
+		sharedFiles.Unlock()
+		assert.Equal(t, 2, f.refs)
+
+		node1.Destroy()
+		sharedFiles.Lock()
+		assert.Equal(t, 1, f.refs)
+		sharedFiles.Unlock()
+		node2.Destroy()
+		sharedFiles.Lock()
+		_, ok := sharedFiles.files[abs]
+		sharedFiles.Unlock()
+		assert.False(t, ok)
+		assert.Nil(t, f.file)
+		assert.Equal(t, strings.Repeat("0123456789\n", 200), readFileString(t, path))
+	})
+
+	t.Run("MaxOpenFiles", func(t *testing.T) {
+		dir := t.TempDir()
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"path":         filepath.Join(dir, "${metadata.deviceId}.log"),
+			"maxOpenFiles": 2,
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+		ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+			assert.Equal(t, types.Success, relationType)
+		})
+		for _, deviceId := range []string{"dev1", "dev2", "dev1", "dev3"} {
+			node.OnMsg(ctx, newWindowMsg(0, deviceId, `{}`))
+			time.Sleep(time.Millisecond)
+		}
+		files := node.(*FileWriteNode).files
+		assert.Equal(t, 2, len(files))
+		_, ok := files[filepath.Join(dir, "dev2.log")]
+		assert.False(t, ok)
+		assert.Equal(t, "{}\n{}\n", readFileString(t, filepath.Join(dir, "dev1.log")))
+	})
+}
@@ -0,0 +1,359 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+//规则链节点配置示例：
+//{
+//        "id": "s1",
+//        "type": "stateMachine",
+//        "name": "设备状态",
+//        "configuration": {
+//          "key": "state:${metadata.deviceId}",
+//          "initialState": "offline",
+//          "states": [
+//            {"name": "offline", "entry": "OnOffline"},
+//            {"name": "online", "entry": "OnOnline", "exit": "LeaveOnline"},
+//            {"name": "maintenance"}
+//          ],
+//          "transitions": [
+//            {"from": "offline", "to": "online", "condition": "msgType == 'CONNECT'"},
+//            {"from": "online", "to": "offline", "condition": "msgType == 'DISCONNECT'"},
+//            {"from": "*", "to": "maintenance", "condition": "msg.mode == 'maintenance'"}
+//          ]
+//        }
+//  }
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/el"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+const (
+	// StateMachineRelationTransition 状态发生变化的关系类型
+	StateMachineRelationTransition = "Transition"
+	// StateMachineRelationUnchanged 状态没有变化的关系类型
+	StateMachineRelationUnchanged = "Unchanged"
+	// StateMachineAnyState 转换规则中匹配任意状态
+	StateMachineAnyState = "*"
+	// StateMachineLevelChain 状态保存在当前规则链命名空间的缓存
+	StateMachineLevelChain = "chain"
+	// StateMachineLevelGlobal 状态保存在全局缓存，可以跨规则链共享
+	StateMachineLevelGlobal = "global"
+)
+
+// 状态机消息元数据key
+const (
+	// StateMachineMetadataState 处理该消息后实体的当前状态
+	StateMachineMetadataState = "state"
+	// StateMachineMetadataPreviousState 处理该消息前实体的状态
+	StateMachineMetadataPreviousState = "previousState"
+)
+
+var stateStores = struct {
+	sync.RWMutex
+	stores map[string]StateStore
+}{stores: make(map[string]StateStore)}
+
+// 注册节点
+func init() {
+	Registry.Add(&StateMachineNode{})
+}
+
+// StateStore 实体状态存储，实现需要保证并发安全
+type StateStore interface {
+	// GetState 获取实体当前状态，不存在返回空字符串
+	GetState(key string) (string, error)
+	// SetState 保存实体当前状态
+	SetState(key string, state string) error
+}
+
+// RegisterStateStore 注册状态存储，节点通过 store 配置使用
+func RegisterStateStore(name string, store StateStore) {
+	stateStores.Lock()
+	defer stateStores.Unlock()
+	stateStores.stores[name] = store
+}
+
+// UnregisterStateStore 删除状态存储
+func UnregisterStateStore(name string) {
+	stateStores.Lock()
+	defer stateStores.Unlock()
+	delete(stateStores.stores, name)
+}
+
+func getStateStore(name string) (StateStore, bool) {
+	stateStores.RLock()
+	defer stateStores.RUnlock()
+	store, ok := stateStores.stores[name]
+	return store, ok
+}
+
+// CacheStateStore 基于 types.Cache 的状态存储
+type CacheStateStore struct {
+	Cache types.Cache
+}
+
+// NewCacheStateStore 创建基于缓存的状态存储
+func NewCacheStateStore(cache types.Cache) *CacheStateStore {
+	return &CacheStateStore{Cache: cache}
+}
+
+func (s *CacheStateStore) GetState(key string) (string, error) {
+	if v := s.Cache.Get(key); v != nil {
+		return str.ToString(v), nil
+	}
+	return "", nil
+}
+
+func (s *CacheStateStore) SetState(key string, state string) error {
+	return s.Cache.Set(key, state, "")
+}
+
+// StateConfig 状态配置
+type StateConfig struct {
+	//状态名称
+	Name string
+	//进入该状态时发送消息的关系类型，为空不发送
+	Entry string
+	//离开该状态时发送消息的关系类型，为空不发送
+	Exit string
+}
+
+// TransitionConfig 状态转换规则
+type TransitionConfig struct {
+	//源状态，* 表示任意状态
+	From string
+	//目标状态
+	To string
+	//触发条件expr表达式，例如：msg.temperature > 50。为空表示无条件转换
+	Condition string
+}
+
+// StateMachineNodeConfiguration 节点配置
+type StateMachineNodeConfiguration struct {
+	//实体状态key，通过 ${metadata.key} 从元数据变量中获取或者通过 ${msg.key} 从消息负荷中获取
+	Key string
+	//实体没有状态时的初始状态，为空则使用第一个状态
+	InitialState string
+	//状态列表
+	States []StateConfig
+	//状态转换规则，按照顺序匹配，使用第一个匹配的规则
+	Transitions []TransitionConfig
+	//缓存级别，chain：规则链缓存，global：全局缓存，默认chain
+	Level string
+	//状态存储，通过 RegisterStateStore 注册。不为空则忽略 level
+	Store string
+}
+
+// StateMachineNode 有限状态机节点，每个实体(key)维护一个当前状态。
+// 消息到达时按照顺序匹配源状态为当前状态(或者*)并且条件满足的第一个转换规则：
+//   - 匹配成功并且状态变化：依次发送到源状态的 exit 链、目标状态的 entry 链(如果配置)和 `Transition` 链
+//   - 没有匹配或者状态没有变化：发送到 `Unchanged` 链
+//
+// 输出消息增加 state(当前状态)和 previousState(处理前状态)元数据。
+// 实体状态保存在 ChainCache/GlobalCache 或者通过 RegisterStateStore 注册的存储中，规则链重新加载后状态不会丢失。
+type StateMachineNode struct {
+	//节点配置
+	Config      StateMachineNodeConfiguration
+	keyTemplate *el.MixedTemplate
+	store       StateStore
+	states      map[string]StateConfig
+	transitions []stateTransition
+	mu          sync.Mutex
+}
+
+// stateTransition 编译后的转换规则
+type stateTransition struct {
+	from    string
+	to      string
+	program *vm.Program
+}
+
+// Type 组件类型
+func (x *StateMachineNode) Type() string {
+	return "stateMachine"
+}
+
+func (x *StateMachineNode) New() types.Node {
+	return &StateMachineNode{Config: StateMachineNodeConfiguration{
+		Key:   "state:${metadata.deviceId}",
+		Level: StateMachineLevelChain,
+	}}
+}
+
+// Init 初始化
+func (x *StateMachineNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(x.Config.Key) == "" {
+		return errors.New("key can not be empty")
+	}
+	if len(x.Config.States) == 0 {
+		return errors.New("states can not be empty")
+	}
+	x.states = make(map[string]StateConfig)
+	for _, state := range x.Config.States {
+		if state.Name == "" || state.Name == StateMachineAnyState {
+			return fmt.Errorf("invalid state name:%s", state.Name)
+		}
+		if _, ok := x.states[state.Name]; ok {
+			return fmt.Errorf("duplicate state:%s", state.Name)
+		}
+		x.states[state.Name] = state
+	}
+	if x.Config.InitialState == "" {
+		x.Config.InitialState = x.Config.States[0].Name
+	} else if _, ok := x.states[x.Config.InitialState]; !ok {
+		return fmt.Errorf("initial state:%s not found", x.Config.InitialState)
+	}
+	x.transitions = nil
+	for _, item := range x.Config.Transitions {
+		if _, ok := x.states[item.From]; !ok && item.From != StateMachineAnyState {
+			return fmt.Errorf("transition from state:%s not found", item.From)
+		}
+		if _, ok := x.states[item.To]; !ok {
+			return fmt.Errorf("transition to state:%s not found", item.To)
+		}
+		transition := stateTransition{from: item.From, to: item.To}
+		if strings.TrimSpace(item.Condition) != "" {
+			if transition.program, err = expr.Compile(item.Condition, expr.AllowUndefinedVariables()); err != nil {
+				return err
+			}
+		}
+		x.transitions = append(x.transitions, transition)
+	}
+	if x.Config.Level == "" {
+		x.Config.Level = StateMachineLevelChain
+	}
+	if x.Config.Level != StateMachineLevelChain && x.Config.Level != StateMachineLevelGlobal {
+		return fmt.Errorf("unsupported level:%s", x.Config.Level)
+	}
+	x.store = nil
+	if x.Config.Store != "" {
+		store, ok := getStateStore(x.Config.Store)
+		if !ok {
+			return fmt.Errorf("state store:%s not found", x.Config.Store)
+		}
+		x.store = store
+	}
+	x.keyTemplate, err = el.NewMixedTemplate(x.Config.Key)
+	return err
+}
+
+// OnMsg 处理消息
+func (x *StateMachineNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	store := x.getStore(ctx)
+	if store == nil {
+		ctx.TellFailure(msg, types.ErrCacheNotInitialized)
+		return
+	}
+	key := x.keyTemplate.ExecuteAsString(base.NodeUtils.GetEvnAndMetadata(ctx, msg))
+	evn := base.NodeUtils.GetEvn(ctx, msg)
+
+	x.mu.Lock()
+	current, err := store.GetState(key)
+	if err != nil {
+		x.mu.Unlock()
+		ctx.TellFailure(msg, err)
+		return
+	}
+	//状态不存在或者状态已经从配置中删除，使用初始状态
+	if _, ok := x.states[current]; !ok {
+		current = x.Config.InitialState
+	}
+	next := current
+	for _, transition := range x.transitions {
+		if transition.from != StateMachineAnyState && transition.from != current {
+			continue
+		}
+		if transition.program != nil {
+			out, err := vm.Run(transition.program, evn)
+			if err != nil {
+				x.mu.Unlock()
+				ctx.TellFailure(msg, err)
+				return
+			}
+			if ok, _ := out.(bool); !ok {
+				continue
+			}
+		}
+		next = transition.to
+		break
+	}
+	if next != current {
+		err = store.SetState(key, next)
+	}
+	x.mu.Unlock()
+
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.Metadata.PutValue(StateMachineMetadataState, next)
+	msg.Metadata.PutValue(StateMachineMetadataPreviousState, current)
+	if next == current {
+		ctx.TellNext(msg, StateMachineRelationUnchanged)
+		return
+	}
+	if exit := x.states[current].Exit; exit != "" {
+		ctx.TellNext(msg.Copy(), exit)
+	}
+	if entry := x.states[next].Entry; entry != "" {
+		ctx.TellNext(msg.Copy(), entry)
+	}
+	ctx.TellNext(msg, StateMachineRelationTransition)
+}
+
+// Destroy 销毁
+func (x *StateMachineNode) Destroy() {
+}
+
+// Def 组件定义，状态的 entry/exit 关系类型由配置决定
+func (x *StateMachineNode) Def() types.ComponentForm {
+	relationTypes := []string{StateMachineRelationTransition, StateMachineRelationUnchanged, types.Failure}
+	return types.ComponentForm{
+		Type:          x.Type(),
+		RelationTypes: &relationTypes,
+	}
+}
+
+func (x *StateMachineNode) getStore(ctx types.RuleContext) StateStore {
+	if x.store != nil {
+		return x.store
+	}
+	var c types.Cache
+	if x.Config.Level == StateMachineLevelGlobal {
+		c = ctx.GlobalCache()
+	} else {
+		c = ctx.ChainCache()
+	}
+	if c == nil {
+		return nil
+	}
+	return NewCacheStateStore(c)
+}
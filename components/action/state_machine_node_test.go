@@ -0,0 +1,189 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/reflect"
+)
+
+// memoryStateStore 测试用状态存储
+type memoryStateStore struct {
+	states sync.Map
+}
+
+func (s *memoryStateStore) GetState(key string) (string, error) {
+	if v, ok := s.states.Load(key); ok {
+		return v.(string), nil
+	}
+	return "", nil
+}
+
+func (s *memoryStateStore) SetState(key string, state string) error {
+	s.states.Store(key, state)
+	return nil
+}
+
+var stateMachineTestConfig = types.Configuration{
+	"key":          "state:${metadata.deviceId}",
+	"initialState": "offline",
+	"states": []interface{}{
+		map[string]interface{}{"name": "offline", "entry": "OnOffline"},
+		map[string]interface{}{"name": "online", "entry": "OnOnline", "exit": "LeaveOnline"},
+		map[string]interface{}{"name": "maintenance"},
+	},
+	"transitions": []interface{}{
+		map[string]interface{}{"from": "offline", "to": "online", "condition": "msgType == 'CONNECT'"},
+		map[string]interface{}{"from": "online", "to": "offline", "condition": "msgType == 'DISCONNECT'"},
+		map[string]interface{}{"from": "*", "to": "maintenance", "condition": "msg.mode == 'maintenance'"},
+		map[string]interface{}{"from": "maintenance", "to": "offline", "condition": "msg.mode == 'normal'"},
+	},
+}
+
+func newStateMsg(deviceId, msgType, data string) types.RuleMsg {
+	metadata := types.NewMetadata()
+	metadata.PutValue("deviceId", deviceId)
+	return types.NewMsg(0, msgType, types.JSON, metadata, data)
+}
+
+func TestStateMachineNode(t *testing.T) {
+	var targetNodeType = "stateMachine"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &StateMachineNode{}, types.Configuration{
+			"key":   "state:${metadata.deviceId}",
+			"level": StateMachineLevelChain,
+		}, Registry)
+		form := reflect.GetComponentForm(&StateMachineNode{})
+		assert.Equal(t, []string{StateMachineRelationTransition, StateMachineRelationUnchanged, types.Failure}, *form.RelationTypes)
+	})
+
+	t.Run("InitNode", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"states": []interface{}{map[string]interface{}{"name": "a"}, map[string]interface{}{"name": "b"}},
+		}, Registry)
+		assert.Nil(t, err)
+		assert.Equal(t, "a", node.(*StateMachineNode).Config.InitialState)
+	})
+
+	t.Run("InitError", func(t *testing.T) {
+		states := []interface{}{map[string]interface{}{"name": "a"}, map[string]interface{}{"name": "b"}}
+		for _, config := range []types.Configuration{
+			{},
+			{"key": "", "states": states},
+			{"states": []interface{}{map[string]interface{}{"name": "a"}, map[string]interface{}{"name": "a"}}},
+			{"states": []interface{}{map[string]interface{}{"name": "*"}}},
+			{"states": states, "initialState": "c"},
+			{"states": states, "transitions": []interface{}{map[string]interface{}{"from": "c", "to": "a"}}},
+			{"states": states, "transitions": []interface{}{map[string]interface{}{"from": "a", "to": "*"}}},
+			{"states": states, "transitions": []interface{}{map[string]interface{}{"from": "a", "to": "b", "condition": "msg.a >"}}},
+			{"states": states, "level": "node"},
+			{"states": states, "store": "notFound"},
+		} {
+			_, err := test.CreateAndInitNode(targetNodeType, config, Registry)
+			assert.NotNil(t, err)
+		}
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, stateMachineTestConfig, Registry)
+		assert.Nil(t, err)
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+
+		node.OnMsg(ctx, newStateMsg("d1", "TELEMETRY", `{}`))
+		node.OnMsg(ctx, newStateMsg("d1", "CONNECT", `{}`))
+		node.OnMsg(ctx, newStateMsg("d2", "CONNECT", `{}`))
+		node.OnMsg(ctx, newStateMsg("d1", "CONNECT", `{}`))
+		node.OnMsg(ctx, newStateMsg("d1", "TELEMETRY", `{"mode":"maintenance"}`))
+		node.OnMsg(ctx, newStateMsg("d1", "CONNECT", `{}`))
+		node.OnMsg(ctx, newStateMsg("d1", "TELEMETRY", `{"mode":"normal"}`))
+		node.OnMsg(ctx, newStateMsg("d2", "DISCONNECT", `{}`))
+
+		type emitted struct {
+			deviceId      string
+			relationType  string
+			state         string
+			previousState string
+		}
+		var actual []emitted
+		for _, item := range collector.get() {
+			actual = append(actual, emitted{
+				deviceId:      item.msg.Metadata.GetValue("deviceId"),
+				relationType:  item.relationType,
+				state:         item.msg.Metadata.GetValue(StateMachineMetadataState),
+				previousState: item.msg.Metadata.GetValue(StateMachineMetadataPreviousState),
+			})
+		}
+		assert.Equal(t, []emitted{
+			{"d1", StateMachineRelationUnchanged, "offline", "offline"},
+			{"d1", "OnOnline", "online", "offline"},
+			{"d1", StateMachineRelationTransition, "online", "offline"},
+			{"d2", "OnOnline", "online", "offline"},
+			{"d2", StateMachineRelationTransition, "online", "offline"},
+			{"d1", StateMachineRelationUnchanged, "online", "online"},
+			{"d1", "LeaveOnline", "maintenance", "online"},
+			{"d1", StateMachineRelationTransition, "maintenance", "online"},
+			{"d1", StateMachineRelationUnchanged, "maintenance", "maintenance"},
+			{"d1", "OnOffline", "offline", "maintenance"},
+			{"d1", StateMachineRelationTransition, "offline", "maintenance"},
+			{"d2", "LeaveOnline", "offline", "online"},
+			{"d2", "OnOffline", "offline", "online"},
+			{"d2", StateMachineRelationTransition, "offline", "online"},
+		}, actual)
+
+		//状态保存在规则链缓存中，新节点实例读取已有状态
+		node2, err := test.CreateAndInitNode(targetNodeType, stateMachineTestConfig, Registry)
+		assert.Nil(t, err)
+		_ = ctx.ChainCache().Set("state:d3", "online", "")
+		node2.OnMsg(ctx, newStateMsg("d3", "DISCONNECT", `{}`))
+		results := collector.get()
+		assert.Equal(t, "online", results[len(results)-1].msg.Metadata.GetValue(StateMachineMetadataPreviousState))
+		assert.Equal(t, "offline", results[len(results)-1].msg.Metadata.GetValue(StateMachineMetadataState))
+	})
+
+	t.Run("Store", func(t *testing.T) {
+		store := &memoryStateStore{}
+		RegisterStateStore("test", store)
+		defer UnregisterStateStore("test")
+
+		config := types.Configuration{"store": "test"}
+		for k, v := range stateMachineTestConfig {
+			config[k] = v
+		}
+		node, err := test.CreateAndInitNode(targetNodeType, config, Registry)
+		assert.Nil(t, err)
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		node.OnMsg(ctx, newStateMsg("d1", "CONNECT", `{}`))
+		state, _ := store.GetState("state:d1")
+		assert.Equal(t, "online", state)
+
+		//未知状态使用初始状态
+		_ = store.SetState("state:d2", "unknown")
+		node.OnMsg(ctx, newStateMsg("d2", "TELEMETRY", `{}`))
+		results := collector.get()
+		assert.Equal(t, "offline", results[len(results)-1].msg.Metadata.GetValue(StateMachineMetadataState))
+	})
+}
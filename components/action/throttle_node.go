@@ -0,0 +1,226 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+//规则链节点配置示例：
+//{
+//        "id": "s1",
+//        "type": "throttle",
+//        "name": "限流",
+//        "configuration": {
+//          "key": "${metadata.deviceId}",
+//          "limit": 1,
+//          "interval": "10s",
+//          "leading": true,
+//          "trailing": true
+//        }
+//  }
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// ThrottleRelationThrottled 超出限制的消息的关系类型
+const ThrottleRelationThrottled = "Throttled"
+
+// 注册节点
+func init() {
+	Registry.Add(&ThrottleNode{})
+}
+
+// ThrottleNodeConfiguration 节点配置
+type ThrottleNodeConfiguration struct {
+	//分组key，通过 ${metadata.key} 从元数据变量中获取或者通过 ${msg.key} 从消息负荷中获取，为空则所有消息共享一个限额
+	Key string
+	//每个时间间隔内允许通过的最大消息数量，默认1
+	Limit int
+	//时间间隔，例如：1s、1m
+	Interval string
+	//是否在时间间隔内立即放行前 limit 条消息，默认true
+	//false：只在时间间隔结束时发送最后一条消息，必须开启trailing，limit不生效
+	Leading bool
+	//是否在时间间隔结束时发送该间隔内最后一条超出限制的消息(占用下一个间隔的额度)
+	Trailing bool
+	//是否直接丢弃超出限制的消息，false：发送到`Throttled`链
+	//丢弃的消息不会流转到任何节点，该消息的执行分支以`Throttled`关系结束
+	DropExcess bool
+}
+
+// ThrottleNode 限流节点，每个key在每个时间间隔内最多允许 limit 条消息通过成功链路(`Success`)，
+// 超出限制的消息发送到`Throttled`链或者丢弃。
+// 如果开启trailing，时间间隔内最后一条超出限制的消息会在间隔结束时发送到成功链路，被覆盖的消息按照超出限制处理。
+// 如果关闭leading，时间间隔内的消息不会立即放行，只在间隔结束时发送最后一条消息(防抖)。
+type ThrottleNode struct {
+	//节点配置
+	Config   ThrottleNodeConfiguration
+	interval time.Duration
+	states   map[string]*throttleState
+	//上一次清理过期状态的时间
+	lastCleanup time.Time
+	mu          sync.Mutex
+	destroyed   bool
+}
+
+// throttleState 每个key的限流状态
+type throttleState struct {
+	//当前时间间隔开始时间
+	start time.Time
+	//当前时间间隔已通过的消息数量
+	count int
+	//等待在间隔结束时发送的消息
+	pending    *types.RuleMsg
+	pendingCtx types.RuleContext
+	timer      *time.Timer
+}
+
+// Type 组件类型
+func (x *ThrottleNode) Type() string {
+	return "throttle"
+}
+
+func (x *ThrottleNode) New() types.Node {
+	return &ThrottleNode{Config: ThrottleNodeConfiguration{Limit: 1, Interval: "1s", Leading: true}}
+}
+
+// Init 初始化
+func (x *ThrottleNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.Config.Limit <= 0 {
+		x.Config.Limit = 1
+	}
+	if !x.Config.Leading && !x.Config.Trailing {
+		return fmt.Errorf("leading and trailing can not both be false")
+	}
+	if x.interval, err = time.ParseDuration(x.Config.Interval); err != nil {
+		return err
+	} else if x.interval <= 0 {
+		return fmt.Errorf("interval must be greater than 0")
+	}
+	x.states = make(map[string]*throttleState)
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *ThrottleNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	key := ""
+	if x.Config.Key != "" {
+		key = str.ExecuteTemplate(x.Config.Key, base.NodeUtils.GetEvnAndMetadata(ctx, msg))
+	}
+	now := time.Now()
+
+	x.mu.Lock()
+	x.cleanup(now)
+	state, ok := x.states[key]
+	if !ok {
+		state = &throttleState{start: now}
+		x.states[key] = state
+	} else if state.pending == nil && now.Sub(state.start) >= x.interval {
+		state.start = now
+		state.count = 0
+	}
+	if x.Config.Leading && state.count < x.Config.Limit {
+		state.count++
+		x.mu.Unlock()
+		ctx.TellSuccess(msg)
+		return
+	}
+	if !x.Config.Trailing {
+		x.mu.Unlock()
+		x.excess(ctx, msg)
+		return
+	}
+	//保留最后一条消息，被覆盖的消息按照超出限制处理
+	oldMsg, oldCtx := state.pending, state.pendingCtx
+	state.pending, state.pendingCtx = &msg, ctx
+	if state.timer == nil {
+		state.timer = time.AfterFunc(state.start.Add(x.interval).Sub(now), func() {
+			x.flush(key, state)
+		})
+	}
+	x.mu.Unlock()
+	if oldMsg != nil {
+		x.excess(oldCtx, *oldMsg)
+	}
+}
+
+// Destroy 销毁，停止等待发送的消息
+func (x *ThrottleNode) Destroy() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.destroyed = true
+	for _, state := range x.states {
+		if state.timer != nil {
+			state.timer.Stop()
+		}
+	}
+	x.states = make(map[string]*throttleState)
+}
+
+// Def 组件定义，超出限制的消息发送到`Throttled`链
+func (x *ThrottleNode) Def() types.ComponentForm {
+	relationTypes := []string{types.Success, ThrottleRelationThrottled, types.Failure}
+	return types.ComponentForm{
+		Type:          x.Type(),
+		RelationTypes: &relationTypes,
+	}
+}
+
+// flush 时间间隔结束，发送等待的消息，并开始新的时间间隔
+func (x *ThrottleNode) flush(key string, state *throttleState) {
+	x.mu.Lock()
+	if x.destroyed || x.states[key] != state || state.pending == nil {
+		x.mu.Unlock()
+		return
+	}
+	msg, ctx := *state.pending, state.pendingCtx
+	state.pending, state.pendingCtx, state.timer = nil, nil, nil
+	state.start = time.Now()
+	state.count = 1
+	x.mu.Unlock()
+	ctx.TellSuccess(msg)
+}
+
+// excess 处理超出限制的消息，丢弃的消息需要显式结束执行分支，否则同步等待的调用方无法结束
+func (x *ThrottleNode) excess(ctx types.RuleContext, msg types.RuleMsg) {
+	if x.Config.DropExcess {
+		ctx.DoOnEnd(msg, nil, ThrottleRelationThrottled)
+	} else {
+		ctx.TellNext(msg, ThrottleRelationThrottled)
+	}
+}
+
+// cleanup 清理已经过期的key，每个时间间隔最多清理一次
+func (x *ThrottleNode) cleanup(now time.Time) {
+	if now.Sub(x.lastCleanup) < x.interval {
+		return
+	}
+	x.lastCleanup = now
+	for key, state := range x.states {
+		if state.pending == nil && now.Sub(state.start) >= x.interval {
+			delete(x.states, key)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/reflect"
+)
+
+func TestThrottleNode(t *testing.T) {
+	var targetNodeType = "throttle"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &ThrottleNode{}, types.Configuration{
+			"limit":    1,
+			"interval": "1s",
+		}, Registry)
+		form := reflect.GetComponentForm(&ThrottleNode{})
+		assert.Equal(t, []string{types.Success, ThrottleRelationThrottled, types.Failure}, *form.RelationTypes)
+	})
+
+	t.Run("InitNode", func(t *testing.T) {
+		test.NodeInit(t, targetNodeType, types.Configuration{
+			"key":      "${metadata.deviceId}",
+			"limit":    -1,
+			"interval": "10s",
+		}, types.Configuration{
+			"key":      "${metadata.deviceId}",
+			"limit":    1,
+			"interval": "10s",
+		}, Registry)
+		_, err := test.CreateAndInitNode(targetNodeType, types.Configuration{"interval": "abc"}, Registry)
+		assert.NotNil(t, err)
+		_, err = test.CreateAndInitNode(targetNodeType, types.Configuration{"interval": "0s"}, Registry)
+		assert.NotNil(t, err)
+		_, err = test.CreateAndInitNode(targetNodeType, types.Configuration{"leading": false}, Registry)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"key":      "${metadata.deviceId}",
+			"limit":    2,
+			"interval": "200ms",
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		for i := 0; i < 3; i++ {
+			node.OnMsg(ctx, newWindowMsg(0, "dev1", `{}`))
+		}
+		node.OnMsg(ctx, newWindowMsg(0, "dev2", `{}`))
+		results := collector.get()
+		assert.Equal(t, 4, len(results))
+		assert.Equal(t, types.Success, results[0].relationType)
+		assert.Equal(t, types.Success, results[1].relationType)
+		assert.Equal(t, ThrottleRelationThrottled, results[2].relationType)
+		assert.Equal(t, types.Success, results[3].relationType)
+
+		//下一个时间间隔
+		time.Sleep(time.Millisecond * 250)
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{}`))
+		results = collector.get()
+		assert.Equal(t, types.Success, results[4].relationType)
+	})
+
+	t.Run("DropExcess", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"interval":   "1m",
+			"dropExcess": true,
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		collector := &windowCollector{}
+		var ended []string
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		ctx.SetEndFunc(func(ctx types.RuleContext, msg types.RuleMsg, err error, relationType string) {
+			ended = append(ended, relationType)
+		})
+		for i := 0; i < 3; i++ {
+			node.OnMsg(ctx, newWindowMsg(0, "dev1", `{}`))
+		}
+		results := collector.get()
+		assert.Equal(t, 1, len(results))
+		assert.Equal(t, types.Success, results[0].relationType)
+		//丢弃的消息结束执行分支
+		assert.Equal(t, []string{types.Success, ThrottleRelationThrottled, ThrottleRelationThrottled}, ended)
+	})
+
+	t.Run("TrailingOnly", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"interval": "200ms",
+			"leading":  false,
+			"trailing": true,
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"seq":1}`))
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"seq":2}`))
+		results := collector.get()
+		//第一条消息不会立即放行
+		assert.Equal(t, 1, len(results))
+		assert.Equal(t, ThrottleRelationThrottled, results[0].relationType)
+		assert.Equal(t, float64(1), results[0].data["seq"])
+
+		results = collector.wait(t, 2, time.Second)
+		assert.Equal(t, types.Success, results[1].relationType)
+		assert.Equal(t, float64(2), results[1].data["seq"])
+
+		//空闲后的消息同样等待间隔结束
+		time.Sleep(time.Millisecond * 250)
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"seq":3}`))
+		assert.Equal(t, 2, len(collector.get()))
+		results = collector.wait(t, 3, time.Second)
+		assert.Equal(t, types.Success, results[2].relationType)
+		assert.Equal(t, float64(3), results[2].data["seq"])
+	})
+
+	t.Run("Trailing", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"interval": "200ms",
+			"trailing": true,
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"seq":1}`))
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"seq":2}`))
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"seq":3}`))
+		results := collector.get()
+		assert.Equal(t, 2, len(results))
+		assert.Equal(t, float64(1), results[0].data["seq"])
+		//被覆盖的消息
+		assert.Equal(t, ThrottleRelationThrottled, results[1].relationType)
+		assert.Equal(t, float64(2), results[1].data["seq"])
+
+		//间隔结束时发送最后一条消息，并占用下一个间隔的额度
+		results = collector.wait(t, 3, time.Second)
+		assert.Equal(t, types.Success, results[2].relationType)
+		assert.Equal(t, float64(3), results[2].data["seq"])
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"seq":4}`))
+		node.Destroy()
+		time.Sleep(time.Millisecond * 300)
+		assert.Equal(t, 3, len(collector.get()))
+	})
+}
@@ -0,0 +1,788 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+//规则链节点配置示例：
+//{
+//        "id": "s1",
+//        "type": "window",
+//        "name": "每个设备每分钟平均温度",
+//        "configuration": {
+//          "key": "${metadata.deviceId}",
+//          "type": "tumbling",
+//          "size": "1m",
+//          "aggregates": [
+//            {"field": "temperature", "func": "avg", "alias": "avgTemperature"},
+//            {"field": "temperature", "func": "max"},
+//            {"func": "count"}
+//          ]
+//        }
+//  }
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/cast"
+	"github.com/rulego/rulego/utils/json"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 窗口类型
+const (
+	// WindowTypeTumbling 滚动窗口，窗口之间不重叠
+	WindowTypeTumbling = "tumbling"
+	// WindowTypeSliding 滑动窗口，每隔 slide 创建一个长度为 size 的窗口，窗口之间可以重叠
+	WindowTypeSliding = "sliding"
+	// WindowTypeSession 会话窗口，同一个key超过 gap 没有新消息则关闭窗口
+	WindowTypeSession = "session"
+)
+
+// 聚合函数，百分位数使用 p+数字，例如：p50、p95、p99.9
+const (
+	WindowFuncCount = "count"
+	WindowFuncSum   = "sum"
+	WindowFuncMin   = "min"
+	WindowFuncMax   = "max"
+	WindowFuncAvg   = "avg"
+	WindowFuncFirst = "first"
+	WindowFuncLast  = "last"
+)
+
+// 窗口结果消息元数据key
+const (
+	WindowMetadataKey   = "windowKey"
+	WindowMetadataStart = "windowStart"
+	WindowMetadataEnd   = "windowEnd"
+)
+
+var (
+	// ErrWindowLateMsg 事件时间早于已经关闭的窗口
+	ErrWindowLateMsg = errors.New("late message, window already closed")
+	// ErrWindowMaxLimit 打开的窗口数量超过限制
+	ErrWindowMaxLimit = errors.New("max limit of windows")
+)
+
+// windowInstances 开启缓存的窗口节点实例，key为缓存key前缀。
+// 节点重新加载时新实例先初始化，恢复窗口之前先让旧实例把未保存的窗口写入缓存
+var windowInstances = struct {
+	sync.Mutex
+	nodes map[string][]*WindowNode
+}{nodes: make(map[string][]*WindowNode)}
+
+// 注册节点
+func init() {
+	Registry.Add(&WindowNode{})
+}
+
+// WindowAggregate 聚合配置
+type WindowAggregate struct {
+	//聚合字段，消息负荷中的字段，支持多级，例如：a.b。count 可以为空
+	Field string
+	//聚合函数：count/sum/min/max/avg/first/last/pNN(百分位数，例如：p95)
+	Func string
+	//结果字段名，默认：func_field，例如：avg_temperature
+	Alias string
+}
+
+// WindowNodeConfiguration 节点配置
+type WindowNodeConfiguration struct {
+	//分组key，通过 ${metadata.key} 从元数据变量中获取或者通过 ${msg.key} 从消息负荷中获取，为空则所有消息一个分组
+	Key string
+	//窗口类型：tumbling/sliding/session，默认tumbling
+	Type string
+	//窗口长度，例如：1m，滚动窗口和滑动窗口有效
+	Size string
+	//滑动步长，例如：10s，滑动窗口有效
+	Slide string
+	//会话超时时间，例如：30s，会话窗口有效
+	Gap string
+	//事件时间，例如：${msg.ts}，支持毫秒时间戳或者RFC3339格式。为空则使用处理时间
+	//使用事件时间时，窗口在最大事件时间(水位线)超过窗口结束时间时关闭，
+	//或者窗口超过一个窗口长度(会话窗口为gap)没有收到新消息时关闭
+	TimeField string
+	//聚合列表
+	Aggregates []WindowAggregate
+	//最大允许打开的窗口数量，超过后新窗口的消息通过失败链路路由，默认10000
+	MaxWindows int
+	//是否把窗口状态保存到 types.Config.Cache，节点重启后恢复未关闭的窗口
+	UseCache bool
+	//窗口状态写入缓存的间隔，例如：1s，默认1s。节点销毁时写入所有未保存的窗口
+	FlushInterval string
+	//百分位数每个窗口保留的最大样本数量，超过后使用蓄水池抽样，默认10000
+	MaxSamples int
+}
+
+// WindowNode 窗口聚合节点
+// 按照key分组，把消息放入滚动、滑动或者会话窗口，窗口关闭时计算聚合结果，每个窗口输出一条JSON消息通过成功链路路由：
+// {"key":"dev1","windowStart":1700000000000,"windowEnd":1700000060000,"count":10,"avg_temperature":25.5}
+// 结果消息的元数据和消息类型取自窗口最后一条消息，并增加 windowKey/windowStart/windowEnd 元数据。
+// 进入窗口的消息不会继续往下传递，迟到的消息和超过窗口数量限制的消息通过失败链路路由。
+type WindowNode struct {
+	//节点配置
+	Config WindowNodeConfiguration
+	//打开的窗口
+	windows map[string]*windowState
+	//窗口最后一条消息的上下文，用于发送结果
+	ctxs    map[string]types.RuleContext
+	lastCtx types.RuleContext
+	//最大事件时间
+	watermark   int64
+	size        int64
+	slide       int64
+	gap         int64
+	aggregates  []windowAggregate
+	cache       types.Cache
+	cachePrefix string
+	mu          sync.Mutex
+	stop        chan struct{}
+	stopOnce    sync.Once
+	//修改后还没有写入缓存的窗口
+	dirty         map[string]struct{}
+	flushInterval time.Duration
+}
+
+// windowAggregate 解析后的聚合配置
+type windowAggregate struct {
+	field      string
+	fn         string
+	percentile float64
+	alias      string
+}
+
+// windowState 窗口状态，可以序列化到缓存
+type windowState struct {
+	Id    string `json:"id"`
+	Key   string `json:"key"`
+	Start int64  `json:"start"`
+	End   int64  `json:"end"`
+	Count int64  `json:"count"`
+	//窗口最后一条消息的时间
+	LastTs int64 `json:"lastTs"`
+	//窗口最后一次更新的处理时间
+	UpdatedAt int64             `json:"updatedAt"`
+	MsgType   string            `json:"msgType"`
+	Metadata  map[string]string `json:"metadata"`
+	Aggs      []*aggState       `json:"aggs"`
+}
+
+// aggState 聚合中间状态
+type aggState struct {
+	//字段出现次数
+	Count int64 `json:"count"`
+	//数值个数
+	Num    int64       `json:"num"`
+	Sum    float64     `json:"sum"`
+	Min    float64     `json:"min"`
+	Max    float64     `json:"max"`
+	First  interface{} `json:"first"`
+	Last   interface{} `json:"last"`
+	Values []float64   `json:"values,omitempty"`
+}
+
+// Type 组件类型
+func (x *WindowNode) Type() string {
+	return "window"
+}
+
+func (x *WindowNode) New() types.Node {
+	return &WindowNode{Config: WindowNodeConfiguration{
+		Type:       WindowTypeTumbling,
+		Size:       "1m",
+		MaxWindows: 10000,
+		Aggregates: []WindowAggregate{{Func: WindowFuncCount}},
+	}}
+}
+
+// Init 初始化
+func (x *WindowNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	if err := maps.Map2Struct(configuration, &x.Config); err != nil {
+		return err
+	}
+	if x.Config.Type == "" {
+		x.Config.Type = WindowTypeTumbling
+	}
+	if x.Config.MaxWindows <= 0 {
+		x.Config.MaxWindows = 10000
+	}
+	if x.Config.MaxSamples <= 0 {
+		x.Config.MaxSamples = 10000
+	}
+	var err error
+	switch x.Config.Type {
+	case WindowTypeTumbling:
+		if x.size, err = parseWindowDuration("size", x.Config.Size); err != nil {
+			return err
+		}
+	case WindowTypeSliding:
+		if x.size, err = parseWindowDuration("size", x.Config.Size); err != nil {
+			return err
+		}
+		if x.slide, err = parseWindowDuration("slide", x.Config.Slide); err != nil {
+			return err
+		}
+		if x.slide > x.size {
+			return fmt.Errorf("slide:%s must not be greater than size:%s", x.Config.Slide, x.Config.Size)
+		}
+	case WindowTypeSession:
+		if x.gap, err = parseWindowDuration("gap", x.Config.Gap); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported window type:%s", x.Config.Type)
+	}
+	x.aggregates = nil
+	for _, item := range x.Config.Aggregates {
+		agg, err := parseWindowAggregate(item)
+		if err != nil {
+			return err
+		}
+		x.aggregates = append(x.aggregates, agg)
+	}
+	x.windows = make(map[string]*windowState)
+	x.ctxs = make(map[string]types.RuleContext)
+	x.dirty = make(map[string]struct{})
+	if x.Config.UseCache {
+		x.flushInterval = time.Second
+		if x.Config.FlushInterval != "" {
+			d, err := time.ParseDuration(x.Config.FlushInterval)
+			if err != nil || d <= 0 {
+				return fmt.Errorf("invalid flushInterval:%s", x.Config.FlushInterval)
+			}
+			x.flushInterval = d
+		}
+		if ruleConfig.Cache == nil {
+			return types.ErrCacheNotInitialized
+		}
+		x.cache = ruleConfig.Cache
+		var chainId string
+		if chainCtx := base.NodeUtils.GetChainCtx(configuration); chainCtx != nil {
+			chainId = chainCtx.GetNodeId().Id
+		}
+		x.cachePrefix = "window:" + chainId + ":" + base.NodeUtils.GetSelfDefinition(configuration).Id + ":"
+		x.register()
+	}
+	x.stop = make(chan struct{})
+	go x.sweep()
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *WindowNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	now := time.Now().UnixMilli()
+	ts := now
+	if x.Config.TimeField != "" {
+		v, err := parseWindowTime(str.ExecuteTemplate(x.Config.TimeField, evn))
+		if err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		ts = v
+	}
+	key := ""
+	if x.Config.Key != "" {
+		key = str.ExecuteTemplate(x.Config.Key, evn)
+	}
+	var data map[string]interface{}
+	if len(x.aggregates) > 0 {
+		data, _ = msg.GetDataAsJson()
+	}
+
+	x.mu.Lock()
+	closed, err := x.assign(ctx, msg, key, ts, now, data)
+	if err != nil {
+		x.mu.Unlock()
+		ctx.TellFailure(msg, err)
+		return
+	}
+	x.lastCtx = ctx
+	if x.isEventTime() {
+		if ts > x.watermark {
+			x.watermark = ts
+		}
+		closed = append(closed, x.collect(now)...)
+	}
+	x.mu.Unlock()
+
+	x.emit(closed)
+}
+
+// Destroy 销毁，未关闭的窗口如果开启了缓存，写入并保留在缓存中
+func (x *WindowNode) Destroy() {
+	if x.stop != nil {
+		x.stopOnce.Do(func() {
+			close(x.stop)
+			if x.cache != nil {
+				x.unregister()
+				x.flush()
+			}
+		})
+	}
+}
+
+// assign 把消息放入所属的窗口，返回因为该消息关闭的窗口
+func (x *WindowNode) assign(ctx types.RuleContext, msg types.RuleMsg, key string, ts, now int64, data map[string]interface{}) ([]closedWindow, error) {
+	var closed []closedWindow
+	var targets []*windowState
+	switch x.Config.Type {
+	case WindowTypeSession:
+		id := key
+		if w, ok := x.windows[id]; ok {
+			if ts >= w.End {
+				//会话已经超时，关闭后开启新的会话
+				emitCtx := x.emitter(w)
+				if emitCtx == nil {
+					emitCtx = ctx
+				}
+				closed = append(closed, x.remove(w, emitCtx))
+			} else if ts <= w.Start-x.gap {
+				return nil, ErrWindowLateMsg
+			} else {
+				if ts < w.Start {
+					w.Start = ts
+				}
+				if ts > w.LastTs {
+					w.LastTs = ts
+					w.End = ts + x.gap
+				}
+				targets = append(targets, w)
+			}
+		}
+		if len(targets) == 0 {
+			w, err := x.open(id, key, ts, ts+x.gap)
+			if err != nil {
+				return closed, err
+			}
+			targets = append(targets, w)
+		}
+	case WindowTypeSliding:
+		//覆盖ts的窗口：start=k*slide 并且 start<=ts<start+size
+		last := floorDiv(ts, x.slide) * x.slide
+		for start := last; start > ts-x.size; start -= x.slide {
+			w, err := x.getOrOpen(key, start, start+x.size)
+			if err == ErrWindowLateMsg {
+				continue
+			} else if err != nil {
+				return closed, err
+			}
+			targets = append(targets, w)
+		}
+		if len(targets) == 0 {
+			return closed, ErrWindowLateMsg
+		}
+	default:
+		start := floorDiv(ts, x.size) * x.size
+		w, err := x.getOrOpen(key, start, start+x.size)
+		if err != nil {
+			return closed, err
+		}
+		targets = append(targets, w)
+	}
+	for _, w := range targets {
+		x.update(w, msg, ts, now, data)
+		x.ctxs[w.Id] = ctx
+		if x.cache != nil {
+			x.dirty[w.Id] = struct{}{}
+		}
+	}
+	return closed, nil
+}
+
+func (x *WindowNode) getOrOpen(key string, start, end int64) (*windowState, error) {
+	id := key + "|" + strconv.FormatInt(start, 10)
+	if w, ok := x.windows[id]; ok {
+		return w, nil
+	}
+	if x.isEventTime() && end <= x.watermark {
+		return nil, ErrWindowLateMsg
+	}
+	return x.open(id, key, start, end)
+}
+
+func (x *WindowNode) open(id, key string, start, end int64) (*windowState, error) {
+	if len(x.windows) >= x.Config.MaxWindows {
+		return nil, ErrWindowMaxLimit
+	}
+	w := &windowState{Id: id, Key: key, Start: start, End: end, LastTs: start}
+	for range x.aggregates {
+		w.Aggs = append(w.Aggs, &aggState{})
+	}
+	x.windows[id] = w
+	return w, nil
+}
+
+func (x *WindowNode) update(w *windowState, msg types.RuleMsg, ts, now int64, data map[string]interface{}) {
+	w.Count++
+	if ts > w.LastTs {
+		w.LastTs = ts
+	}
+	w.UpdatedAt = now
+	w.MsgType = msg.Type
+	if msg.Metadata != nil {
+		w.Metadata = msg.Metadata.Values()
+	}
+	for i, agg := range x.aggregates {
+		if agg.field == "" {
+			continue
+		}
+		v := maps.Get(data, agg.field)
+		if v == nil {
+			continue
+		}
+		state := w.Aggs[i]
+		state.Count++
+		if state.First == nil {
+			state.First = v
+		}
+		state.Last = v
+		f, err := cast.ToFloat64E(v)
+		if err != nil {
+			continue
+		}
+		if state.Num == 0 || f < state.Min {
+			state.Min = f
+		}
+		if state.Num == 0 || f > state.Max {
+			state.Max = f
+		}
+		state.Num++
+		state.Sum += f
+		if agg.percentile >= 0 {
+			if len(state.Values) < x.Config.MaxSamples {
+				state.Values = append(state.Values, f)
+			} else if j := rand.Int63n(state.Num); j < int64(len(state.Values)) {
+				//蓄水池抽样，每个值被保留的概率相同
+				state.Values[j] = f
+			}
+		}
+	}
+}
+
+// closedWindow 关闭的窗口以及用于发送结果的上下文
+type closedWindow struct {
+	window *windowState
+	ctx    types.RuleContext
+}
+
+// collect 收集可以关闭的窗口
+// 从缓存恢复的窗口在节点收到消息之前没有可用的上下文，继续保留在缓存中，等待下一条消息到达后关闭
+func (x *WindowNode) collect(now int64) []closedWindow {
+	var closed []closedWindow
+	for _, w := range x.windows {
+		if !x.canClose(w, now) {
+			continue
+		}
+		if ctx := x.emitter(w); ctx != nil {
+			closed = append(closed, x.remove(w, ctx))
+		}
+	}
+	sort.Slice(closed, func(i, j int) bool {
+		if closed[i].window.End == closed[j].window.End {
+			return closed[i].window.Id < closed[j].window.Id
+		}
+		return closed[i].window.End < closed[j].window.End
+	})
+	return closed
+}
+
+func (x *WindowNode) canClose(w *windowState, now int64) bool {
+	if !x.isEventTime() {
+		return w.End <= now
+	}
+	if w.End <= x.watermark {
+		return true
+	}
+	//长时间没有新消息，按照处理时间关闭
+	idle := x.size
+	if x.Config.Type == WindowTypeSession {
+		idle = x.gap
+	}
+	return w.UpdatedAt > 0 && now-w.UpdatedAt >= idle
+}
+
+// emitter 获取发送窗口结果的上下文，恢复的窗口使用最后一条消息的上下文
+func (x *WindowNode) emitter(w *windowState) types.RuleContext {
+	if ctx := x.ctxs[w.Id]; ctx != nil {
+		return ctx
+	}
+	return x.lastCtx
+}
+
+func (x *WindowNode) remove(w *windowState, ctx types.RuleContext) closedWindow {
+	delete(x.windows, w.Id)
+	delete(x.ctxs, w.Id)
+	delete(x.dirty, w.Id)
+	if x.cache != nil {
+		_ = x.cache.Delete(x.cachePrefix + w.Id)
+	}
+	return closedWindow{window: w, ctx: ctx}
+}
+
+// emit 发送窗口聚合结果
+func (x *WindowNode) emit(closed []closedWindow) {
+	for _, item := range closed {
+		w := item.window
+		result := map[string]interface{}{
+			"key":               w.Key,
+			WindowMetadataStart: w.Start,
+			WindowMetadataEnd:   w.End,
+			"count":             w.Count,
+		}
+		for i, agg := range x.aggregates {
+			result[agg.alias] = agg.value(w, w.Aggs[i])
+		}
+		data, err := json.Marshal(result)
+		metadata := types.BuildMetadata(w.Metadata)
+		metadata.PutValue(WindowMetadataKey, w.Key)
+		metadata.PutValue(WindowMetadataStart, strconv.FormatInt(w.Start, 10))
+		metadata.PutValue(WindowMetadataEnd, strconv.FormatInt(w.End, 10))
+		msg := types.NewMsg(0, w.MsgType, types.JSON, metadata, string(data))
+		if err != nil {
+			item.ctx.TellFailure(msg, err)
+		} else {
+			item.ctx.TellSuccess(msg)
+		}
+	}
+}
+
+// sweep 定时关闭到期的窗口
+func (x *WindowNode) sweep() {
+	ticker := time.NewTicker(x.sweepInterval())
+	defer ticker.Stop()
+	var flushC <-chan time.Time
+	if x.cache != nil {
+		flushTicker := time.NewTicker(x.flushInterval)
+		defer flushTicker.Stop()
+		flushC = flushTicker.C
+	}
+	for {
+		select {
+		case <-x.stop:
+			return
+		case <-flushC:
+			x.flush()
+		case <-ticker.C:
+			x.mu.Lock()
+			closed := x.collect(time.Now().UnixMilli())
+			x.mu.Unlock()
+			x.emit(closed)
+		}
+	}
+}
+
+func (x *WindowNode) sweepInterval() time.Duration {
+	d := x.size
+	if x.slide > 0 && x.slide < d {
+		d = x.slide
+	}
+	if x.gap > 0 && (d == 0 || x.gap < d) {
+		d = x.gap
+	}
+	interval := time.Duration(d) * time.Millisecond / 10
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	} else if interval > time.Second {
+		interval = time.Second
+	}
+	return interval
+}
+
+func (x *WindowNode) isEventTime() bool {
+	return x.Config.TimeField != ""
+}
+
+// flush 把修改过的窗口状态写入缓存
+func (x *WindowNode) flush() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for id := range x.dirty {
+		if w, ok := x.windows[id]; ok {
+			if data, err := json.Marshal(w); err == nil {
+				_ = x.cache.Set(x.cachePrefix+w.Id, string(data), "")
+			}
+		}
+		delete(x.dirty, id)
+	}
+}
+
+// register 让使用相同缓存的旧实例写入未保存的窗口，然后从缓存恢复窗口并登记当前实例
+func (x *WindowNode) register() {
+	windowInstances.Lock()
+	defer windowInstances.Unlock()
+	for _, node := range windowInstances.nodes[x.cachePrefix] {
+		if node.cache == x.cache {
+			node.flush()
+		}
+	}
+	x.restore()
+	windowInstances.nodes[x.cachePrefix] = append(windowInstances.nodes[x.cachePrefix], x)
+}
+
+func (x *WindowNode) unregister() {
+	windowInstances.Lock()
+	defer windowInstances.Unlock()
+	nodes := windowInstances.nodes[x.cachePrefix]
+	for i, node := range nodes {
+		if node == x {
+			nodes = append(nodes[:i:i], nodes[i+1:]...)
+			break
+		}
+	}
+	if len(nodes) == 0 {
+		delete(windowInstances.nodes, x.cachePrefix)
+	} else {
+		windowInstances.nodes[x.cachePrefix] = nodes
+	}
+}
+
+// restore 从缓存恢复未关闭的窗口
+func (x *WindowNode) restore() {
+	for _, v := range x.cache.GetByPrefix(x.cachePrefix) {
+		var w windowState
+		if err := json.Unmarshal([]byte(str.ToString(v)), &w); err != nil || w.Id == "" {
+			continue
+		}
+		//聚合配置修改后，丢弃不匹配的状态
+		if len(w.Aggs) != len(x.aggregates) {
+			continue
+		}
+		x.windows[w.Id] = &w
+		if x.isEventTime() && w.LastTs > x.watermark {
+			x.watermark = w.LastTs
+		}
+	}
+}
+
+// value 计算聚合结果，没有数据返回nil
+func (agg windowAggregate) value(w *windowState, state *aggState) interface{} {
+	if agg.fn == WindowFuncCount {
+		if agg.field == "" {
+			return w.Count
+		}
+		return state.Count
+	}
+	if agg.fn == WindowFuncFirst {
+		return state.First
+	}
+	if agg.fn == WindowFuncLast {
+		return state.Last
+	}
+	if state.Num == 0 {
+		return nil
+	}
+	switch agg.fn {
+	case WindowFuncSum:
+		return state.Sum
+	case WindowFuncMin:
+		return state.Min
+	case WindowFuncMax:
+		return state.Max
+	case WindowFuncAvg:
+		return state.Sum / float64(state.Num)
+	default:
+		return percentile(state.Values, agg.percentile)
+	}
+}
+
+// percentile 线性插值计算百分位数
+func percentile(values []float64, p float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return sorted[lower]
+	}
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+func parseWindowAggregate(item WindowAggregate) (windowAggregate, error) {
+	agg := windowAggregate{
+		field:      strings.TrimSpace(item.Field),
+		fn:         strings.ToLower(strings.TrimSpace(item.Func)),
+		percentile: -1,
+		alias:      item.Alias,
+	}
+	switch agg.fn {
+	case WindowFuncCount:
+	case WindowFuncSum, WindowFuncMin, WindowFuncMax, WindowFuncAvg, WindowFuncFirst, WindowFuncLast:
+		if agg.field == "" {
+			return agg, fmt.Errorf("aggregate func:%s field can not be empty", item.Func)
+		}
+	default:
+		if !strings.HasPrefix(agg.fn, "p") || agg.field == "" {
+			return agg, fmt.Errorf("unsupported aggregate func:%s", item.Func)
+		}
+		p, err := strconv.ParseFloat(agg.fn[1:], 64)
+		if err != nil || p < 0 || p > 100 {
+			return agg, fmt.Errorf("unsupported aggregate func:%s", item.Func)
+		}
+		agg.percentile = p
+	}
+	if agg.alias == "" {
+		if agg.field == "" {
+			agg.alias = agg.fn
+		} else {
+			agg.alias = agg.fn + "_" + strings.ReplaceAll(agg.field, ".", "_")
+		}
+	}
+	return agg, nil
+}
+
+func parseWindowDuration(name, value string) (int64, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s:%s", name, value)
+	}
+	if d < time.Millisecond {
+		return 0, fmt.Errorf("%s:%s must be greater than 1ms", name, value)
+	}
+	return d.Milliseconds(), nil
+}
+
+// parseWindowTime 解析事件时间，支持毫秒时间戳和RFC3339格式
+func parseWindowTime(value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return int64(f), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t.UnixMilli(), nil
+	}
+	return 0, fmt.Errorf("invalid event time:%s", value)
+}
+
+// floorDiv 向下取整除法，兼容负数
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
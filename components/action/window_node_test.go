@@ -0,0 +1,421 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package action
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/cache"
+	"github.com/rulego/rulego/utils/json"
+)
+
+type windowResult struct {
+	msg          types.RuleMsg
+	relationType string
+	err          error
+	data         map[string]interface{}
+}
+
+// windowCollector 收集窗口节点的输出
+type windowCollector struct {
+	mu      sync.Mutex
+	results []windowResult
+}
+
+func (c *windowCollector) callback(msg types.RuleMsg, relationType string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var data map[string]interface{}
+	_ = json.Unmarshal([]byte(msg.GetData()), &data)
+	c.results = append(c.results, windowResult{msg: msg, relationType: relationType, err: err, data: data})
+}
+
+func (c *windowCollector) get() []windowResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]windowResult(nil), c.results...)
+}
+
+func (c *windowCollector) wait(t *testing.T, n int, timeout time.Duration) []windowResult {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if results := c.get(); len(results) >= n {
+			return results
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	results := c.get()
+	t.Fatalf("expected %d results, got %d", n, len(results))
+	return results
+}
+
+func newWindowMsg(ts int64, deviceId, data string) types.RuleMsg {
+	metadata := types.NewMetadata()
+	metadata.PutValue("deviceId", deviceId)
+	return types.NewMsg(ts, "TELEMETRY", types.JSON, metadata, data)
+}
+
+func TestWindowNode(t *testing.T) {
+	var targetNodeType = "window"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &WindowNode{}, types.Configuration{
+			"type":       WindowTypeTumbling,
+			"size":       "1m",
+			"maxWindows": 10000,
+		}, Registry)
+	})
+
+	t.Run("InitNode", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"type":  WindowTypeSliding,
+			"size":  "1m",
+			"slide": "10s",
+			"aggregates": []interface{}{
+				map[string]interface{}{"field": "temperature", "func": "p95"},
+			},
+		}, Registry)
+		assert.Nil(t, err)
+		windowNode := node.(*WindowNode)
+		assert.Equal(t, int64(60000), windowNode.size)
+		assert.Equal(t, int64(10000), windowNode.slide)
+		assert.Equal(t, "p95_temperature", windowNode.aggregates[0].alias)
+		assert.Equal(t, float64(95), windowNode.aggregates[0].percentile)
+		node.Destroy()
+	})
+
+	t.Run("InitError", func(t *testing.T) {
+		for _, config := range []types.Configuration{
+			{"type": "unknown"},
+			{"size": "abc"},
+			{"type": WindowTypeSliding, "size": "10s", "slide": "1m"},
+			{"type": WindowTypeSession},
+			{"aggregates": []interface{}{map[string]interface{}{"func": "avg"}}},
+			{"aggregates": []interface{}{map[string]interface{}{"field": "a", "func": "median"}}},
+			{"aggregates": []interface{}{map[string]interface{}{"field": "a", "func": "p101"}}},
+		} {
+			_, err := test.CreateAndInitNode(targetNodeType, config, Registry)
+			assert.NotNil(t, err)
+		}
+		config := types.NewConfig()
+		config.Cache = nil
+		node := &WindowNode{}
+		err := node.Init(config, types.Configuration{"size": "1s", "useCache": true})
+		assert.Equal(t, types.ErrCacheNotInitialized, err)
+	})
+
+	t.Run("TumblingProcessingTime", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"key":  "${metadata.deviceId}",
+			"size": "200ms",
+			"aggregates": []interface{}{
+				map[string]interface{}{"field": "temperature", "func": "avg", "alias": "avgTemperature"},
+				map[string]interface{}{"field": "temperature", "func": "min"},
+				map[string]interface{}{"field": "temperature", "func": "max"},
+				map[string]interface{}{"field": "temperature", "func": "first"},
+				map[string]interface{}{"field": "temperature", "func": "last"},
+				map[string]interface{}{"field": "temperature", "func": "sum"},
+				map[string]interface{}{"field": "temperature", "func": "p50"},
+				map[string]interface{}{"field": "humidity", "func": "count"},
+			},
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		//等待新窗口开始，避免消息跨越两个窗口
+		time.Sleep(time.Duration(200-time.Now().UnixMilli()%200) * time.Millisecond)
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"temperature":20,"humidity":50}`))
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"temperature":30}`))
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"temperature":25}`))
+		node.OnMsg(ctx, newWindowMsg(0, "dev2", `{"temperature":10}`))
+		//进入窗口的消息不会继续传递
+		assert.Equal(t, 0, len(collector.get()))
+
+		results := collector.wait(t, 2, time.Second*2)
+		assert.Equal(t, 2, len(results))
+		var dev1, dev2 windowResult
+		for _, item := range results {
+			assert.Equal(t, types.Success, item.relationType)
+			if item.data["key"] == "dev1" {
+				dev1 = item
+			} else {
+				dev2 = item
+			}
+		}
+		assert.Equal(t, float64(3), dev1.data["count"])
+		assert.Equal(t, float64(25), dev1.data["avgTemperature"])
+		assert.Equal(t, float64(20), dev1.data["min_temperature"])
+		assert.Equal(t, float64(30), dev1.data["max_temperature"])
+		assert.Equal(t, float64(20), dev1.data["first_temperature"])
+		assert.Equal(t, float64(25), dev1.data["last_temperature"])
+		assert.Equal(t, float64(75), dev1.data["sum_temperature"])
+		assert.Equal(t, float64(25), dev1.data["p50_temperature"])
+		assert.Equal(t, float64(1), dev1.data["count_humidity"])
+		assert.Equal(t, int64(200), int64(dev1.data["windowEnd"].(float64)-dev1.data["windowStart"].(float64)))
+		assert.Equal(t, "dev1", dev1.msg.Metadata.GetValue(WindowMetadataKey))
+		assert.Equal(t, "dev1", dev1.msg.Metadata.GetValue("deviceId"))
+		assert.Equal(t, "TELEMETRY", dev1.msg.Type)
+		assert.Equal(t, types.JSON, dev1.msg.DataType)
+
+		assert.Equal(t, float64(1), dev2.data["count"])
+		assert.Equal(t, float64(10), dev2.data["avgTemperature"])
+		assert.Equal(t, float64(0), dev2.data["count_humidity"])
+	})
+
+	t.Run("TumblingEventTime", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"size":      "1s",
+			"timeField": "${msg.time}",
+			"aggregates": []interface{}{
+				map[string]interface{}{"field": "value", "func": "p90"},
+				map[string]interface{}{"field": "value", "func": "max"},
+			},
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"time":1000,"value":1}`))
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"time":1500,"value":2}`))
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"time":"1970-01-01T00:00:01.900Z","value":3}`))
+		assert.Equal(t, 0, len(collector.get()))
+		//水位线超过窗口结束时间，关闭窗口
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"time":2100,"value":4}`))
+		results := collector.get()
+		assert.Equal(t, 1, len(results))
+		assert.Equal(t, float64(1000), results[0].data["windowStart"])
+		assert.Equal(t, float64(2000), results[0].data["windowEnd"])
+		assert.Equal(t, float64(3), results[0].data["count"])
+		assert.Equal(t, 2.8, results[0].data["p90_value"])
+		assert.Equal(t, float64(3), results[0].data["max_value"])
+
+		//迟到的消息
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"time":1200,"value":5}`))
+		results = collector.get()
+		assert.Equal(t, 2, len(results))
+		assert.Equal(t, types.Failure, results[1].relationType)
+		assert.Equal(t, ErrWindowLateMsg, results[1].err)
+
+		//错误的时间
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"time":"abc","value":5}`))
+		results = collector.get()
+		assert.Equal(t, 3, len(results))
+		assert.Equal(t, types.Failure, results[2].relationType)
+	})
+
+	t.Run("SlidingEventTime", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"type":      WindowTypeSliding,
+			"size":      "2s",
+			"slide":     "1s",
+			"timeField": "${msg.time}",
+			"aggregates": []interface{}{
+				map[string]interface{}{"field": "value", "func": "sum"},
+			},
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"time":1500,"value":1}`))
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"time":2500,"value":2}`))
+		//关闭 [0,2000)
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"time":3000,"value":4}`))
+		//关闭 [1000,3000) [2000,4000)
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{"time":4000,"value":8}`))
+		results := collector.get()
+		assert.Equal(t, 3, len(results))
+		assert.Equal(t, float64(0), results[0].data["windowStart"])
+		assert.Equal(t, float64(1), results[0].data["sum_value"])
+		assert.Equal(t, float64(1000), results[1].data["windowStart"])
+		assert.Equal(t, float64(3), results[1].data["sum_value"])
+		assert.Equal(t, float64(2000), results[2].data["windowStart"])
+		assert.Equal(t, float64(6), results[2].data["sum_value"])
+	})
+
+	t.Run("SessionProcessingTime", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"type": WindowTypeSession,
+			"key":  "${metadata.deviceId}",
+			"gap":  "150ms",
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		for i := 0; i < 3; i++ {
+			node.OnMsg(ctx, newWindowMsg(0, "dev1", `{}`))
+			time.Sleep(time.Millisecond * 50)
+		}
+		assert.Equal(t, 0, len(collector.get()))
+		results := collector.wait(t, 1, time.Second)
+		assert.Equal(t, float64(3), results[0].data["count"])
+		assert.Equal(t, "dev1", results[0].data["key"])
+
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{}`))
+		results = collector.wait(t, 2, time.Second)
+		assert.Equal(t, float64(1), results[1].data["count"])
+	})
+
+	t.Run("MaxWindows", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"key":        "${metadata.deviceId}",
+			"size":       "1m",
+			"maxWindows": 1,
+		}, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(types.NewConfig(), collector.callback)
+		node.OnMsg(ctx, newWindowMsg(0, "dev1", `{}`))
+		node.OnMsg(ctx, newWindowMsg(0, "dev2", `{}`))
+		results := collector.get()
+		assert.Equal(t, 1, len(results))
+		assert.Equal(t, ErrWindowMaxLimit, results[0].err)
+	})
+
+	t.Run("UseCache", func(t *testing.T) {
+		config := types.NewConfig()
+		config.Cache = cache.NewMemoryCache(time.Minute)
+		configuration := types.Configuration{
+			"size":      "1s",
+			"timeField": "${msg.time}",
+			"useCache":  true,
+			"aggregates": []interface{}{
+				map[string]interface{}{"field": "value", "func": "avg"},
+			},
+			types.NodeConfigurationKeySelfDefinition: types.RuleNode{Id: "s1"},
+		}
+		node1 := &WindowNode{}
+		err := node1.Init(config, configuration)
+		assert.Nil(t, err)
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(config, collector.callback)
+		node1.OnMsg(ctx, newWindowMsg(0, "dev1", `{"time":1000,"value":10}`))
+		node1.Destroy()
+		assert.Equal(t, 1, len(config.Cache.GetByPrefix("window::s1:")))
+
+		//重启后恢复窗口
+		node2 := &WindowNode{}
+		err = node2.Init(config, configuration)
+		assert.Nil(t, err)
+		defer node2.Destroy()
+		node2.OnMsg(ctx, newWindowMsg(0, "dev1", `{"time":1500,"value":20}`))
+		node2.OnMsg(ctx, newWindowMsg(0, "dev1", `{"time":2500,"value":30}`))
+		results := collector.get()
+		assert.Equal(t, 1, len(results))
+		assert.Equal(t, float64(2), results[0].data["count"])
+		assert.Equal(t, float64(15), results[0].data["avg_value"])
+		//关闭的窗口从缓存删除，新的窗口定时写入缓存
+		assert.Equal(t, 0, len(config.Cache.GetByPrefix("window::s1:")))
+		node2.flush()
+		assert.Equal(t, 1, len(config.Cache.GetByPrefix("window::s1:|2000")))
+	})
+
+	t.Run("UseCacheFlush", func(t *testing.T) {
+		config := types.NewConfig()
+		config.Cache = cache.NewMemoryCache(time.Minute)
+		configuration := types.Configuration{
+			"size":          "1m",
+			"useCache":      true,
+			"flushInterval": "100ms",
+			"maxSamples":    10,
+			"aggregates": []interface{}{
+				map[string]interface{}{"field": "value", "func": "p50"},
+			},
+			types.NodeConfigurationKeySelfDefinition: types.RuleNode{Id: "s3"},
+		}
+		node1 := &WindowNode{}
+		assert.Nil(t, node1.Init(config, configuration))
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(config, collector.callback)
+		for i := 0; i < 100; i++ {
+			node1.OnMsg(ctx, newWindowMsg(0, "dev1", fmt.Sprintf(`{"value":%d}`, i)))
+		}
+		//百分位数样本数量有上限
+		for _, w := range node1.windows {
+			assert.Equal(t, int64(100), w.Count)
+			assert.Equal(t, 10, len(w.Aggs[0].Values))
+		}
+		//状态定时写入缓存，不是每条消息都写入
+		assert.Equal(t, 0, len(config.Cache.GetByPrefix("window::s3:")))
+		time.Sleep(time.Millisecond * 250)
+		assert.Equal(t, 1, len(config.Cache.GetByPrefix("window::s3:")))
+
+		//重新加载时新实例先初始化，旧实例未写入缓存的窗口先写入再恢复
+		node1.OnMsg(ctx, newWindowMsg(0, "dev1", `{"value":100}`))
+		node2 := &WindowNode{}
+		assert.Nil(t, node2.Init(config, configuration))
+		defer node2.Destroy()
+		node1.Destroy()
+		assert.Equal(t, 1, len(node2.windows))
+		for _, w := range node2.windows {
+			assert.Equal(t, int64(101), w.Count)
+		}
+	})
+
+	t.Run("UseCacheOverdue", func(t *testing.T) {
+		config := types.NewConfig()
+		config.Cache = cache.NewMemoryCache(time.Minute)
+		configuration := types.Configuration{
+			"key":      "${metadata.deviceId}",
+			"size":     "200ms",
+			"useCache": true,
+			"aggregates": []interface{}{
+				map[string]interface{}{"field": "value", "func": "sum"},
+			},
+			types.NodeConfigurationKeySelfDefinition: types.RuleNode{Id: "s2"},
+		}
+		node1 := &WindowNode{}
+		assert.Nil(t, node1.Init(config, configuration))
+		collector := &windowCollector{}
+		ctx := test.NewRuleContext(config, collector.callback)
+		node1.OnMsg(ctx, newWindowMsg(0, "dev1", `{"value":10}`))
+		node1.Destroy()
+
+		//重启时窗口已经到期，没有可用的上下文，保留在缓存中
+		time.Sleep(time.Millisecond * 300)
+		node2 := &WindowNode{}
+		assert.Nil(t, node2.Init(config, configuration))
+		defer node2.Destroy()
+		time.Sleep(time.Millisecond * 100)
+		assert.Equal(t, 0, len(collector.get()))
+		assert.Equal(t, 1, len(config.Cache.GetByPrefix("window::s2:dev1")))
+
+		//新消息到达后发送恢复的窗口
+		node2.OnMsg(ctx, newWindowMsg(0, "dev2", `{"value":20}`))
+		results := collector.wait(t, 1, time.Second)
+		assert.Equal(t, types.Success, results[0].relationType)
+		assert.Equal(t, "dev1", results[0].data["key"])
+		assert.Equal(t, float64(10), results[0].data["sum_value"])
+		assert.Equal(t, 0, len(config.Cache.GetByPrefix("window::s2:dev1")))
+		results = collector.wait(t, 2, time.Second)
+		assert.Equal(t, "dev2", results[1].data["key"])
+	})
+}
@@ -0,0 +1,305 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+//规则链节点配置示例：
+// {
+//        "id": "s1",
+//        "type": "cacheIncr",
+//        "name": "设备消息计数",
+//        "configuration": {
+//          "level": "global",
+//          "key": "count:${metadata.deviceId}",
+//          "delta": "1",
+//          "ttl": "1h",
+//          "resultKey": "count"
+//        }
+//  }
+// {
+//        "id": "s2",
+//        "type": "cacheCAS",
+//        "name": "抢占任务",
+//        "configuration": {
+//          "level": "global",
+//          "key": "task:${metadata.taskId}",
+//          "value": "${metadata.workerId}",
+//          "ttl": "30s"
+//        }
+//  }
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/el"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+// 注册节点
+func init() {
+	Registry.Add(&CacheIncrNode{})
+	Registry.Add(&CacheCASNode{})
+}
+
+// CacheIncrDefaultResultKey 计数结果默认保存的元数据key
+const CacheIncrDefaultResultKey = "cacheValue"
+
+// getLevelCache 根据缓存级别获取缓存实例
+func getLevelCache(ctx types.RuleContext, level string) types.Cache {
+	if level == CacheLevelGlobal {
+		return ctx.GlobalCache()
+	}
+	return ctx.ChainCache()
+}
+
+// checkLevel 检查缓存级别，为空使用chain
+func checkLevel(level string) (string, error) {
+	if level == "" {
+		return CacheLevelChain, nil
+	}
+	if level != CacheLevelChain && level != CacheLevelGlobal {
+		return "", fmt.Errorf("unsupported level:%s", level)
+	}
+	return level, nil
+}
+
+// CacheIncrNodeConfiguration 缓存原子计数节点配置
+type CacheIncrNodeConfiguration struct {
+	// Level 缓存级别，chain或global，默认chain
+	Level string `json:"level"`
+	// Key 计数器key
+	// 可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
+	Key string `json:"key"`
+	// Delta 增量，可以为负数，默认1
+	// 可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
+	Delta string `json:"delta"`
+	// Ttl 过期时间，只在计数器创建时设置，已存在的计数器保留原过期时间，用于实现固定窗口计数
+	// 示例：1h(1小时) 10m(10分钟) 10s(10秒)，如果为空或者0，则表示永不过期
+	Ttl string `json:"ttl"`
+	// ResultKey 计数结果保存到元数据的key，默认cacheValue
+	ResultKey string `json:"resultKey"`
+}
+
+// CacheIncrNode 缓存原子计数节点，对缓存中的整数原子加减，并发安全
+// 计数结果保存到元数据 ResultKey 中，然后发送到`Success`链。如果缓存中已存在的值不是整数，或者增量不是整数，则发送到`Failure`链
+type CacheIncrNode struct {
+	//节点配置
+	Config CacheIncrNodeConfiguration
+	//key模板
+	keyTemplate *el.MixedTemplate
+	//增量模板
+	deltaTemplate *el.MixedTemplate
+}
+
+// Type 返回组件类型
+func (x *CacheIncrNode) Type() string {
+	return "cacheIncr"
+}
+
+func (x *CacheIncrNode) New() types.Node {
+	return &CacheIncrNode{Config: CacheIncrNodeConfiguration{
+		Level:     CacheLevelChain,
+		Key:       "key1",
+		Delta:     "1",
+		ResultKey: CacheIncrDefaultResultKey,
+	}}
+}
+
+// Init 初始化组件
+func (x *CacheIncrNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.Config.Level, err = checkLevel(x.Config.Level); err != nil {
+		return err
+	}
+	if strings.TrimSpace(x.Config.Key) == "" {
+		return errors.New("key can not be empty")
+	}
+	if strings.TrimSpace(x.Config.Delta) == "" {
+		x.Config.Delta = "1"
+	}
+	if x.Config.ResultKey == "" {
+		x.Config.ResultKey = CacheIncrDefaultResultKey
+	}
+	if x.keyTemplate, err = el.NewMixedTemplate(x.Config.Key); err != nil {
+		return err
+	}
+	if x.deltaTemplate, err = el.NewMixedTemplate(x.Config.Delta); err != nil {
+		return err
+	}
+	if !x.deltaTemplate.HasVar() {
+		if _, err = strconv.ParseInt(strings.TrimSpace(x.Config.Delta), 10, 64); err != nil {
+			return fmt.Errorf("delta must be an integer:%s", x.Config.Delta)
+		}
+	}
+	return nil
+}
+
+func (x *CacheIncrNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	key := x.keyTemplate.ExecuteAsString(evn)
+	if key == "" {
+		ctx.TellFailure(msg, errors.New("key is empty"))
+		return
+	}
+	deltaStr := strings.TrimSpace(x.deltaTemplate.ExecuteAsString(evn))
+	delta, err := strconv.ParseInt(deltaStr, 10, 64)
+	if err != nil {
+		ctx.TellFailure(msg, fmt.Errorf("delta must be an integer:%s", deltaStr))
+		return
+	}
+	c := getLevelCache(ctx, x.Config.Level)
+	if c == nil {
+		ctx.TellFailure(msg, types.ErrCacheNotInitialized)
+		return
+	}
+	value, err := c.Incr(key, delta, x.Config.Ttl)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.Metadata.PutValue(x.Config.ResultKey, strconv.FormatInt(value, 10))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁组件
+func (x *CacheIncrNode) Destroy() {
+}
+
+// CacheCASNodeConfiguration 缓存比较并设置节点配置
+type CacheCASNodeConfiguration struct {
+	// Level 缓存级别，chain或global，默认chain
+	Level string `json:"level"`
+	// Key 键
+	// 可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
+	Key string `json:"key"`
+	// Expected 期望的当前值，只有缓存中的当前值等于该值时才设置新值。值相等或者字符串形式相等视为相等
+	// 如果为空(null)，则只有key不存在时才设置新值
+	// 可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
+	Expected interface{} `json:"expected"`
+	// Value 新值
+	// 可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
+	Value interface{} `json:"value"`
+	// Ttl 新值的过期时间
+	// 示例：1h(1小时) 10m(10分钟) 10s(10秒)，如果为空或者0，则表示永不过期
+	Ttl string `json:"ttl"`
+}
+
+// CacheCASNode 缓存比较并设置节点，原子地比较缓存中的当前值，相等则设置新值
+// 可用于分布式锁、任务抢占、乐观锁等场景
+// 设置成功发送到`True`链，当前值不匹配发送到`False`链，出错发送到`Failure`链
+type CacheCASNode struct {
+	//节点配置
+	Config CacheCASNodeConfiguration
+	//key模板
+	keyTemplate *el.MixedTemplate
+	//期望值模板
+	expectedTemplate el.Template
+	//新值模板
+	valueTemplate el.Template
+}
+
+// Type 返回组件类型
+func (x *CacheCASNode) Type() string {
+	return "cacheCAS"
+}
+
+func (x *CacheCASNode) New() types.Node {
+	return &CacheCASNode{Config: CacheCASNodeConfiguration{
+		Level: CacheLevelChain,
+		Key:   "key1",
+		Value: "value1",
+	}}
+}
+
+// Init 初始化组件
+func (x *CacheCASNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.Config.Level, err = checkLevel(x.Config.Level); err != nil {
+		return err
+	}
+	if strings.TrimSpace(x.Config.Key) == "" {
+		return errors.New("key can not be empty")
+	}
+	if x.keyTemplate, err = el.NewMixedTemplate(x.Config.Key); err != nil {
+		return err
+	}
+	if x.Config.Expected != nil {
+		if x.expectedTemplate, err = el.NewTemplate(x.Config.Expected); err != nil {
+			return err
+		}
+	}
+	x.valueTemplate, err = el.NewTemplate(x.Config.Value)
+	return err
+}
+
+func (x *CacheCASNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	key := x.keyTemplate.ExecuteAsString(evn)
+	if key == "" {
+		ctx.TellFailure(msg, errors.New("key is empty"))
+		return
+	}
+	var expected interface{}
+	var err error
+	if x.expectedTemplate != nil {
+		if expected, err = x.expectedTemplate.Execute(evn); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	value, err := x.valueTemplate.Execute(evn)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	c := getLevelCache(ctx, x.Config.Level)
+	if c == nil {
+		ctx.TellFailure(msg, types.ErrCacheNotInitialized)
+		return
+	}
+	ok, err := c.CompareAndSet(key, expected, value, x.Config.Ttl)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+	} else if ok {
+		ctx.TellNext(msg, types.True)
+	} else {
+		ctx.TellNext(msg, types.False)
+	}
+}
+
+// Destroy 销毁组件
+func (x *CacheCASNode) Destroy() {
+}
+
+// Def 组件定义，设置成功发送到`True`链，当前值不匹配发送到`False`链
+func (x *CacheCASNode) Def() types.ComponentForm {
+	relationTypes := []string{types.True, types.False, types.Failure}
+	return types.ComponentForm{
+		Type:          x.Type(),
+		RelationTypes: &relationTypes,
+	}
+}
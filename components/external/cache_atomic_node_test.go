@@ -0,0 +1,181 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/reflect"
+	"github.com/rulego/rulego/utils/str"
+)
+
+func newCacheTestMsg(deviceId string, data string) types.RuleMsg {
+	metadata := types.NewMetadata()
+	metadata.PutValue("deviceId", deviceId)
+	return types.NewMsg(0, "TEST_MSG", types.JSON, metadata, data)
+}
+
+func TestCacheIncrNode(t *testing.T) {
+	var targetNodeType = "cacheIncr"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &CacheIncrNode{}, types.Configuration{
+			"level":     CacheLevelChain,
+			"key":       "key1",
+			"delta":     "1",
+			"resultKey": CacheIncrDefaultResultKey,
+		}, Registry)
+	})
+
+	t.Run("InitError", func(t *testing.T) {
+		for _, config := range []types.Configuration{
+			{"key": ""},
+			{"key": "a", "level": "node"},
+			{"key": "a", "delta": "1.5"},
+		} {
+			_, err := test.CreateAndInitNode(targetNodeType, config, Registry)
+			assert.NotNil(t, err)
+		}
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"key":       "count:${metadata.deviceId}",
+			"delta":     "${msg.n}",
+			"resultKey": "count",
+		}, Registry)
+		assert.Nil(t, err)
+
+		var mu sync.Mutex
+		var results []string
+		var relations []string
+		ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			results = append(results, msg.Metadata.GetValue("count"))
+			relations = append(relations, relationType)
+		})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				node.OnMsg(ctx, newCacheTestMsg("d1", `{"n":2}`))
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int64(200), ctx.ChainCache().Get("count:d1"))
+
+		results = nil
+		relations = nil
+		node.OnMsg(ctx, newCacheTestMsg("d1", `{"n":-50}`))
+		node.OnMsg(ctx, newCacheTestMsg("d2", `{"n":1}`))
+		node.OnMsg(ctx, newCacheTestMsg("d2", `{"n":"a"}`))
+		_ = ctx.ChainCache().Set("count:d3", "abc", "")
+		node.OnMsg(ctx, newCacheTestMsg("d3", `{"n":1}`))
+		assert.Equal(t, []string{"150", "1", "", ""}, results)
+		assert.Equal(t, []string{types.Success, types.Success, types.Failure, types.Failure}, relations)
+	})
+
+	t.Run("GlobalLevel", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"level": CacheLevelGlobal,
+			"key":   "total",
+			"delta": -1,
+			"ttl":   "1m",
+		}, Registry)
+		assert.Nil(t, err)
+		var value string
+		ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+			value = msg.Metadata.GetValue(CacheIncrDefaultResultKey)
+		})
+		node.OnMsg(ctx, newCacheTestMsg("d1", `{}`))
+		node.OnMsg(ctx, newCacheTestMsg("d1", `{}`))
+		assert.Equal(t, "-2", value)
+		_, ok := ctx.GlobalCache().TTL("total")
+		assert.True(t, ok)
+	})
+}
+
+func TestCacheCASNode(t *testing.T) {
+	var targetNodeType = "cacheCAS"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &CacheCASNode{}, types.Configuration{
+			"level": CacheLevelChain,
+			"key":   "key1",
+			"value": "value1",
+		}, Registry)
+		form := reflect.GetComponentForm(&CacheCASNode{})
+		assert.Equal(t, []string{types.True, types.False, types.Failure}, *form.RelationTypes)
+	})
+
+	t.Run("InitError", func(t *testing.T) {
+		for _, config := range []types.Configuration{
+			{"key": ""},
+			{"key": "a", "level": "node"},
+			{"key": "a", "value": "${msg.a >}"},
+		} {
+			_, err := test.CreateAndInitNode(targetNodeType, config, Registry)
+			assert.NotNil(t, err)
+		}
+	})
+
+	t.Run("SetIfAbsent", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"key":   "lock:${metadata.deviceId}",
+			"value": "${msg.worker}",
+			"ttl":   "1m",
+		}, Registry)
+		assert.Nil(t, err)
+		var relations []string
+		ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+			relations = append(relations, relationType)
+		})
+		node.OnMsg(ctx, newCacheTestMsg("d1", `{"worker":"w1"}`))
+		node.OnMsg(ctx, newCacheTestMsg("d1", `{"worker":"w2"}`))
+		node.OnMsg(ctx, newCacheTestMsg("d2", `{"worker":"w2"}`))
+		assert.Equal(t, []string{types.True, types.False, types.True}, relations)
+		assert.Equal(t, "w1", ctx.ChainCache().Get("lock:d1"))
+	})
+
+	t.Run("CompareAndSet", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"level":    CacheLevelGlobal,
+			"key":      "version:${metadata.deviceId}",
+			"expected": "${msg.version}",
+			"value":    "${msg.version + 1}",
+		}, Registry)
+		assert.Nil(t, err)
+		var relations []string
+		ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+			relations = append(relations, relationType)
+		})
+		_ = ctx.GlobalCache().Set("version:d1", 1, "")
+		node.OnMsg(ctx, newCacheTestMsg("d1", `{"version":1}`))
+		node.OnMsg(ctx, newCacheTestMsg("d1", `{"version":1}`))
+		node.OnMsg(ctx, newCacheTestMsg("d1", `{"version":2}`))
+		node.OnMsg(ctx, newCacheTestMsg("d2", `{"version":1}`))
+		assert.Equal(t, []string{types.True, types.False, types.True, types.False}, relations)
+		assert.Equal(t, "3", str.ToString(ctx.GlobalCache().Get("version:d1")))
+	})
+}
@@ -17,7 +17,9 @@
 package external
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	_ "github.com/go-sql-driver/mysql"
@@ -28,6 +30,10 @@ import (
 	"github.com/rulego/rulego/utils/maps"
 	"github.com/rulego/rulego/utils/str"
 	"strings"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
 )
 
 // 注册节点
@@ -46,11 +52,36 @@ const (
 	lastInsertIdKey = "lastInsertId"
 )
 
+// 跨节点事务模式
+const (
+	// DbTxModeBegin 开启事务，并在事务中执行SQL，事务保存在规则链上下文，后续使用同一个数据库的dbClient节点加入该事务
+	DbTxModeBegin = "begin"
+	// DbTxModeCommit 在事务中执行SQL后提交事务
+	DbTxModeCommit = "commit"
+	// DbTxModeRollback 回滚事务，不执行SQL
+	DbTxModeRollback = "rollback"
+)
+
+var (
+	// ErrDbTxNotFound 规则链上下文不存在事务
+	ErrDbTxNotFound = errors.New("transaction not found in rule context")
+	// ErrDbTxAlreadyBegun 规则链上下文已经存在同一个数据库的事务
+	ErrDbTxAlreadyBegun = errors.New("transaction already begun in rule context")
+)
+
+// DbStatement 事务中执行的SQL语句
+type DbStatement struct {
+	// Sql SQL语句
+	Sql string
+	// Params SQL语句参数列表，和 DbClientNodeConfiguration.Params 相同
+	Params []interface{}
+}
+
 // DbClientNodeConfiguration 节点配置
 type DbClientNodeConfiguration struct {
-	// DriverName 数据库驱动名称，mysql或postgres
+	// DriverName 数据库驱动名称，mysql、postgres或sqlite
 	DriverName string
-	// Dsn 数据库连接配置，参考sql.Open参数
+	// Dsn 数据库连接配置，参考sql.Open参数，sqlite为数据库文件路径，例如：file:data.db?_pragma=busy_timeout(5000)
 	Dsn string
 	// PoolSize 连接池大小
 	PoolSize int
@@ -58,24 +89,110 @@ type DbClientNodeConfiguration struct {
 	Sql string
 	// Params SQL语句参数列表，可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
 	Params []interface{}
+	// NamedParams 是否开启 :name 命名参数，只对没有配置Params的语句生效。
+	// 开启后命名参数优先从消息负荷取值，其次从元数据取值，也可以使用 :msg.name 或者 :metadata.name 指定来源。
+	// 引号中的内容以及 :: 类型转换和 := 赋值不会被替换，但是其他 :标识符 (例如postgres数组切片 arr[a:b])会被当作命名参数
+	NamedParams bool
 	// GetOne 是否只返回一条记录，true:返回结构不是数组结构，false：返回数据是数组结构
 	GetOne bool
+	// Statements 在同一个事务中依次执行的SQL语句列表，任意语句执行失败则回滚，配置后忽略Sql和Params
+	// 影响行数为所有语句之和，查询语句的结果作为消息负荷
+	Statements []DbStatement
+	// Batch 批量执行，消息负荷为JSON数组，数组的每个元素作为 ${msg.xx} 和命名参数的取值，
+	// 在同一个事务中使用预编译语句执行，适用于批量插入
+	Batch bool
+	// TxMode 跨节点事务模式：begin、commit、rollback，begin和commit模式Sql为空时只开启或者提交事务
+	// 为空时，如果规则链上下文存在同一个数据库的事务，则在该事务中执行。事务中任意节点执行失败，事务回滚
+	// begin节点之后的所有分支执行结束时，事务没有提交则自动回滚
+	TxMode string
+	// TxTimeout 跨节点事务超时时间，单位秒，超时未提交自动回滚，默认30秒
+	TxTimeout int
+	// MigrationsDir 数据库迁移脚本目录，初始化时按照版本号顺序执行未执行过的脚本，
+	// 文件名格式：<版本号>_<名称>.up.sql，例如：0001_create_users.up.sql
+	// 配置后节点初始化时立即连接数据库，迁移失败则节点初始化失败
+	MigrationsDir string
+	// MigrationsTable 记录已执行迁移脚本的表名，默认：schema_migrations
+	MigrationsTable string
 }
 
-type DbClientNode struct {
-	base.SharedNode[*sql.DB]
-	//节点配置
-	Config DbClientNodeConfiguration
-	client *sql.DB
-	//操作类型 SELECT\UPDATE\INSERT\DELETE
+// dbExecutor SQL执行器，*sql.DB 或者 *sql.Tx
+type dbExecutor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	Prepare(query string) (*sql.Stmt, error)
+}
+
+// dbTxKey 规则链上下文中事务的key，相同驱动和数据源的节点共享一个事务
+type dbTxKey struct {
+	driverName string
+	dsn        string
+}
+
+// dbTx 跨节点事务
+type dbTx struct {
+	tx     *sql.Tx
+	cancel context.CancelFunc
+	mu     sync.Mutex
+	done   bool
+}
+
+// commit 提交事务，事务已经结束返回 sql.ErrTxDone
+func (t *dbTx) commit() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+	defer t.cancel()
+	return t.tx.Commit()
+}
+
+// rollback 回滚事务，事务已经结束则忽略
+func (t *dbTx) rollback() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done {
+		return nil
+	}
+	t.done = true
+	defer t.cancel()
+	return t.tx.Rollback()
+}
+
+// dbStatement 预处理后的SQL语句
+type dbStatement struct {
+	sql string
+	//操作类型 SELECT\UPDATE\INSERT\DELETE，sql有变量时为空
 	opType string
-	//sqlTemplate    str.Template
-	//paramsTemplate []str.Template
 	//sql是否有变量
 	sqlHasVar bool
 	//参数是否有变量
 	paramsHasVar   bool
 	paramsTemplate []el.Template
+	//命名参数模式，开启命名参数并且不配置参数时才使用命名参数
+	named bool
+	//命名参数名称列表，按照占位符顺序
+	paramNames []string
+}
+
+// dbResult SQL执行结果
+type dbResult struct {
+	data         interface{}
+	hasData      bool
+	hasExec      bool
+	hasInsert    bool
+	rowsAffected int64
+	lastInsertId int64
+}
+
+type DbClientNode struct {
+	base.SharedNode[*sql.DB]
+	//节点配置
+	Config DbClientNodeConfiguration
+	client *sql.DB
+	//需要执行的SQL语句列表
+	statements []*dbStatement
 }
 
 // Type 返回组件类型
@@ -100,112 +217,385 @@ func (x *DbClientNode) Init(ruleConfig types.Config, configuration types.Configu
 	if x.Config.DriverName == "" {
 		x.Config.DriverName = "mysql"
 	}
+	if x.Config.TxTimeout <= 0 {
+		x.Config.TxTimeout = 30
+	}
 
 	if !base.NodeUtils.IsInitNetResource(ruleConfig, configuration) {
-		if x.Config.Sql == "" {
-			return errors.New("sql can not empty")
+		switch x.Config.TxMode {
+		case "", DbTxModeBegin, DbTxModeCommit, DbTxModeRollback:
+		default:
+			return fmt.Errorf("unsupported txMode: %s", x.Config.TxMode)
 		}
-		//检查是否需要转换成$1风格占位符
-		x.Config.Sql = str.ConvertDollarPlaceholder(x.Config.Sql, x.Config.DriverName)
-		if str.CheckHasVar(x.Config.Sql) {
-			x.sqlHasVar = true
+		statements := x.Config.Statements
+		if len(statements) == 0 && x.Config.Sql != "" {
+			statements = []DbStatement{{Sql: x.Config.Sql, Params: x.Config.Params}}
 		}
-		if !x.sqlHasVar {
-			x.opType = x.getOpType(x.Config.Sql)
-			if err = x.checkOpType(x.opType, x.Config.Sql); err != nil {
-				return err
-			}
+		//开启、提交和回滚事务可以不执行SQL
+		if len(statements) == 0 && x.Config.TxMode == "" {
+			return errors.New("sql can not empty")
 		}
-		//检查是参数否有变量
-		for _, item := range x.Config.Params {
-
-			if temp, err := el.NewTemplate(item); err != nil {
+		if x.Config.TxMode == DbTxModeRollback {
+			statements = nil
+		}
+		x.statements = nil
+		for _, item := range statements {
+			statement, err := x.newStatement(item.Sql, item.Params)
+			if err != nil {
 				return err
-			} else {
-				x.paramsTemplate = append(x.paramsTemplate, temp)
-				if !temp.IsNotVar() {
-					x.paramsHasVar = true
-				}
 			}
+			x.statements = append(x.statements, statement)
 		}
-
 	}
 	//初始化客户端
-	return x.SharedNode.Init(ruleConfig, x.Type(), x.Config.Dsn, ruleConfig.NodeClientInitNow, func() (*sql.DB, error) {
+	//配置了迁移脚本，立即初始化，保证后续节点使用的表已经存在
+	initNow := ruleConfig.NodeClientInitNow || x.Config.MigrationsDir != ""
+	return x.SharedNode.Init(ruleConfig, x.Type(), x.Config.Dsn, initNow, func() (*sql.DB, error) {
 		return x.initClient()
 	})
 }
 
+// newStatement 预处理SQL语句
+func (x *DbClientNode) newStatement(sqlStr string, params []interface{}) (*dbStatement, error) {
+	if sqlStr == "" {
+		return nil, errors.New("sql can not empty")
+	}
+	statement := &dbStatement{named: x.Config.NamedParams && len(params) == 0}
+	if str.CheckHasVar(sqlStr) {
+		statement.sqlHasVar = true
+	} else {
+		if statement.named {
+			sqlStr, statement.paramNames = parseNamedParams(sqlStr)
+		}
+		statement.opType = x.getOpType(sqlStr)
+		if err := x.checkOpType(statement.opType, sqlStr); err != nil {
+			return nil, err
+		}
+	}
+	//检查是否需要转换成$1风格占位符
+	statement.sql = str.ConvertDollarPlaceholder(sqlStr, x.Config.DriverName)
+	//检查是参数否有变量
+	for _, item := range params {
+		if temp, err := el.NewTemplate(item); err != nil {
+			return nil, err
+		} else {
+			statement.paramsTemplate = append(statement.paramsTemplate, temp)
+			if !temp.IsNotVar() {
+				statement.paramsHasVar = true
+			}
+		}
+	}
+	return statement, nil
+}
+
 // OnMsg 处理消息
 func (x *DbClientNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
-	var data interface{}
-	var err error
-	var rowsAffected int64
-	var lastInsertId int64
-	var evn map[string]interface{}
-	if x.sqlHasVar || x.paramsHasVar {
-		evn = base.NodeUtils.GetEvnAndMetadata(ctx, msg)
-	}
-	var sqlStr = x.Config.Sql
-	if x.sqlHasVar {
-		//转换sql变量
-		sqlStr = str.ExecuteTemplate(x.Config.Sql, evn)
-		sqlStr = str.ConvertDollarPlaceholder(sqlStr, x.Config.DriverName)
-	}
-	opType := x.opType
-	if opType == "" {
-		opType = x.getOpType(sqlStr)
-		if err := x.checkOpType(opType, sqlStr); err != nil {
+	client, err := x.SharedNode.Get()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	sharedTx := x.getTx(ctx)
+	switch x.Config.TxMode {
+	case DbTxModeRollback:
+		if sharedTx == nil {
+			ctx.TellFailure(msg, ErrDbTxNotFound)
+		} else if err = sharedTx.rollback(); err != nil {
 			ctx.TellFailure(msg, err)
+		} else {
+			ctx.TellSuccess(msg)
+		}
+		return
+	case DbTxModeBegin:
+		if sharedTx != nil {
+			ctx.TellFailure(msg, ErrDbTxAlreadyBegun)
+			return
+		}
+		if sharedTx, err = x.beginTx(ctx, client); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	case DbTxModeCommit:
+		if sharedTx == nil {
+			ctx.TellFailure(msg, ErrDbTxNotFound)
 			return
 		}
 	}
-	var params []interface{}
-	//转换参数变量
-	for _, item := range x.paramsTemplate {
-		param, err := item.Execute(evn)
-		if err != nil {
+
+	var executor dbExecutor = client
+	var localTx *sql.Tx
+	if sharedTx != nil {
+		executor = sharedTx.tx
+	} else if len(x.statements) > 1 || x.Config.Batch {
+		//多条语句或者批量执行使用本地事务
+		if localTx, err = client.Begin(); err != nil {
 			ctx.TellFailure(msg, err)
 			return
 		}
-		params = append(params, param)
+		executor = localTx
+	}
+	result, err := x.execute(ctx, executor, msg)
+	if err == nil && localTx != nil {
+		err = localTx.Commit()
+	} else if err != nil && localTx != nil {
+		_ = localTx.Rollback()
+	}
+	if sharedTx != nil {
+		if err != nil {
+			//事务中任意节点执行失败，回滚事务
+			_ = sharedTx.rollback()
+		} else if x.Config.TxMode == DbTxModeCommit {
+			err = sharedTx.commit()
+		}
 	}
-	client, err := x.SharedNode.Get()
 	if err != nil {
 		ctx.TellFailure(msg, err)
 		return
 	}
+	if result.hasData {
+		msg.SetData(str.ToString(result.data))
+	}
+	if result.hasExec {
+		msg.Metadata.PutValue(rowsAffectedKey, str.ToString(result.rowsAffected))
+	}
+	if result.hasInsert {
+		msg.Metadata.PutValue(lastInsertIdKey, str.ToString(result.lastInsertId))
+	}
+	ctx.TellSuccess(msg)
+}
+
+// getTx 获取规则链上下文中同一个数据库的事务
+func (x *DbClientNode) getTx(ctx types.RuleContext) *dbTx {
+	if c := ctx.GetContext(); c != nil {
+		if tx, ok := c.Value(x.txKey()).(*dbTx); ok {
+			return tx
+		}
+	}
+	return nil
+}
+
+// txKey 事务在规则链上下文中的key
+func (x *DbClientNode) txKey() dbTxKey {
+	return dbTxKey{driverName: x.Config.DriverName, dsn: x.Config.Dsn}
+}
+
+// beginTx 开启事务并保存到规则链上下文，超时未提交自动回滚
+func (x *DbClientNode) beginTx(ctx types.RuleContext, client *sql.DB) (*dbTx, error) {
+	parent := ctx.GetContext()
+	if parent == nil {
+		parent = context.Background()
+	}
+	txCtx, cancel := context.WithTimeout(parent, time.Duration(x.Config.TxTimeout)*time.Second)
+	tx, err := client.BeginTx(txCtx, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	sharedTx := &dbTx{tx: tx, cancel: cancel}
+	ctx.SetContext(context.WithValue(parent, x.txKey(), sharedTx))
+	//当前节点之后的所有分支执行结束，事务没有提交则回滚，不需要等待超时
+	ctx.SetOnAllNodeCompleted(func() {
+		_ = sharedTx.rollback()
+	})
+	return sharedTx, nil
+}
+
+// execute 依次执行SQL语句
+func (x *DbClientNode) execute(ctx types.RuleContext, executor dbExecutor, msg types.RuleMsg) (dbResult, error) {
+	var result dbResult
+	var evn map[string]interface{}
+	for _, statement := range x.statements {
+		if evn == nil && (statement.sqlHasVar || statement.paramsHasVar || statement.named || x.Config.Batch) {
+			evn = base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+		}
+		sqlStr, opType, paramNames, err := x.render(statement, evn)
+		if err != nil {
+			return result, err
+		}
+		if x.Config.Batch {
+			err = x.executeBatch(executor, statement, sqlStr, opType, paramNames, evn, msg, &result)
+		} else {
+			var params []interface{}
+			if params, err = x.params(statement, paramNames, evn); err == nil {
+				err = x.executeOne(executor, sqlStr, opType, params, &result)
+			}
+		}
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// render 转换sql变量，返回sql、操作类型和命名参数名称列表
+func (x *DbClientNode) render(statement *dbStatement, evn map[string]interface{}) (string, string, []string, error) {
+	if !statement.sqlHasVar {
+		return statement.sql, statement.opType, statement.paramNames, nil
+	}
+	sqlStr := str.ExecuteTemplate(statement.sql, evn)
+	var paramNames []string
+	if statement.named {
+		sqlStr, paramNames = parseNamedParams(sqlStr)
+	}
+	sqlStr = str.ConvertDollarPlaceholder(sqlStr, x.Config.DriverName)
+	opType := x.getOpType(sqlStr)
+	if err := x.checkOpType(opType, sqlStr); err != nil {
+		return "", "", nil, err
+	}
+	return sqlStr, opType, paramNames, nil
+}
+
+// params 转换参数变量
+func (x *DbClientNode) params(statement *dbStatement, paramNames []string, evn map[string]interface{}) ([]interface{}, error) {
+	var params []interface{}
+	if statement.named {
+		for _, name := range paramNames {
+			params = append(params, namedParamValue(name, evn))
+		}
+		return params, nil
+	}
+	for _, item := range statement.paramsTemplate {
+		param, err := item.Execute(evn)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, param)
+	}
+	return params, nil
+}
+
+// executeOne 执行一条SQL语句
+func (x *DbClientNode) executeOne(executor dbExecutor, sqlStr, opType string, params []interface{}, result *dbResult) error {
 	switch opType {
 	case SELECT:
-		data, err = x.query(client, sqlStr, params, x.Config.GetOne)
-	case UPDATE:
-		rowsAffected, err = x.update(client, sqlStr, params)
+		data, err := x.query(executor, sqlStr, params, x.Config.GetOne)
+		if err != nil {
+			return err
+		}
+		result.data = data
+		result.hasData = true
+	case UPDATE, DELETE:
+		var rowsAffected int64
+		var err error
+		if opType == UPDATE {
+			rowsAffected, err = x.update(executor, sqlStr, params)
+		} else {
+			rowsAffected, err = x.delete(executor, sqlStr, params)
+		}
+		if err != nil {
+			return err
+		}
+		result.rowsAffected += rowsAffected
+		result.hasExec = true
 	case INSERT:
-		rowsAffected, lastInsertId, err = x.insert(client, sqlStr, params)
-	case DELETE:
-		rowsAffected, err = x.delete(client, sqlStr, params)
+		rowsAffected, lastInsertId, err := x.insert(executor, sqlStr, params)
+		if err != nil {
+			return err
+		}
+		result.rowsAffected += rowsAffected
+		result.lastInsertId = lastInsertId
+		result.hasExec = true
+		result.hasInsert = true
 	default:
-		err = fmt.Errorf("unsupported sql statement: %s", sqlStr)
+		return fmt.Errorf("unsupported sql statement: %s", sqlStr)
 	}
+	return nil
+}
 
+// executeBatch 使用预编译语句，以消息负荷JSON数组的每个元素作为参数执行SQL语句
+func (x *DbClientNode) executeBatch(executor dbExecutor, statement *dbStatement, sqlStr, opType string, paramNames []string,
+	evn map[string]interface{}, msg types.RuleMsg, result *dbResult) error {
+	if opType == SELECT {
+		return fmt.Errorf("batch does not support select statement: %s", sqlStr)
+	}
+	var items []interface{}
+	if err := json.Unmarshal([]byte(msg.GetData()), &items); err != nil {
+		return fmt.Errorf("batch data must be a json array: %w", err)
+	}
+	stmt, err := executor.Prepare(sqlStr)
 	if err != nil {
-		ctx.TellFailure(msg, err)
-	} else {
-		switch opType {
-		case SELECT:
-			msg.SetData(str.ToString(data))
-		case UPDATE, DELETE:
-			msg.Metadata.PutValue(rowsAffectedKey, str.ToString(rowsAffected))
-		case INSERT:
-			msg.Metadata.PutValue(rowsAffectedKey, str.ToString(rowsAffected))
-			msg.Metadata.PutValue(lastInsertIdKey, str.ToString(lastInsertId))
+		return err
+	}
+	defer stmt.Close()
+	itemEvn := make(map[string]interface{}, len(evn))
+	for k, v := range evn {
+		itemEvn[k] = v
+	}
+	for _, item := range items {
+		itemEvn[types.MsgKey] = item
+		params, err := x.params(statement, paramNames, itemEvn)
+		if err != nil {
+			return err
 		}
-		ctx.TellSuccess(msg)
+		r, err := stmt.Exec(params...)
+		if err != nil {
+			return err
+		}
+		rowsAffected, err := r.RowsAffected()
+		if err != nil {
+			return err
+		}
+		result.rowsAffected += rowsAffected
+		if opType == INSERT {
+			result.lastInsertId, _ = r.LastInsertId()
+		}
+	}
+	result.hasExec = true
+	result.hasInsert = result.hasInsert || opType == INSERT
+	return nil
+}
+
+// namedParamValue 获取命名参数的值，msg.和metadata.前缀指定来源，否则优先从消息负荷取值，其次从元数据取值
+func namedParamValue(name string, evn map[string]interface{}) interface{} {
+	if strings.HasPrefix(name, types.MsgKey+".") || strings.HasPrefix(name, types.MetadataKey+".") {
+		return maps.Get(evn, name)
+	}
+	if v := maps.Get(evn[types.MsgKey], name); v != nil {
+		return v
+	}
+	return maps.Get(evn[types.MetadataKey], name)
+}
+
+// parseNamedParams 把 :name 命名参数转换成 ? 占位符，返回参数名称列表
+// 忽略引号中的内容以及 :: 类型转换和 := 赋值
+func parseNamedParams(sqlStr string) (string, []string) {
+	var sb strings.Builder
+	var names []string
+	var quote byte
+	for i := 0; i < len(sqlStr); i++ {
+		c := sqlStr[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			sb.WriteByte(c)
+			continue
+		}
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == ':' && i+1 < len(sqlStr) && isNameStart(sqlStr[i+1]) && (i == 0 || sqlStr[i-1] != ':'):
+			end := i + 1
+			for end < len(sqlStr) && (isNameStart(sqlStr[end]) || (sqlStr[end] >= '0' && sqlStr[end] <= '9') || sqlStr[end] == '.') {
+				end++
+			}
+			name := strings.TrimRight(sqlStr[i+1:end], ".")
+			names = append(names, name)
+			sb.WriteByte('?')
+			i += len(name)
+			continue
+		}
+		sb.WriteByte(c)
 	}
+	return sb.String(), names
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
 }
 
 // query 查询数据并返回map或slice类型
-func (x *DbClientNode) query(client *sql.DB, sqlStr string, params []interface{}, getOne bool) (interface{}, error) {
+func (x *DbClientNode) query(client dbExecutor, sqlStr string, params []interface{}, getOne bool) (interface{}, error) {
 	rows, err := client.Query(sqlStr, params...)
 	if err != nil {
 		return nil, err
@@ -275,7 +665,7 @@ func (x *DbClientNode) query(client *sql.DB, sqlStr string, params []interface{}
 }
 
 // update 修改数据并返回影响行数
-func (x *DbClientNode) update(client *sql.DB, sqlStr string, params []interface{}) (int64, error) {
+func (x *DbClientNode) update(client dbExecutor, sqlStr string, params []interface{}) (int64, error) {
 	result, err := client.Exec(sqlStr, params...)
 	if err != nil {
 		return 0, err
@@ -288,7 +678,7 @@ func (x *DbClientNode) update(client *sql.DB, sqlStr string, params []interface{
 }
 
 // insert 插入数据并返回自增ID
-func (x *DbClientNode) insert(client *sql.DB, sqlStr string, params []interface{}) (int64, int64, error) {
+func (x *DbClientNode) insert(client dbExecutor, sqlStr string, params []interface{}) (int64, int64, error) {
 	result, err := client.Exec(sqlStr, params...)
 	if err != nil {
 		return 0, 0, err
@@ -304,7 +694,7 @@ func (x *DbClientNode) insert(client *sql.DB, sqlStr string, params []interface{
 }
 
 // delete 删除数据并返回影响行数
-func (x *DbClientNode) delete(client *sql.DB, sqlStr string, params []interface{}) (int64, error) {
+func (x *DbClientNode) delete(client dbExecutor, sqlStr string, params []interface{}) (int64, error) {
 	result, err := client.Exec(sqlStr, params...)
 	if err != nil {
 		return 0, err
@@ -339,6 +729,14 @@ func (x *DbClientNode) initClient() (*sql.DB, error) {
 			x.client.SetMaxOpenConns(x.Config.PoolSize)
 			x.client.SetMaxIdleConns(x.Config.PoolSize / 2)
 			err = x.client.Ping()
+			if err == nil && x.Config.MigrationsDir != "" {
+				if err = migrate(x.client, x.Config.DriverName, x.Config.MigrationsDir, x.Config.MigrationsTable); err != nil {
+					//迁移失败，下次获取客户端时重试
+					_ = x.client.Close()
+					x.client = nil
+					return nil, err
+				}
+			}
 			return x.client, err
 		} else {
 			return nil, err
@@ -0,0 +1,116 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package external_test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/action"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 规则链：开启事务并插入数据，下一个节点决定提交事务或者失败结束
+var dbTxChain = `{
+  "ruleChain": {
+    "id": "%s"
+  },
+  "metadata": {
+    "nodes": [
+      {
+        "id": "s1",
+        "type": "dbClient",
+        "configuration": {
+          "driverName": "sqlite",
+          "dsn": "%s",
+          "sql": "insert into orders (id) values (:id)",
+          "namedParams": true,
+          "txMode": "begin"
+        }
+      },
+      {
+        "id": "s2",
+        "type": "%s",
+        "configuration": %s
+      }
+    ],
+    "connections": [
+      {
+        "fromId": "s1",
+        "toId": "s2",
+        "type": "Success"
+      }
+    ]
+  }
+}`
+
+// TestDbClientNodeTxEngine 通过规则引擎执行跨节点事务，没有提交的事务在规则链执行结束时回滚
+func TestDbClientNodeTxEngine(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "tx.db") + "?_pragma=busy_timeout(2000)"
+	db, err := sql.Open("sqlite", dsn)
+	assert.Nil(t, err)
+	defer db.Close()
+	_, err = db.Exec("create table orders (id integer)")
+	assert.Nil(t, err)
+
+	action.Functions.Register("dbTxFail", func(ctx types.RuleContext, msg types.RuleMsg) {
+		ctx.TellFailure(msg, errors.New("check failed"))
+	})
+	newEngine := func(nodeType, configuration string) types.RuleEngine {
+		id := str.RandomStr(10)
+		ruleEngine, err := engine.New(id, []byte(fmt.Sprintf(dbTxChain, id, dsn, nodeType, configuration)))
+		assert.Nil(t, err)
+		t.Cleanup(func() {
+			engine.Del(id)
+		})
+		return ruleEngine
+	}
+	failEngine := newEngine("functions", `{"functionName": "dbTxFail"}`)
+	commitEngine := newEngine("dbClient", fmt.Sprintf(`{"driverName": "sqlite", "dsn": "%s", "sql": "", "txMode": "commit"}`, dsn))
+
+	var relationType string
+	var resultErr error
+	onEnd := types.WithOnEnd(func(ctx types.RuleContext, msg types.RuleMsg, err error, r string) {
+		relationType = r
+		resultErr = err
+	})
+	//非数据库节点失败，没有执行提交或者回滚节点
+	failEngine.OnMsgAndWait(types.NewMsg(0, "TEST", types.JSON, types.NewMetadata(), `{"id":1}`), onEnd)
+	assert.Equal(t, types.Failure, relationType)
+	assert.Equal(t, "check failed", resultErr.Error())
+
+	//事务已经回滚并释放锁，后续事务可以写入
+	commitEngine.OnMsgAndWait(types.NewMsg(0, "TEST", types.JSON, types.NewMetadata(), `{"id":2}`), onEnd)
+	assert.Equal(t, types.Success, relationType)
+	assert.Nil(t, resultErr)
+
+	var ids []int
+	rows, err := db.Query("select id from orders")
+	assert.Nil(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		assert.Nil(t, rows.Scan(&id))
+		ids = append(ids, id)
+	}
+	assert.Equal(t, []int{2}, ids)
+}
@@ -17,6 +17,11 @@
 package external
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/rulego/rulego/api/types"
 	"github.com/rulego/rulego/test"
 	"github.com/rulego/rulego/test/assert"
@@ -480,3 +485,268 @@ type testUser struct {
 	Name string `json:"name"`
 	Age  int    `json:"age"`
 }
+
+func newDbMockNode(t *testing.T, dsn string, configuration types.Configuration) (*DbClientNode, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.NewWithDSN(dsn, sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	configuration["driverName"] = "sqlmock"
+	configuration["dsn"] = dsn
+	node, err := test.CreateAndInitNode("dbClient", configuration, Registry)
+	assert.Nil(t, err)
+	return node.(*DbClientNode), mock
+}
+
+func TestDbClientNodeNamedParams(t *testing.T) {
+	sqlStr, names := parseNamedParams("insert into users (id,name,age) values (:id, :metadata.name,:msg.user.age)")
+	assert.Equal(t, "insert into users (id,name,age) values (?, ?,?)", sqlStr)
+	assert.Equal(t, []string{"id", "metadata.name", "msg.user.age"}, names)
+	sqlStr, names = parseNamedParams("select ':x', id::text, @a := 1 from users where id=:id.")
+	assert.Equal(t, "select ':x', id::text, @a := 1 from users where id=?.", sqlStr)
+	assert.Equal(t, []string{"id"}, names)
+
+	node, mock := newDbMockNode(t, "namedParams", types.Configuration{
+		"sql":         "insert into users (id,name,age) values (:id,:name,:msg.age)",
+		"namedParams": true,
+	})
+	mock.ExpectExec("insert into users (id,name,age) values (?,?,?)").
+		WithArgs(float64(1), "test01", float64(18)).
+		WillReturnResult(sqlmock.NewResult(5, 1))
+
+	var relationType string
+	var result types.RuleMsg
+	ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, r string, err error) {
+		relationType = r
+		result = msg
+		assert.Nil(t, err)
+	})
+	metadata := types.NewMetadata()
+	metadata.PutValue("name", "test01")
+	node.OnMsg(ctx, types.NewMsg(0, "TEST", types.JSON, metadata, `{"id":1,"age":18}`))
+	assert.Equal(t, types.Success, relationType)
+	assert.Equal(t, "1", result.Metadata.GetValue(rowsAffectedKey))
+	assert.Equal(t, "5", result.Metadata.GetValue(lastInsertIdKey))
+	assert.Nil(t, mock.ExpectationsWereMet())
+
+	//没有开启命名参数，SQL语句保持不变
+	legacy, legacyMock := newDbMockNode(t, "legacyParams", types.Configuration{
+		"sql": "update users set tags = tags[lo:hi]",
+	})
+	legacyMock.ExpectExec("update users set tags = tags[lo:hi]").WithArgs().WillReturnResult(sqlmock.NewResult(0, 1))
+	legacy.OnMsg(ctx, types.NewMsg(0, "TEST", types.JSON, metadata, `{"lo":1}`))
+	assert.Equal(t, types.Success, relationType)
+	assert.Nil(t, legacyMock.ExpectationsWereMet())
+}
+
+func TestDbClientNodeStatements(t *testing.T) {
+	_, err := test.CreateAndInitNode("dbClient", types.Configuration{
+		"statements": []interface{}{map[string]interface{}{"sql": "xx"}},
+	}, Registry)
+	assert.Equal(t, "unsupported sql statement: xx", err.Error())
+	_, err = test.CreateAndInitNode("dbClient", types.Configuration{
+		"sql":    "select 1",
+		"txMode": "xx",
+	}, Registry)
+	assert.NotNil(t, err)
+
+	node, mock := newDbMockNode(t, "statements", types.Configuration{
+		"statements": []interface{}{
+			map[string]interface{}{"sql": "update account set balance = balance - ? where id = ?", "params": []interface{}{"${msg.amount}", "${msg.from}"}},
+			map[string]interface{}{"sql": "update account set balance = balance + :amount where id = :to"},
+			map[string]interface{}{"sql": "select balance from account where id = :from"},
+		},
+		"getOne":      true,
+		"namedParams": true,
+	})
+	var relationType string
+	var result types.RuleMsg
+	var resultErr error
+	ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, r string, err error) {
+		relationType = r
+		result = msg
+		resultErr = err
+	})
+	msg := types.NewMsg(0, "TEST", types.JSON, types.NewMetadata(), `{"from":"a","to":"b","amount":10}`)
+
+	t.Run("Commit", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("update account set balance = balance - ? where id = ?").WithArgs(float64(10), "a").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("update account set balance = balance + ? where id = ?").WithArgs(float64(10), "b").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("select balance from account where id = ?").WithArgs("a").WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(90))
+		mock.ExpectCommit()
+		node.OnMsg(ctx, msg.Copy())
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "2", result.Metadata.GetValue(rowsAffectedKey))
+		assert.Equal(t, `{"balance":90}`, result.GetData())
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("update account set balance = balance - ? where id = ?").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("update account set balance = balance + ? where id = ?").WillReturnError(errors.New("account not found"))
+		mock.ExpectRollback()
+		node.OnMsg(ctx, msg.Copy())
+		assert.Equal(t, types.Failure, relationType)
+		assert.Equal(t, "account not found", resultErr.Error())
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDbClientNodeBatch(t *testing.T) {
+	node, mock := newDbMockNode(t, "batch", types.Configuration{
+		"sql":         "insert into users (id,name,source) values (:id,:name,:source)",
+		"batch":       true,
+		"namedParams": true,
+	})
+	var relationType string
+	var result types.RuleMsg
+	var resultErr error
+	ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, r string, err error) {
+		relationType = r
+		result = msg
+		resultErr = err
+	})
+	metadata := types.NewMetadata()
+	metadata.PutValue("source", "import")
+
+	t.Run("Insert", func(t *testing.T) {
+		mock.ExpectBegin()
+		prepare := mock.ExpectPrepare("insert into users (id,name,source) values (?,?,?)")
+		prepare.ExpectExec().WithArgs(float64(1), "u1", "import").WillReturnResult(sqlmock.NewResult(1, 1))
+		prepare.ExpectExec().WithArgs(float64(2), "u2", "import").WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+		node.OnMsg(ctx, types.NewMsg(0, "TEST", types.JSON, metadata, `[{"id":1,"name":"u1"},{"id":2,"name":"u2"}]`))
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "2", result.Metadata.GetValue(rowsAffectedKey))
+		assert.Equal(t, "2", result.Metadata.GetValue(lastInsertIdKey))
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		mock.ExpectBegin()
+		prepare := mock.ExpectPrepare("insert into users (id,name,source) values (?,?,?)")
+		prepare.ExpectExec().WillReturnResult(sqlmock.NewResult(1, 1))
+		prepare.ExpectExec().WillReturnError(errors.New("duplicate key"))
+		mock.ExpectRollback()
+		node.OnMsg(ctx, types.NewMsg(0, "TEST", types.JSON, metadata, `[{"id":1,"name":"u1"},{"id":1,"name":"u1"}]`))
+		assert.Equal(t, types.Failure, relationType)
+		assert.Equal(t, "duplicate key", resultErr.Error())
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotArray", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		node.OnMsg(ctx, types.NewMsg(0, "TEST", types.JSON, metadata, `{"id":1}`))
+		assert.Equal(t, types.Failure, relationType)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDbClientNodeSharedTx(t *testing.T) {
+	dsn := "sharedTx"
+	begin, mock := newDbMockNode(t, dsn, types.Configuration{
+		"sql":         "insert into orders (id) values (:id)",
+		"txMode":      DbTxModeBegin,
+		"namedParams": true,
+	})
+	newNode := func(configuration types.Configuration) *DbClientNode {
+		configuration["driverName"] = "sqlmock"
+		configuration["dsn"] = dsn
+		configuration["namedParams"] = true
+		node, err := test.CreateAndInitNode("dbClient", configuration, Registry)
+		assert.Nil(t, err)
+		return node.(*DbClientNode)
+	}
+	update := newNode(types.Configuration{"sql": "update stock set qty = qty - 1 where id = :id"})
+	commit := newNode(types.Configuration{"sql": "", "txMode": DbTxModeCommit})
+	rollback := newNode(types.Configuration{"txMode": DbTxModeRollback})
+
+	var relationType string
+	var resultErr error
+	newCtx := func() types.RuleContext {
+		ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, r string, err error) {
+			relationType = r
+			resultErr = err
+		})
+		ctx.SetContext(context.Background())
+		return ctx
+	}
+	msg := types.NewMsg(0, "TEST", types.JSON, types.NewMetadata(), `{"id":1}`)
+
+	t.Run("Commit", func(t *testing.T) {
+		ctx := newCtx()
+		mock.ExpectBegin()
+		mock.ExpectExec("insert into orders (id) values (?)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("update stock set qty = qty - 1 where id = ?").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		begin.OnMsg(ctx, msg)
+		assert.Equal(t, types.Success, relationType)
+		//同一个规则链上下文不能重复开启事务
+		begin.OnMsg(ctx, msg)
+		assert.Equal(t, ErrDbTxAlreadyBegun, resultErr)
+		update.OnMsg(ctx, msg)
+		assert.Equal(t, types.Success, relationType)
+		commit.OnMsg(ctx, msg)
+		assert.Equal(t, types.Success, relationType)
+		assert.Nil(t, mock.ExpectationsWereMet())
+		//事务已经结束
+		commit.OnMsg(ctx, msg)
+		assert.Equal(t, sql.ErrTxDone, resultErr)
+	})
+
+	t.Run("RollbackOnFailure", func(t *testing.T) {
+		ctx := newCtx()
+		mock.ExpectBegin()
+		mock.ExpectExec("insert into orders (id) values (?)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec("update stock set qty = qty - 1 where id = ?").WillReturnError(errors.New("out of stock"))
+		mock.ExpectRollback()
+		begin.OnMsg(ctx, msg)
+		update.OnMsg(ctx, msg)
+		assert.Equal(t, types.Failure, relationType)
+		//失败分支的回滚节点，事务已经回滚
+		rollback.OnMsg(ctx, msg)
+		assert.Equal(t, types.Success, relationType)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		ctx := newCtx()
+		mock.ExpectBegin()
+		mock.ExpectExec("insert into orders (id) values (?)").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectRollback()
+		begin.OnMsg(ctx, msg)
+		rollback.OnMsg(ctx, msg)
+		assert.Equal(t, types.Success, relationType)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		ctx := newCtx()
+		commit.OnMsg(ctx, msg)
+		assert.Equal(t, ErrDbTxNotFound, resultErr)
+		rollback.OnMsg(ctx, msg)
+		assert.Equal(t, ErrDbTxNotFound, resultErr)
+		//没有事务时直接执行
+		mock.ExpectExec("update stock set qty = qty - 1 where id = ?").WillReturnResult(sqlmock.NewResult(0, 1))
+		update.OnMsg(ctx, msg)
+		assert.Equal(t, types.Success, relationType)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		timeoutBegin := newNode(types.Configuration{"sql": "", "txMode": DbTxModeBegin, "txTimeout": 1})
+		ctx := newCtx()
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+		timeoutBegin.OnMsg(ctx, msg)
+		assert.Equal(t, types.Success, relationType)
+		time.Sleep(time.Millisecond * 1200)
+		assert.Nil(t, mock.ExpectationsWereMet())
+		commit.OnMsg(ctx, msg)
+		assert.Equal(t, types.Failure, relationType)
+	})
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego/utils/str"
+)
+
+// DefaultMigrationsTable 默认记录已执行迁移脚本的表名
+const DefaultMigrationsTable = "schema_migrations"
+
+// migrationUpSuffix 迁移脚本文件后缀
+const migrationUpSuffix = ".up.sql"
+
+// migrateLock 同一个进程内多个节点串行执行迁移，防止重复执行同一个版本
+var migrateLock sync.Mutex
+
+// dbMigration 迁移脚本
+type dbMigration struct {
+	version int64
+	name    string
+	path    string
+}
+
+// loadMigrations 加载目录中的迁移脚本，文件名格式：<版本号>_<名称>.up.sql，例如：0001_create_users.up.sql
+// 其他文件(例如 .down.sql)忽略，按照版本号升序返回，版本号重复返回错误
+func loadMigrations(dir string) ([]dbMigration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var migrations []dbMigration
+	versions := make(map[int64]string)
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(fileName, migrationUpSuffix) {
+			continue
+		}
+		name := strings.TrimSuffix(fileName, migrationUpSuffix)
+		versionStr := name
+		if index := strings.Index(name, "_"); index >= 0 {
+			versionStr = name[:index]
+		}
+		version, err := strconv.ParseInt(versionStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", fileName)
+		}
+		if exist, ok := versions[version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", version, exist, fileName)
+		}
+		versions[version] = fileName
+		migrations = append(migrations, dbMigration{version: version, name: name, path: filepath.Join(dir, fileName)})
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	return migrations, nil
+}
+
+// migrate 执行未执行过的迁移脚本，每个脚本在单独的事务中执行并记录到迁移表
+// 一个脚本可以包含多条语句，mysql需要在dsn中配置 multiStatements=true
+func migrate(db *sql.DB, driverName, dir, table string) error {
+	if table == "" {
+		table = DefaultMigrationsTable
+	}
+	if !isIdentifier(table) {
+		return fmt.Errorf("invalid migrations table name: %s", table)
+	}
+	migrations, err := loadMigrations(dir)
+	if err != nil {
+		return err
+	}
+	migrateLock.Lock()
+	defer migrateLock.Unlock()
+
+	if _, err = db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version BIGINT PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at BIGINT NOT NULL)", table)); err != nil {
+		return err
+	}
+	applied, err := appliedVersions(db, table)
+	if err != nil {
+		return err
+	}
+	insertSql := str.ConvertDollarPlaceholder(fmt.Sprintf("INSERT INTO %s (version, name, applied_at) VALUES (?, ?, ?)", table), driverName)
+	for _, item := range migrations {
+		if applied[item.version] {
+			continue
+		}
+		script, err := os.ReadFile(item.path)
+		if err != nil {
+			return err
+		}
+		if err = applyMigration(db, item, string(script), insertSql); err != nil {
+			return fmt.Errorf("apply migration %s error: %w", item.name, err)
+		}
+	}
+	return nil
+}
+
+// appliedVersions 查询已执行的版本
+func appliedVersions(db *sql.DB, table string) (map[int64]bool, error) {
+	rows, err := db.Query(fmt.Sprintf("SELECT version FROM %s", table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[int64]bool)
+	for rows.Next() {
+		var version int64
+		if err = rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// applyMigration 在事务中执行迁移脚本并记录版本
+func applyMigration(db *sql.DB, item dbMigration, script, insertSql string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(script) != "" {
+		if _, err = tx.Exec(script); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	if _, err = tx.Exec(insertSql, item.version, item.name, time.Now().UnixMilli()); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// isIdentifier 是否是合法的表名
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if !(c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9')) {
+			return false
+		}
+	}
+	return true
+}
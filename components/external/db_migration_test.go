@@ -0,0 +1,138 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func writeMigration(t *testing.T, dir, name, script string) {
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, name), []byte(script), 0644))
+}
+
+func TestLoadMigrations(t *testing.T) {
+	dir := t.TempDir()
+	writeMigration(t, dir, "0010_add_index.up.sql", "")
+	writeMigration(t, dir, "0002_create_users.up.sql", "")
+	writeMigration(t, dir, "0002_create_users.down.sql", "")
+	writeMigration(t, dir, "README.md", "")
+	writeMigration(t, dir, "3.up.sql", "")
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "0004_dir.up.sql"), 0755))
+
+	migrations, err := loadMigrations(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(migrations))
+	assert.Equal(t, int64(2), migrations[0].version)
+	assert.Equal(t, "0002_create_users", migrations[0].name)
+	assert.Equal(t, int64(3), migrations[1].version)
+	assert.Equal(t, int64(10), migrations[2].version)
+
+	writeMigration(t, dir, "02_duplicate.up.sql", "")
+	_, err = loadMigrations(dir)
+	assert.NotNil(t, err)
+	assert.Nil(t, os.Remove(filepath.Join(dir, "02_duplicate.up.sql")))
+
+	writeMigration(t, dir, "v5_invalid.up.sql", "")
+	_, err = loadMigrations(dir)
+	assert.Equal(t, "invalid migration file name: v5_invalid.up.sql", err.Error())
+
+	_, err = loadMigrations(filepath.Join(dir, "notFound"))
+	assert.NotNil(t, err)
+}
+
+func TestDbClientNodeSqlite(t *testing.T) {
+	dir := t.TempDir()
+	migrationsDir := filepath.Join(dir, "migrations")
+	assert.Nil(t, os.Mkdir(migrationsDir, 0755))
+	writeMigration(t, migrationsDir, "0001_create_users.up.sql", `
+CREATE TABLE users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL
+);
+CREATE INDEX idx_users_name ON users (name);`)
+	dsn := filepath.Join(dir, "test.db")
+
+	newNode := func(configuration types.Configuration) (types.Node, error) {
+		configuration["driverName"] = "sqlite"
+		configuration["dsn"] = dsn
+		configuration["migrationsDir"] = migrationsDir
+		return test.CreateAndInitNode("dbClient", configuration, Registry)
+	}
+
+	insertNode, err := newNode(types.Configuration{
+		"sql":         "insert into users (name) values (:name)",
+		"namedParams": true,
+	})
+	assert.Nil(t, err)
+	defer insertNode.Destroy()
+	queryNode, err := newNode(types.Configuration{
+		"sql":    "select id,name from users where name = ?",
+		"params": []interface{}{"${metadata.name}"},
+		"getOne": true,
+	})
+	assert.Nil(t, err)
+	defer queryNode.Destroy()
+
+	var relationType string
+	var result types.RuleMsg
+	var resultErr error
+	ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, r string, err error) {
+		relationType = r
+		result = msg
+		resultErr = err
+	})
+	metadata := types.NewMetadata()
+	metadata.PutValue("name", "test01")
+	insertNode.OnMsg(ctx, types.NewMsg(0, "TEST", types.JSON, metadata, "{}"))
+	assert.Equal(t, types.Success, relationType)
+	assert.Nil(t, resultErr)
+	assert.Equal(t, "1", result.Metadata.GetValue(lastInsertIdKey))
+
+	queryNode.OnMsg(ctx, types.NewMsg(0, "TEST", types.JSON, metadata, "{}"))
+	assert.Equal(t, types.Success, relationType)
+	assert.Equal(t, `{"id":1,"name":"test01"}`, result.GetData())
+
+	//新增的迁移脚本在下一次初始化时执行，已经执行过的脚本不会重复执行
+	writeMigration(t, migrationsDir, "0002_add_email.up.sql", "ALTER TABLE users ADD COLUMN email TEXT;")
+	node, err := newNode(types.Configuration{"sql": "select email from users"})
+	assert.Nil(t, err)
+	node.Destroy()
+
+	db, err := sql.Open("sqlite", dsn)
+	assert.Nil(t, err)
+	defer db.Close()
+	var count int
+	assert.Nil(t, db.QueryRow("select count(*) from "+DefaultMigrationsTable).Scan(&count))
+	assert.Equal(t, 2, count)
+
+	//迁移失败，初始化失败，版本不会记录
+	writeMigration(t, migrationsDir, "0003_invalid.up.sql", "CREATE TABLE users (id INTEGER);")
+	_, err = newNode(types.Configuration{"sql": "select * from users"})
+	assert.NotNil(t, err)
+	assert.Nil(t, db.QueryRow("select count(*) from "+DefaultMigrationsTable).Scan(&count))
+	assert.Equal(t, 2, count)
+
+	_, err = newNode(types.Configuration{"sql": "select * from users", "migrationsTable": "users;drop"})
+	assert.Equal(t, "invalid migrations table name: users;drop", err.Error())
+}
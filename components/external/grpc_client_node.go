@@ -0,0 +1,419 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+// 共享节点配置示例，加载到 node_pool 后，其他grpcClient节点通过 "server":"ref://grpc_client" 复用连接：
+// {
+//        "id": "grpc_client",
+//        "type": "x/grpcClient",
+//        "name": "gRPC客户端",
+//        "configuration": {
+//          "server": "127.0.0.1:9090"
+//        }
+//  }
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/el"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/protoutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// 注册节点
+func init() {
+	Registry.Add(&GrpcClientNode{})
+}
+
+// GrpcClientKeyCode 调用失败时，gRPC状态码写入的元数据key
+const GrpcClientKeyCode = "grpcCode"
+
+// GrpcClientNodeConfiguration 节点配置
+type GrpcClientNodeConfiguration struct {
+	// Server 服务地址，例如：127.0.0.1:9090
+	// 使用 ref://{resourceId} 引用资源池中的共享连接
+	Server string `json:"server"`
+	// Method 调用的方法，格式：package.Service/Method，可以使用 ${metadata.key} 或者 ${msg.key} 变量
+	Method string `json:"method"`
+	// DescriptorSet 描述服务的descriptor set文件路径，多个使用逗号隔开
+	// 为空则通过服务端反射获取服务描述
+	DescriptorSet string `json:"descriptorSet"`
+	// Request 请求消息JSON，可以使用 ${metadata.key} 或者 ${msg.key} 变量，为空则使用消息负荷
+	Request string `json:"request"`
+	// Headers 请求元数据，可以使用 ${metadata.key} 或者 ${msg.key} 变量
+	Headers map[string]string `json:"headers"`
+	// Tls 是否使用TLS连接
+	Tls bool `json:"tls"`
+	// CaFile CA证书文件，为空使用系统证书
+	CaFile string `json:"caFile"`
+	// CertFile 客户端证书文件，双向认证时配置
+	CertFile string `json:"certFile"`
+	// CertKeyFile 客户端证书私钥文件
+	CertKeyFile string `json:"certKeyFile"`
+	// InsecureSkipVerify 是否跳过服务端证书验证
+	InsecureSkipVerify bool `json:"insecureSkipVerify"`
+	// UseProtoNames 响应转换成JSON时是否使用proto文件中的字段名，默认使用lowerCamelCase
+	UseProtoNames bool `json:"useProtoNames"`
+	// EmitUnpopulated 响应转换成JSON时是否输出默认值的字段
+	EmitUnpopulated bool `json:"emitUnpopulated"`
+	// Timeout 调用超时时间，单位秒，默认10秒。规则上下文的截止时间更早时以上下文为准
+	Timeout int `json:"timeout"`
+}
+
+// GrpcClientNode gRPC客户端节点，通过descriptor set或者服务端反射动态调用任意gRPC服务，不需要生成代码
+// 请求消息由JSON转换，响应转换成JSON写入消息负荷：
+// 一元调用：响应消息
+// 服务端流：所有响应消息组成的数组
+// 不支持客户端流和双向流。调用失败发送到`Failure`链，gRPC状态码写入元数据 grpcCode
+type GrpcClientNode struct {
+	base.SharedNode[*grpc.ClientConn]
+	//节点配置
+	Config          GrpcClientNodeConfiguration
+	conn            *grpc.ClientConn
+	methodTemplate  *el.MixedTemplate
+	requestTemplate *el.MixedTemplate
+	headerTemplates map[string]*el.MixedTemplate
+	// files descriptor set 中的服务描述
+	files *protoregistry.Files
+	// reflectFiles 通过反射获取的服务描述，key为服务名
+	reflectFiles sync.Map
+}
+
+// Type 返回组件类型
+func (x *GrpcClientNode) Type() string {
+	return "x/grpcClient"
+}
+
+func (x *GrpcClientNode) New() types.Node {
+	return &GrpcClientNode{Config: GrpcClientNodeConfiguration{
+		Server:  "127.0.0.1:9090",
+		Timeout: 10,
+	}}
+}
+
+// Init 初始化组件
+func (x *GrpcClientNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.Config.Timeout <= 0 {
+		x.Config.Timeout = 10
+	}
+	if !base.NodeUtils.IsInitNetResource(ruleConfig, configuration) {
+		if err = x.initTemplates(); err != nil {
+			return err
+		}
+		if x.Config.DescriptorSet != "" {
+			if x.files, err = protoutil.LoadDescriptorSet(strings.Split(x.Config.DescriptorSet, ",")...); err != nil {
+				return err
+			}
+			if !x.methodTemplate.HasVar() {
+				if _, err = x.findMethod(context.Background(), nil, x.Config.Method); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return x.SharedNode.Init(ruleConfig, x.Type(), x.Config.Server, ruleConfig.NodeClientInitNow, func() (*grpc.ClientConn, error) {
+		return x.initClient()
+	})
+}
+
+// initTemplates 预编译模板
+func (x *GrpcClientNode) initTemplates() error {
+	var err error
+	if strings.TrimSpace(x.Config.Method) == "" {
+		return errors.New("method can not empty")
+	}
+	if x.methodTemplate, err = el.NewMixedTemplate(x.Config.Method); err != nil {
+		return err
+	}
+	if x.Config.Request != "" {
+		if x.requestTemplate, err = el.NewMixedTemplate(x.Config.Request); err != nil {
+			return err
+		}
+	}
+	x.headerTemplates = make(map[string]*el.MixedTemplate, len(x.Config.Headers))
+	for key, value := range x.Config.Headers {
+		if x.headerTemplates[key], err = el.NewMixedTemplate(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *GrpcClientNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	conn, err := x.SharedNode.Get()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+
+	parent := ctx.GetContext()
+	if parent == nil {
+		parent = context.Background()
+	}
+	c, cancel := context.WithTimeout(parent, time.Duration(x.Config.Timeout)*time.Second)
+	defer cancel()
+
+	md, err := x.findMethod(c, conn, x.methodTemplate.ExecuteAsString(evn))
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	if md.IsStreamingClient() {
+		ctx.TellFailure(msg, fmt.Errorf("client streaming method %s is not supported", protoutil.FullMethod(md)))
+		return
+	}
+	var data []byte
+	if x.requestTemplate != nil {
+		data = []byte(x.requestTemplate.ExecuteAsString(evn))
+	} else {
+		data = []byte(msg.GetData())
+	}
+	in, err := protoutil.NewMessage(md.Input(), data)
+	if err != nil {
+		ctx.TellFailure(msg, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	for key, tmpl := range x.headerTemplates {
+		c = metadata.AppendToOutgoingContext(c, key, tmpl.ExecuteAsString(evn))
+	}
+
+	var result []byte
+	if md.IsStreamingServer() {
+		result, err = x.stream(c, conn, md, in)
+	} else {
+		out := dynamicpb.NewMessage(md.Output())
+		if err = conn.Invoke(c, protoutil.FullMethod(md), protoadapt.MessageV1Of(in), protoadapt.MessageV1Of(out)); err == nil {
+			result, err = protoutil.ToJson(out, x.Config.UseProtoNames, x.Config.EmitUnpopulated)
+		}
+	}
+	if err != nil {
+		if s, ok := status.FromError(err); ok {
+			msg.Metadata.PutValue(GrpcClientKeyCode, s.Code().String())
+		}
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.DataType = types.JSON
+	msg.SetData(string(result))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁，只关闭自身创建的连接
+func (x *GrpcClientNode) Destroy() {
+	if x.conn != nil {
+		_ = x.conn.Close()
+		x.conn = nil
+	}
+}
+
+// stream 服务端流调用，响应消息组成JSON数组
+func (x *GrpcClientNode) stream(ctx context.Context, conn *grpc.ClientConn, md protoreflect.MethodDescriptor, in proto.Message) ([]byte, error) {
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, protoutil.FullMethod(md))
+	if err != nil {
+		return nil, err
+	}
+	if err = stream.SendMsg(protoadapt.MessageV1Of(in)); err != nil {
+		return nil, err
+	}
+	if err = stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i := 0; ; i++ {
+		out := dynamicpb.NewMessage(md.Output())
+		if err = stream.RecvMsg(protoadapt.MessageV1Of(out)); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		data, err := protoutil.ToJson(out, x.Config.UseProtoNames, x.Config.EmitUnpopulated)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(data)
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
+
+// findMethod 查找方法描述，没有配置descriptor set则通过服务端反射获取，并按服务缓存
+func (x *GrpcClientNode) findMethod(ctx context.Context, conn *grpc.ClientConn, fullMethod string) (protoreflect.MethodDescriptor, error) {
+	if x.files != nil {
+		return protoutil.FindMethod(x.files, fullMethod)
+	}
+	service, _, err := protoutil.ParseMethod(fullMethod)
+	if err != nil {
+		return nil, err
+	}
+	if files, ok := x.reflectFiles.Load(service); ok {
+		return protoutil.FindMethod(files.(*protoregistry.Files), fullMethod)
+	}
+	files, err := x.resolveService(ctx, conn, service)
+	if err != nil {
+		return nil, err
+	}
+	md, err := protoutil.FindMethod(files, fullMethod)
+	if err != nil {
+		return nil, err
+	}
+	x.reflectFiles.Store(service, files)
+	return md, nil
+}
+
+// resolveService 通过服务端反射获取服务所在文件及其依赖的描述
+func (x *GrpcClientNode) resolveService(ctx context.Context, conn *grpc.ClientConn, service string) (*protoregistry.Files, error) {
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = stream.CloseSend()
+	}()
+	var fds []*descriptorpb.FileDescriptorProto
+	//已经收到的文件和已经请求的依赖，服务端没有返回请求的依赖时不再重复请求
+	var received = make(map[string]bool)
+	var requested = make(map[string]bool)
+	var request = &reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: service},
+	}
+	for request != nil {
+		if err = stream.Send(request); err != nil {
+			return nil, err
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if errResp := resp.GetErrorResponse(); errResp != nil {
+			return nil, fmt.Errorf("reflection error: %s", errResp.GetErrorMessage())
+		}
+		for _, item := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			var fdp descriptorpb.FileDescriptorProto
+			if err = proto.Unmarshal(item, &fdp); err != nil {
+				return nil, err
+			}
+			if !received[fdp.GetName()] {
+				received[fdp.GetName()] = true
+				fds = append(fds, &fdp)
+			}
+		}
+		//服务端只返回客户端还没有获取的依赖，缺少的依赖逐个请求
+		request = nil
+		for _, fdp := range fds {
+			for _, dep := range fdp.GetDependency() {
+				if received[dep] || requested[dep] {
+					continue
+				}
+				if _, err := protoregistry.GlobalFiles.FindFileByPath(dep); err == nil {
+					continue
+				}
+				requested[dep] = true
+				request = &reflectionpb.ServerReflectionRequest{
+					MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: dep},
+				}
+				break
+			}
+			if request != nil {
+				break
+			}
+		}
+	}
+	return protoutil.NewFiles(fds)
+}
+
+// initClient 初始化客户端
+func (x *GrpcClientNode) initClient() (*grpc.ClientConn, error) {
+	if x.conn != nil {
+		return x.conn, nil
+	}
+	x.Locker.Lock()
+	defer x.Locker.Unlock()
+	if x.conn != nil {
+		return x.conn, nil
+	}
+	creds := insecure.NewCredentials()
+	if x.Config.Tls {
+		tlsConfig, err := x.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	conn, err := grpc.Dial(x.Config.Server, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	x.conn = conn
+	return x.conn, nil
+}
+
+func (x *GrpcClientNode) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: x.Config.InsecureSkipVerify}
+	if x.Config.CaFile != "" {
+		ca, err := os.ReadFile(x.Config.CaFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("invalid ca file: %s", x.Config.CaFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if x.Config.CertFile != "" && x.Config.CertKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(x.Config.CertFile, x.Config.CertKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,370 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/protoutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const grpcDescriptorSet = "../../testdata/grpc/greeter.pb"
+
+// grpcImportDescriptorSet 导入非标准proto文件的服务描述
+const grpcImportDescriptorSet = "../../testdata/grpc/device.pb"
+
+type greeterServices struct{}
+
+func (greeterServices) GetServiceInfo() map[string]grpc.ServiceInfo {
+	return map[string]grpc.ServiceInfo{"rulego.test.Greeter": {}}
+}
+
+// singleFileReflection 只返回请求的文件，不返回依赖文件的反射服务
+type singleFileReflection struct {
+	reflectionpb.UnimplementedServerReflectionServer
+	files *protoregistry.Files
+}
+
+func (s singleFileReflection) ServerReflectionInfo(stream reflectionpb.ServerReflection_ServerReflectionInfoServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		var fd protoreflect.FileDescriptor
+		switch r := req.MessageRequest.(type) {
+		case *reflectionpb.ServerReflectionRequest_FileContainingSymbol:
+			if d, err := s.files.FindDescriptorByName(protoreflect.FullName(r.FileContainingSymbol)); err == nil {
+				fd = d.ParentFile()
+			}
+		case *reflectionpb.ServerReflectionRequest_FileByFilename:
+			fd, _ = s.files.FindFileByPath(r.FileByFilename)
+		}
+		resp := &reflectionpb.ServerReflectionResponse{OriginalRequest: req}
+		if fd == nil {
+			resp.MessageResponse = &reflectionpb.ServerReflectionResponse_ErrorResponse{
+				ErrorResponse: &reflectionpb.ErrorResponse{ErrorCode: int32(codes.NotFound), ErrorMessage: "not found"},
+			}
+		} else {
+			data, err := proto.Marshal(protodesc.ToFileDescriptorProto(fd))
+			if err != nil {
+				return err
+			}
+			resp.MessageResponse = &reflectionpb.ServerReflectionResponse_FileDescriptorResponse{
+				FileDescriptorResponse: &reflectionpb.FileDescriptorResponse{FileDescriptorProto: [][]byte{data}},
+			}
+		}
+		if err = stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// startGreeterServer 启动测试gRPC服务，SayHello返回问候语，StreamHello返回count条消息
+// name为fail返回InvalidArgument，name为slow等待直到超时
+func startGreeterServer(t *testing.T, files *protoregistry.Files, opts ...grpc.ServerOption) string {
+	return serveGreeter(t, files, reflection.NewServer(reflection.ServerOptions{
+		Services:           greeterServices{},
+		DescriptorResolver: files,
+	}), opts...)
+}
+
+// serveGreeter 使用指定的反射服务启动测试gRPC服务
+func serveGreeter(t *testing.T, files *protoregistry.Files, reflectionServer reflectionpb.ServerReflectionServer, opts ...grpc.ServerOption) string {
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		fullMethod, _ := grpc.MethodFromServerStream(stream)
+		md, err := protoutil.FindMethod(files, fullMethod)
+		if err != nil {
+			return status.Error(codes.Unimplemented, err.Error())
+		}
+		in := dynamicpb.NewMessage(md.Input())
+		if err = stream.RecvMsg(protoadapt.MessageV1Of(in)); err != nil {
+			return err
+		}
+		name := in.Get(md.Input().Fields().ByName("name")).String()
+		count := in.Get(md.Input().Fields().ByName("count")).Int()
+		switch name {
+		case "fail":
+			return status.Error(codes.InvalidArgument, "invalid name")
+		case "slow":
+			<-stream.Context().Done()
+			return stream.Context().Err()
+		}
+		token := ""
+		if values := metadata.ValueFromIncomingContext(stream.Context(), "token"); len(values) > 0 {
+			token = values[0]
+		}
+		if !md.IsStreamingServer() {
+			count = 1
+		}
+		for i := int64(1); i <= count; i++ {
+			out, err := protoutil.NewMessage(md.Output(), []byte(fmt.Sprintf(`{"message":"hello %s%s","index":%d}`, name, token, i)))
+			if err != nil {
+				return err
+			}
+			if err = stream.SendMsg(protoadapt.MessageV1Of(out)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	server := grpc.NewServer(append(opts, grpc.UnknownServiceHandler(handler))...)
+	reflectionpb.RegisterServerReflectionServer(server, reflectionServer)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go func() {
+		_ = server.Serve(ln)
+	}()
+	t.Cleanup(server.Stop)
+	return ln.Addr().String()
+}
+
+// writeSelfSignedCert 生成自签名证书，返回证书和私钥文件路径
+func writeSelfSignedCert(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	assert.Nil(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644))
+	assert.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return certFile, keyFile
+}
+
+type grpcResult struct {
+	msg          types.RuleMsg
+	relationType string
+	err          error
+}
+
+// grpcOnMsg 创建节点并处理一条消息，返回处理结果
+func grpcOnMsg(t *testing.T, configuration types.Configuration, ctx context.Context, data string) grpcResult {
+	node, err := test.CreateAndInitNode("x/grpcClient", configuration, Registry)
+	assert.Nil(t, err)
+	defer node.Destroy()
+	var result grpcResult
+	ruleCtx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+		result = grpcResult{msg: msg, relationType: relationType, err: err}
+	})
+	if ctx != nil {
+		ruleCtx.SetContext(ctx)
+	}
+	metadata := types.NewMetadata()
+	metadata.PutValue("token", "abc")
+	node.OnMsg(ruleCtx, types.NewMsg(0, "TEST_MSG", types.JSON, metadata, data))
+	return result
+}
+
+func TestGrpcClientNode(t *testing.T) {
+	var targetNodeType = "x/grpcClient"
+	files, err := protoutil.LoadDescriptorSet(grpcDescriptorSet)
+	assert.Nil(t, err)
+	addr := startGreeterServer(t, files)
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &GrpcClientNode{}, types.Configuration{
+			"server":  "127.0.0.1:9090",
+			"timeout": 10,
+		}, Registry)
+	})
+
+	t.Run("InitError", func(t *testing.T) {
+		for _, configuration := range []types.Configuration{
+			{"server": addr},
+			{"server": addr, "method": "rulego.test.Greeter/SayHello", "descriptorSet": "not_found.pb"},
+			{"server": addr, "method": "rulego.test.Greeter/Nothing", "descriptorSet": grpcDescriptorSet},
+		} {
+			_, err := test.CreateAndInitNode(targetNodeType, configuration, Registry)
+			assert.NotNil(t, err)
+		}
+	})
+
+	t.Run("Descriptor", func(t *testing.T) {
+		configuration := types.Configuration{
+			"server":        addr,
+			"method":        "rulego.test.Greeter/SayHello",
+			"descriptorSet": grpcDescriptorSet,
+			"headers":       map[string]string{"token": "-${metadata.token}"},
+		}
+		result := grpcOnMsg(t, configuration, nil, `{"name":"lala"}`)
+		assert.Equal(t, types.Success, result.relationType)
+		assert.Equal(t, types.JSON, result.msg.DataType)
+		assert.Equal(t, `{"message":"hello lala-abc","index":1}`, result.msg.GetData())
+
+		//请求模板和输出默认值
+		configuration["request"] = `{"name":"${msg.user}"}`
+		configuration["useProtoNames"] = true
+		configuration["emitUnpopulated"] = true
+		result = grpcOnMsg(t, configuration, nil, `{"user":"tom"}`)
+		assert.Equal(t, `{"message":"hello tom-abc","index":1,"time":null}`, result.msg.GetData())
+
+		//服务返回错误
+		result = grpcOnMsg(t, configuration, nil, `{"user":"fail"}`)
+		assert.Equal(t, types.Failure, result.relationType)
+		assert.Equal(t, codes.InvalidArgument.String(), result.msg.Metadata.GetValue(GrpcClientKeyCode))
+
+		//请求不合法
+		delete(configuration, "request")
+		result = grpcOnMsg(t, configuration, nil, `{"unknown":1}`)
+		assert.Equal(t, types.Failure, result.relationType)
+	})
+
+	t.Run("Reflection", func(t *testing.T) {
+		configuration := types.Configuration{
+			"server": addr,
+			"method": "${metadata.method}",
+		}
+		node, err := test.CreateAndInitNode(targetNodeType, configuration, Registry)
+		assert.Nil(t, err)
+		defer node.Destroy()
+		var results []grpcResult
+		ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+			results = append(results, grpcResult{msg: msg, relationType: relationType, err: err})
+		})
+		for _, method := range []string{"rulego.test.Greeter/StreamHello", "rulego.test.Greeter/SayHello", "rulego.test.Greeter/Chat", "rulego.test.Nothing/SayHello"} {
+			metadata := types.NewMetadata()
+			metadata.PutValue("method", method)
+			node.OnMsg(ctx, types.NewMsg(0, "TEST_MSG", types.JSON, metadata, `{"name":"lala","count":2}`))
+		}
+		assert.Equal(t, 4, len(results))
+		assert.Equal(t, types.Success, results[0].relationType)
+		assert.Equal(t, `[{"message":"hello lala","index":1},{"message":"hello lala","index":2}]`, results[0].msg.GetData())
+		assert.Equal(t, types.Success, results[1].relationType)
+		assert.Equal(t, `{"message":"hello lala","index":1}`, results[1].msg.GetData())
+		//不支持双向流
+		assert.Equal(t, types.Failure, results[2].relationType)
+		assert.True(t, strings.Contains(results[2].err.Error(), "not supported"))
+		//服务不存在
+		assert.Equal(t, types.Failure, results[3].relationType)
+		_, ok := node.(*GrpcClientNode).reflectFiles.Load("rulego.test.Greeter")
+		assert.True(t, ok)
+	})
+
+	t.Run("ReflectionImport", func(t *testing.T) {
+		importFiles, err := protoutil.LoadDescriptorSet(grpcImportDescriptorSet)
+		assert.Nil(t, err)
+		//服务端不返回依赖文件，客户端逐个请求导入的common.proto
+		importAddr := serveGreeter(t, importFiles, singleFileReflection{files: importFiles})
+		result := grpcOnMsg(t, types.Configuration{
+			"server": importAddr,
+			"method": "rulego.test.Device/SayHello",
+		}, nil, `{"name":"lala"}`)
+		assert.Equal(t, types.Success, result.relationType)
+		assert.Equal(t, `{"message":"hello lala","index":1}`, result.msg.GetData())
+	})
+
+	t.Run("Deadline", func(t *testing.T) {
+		configuration := types.Configuration{
+			"server":        addr,
+			"method":        "rulego.test.Greeter/SayHello",
+			"descriptorSet": grpcDescriptorSet,
+		}
+		//规则上下文的截止时间传递到服务端
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		result := grpcOnMsg(t, configuration, ctx, `{"name":"slow"}`)
+		assert.Equal(t, types.Failure, result.relationType)
+		assert.Equal(t, codes.DeadlineExceeded.String(), result.msg.Metadata.GetValue(GrpcClientKeyCode))
+		assert.True(t, time.Since(start) < 5*time.Second)
+
+		configuration["timeout"] = 1
+		result = grpcOnMsg(t, configuration, nil, `{"name":"slow"}`)
+		assert.Equal(t, codes.DeadlineExceeded.String(), result.msg.Metadata.GetValue(GrpcClientKeyCode))
+	})
+
+	t.Run("Tls", func(t *testing.T) {
+		certFile, keyFile := writeSelfSignedCert(t)
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		assert.Nil(t, err)
+		tlsAddr := startGreeterServer(t, files, grpc.Creds(credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{cert}})))
+		configuration := types.Configuration{
+			"server":  tlsAddr,
+			"method":  "rulego.test.Greeter/SayHello",
+			"tls":     true,
+			"caFile":  certFile,
+			"timeout": 2,
+		}
+		result := grpcOnMsg(t, configuration, nil, `{"name":"lala"}`)
+		assert.Equal(t, types.Success, result.relationType)
+		assert.Equal(t, `{"message":"hello lala","index":1}`, result.msg.GetData())
+
+		//证书不受信任
+		delete(configuration, "caFile")
+		result = grpcOnMsg(t, configuration, nil, `{"name":"lala"}`)
+		assert.Equal(t, types.Failure, result.relationType)
+
+		configuration["insecureSkipVerify"] = true
+		result = grpcOnMsg(t, configuration, nil, `{"name":"lala"}`)
+		assert.Equal(t, types.Success, result.relationType)
+
+		//连接时加载证书失败
+		configuration["caFile"] = "not_found.crt"
+		result = grpcOnMsg(t, configuration, nil, `{"name":"lala"}`)
+		assert.Equal(t, types.Failure, result.relationType)
+	})
+}
@@ -0,0 +1,752 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+// 共享节点配置示例，加载到 node_pool 后，其他mongoClient节点通过 "server":"ref://mongo_client" 复用连接池：
+// {
+//        "id": "mongo_client",
+//        "type": "x/mongoClient",
+//        "name": "mongodb客户端",
+//        "configuration": {
+//          "server": "mongodb://127.0.0.1:27017",
+//          "poolSize": 100
+//        }
+//  }
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/el"
+	"github.com/rulego/rulego/utils/json"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 注册节点
+func init() {
+	Registry.Add(&MongoClientNode{})
+}
+
+// mongoClient 操作类型
+const (
+	MongoOpFind      = "find"
+	MongoOpFindOne   = "findOne"
+	MongoOpInsert    = "insert"
+	MongoOpUpdate    = "update"
+	MongoOpUpsert    = "upsert"
+	MongoOpDelete    = "delete"
+	MongoOpAggregate = "aggregate"
+)
+
+var (
+	// ErrMongoEmptyFilter update、upsert、delete的查询条件为空
+	ErrMongoEmptyFilter = errors.New("filter can not empty for update, upsert and delete, set allowEmptyFilter to operate on all documents")
+	// ErrMongoFilterVarNil update、upsert、delete的查询条件变量值为空
+	ErrMongoFilterVarNil = errors.New("filter variable is nil")
+)
+
+// MongoClientNodeConfiguration 节点配置
+type MongoClientNodeConfiguration struct {
+	// Server 连接地址，例如：mongodb://127.0.0.1:27017
+	// 使用 ref://{resourceId} 引用资源池中的共享连接
+	Server string `json:"server"`
+	// PoolSize 连接池大小，0使用默认值
+	PoolSize uint64 `json:"poolSize"`
+	// Database 数据库，可以使用 ${metadata.key} 或者 ${msg.key} 变量
+	Database string `json:"database"`
+	// Collection 集合，可以使用 ${metadata.key} 或者 ${msg.key} 变量
+	Collection string `json:"collection"`
+	// OpType 操作类型：find、findOne、insert、update、upsert、delete、aggregate
+	OpType string `json:"opType"`
+	// Filter 查询条件，JSON对象或者JSON字符串，支持扩展JSON，例如：{"_id":{"$oid":"${msg.id}"}}
+	// 值为 ${msg.key} 时保持变量原有类型。find、findOne、update、upsert、delete有效
+	Filter interface{} `json:"filter"`
+	// Doc 文档，JSON对象、数组或者JSON字符串，${msg} 表示使用整个消息负荷
+	// insert：插入的文档，数组则批量插入。update、upsert：更新文档，没有使用$set等操作符时，作为$set的值
+	Doc interface{} `json:"doc"`
+	// Pipeline 聚合管道，JSON数组或者JSON字符串，aggregate有效
+	Pipeline interface{} `json:"pipeline"`
+	// Projection 返回字段，例如：{"name":1,"_id":0}，find、findOne有效
+	Projection interface{} `json:"projection"`
+	// Sort 排序字段，多个使用逗号分隔，-表示降序，例如：-ts,name，find、findOne有效
+	Sort string `json:"sort"`
+	// Limit 返回的最大文档数，0表示不限制，find有效
+	Limit int64 `json:"limit"`
+	// Many 是否更新或者删除所有匹配的文档，false只处理第一个匹配的文档。update、upsert、delete有效
+	Many bool `json:"many"`
+	// AllowEmptyFilter 是否允许update、upsert、delete使用空的查询条件，默认false
+	// 为false时，查询条件为空或者查询条件中的变量值为空，发送到`Failure`链，防止误操作整个集合
+	AllowEmptyFilter bool `json:"allowEmptyFilter"`
+	// Timeout 操作超时时间，单位秒，默认10秒
+	Timeout int `json:"timeout"`
+}
+
+// MongoClientNode mongodb客户端节点，对集合进行增删改查和聚合操作，结果写入消息负荷：
+// find、aggregate：文档数组
+// findOne：文档，没有匹配的文档则发送到`Failure`链
+// insert：{"insertedIds":[]}
+// update、upsert：{"matchedCount":0,"modifiedCount":0,"upsertedCount":0,"upsertedId":""}
+// delete：{"deletedCount":0}
+// ObjectID转换成十六进制字符串，时间转换成RFC3339格式字符串
+type MongoClientNode struct {
+	base.SharedNode[*mongo.Client]
+	//节点配置
+	Config             MongoClientNodeConfiguration
+	client             *mongo.Client
+	databaseTemplate   el.Template
+	collectionTemplate el.Template
+	filter             *mongoTemplate
+	doc                *mongoTemplate
+	pipeline           *mongoTemplate
+	projection         *mongoTemplate
+	sort               bson.D
+}
+
+// Type 返回组件类型
+func (x *MongoClientNode) Type() string {
+	return "x/mongoClient"
+}
+
+func (x *MongoClientNode) New() types.Node {
+	return &MongoClientNode{Config: MongoClientNodeConfiguration{
+		Server:     "mongodb://127.0.0.1:27017",
+		Database:   "test",
+		Collection: "test",
+		OpType:     MongoOpFind,
+	}}
+}
+
+// Init 初始化组件
+func (x *MongoClientNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.Config.Timeout <= 0 {
+		x.Config.Timeout = 10
+	}
+	if !base.NodeUtils.IsInitNetResource(ruleConfig, configuration) {
+		if err = x.initTemplates(); err != nil {
+			return err
+		}
+	}
+	return x.SharedNode.Init(ruleConfig, x.Type(), x.Config.Server, ruleConfig.NodeClientInitNow, func() (*mongo.Client, error) {
+		return x.initClient()
+	})
+}
+
+// initTemplates 检查操作类型并预编译模板
+func (x *MongoClientNode) initTemplates() error {
+	var err error
+	switch x.Config.OpType {
+	case MongoOpFind, MongoOpFindOne, MongoOpDelete:
+	case MongoOpInsert, MongoOpUpdate, MongoOpUpsert:
+		if x.Config.Doc == nil {
+			return fmt.Errorf("doc can not empty for %s", x.Config.OpType)
+		}
+	case MongoOpAggregate:
+		if x.Config.Pipeline == nil {
+			return errors.New("pipeline can not empty for aggregate")
+		}
+	default:
+		return fmt.Errorf("unsupported opType: %s", x.Config.OpType)
+	}
+	if x.Config.Database == "" || x.Config.Collection == "" {
+		return errors.New("database and collection can not empty")
+	}
+	if x.databaseTemplate, err = newMongoStringTemplate(x.Config.Database); err != nil {
+		return err
+	}
+	if x.collectionTemplate, err = newMongoStringTemplate(x.Config.Collection); err != nil {
+		return err
+	}
+	if x.filter, err = newMongoTemplate(x.Config.Filter); err != nil {
+		return fmt.Errorf("invalid filter: %w", err)
+	}
+	if x.requireFilter() && x.filter.empty() {
+		return ErrMongoEmptyFilter
+	}
+	if x.doc, err = newMongoTemplate(x.Config.Doc); err != nil {
+		return fmt.Errorf("invalid doc: %w", err)
+	}
+	if x.pipeline, err = newMongoTemplate(x.Config.Pipeline); err != nil {
+		return fmt.Errorf("invalid pipeline: %w", err)
+	}
+	if x.projection, err = newMongoTemplate(x.Config.Projection); err != nil {
+		return fmt.Errorf("invalid projection: %w", err)
+	}
+	x.sort = parseMongoSort(x.Config.Sort)
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *MongoClientNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	client, err := x.SharedNode.Get()
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	evn := base.NodeUtils.GetEvnAndMetadata(ctx, msg)
+	database := str.ToString(x.execute(x.databaseTemplate, evn))
+	collectionName := str.ToString(x.execute(x.collectionTemplate, evn))
+	if database == "" || collectionName == "" {
+		ctx.TellFailure(msg, errors.New("database and collection can not empty"))
+		return
+	}
+	collection := client.Database(database).Collection(collectionName)
+
+	parent := ctx.GetContext()
+	if parent == nil {
+		parent = context.Background()
+	}
+	c, cancel := context.WithTimeout(parent, time.Duration(x.Config.Timeout)*time.Second)
+	defer cancel()
+
+	result, err := x.do(c, collection, evn)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.SetData(str.ToString(result))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁，只关闭自身创建的连接
+func (x *MongoClientNode) Destroy() {
+	if x.client != nil {
+		_ = x.client.Disconnect(context.Background())
+		x.client = nil
+	}
+}
+
+// requireFilter update、upsert、delete是否必须指定查询条件
+func (x *MongoClientNode) requireFilter() bool {
+	switch x.Config.OpType {
+	case MongoOpUpdate, MongoOpUpsert, MongoOpDelete:
+		return !x.Config.AllowEmptyFilter
+	default:
+		return false
+	}
+}
+
+// do 执行操作，返回写入消息负荷的结果
+func (x *MongoClientNode) do(ctx context.Context, collection *mongo.Collection, evn map[string]interface{}) (interface{}, error) {
+	var filter bson.D
+	var err error
+	if x.requireFilter() {
+		filter, err = x.filter.requiredDocument(evn)
+	} else {
+		filter, err = x.filter.document(evn)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch x.Config.OpType {
+	case MongoOpFind:
+		opts := options.Find()
+		if x.Config.Limit > 0 {
+			opts.SetLimit(x.Config.Limit)
+		}
+		if len(x.sort) > 0 {
+			opts.SetSort(x.sort)
+		}
+		if projection, err := x.projection.document(evn); err != nil {
+			return nil, err
+		} else if len(projection) > 0 {
+			opts.SetProjection(projection)
+		}
+		cursor, err := collection.Find(ctx, filter, opts)
+		if err != nil {
+			return nil, err
+		}
+		return decodeMongoCursor(ctx, cursor)
+	case MongoOpFindOne:
+		opts := options.FindOne()
+		if len(x.sort) > 0 {
+			opts.SetSort(x.sort)
+		}
+		if projection, err := x.projection.document(evn); err != nil {
+			return nil, err
+		} else if len(projection) > 0 {
+			opts.SetProjection(projection)
+		}
+		var doc bson.D
+		if err = collection.FindOne(ctx, filter, opts).Decode(&doc); err != nil {
+			return nil, err
+		}
+		return toPlainValue(doc), nil
+	case MongoOpInsert:
+		return x.insert(ctx, collection, evn)
+	case MongoOpUpdate, MongoOpUpsert:
+		update, err := x.doc.document(evn)
+		if err != nil {
+			return nil, err
+		}
+		if !hasMongoOperator(update) {
+			update = bson.D{{Key: "$set", Value: update}}
+		}
+		opts := options.Update().SetUpsert(x.Config.OpType == MongoOpUpsert)
+		var result *mongo.UpdateResult
+		if x.Config.Many {
+			result, err = collection.UpdateMany(ctx, filter, update, opts)
+		} else {
+			result, err = collection.UpdateOne(ctx, filter, update, opts)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"matchedCount":  result.MatchedCount,
+			"modifiedCount": result.ModifiedCount,
+			"upsertedCount": result.UpsertedCount,
+			"upsertedId":    toPlainValue(result.UpsertedID),
+		}, nil
+	case MongoOpDelete:
+		var result *mongo.DeleteResult
+		if x.Config.Many {
+			result, err = collection.DeleteMany(ctx, filter)
+		} else {
+			result, err = collection.DeleteOne(ctx, filter)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"deletedCount": result.DeletedCount}, nil
+	case MongoOpAggregate:
+		pipeline, err := x.pipeline.array(evn)
+		if err != nil {
+			return nil, err
+		}
+		cursor, err := collection.Aggregate(ctx, pipeline)
+		if err != nil {
+			return nil, err
+		}
+		return decodeMongoCursor(ctx, cursor)
+	}
+	return nil, fmt.Errorf("unsupported opType: %s", x.Config.OpType)
+}
+
+// insert 插入文档，数组则批量插入
+func (x *MongoClientNode) insert(ctx context.Context, collection *mongo.Collection, evn map[string]interface{}) (interface{}, error) {
+	value, err := x.doc.value(evn)
+	if err != nil {
+		return nil, err
+	}
+	var insertedIds []interface{}
+	if items, ok := value.([]interface{}); ok {
+		if len(items) == 0 {
+			return nil, errors.New("no documents to insert")
+		}
+		docs := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			doc, err := toMongoDocument(item)
+			if err != nil {
+				return nil, err
+			}
+			docs = append(docs, doc)
+		}
+		result, err := collection.InsertMany(ctx, docs)
+		if err != nil {
+			return nil, err
+		}
+		insertedIds = result.InsertedIDs
+	} else {
+		doc, err := toMongoDocument(value)
+		if err != nil {
+			return nil, err
+		}
+		result, err := collection.InsertOne(ctx, doc)
+		if err != nil {
+			return nil, err
+		}
+		insertedIds = []interface{}{result.InsertedID}
+	}
+	return map[string]interface{}{"insertedIds": toPlainValue(insertedIds)}, nil
+}
+
+func (x *MongoClientNode) execute(tmpl el.Template, evn map[string]interface{}) interface{} {
+	if tmpl == nil {
+		return nil
+	}
+	if v, err := tmpl.Execute(evn); err == nil {
+		return v
+	}
+	return nil
+}
+
+// initClient 初始化客户端
+func (x *MongoClientNode) initClient() (*mongo.Client, error) {
+	if x.client != nil {
+		return x.client, nil
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), 4*time.Second)
+	x.Locker.Lock()
+	defer func() {
+		cancel()
+		x.Locker.Unlock()
+	}()
+	if x.client != nil {
+		return x.client, nil
+	}
+	opts := options.Client().ApplyURI(x.Config.Server)
+	if x.Config.PoolSize > 0 {
+		opts.SetMaxPoolSize(x.Config.PoolSize)
+	}
+	client, err := mongo.Connect(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err = client.Ping(ctx, nil); err != nil {
+		_ = client.Disconnect(context.Background())
+		return nil, err
+	}
+	x.client = client
+	return x.client, nil
+}
+
+// mongoTemplate JSON模板，字符串叶子节点可以使用变量，整个值为 ${xx} 时保持变量原有类型
+type mongoTemplate struct {
+	root interface{}
+}
+
+// newMongoTemplate 创建模板，tmpl为JSON字符串时先解析成JSON对象或者数组
+func newMongoTemplate(tmpl interface{}) (*mongoTemplate, error) {
+	if tmpl == nil {
+		return &mongoTemplate{}, nil
+	}
+	if v, ok := tmpl.(string); ok {
+		trimV := strings.TrimSpace(v)
+		if trimV == "" {
+			return &mongoTemplate{}, nil
+		}
+		if strings.HasPrefix(trimV, "{") || strings.HasPrefix(trimV, "[") {
+			var value interface{}
+			if err := json.Unmarshal([]byte(trimV), &value); err != nil {
+				return nil, err
+			}
+			tmpl = value
+		}
+	}
+	root, err := compileMongoTemplate(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	return &mongoTemplate{root: root}, nil
+}
+
+// newMongoStringTemplate 创建字符串模板，多个变量拼接使用字符串模板，例如：${metadata.type}-${msg.id}
+func newMongoStringTemplate(tmpl string) (el.Template, error) {
+	if strings.Count(tmpl, str.VarPrefix) > 1 {
+		return el.NewMixedTemplate(tmpl)
+	}
+	return el.NewTemplate(tmpl)
+}
+
+func compileMongoTemplate(v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			compiled, err := compileMongoTemplate(item)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = compiled
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			compiled, err := compileMongoTemplate(item)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, compiled)
+		}
+		return result, nil
+	case string:
+		if str.CheckHasVar(value) {
+			return newMongoStringTemplate(value)
+		}
+		return value, nil
+	default:
+		return v, nil
+	}
+}
+
+func executeMongoTemplate(v interface{}, evn map[string]interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			executed, err := executeMongoTemplate(item, evn)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = executed
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			executed, err := executeMongoTemplate(item, evn)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, executed)
+		}
+		return result, nil
+	case el.Template:
+		return value.Execute(evn)
+	default:
+		return v, nil
+	}
+}
+
+// value 使用消息环境变量执行模板，返回普通JSON值
+func (t *mongoTemplate) value(evn map[string]interface{}) (interface{}, error) {
+	if t == nil || t.root == nil {
+		return nil, nil
+	}
+	v, err := executeMongoTemplate(t.root, evn)
+	if err != nil {
+		return nil, err
+	}
+	//${msg} 得到的可能是JSON字符串
+	if s, ok := v.(string); ok {
+		trimV := strings.TrimSpace(s)
+		if strings.HasPrefix(trimV, "{") || strings.HasPrefix(trimV, "[") {
+			var value interface{}
+			if err = json.Unmarshal([]byte(trimV), &value); err == nil {
+				return value, nil
+			}
+		}
+	}
+	return v, nil
+}
+
+// document 执行模板并转换成bson文档，模板为空返回空文档
+func (t *mongoTemplate) document(evn map[string]interface{}) (bson.D, error) {
+	v, err := t.value(evn)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return bson.D{}, nil
+	}
+	return toMongoDocument(v)
+}
+
+// requiredDocument 执行模板并转换成bson文档，变量值为空或者文档为空返回错误。
+// 例如消息中不存在msg.id时，{"id":"${msg.id}"} 会匹配所有不存在id字段的文档
+func (t *mongoTemplate) requiredDocument(evn map[string]interface{}) (bson.D, error) {
+	if t.empty() {
+		return nil, ErrMongoEmptyFilter
+	}
+	v, err := t.value(evn)
+	if err != nil {
+		return nil, err
+	}
+	if hasNilMongoVar(t.root, v) {
+		return nil, ErrMongoFilterVarNil
+	}
+	doc, err := toMongoDocument(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(doc) == 0 {
+		return nil, ErrMongoEmptyFilter
+	}
+	return doc, nil
+}
+
+// empty 模板是否为空，变量模板需要执行后才能判断
+func (t *mongoTemplate) empty() bool {
+	if t == nil || t.root == nil {
+		return true
+	}
+	switch value := t.root.(type) {
+	case map[string]interface{}:
+		return len(value) == 0
+	case []interface{}:
+		return len(value) == 0
+	default:
+		return false
+	}
+}
+
+// hasNilMongoVar 模板中的变量执行后是否存在空值，tmpl为编译后的模板，v为执行结果
+func hasNilMongoVar(tmpl, v interface{}) bool {
+	switch value := tmpl.(type) {
+	case map[string]interface{}:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for k, item := range value {
+			if hasNilMongoVar(item, m[k]) {
+				return true
+			}
+		}
+	case []interface{}:
+		items, ok := v.([]interface{})
+		if !ok || len(items) != len(value) {
+			return false
+		}
+		for i, item := range value {
+			if hasNilMongoVar(item, items[i]) {
+				return true
+			}
+		}
+	case el.Template:
+		return v == nil
+	}
+	return false
+}
+
+// array 执行模板并转换成bson文档数组
+func (t *mongoTemplate) array(evn map[string]interface{}) (bson.A, error) {
+	v, err := t.value(evn)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil, errors.New("value is not an array")
+	}
+	result := make(bson.A, 0, len(items))
+	for _, item := range items {
+		doc, err := toMongoDocument(item)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, doc)
+	}
+	return result, nil
+}
+
+// toMongoDocument 把JSON对象转换成bson文档，支持扩展JSON，例如：{"$oid":""}、{"$date":""}
+func toMongoDocument(v interface{}) (bson.D, error) {
+	if _, ok := v.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("value is not a JSON object: %v", v)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.D
+	if err = bson.UnmarshalExtJSON(b, false, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// hasMongoOperator 更新文档是否使用了$set等操作符
+func hasMongoOperator(doc bson.D) bool {
+	for _, item := range doc {
+		if strings.HasPrefix(item.Key, "$") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMongoSort 解析排序字段，例如：-ts,name
+func parseMongoSort(sort string) bson.D {
+	var result bson.D
+	for _, item := range strings.Split(sort, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if strings.HasPrefix(item, "-") {
+			result = append(result, bson.E{Key: strings.TrimSpace(item[1:]), Value: -1})
+		} else {
+			result = append(result, bson.E{Key: strings.TrimPrefix(item, "+"), Value: 1})
+		}
+	}
+	return result
+}
+
+// decodeMongoCursor 读取游标中的所有文档
+func decodeMongoCursor(ctx context.Context, cursor *mongo.Cursor) (interface{}, error) {
+	var docs []bson.D
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	result := make([]interface{}, 0, len(docs))
+	for _, doc := range docs {
+		result = append(result, toPlainValue(doc))
+	}
+	return result, nil
+}
+
+// toPlainValue 把bson值转换成普通JSON值
+func toPlainValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case bson.D:
+		result := make(map[string]interface{}, len(value))
+		for _, item := range value {
+			result[item.Key] = toPlainValue(item.Value)
+		}
+		return result
+	case bson.M:
+		result := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			result[k] = toPlainValue(item)
+		}
+		return result
+	case bson.A:
+		result := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			result = append(result, toPlainValue(item))
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, 0, len(value))
+		for _, item := range value {
+			result = append(result, toPlainValue(item))
+		}
+		return result
+	case primitive.ObjectID:
+		return value.Hex()
+	case primitive.DateTime:
+		return value.Time().UTC().Format(time.RFC3339Nano)
+	case primitive.Timestamp:
+		return value.T
+	case primitive.Decimal128:
+		return value.String()
+	case primitive.Binary:
+		return value.Data
+	case primitive.Regex:
+		return value.Pattern
+	case primitive.Null, primitive.Undefined:
+		return nil
+	default:
+		return v
+	}
+}
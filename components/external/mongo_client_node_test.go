@@ -0,0 +1,446 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/json"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestMongoTemplate(t *testing.T) {
+	evn := map[string]interface{}{
+		"msg": map[string]interface{}{
+			"deviceId": "d1",
+			"temp":     float64(25),
+			"tags":     []interface{}{"a", "b"},
+		},
+		"metadata": map[string]interface{}{
+			"productType": "sensor",
+		},
+		"productType": "sensor",
+	}
+
+	tmpl, err := newMongoTemplate(map[string]interface{}{
+		"deviceId": "${msg.deviceId}",
+		"temp":     map[string]interface{}{"$gt": "${msg.temp}"},
+		"name":     "${metadata.productType}-${msg.deviceId}",
+		"tags":     "${msg.tags}",
+		"fixed":    true,
+	})
+	assert.Nil(t, err)
+	doc, err := tmpl.document(evn)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"deviceId": "d1",
+		"temp":     map[string]interface{}{"$gt": int32(25)},
+		"name":     "sensor-d1",
+		"tags":     []interface{}{"a", "b"},
+		"fixed":    true,
+	}, toPlainValue(doc))
+
+	//JSON字符串和扩展JSON
+	tmpl, err = newMongoTemplate(`{"_id":{"$oid":"${msg.id}"},"ts":{"$date":"2024-01-01T00:00:00Z"}}`)
+	assert.Nil(t, err)
+	id := primitive.NewObjectID()
+	doc, err = tmpl.document(map[string]interface{}{"msg": map[string]interface{}{"id": id.Hex()}})
+	assert.Nil(t, err)
+	assert.Equal(t, id, doc.Map()["_id"])
+	assert.Equal(t, "2024-01-01T00:00:00Z", toPlainValue(doc.Map()["ts"]))
+
+	//整个消息负荷
+	tmpl, err = newMongoTemplate("${msg}")
+	assert.Nil(t, err)
+	v, err := tmpl.value(map[string]interface{}{"msg": `[{"a":1},{"a":2}]`})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(v.([]interface{})))
+	arr, err := tmpl.array(map[string]interface{}{"msg": []interface{}{map[string]interface{}{"$match": map[string]interface{}{"a": 1}}}})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(arr))
+	_, err = tmpl.array(map[string]interface{}{"msg": map[string]interface{}{}})
+	assert.NotNil(t, err)
+	_, err = tmpl.document(map[string]interface{}{"msg": "abc"})
+	assert.NotNil(t, err)
+
+	//空模板
+	tmpl, err = newMongoTemplate(nil)
+	assert.Nil(t, err)
+	doc, err = tmpl.document(evn)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(doc))
+	_, err = newMongoTemplate(`{"a":`)
+	assert.NotNil(t, err)
+}
+
+func TestMongoHelpers(t *testing.T) {
+	assert.Equal(t, bson.D{{Key: "ts", Value: -1}, {Key: "name", Value: 1}}, parseMongoSort("-ts, name,"))
+	assert.Equal(t, 0, len(parseMongoSort("")))
+	assert.True(t, hasMongoOperator(bson.D{{Key: "$set", Value: bson.D{}}}))
+	assert.False(t, hasMongoOperator(bson.D{{Key: "name", Value: "a"}}))
+
+	id := primitive.NewObjectID()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, map[string]interface{}{
+		"_id":   id.Hex(),
+		"ts":    "2024-01-01T00:00:00Z",
+		"items": []interface{}{int32(1), map[string]interface{}{"a": nil}},
+		"m":     map[string]interface{}{"b": "c"},
+	}, toPlainValue(bson.D{
+		{Key: "_id", Value: id},
+		{Key: "ts", Value: primitive.NewDateTimeFromTime(now)},
+		{Key: "items", Value: bson.A{int32(1), bson.D{{Key: "a", Value: primitive.Null{}}}}},
+		{Key: "m", Value: bson.M{"b": "c"}},
+	}))
+}
+
+func TestMongoClientNode(t *testing.T) {
+	var targetNodeType = "x/mongoClient"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &MongoClientNode{}, types.Configuration{
+			"server":     "mongodb://127.0.0.1:27017",
+			"database":   "test",
+			"collection": "test",
+			"opType":     MongoOpFind,
+		}, Registry)
+	})
+
+	t.Run("InitError", func(t *testing.T) {
+		for _, configuration := range []types.Configuration{
+			{"opType": "xx"},
+			{"opType": MongoOpInsert},
+			{"opType": MongoOpAggregate},
+			{"database": ""},
+			{"filter": `{"a":`},
+			{"opType": MongoOpUpdate, "filter": `{"a":1}`, "doc": "{"},
+		} {
+			_, err := test.CreateAndInitNode(targetNodeType, configuration, Registry)
+			assert.NotNil(t, err)
+		}
+		//update、upsert、delete没有查询条件
+		for _, configuration := range []types.Configuration{
+			{"opType": MongoOpDelete},
+			{"opType": MongoOpDelete, "filter": "{}"},
+			{"opType": MongoOpUpdate, "doc": `{"a":1}`},
+			{"opType": MongoOpUpsert, "filter": map[string]interface{}{}, "doc": `{"a":1}`},
+		} {
+			configuration["database"] = "test"
+			configuration["collection"] = "test"
+			_, err := test.CreateAndInitNode(targetNodeType, configuration, Registry)
+			assert.Equal(t, ErrMongoEmptyFilter, err)
+		}
+	})
+
+	t.Run("ConnectError", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"server": "mongodb://127.0.0.1:1/?serverSelectionTimeoutMS=200",
+		}, Registry)
+		assert.Nil(t, err)
+		var relationType string
+		ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, r string, err error) {
+			relationType = r
+		})
+		node.OnMsg(ctx, types.NewMsg(0, "TEST_MSG", types.JSON, types.NewMetadata(), "{}"))
+		assert.Equal(t, types.Failure, relationType)
+		node.Destroy()
+	})
+
+	t.Run("Mock", func(t *testing.T) {
+		mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+		var relationType string
+		var result types.RuleMsg
+		var resultErr error
+		ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, r string, err error) {
+			relationType = r
+			result = msg
+			resultErr = err
+		})
+		metadata := types.NewMetadata()
+		metadata.PutValue("deviceId", "d1")
+		metadata.PutValue("productType", "sensor")
+		//使用模拟连接创建节点，返回发送的命令
+		onMsg := func(mt *mtest.T, configuration types.Configuration, data string) bson.Raw {
+			configuration["database"] = "rulego_test"
+			configuration["collection"] = "${metadata.productType}_devices"
+			node, err := test.CreateAndInitNode(targetNodeType, configuration, Registry)
+			assert.Nil(t, err)
+			node.(*MongoClientNode).client = mt.Client
+			mt.ClearEvents()
+			node.OnMsg(ctx, types.NewMsg(0, "TEST_MSG", types.JSON, metadata, data))
+			if event := mt.GetStartedEvent(); event != nil {
+				assert.Equal(t, "rulego_test", event.DatabaseName)
+				return event.Command
+			}
+			return nil
+		}
+
+		mt.Run("Find", func(mt *mtest.T) {
+			id := primitive.NewObjectID()
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "rulego_test.sensor_devices", mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: id}, {Key: "deviceId", Value: "d1"}, {Key: "temp", Value: int32(25)}}))
+			cmd := onMsg(mt, types.Configuration{
+				"opType": MongoOpFind,
+				"filter": `{"deviceId":"${metadata.deviceId}","temp":{"$gte":"${msg.min}"}}`,
+				"sort":   "-temp",
+				"limit":  10,
+			}, `{"min":20}`)
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, `[{"_id":"`+id.Hex()+`","deviceId":"d1","temp":25}]`, result.GetData())
+			assert.Equal(t, "sensor_devices", cmd.Lookup("find").StringValue())
+			assert.Equal(t, `{"deviceId": "d1","temp": {"$gte": {"$numberInt":"20"}}}`, cmd.Lookup("filter").String())
+			assert.Equal(t, `{"temp": {"$numberInt":"-1"}}`, cmd.Lookup("sort").String())
+		})
+
+		mt.Run("FindOne", func(mt *mtest.T) {
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "rulego_test.sensor_devices", mtest.FirstBatch,
+				bson.D{{Key: "deviceId", Value: "d1"}}))
+			onMsg(mt, types.Configuration{
+				"opType":     MongoOpFindOne,
+				"filter":     map[string]interface{}{"deviceId": "${metadata.deviceId}"},
+				"projection": map[string]interface{}{"_id": 0},
+			}, "{}")
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, `{"deviceId":"d1"}`, result.GetData())
+
+			//没有匹配的文档
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "rulego_test.sensor_devices", mtest.FirstBatch))
+			onMsg(mt, types.Configuration{"opType": MongoOpFindOne}, "{}")
+			assert.Equal(t, types.Failure, relationType)
+			assert.Equal(t, mongo.ErrNoDocuments, resultErr)
+		})
+
+		mt.Run("Insert", func(mt *mtest.T) {
+			mt.AddMockResponses(mtest.CreateSuccessResponse())
+			cmd := onMsg(mt, types.Configuration{"opType": MongoOpInsert, "doc": "${msg}"}, `[{"deviceId":"d1"},{"deviceId":"d2"}]`)
+			assert.Equal(t, types.Success, relationType)
+			data, _ := result.GetDataAsJson()
+			assert.Equal(t, 2, len(data["insertedIds"].([]interface{})))
+			docs, _ := cmd.Lookup("documents").Array().Values()
+			assert.Equal(t, 2, len(docs))
+
+			mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "duplicate key"}))
+			onMsg(mt, types.Configuration{"opType": MongoOpInsert, "doc": map[string]interface{}{"deviceId": "${metadata.deviceId}"}}, "{}")
+			assert.Equal(t, types.Failure, relationType)
+			assert.True(t, mongo.IsDuplicateKeyError(resultErr))
+		})
+
+		mt.Run("Update", func(mt *mtest.T) {
+			mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+			cmd := onMsg(mt, types.Configuration{
+				"opType": MongoOpUpdate,
+				"filter": map[string]interface{}{"deviceId": "${metadata.deviceId}"},
+				"doc":    map[string]interface{}{"temp": "${msg.temp}"},
+			}, `{"temp":25}`)
+			assert.Equal(t, types.Success, relationType)
+			data, _ := result.GetDataAsJson()
+			assert.Equal(t, float64(1), data["modifiedCount"])
+			update := cmd.Lookup("updates").Array().Index(0).Value().Document()
+			assert.Equal(t, `{"$set": {"temp": {"$numberInt":"25"}}}`, update.Lookup("u").String())
+			multi, _ := update.Lookup("multi").BooleanOK()
+			assert.False(t, multi)
+		})
+
+		mt.Run("Upsert", func(mt *mtest.T) {
+			mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1},
+				bson.E{Key: "upserted", Value: bson.A{bson.D{{Key: "index", Value: 0}, {Key: "_id", Value: "d3"}}}}))
+			cmd := onMsg(mt, types.Configuration{
+				"opType": MongoOpUpsert,
+				"filter": `{"deviceId":"${msg.deviceId}"}`,
+				"doc":    `{"$inc":{"count":1}}`,
+				"many":   true,
+			}, `{"deviceId":"d3"}`)
+			assert.Equal(t, types.Success, relationType)
+			data, _ := result.GetDataAsJson()
+			assert.Equal(t, float64(1), data["upsertedCount"])
+			assert.Equal(t, "d3", data["upsertedId"])
+			update := cmd.Lookup("updates").Array().Index(0).Value().Document()
+			assert.True(t, update.Lookup("upsert").Boolean())
+			assert.True(t, update.Lookup("multi").Boolean())
+		})
+
+		mt.Run("Delete", func(mt *mtest.T) {
+			mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 3}))
+			cmd := onMsg(mt, types.Configuration{
+				"opType": MongoOpDelete,
+				"filter": map[string]interface{}{"temp": map[string]interface{}{"$lt": 50}},
+				"many":   true,
+			}, "{}")
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, `{"deletedCount":3}`, result.GetData())
+			assert.Equal(t, int32(0), cmd.Lookup("deletes").Array().Index(0).Value().Document().Lookup("limit").Int32())
+		})
+
+		mt.Run("EmptyFilter", func(mt *mtest.T) {
+			//消息中不存在变量，不能匹配所有不存在该字段的文档
+			cmd := onMsg(mt, types.Configuration{
+				"opType": MongoOpDelete,
+				"filter": `{"_id":"${msg.id}"}`,
+				"many":   true,
+			}, "{}")
+			assert.Equal(t, types.Failure, relationType)
+			assert.Equal(t, ErrMongoFilterVarNil, resultErr)
+			assert.Nil(t, cmd)
+
+			cmd = onMsg(mt, types.Configuration{
+				"opType": MongoOpUpdate,
+				"filter": "${msg.filter}",
+				"doc":    `{"temp":1}`,
+			}, `{"filter":{}}`)
+			assert.Equal(t, types.Failure, relationType)
+			assert.Equal(t, ErrMongoEmptyFilter, resultErr)
+			assert.Nil(t, cmd)
+
+			//允许空的查询条件
+			mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 5}))
+			cmd = onMsg(mt, types.Configuration{
+				"opType":           MongoOpDelete,
+				"many":             true,
+				"allowEmptyFilter": true,
+			}, "{}")
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, `{"deletedCount":5}`, result.GetData())
+			assert.Equal(t, `{}`, cmd.Lookup("deletes").Array().Index(0).Value().Document().Lookup("q").String())
+		})
+
+		mt.Run("Aggregate", func(mt *mtest.T) {
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, "rulego_test.sensor_devices", mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: primitive.Null{}}, {Key: "total", Value: int32(95)}}))
+			cmd := onMsg(mt, types.Configuration{
+				"opType":   MongoOpAggregate,
+				"pipeline": `[{"$match":{"deviceId":"${metadata.deviceId}"}},{"$group":{"_id":null,"total":{"$sum":"$temp"}}}]`,
+			}, "{}")
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, `[{"_id":null,"total":95}]`, result.GetData())
+			stages, _ := cmd.Lookup("pipeline").Array().Values()
+			assert.Equal(t, 2, len(stages))
+			assert.Equal(t, `{"deviceId": "d1"}`, stages[0].Document().Lookup("$match").String())
+		})
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		server := os.Getenv("MONGO_SERVER")
+		if server == "" {
+			server = "mongodb://127.0.0.1:27017/?serverSelectionTimeoutMS=1000"
+		}
+		collection := "devices_" + primitive.NewObjectID().Hex()
+		newNode := func(configuration types.Configuration) types.Node {
+			configuration["server"] = server
+			configuration["database"] = "rulego_test"
+			configuration["collection"] = collection
+			node, err := test.CreateAndInitNode(targetNodeType, configuration, Registry)
+			assert.Nil(t, err)
+			return node
+		}
+		insertNode := newNode(types.Configuration{"opType": MongoOpInsert, "doc": "${msg}"})
+		defer insertNode.Destroy()
+		if _, err := insertNode.(*MongoClientNode).Get(); err != nil {
+			t.Skip("mongod is not available: ", err)
+		}
+		defer func() {
+			client, _ := insertNode.(*MongoClientNode).Get()
+			_ = client.Database("rulego_test").Collection(collection).Drop(context.Background())
+		}()
+
+		var relationType string
+		var result types.RuleMsg
+		var resultErr error
+		ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, r string, err error) {
+			relationType = r
+			result = msg
+			resultErr = err
+		})
+		metadata := types.NewMetadata()
+		metadata.PutValue("deviceId", "d1")
+		onMsg := func(node types.Node, data string) {
+			node.OnMsg(ctx, types.NewMsg(0, "TEST_MSG", types.JSON, metadata, data))
+			assert.Nil(t, resultErr)
+		}
+
+		onMsg(insertNode, `[{"deviceId":"d1","temp":20},{"deviceId":"d2","temp":30}]`)
+		assert.Equal(t, types.Success, relationType)
+		data, _ := result.GetDataAsJson()
+		assert.Equal(t, 2, len(data["insertedIds"].([]interface{})))
+
+		updateNode := newNode(types.Configuration{
+			"opType": MongoOpUpdate,
+			"filter": map[string]interface{}{"deviceId": "${metadata.deviceId}"},
+			"doc":    map[string]interface{}{"temp": "${msg.temp}"},
+		})
+		onMsg(updateNode, `{"temp":25}`)
+		data, _ = result.GetDataAsJson()
+		assert.Equal(t, float64(1), data["modifiedCount"])
+
+		upsertNode := newNode(types.Configuration{
+			"opType": MongoOpUpsert,
+			"filter": `{"deviceId":"${msg.deviceId}"}`,
+			"doc":    `{"$set":{"temp":"${msg.temp}"}}`,
+		})
+		onMsg(upsertNode, `{"deviceId":"d3","temp":40}`)
+		data, _ = result.GetDataAsJson()
+		assert.Equal(t, float64(1), data["upsertedCount"])
+
+		findOneNode := newNode(types.Configuration{
+			"opType":     MongoOpFindOne,
+			"filter":     map[string]interface{}{"deviceId": "${metadata.deviceId}"},
+			"projection": map[string]interface{}{"_id": 0},
+		})
+		onMsg(findOneNode, "{}")
+		assert.Equal(t, `{"deviceId":"d1","temp":25}`, result.GetData())
+
+		findNode := newNode(types.Configuration{
+			"opType": MongoOpFind,
+			"filter": `{"temp":{"$gte":"${msg.min}"}}`,
+			"sort":   "-temp",
+			"limit":  2,
+		})
+		onMsg(findNode, `{"min":25}`)
+		var list []interface{}
+		_ = json.Unmarshal([]byte(result.GetData()), &list)
+		assert.Equal(t, 2, len(list))
+		assert.Equal(t, "d3", list[0].(map[string]interface{})["deviceId"])
+
+		aggregateNode := newNode(types.Configuration{
+			"opType":   MongoOpAggregate,
+			"pipeline": `[{"$group":{"_id":null,"total":{"$sum":"$temp"}}}]`,
+		})
+		onMsg(aggregateNode, "{}")
+		_ = json.Unmarshal([]byte(result.GetData()), &list)
+		assert.Equal(t, float64(95), list[0].(map[string]interface{})["total"])
+
+		deleteNode := newNode(types.Configuration{
+			"opType": MongoOpDelete,
+			"filter": map[string]interface{}{"temp": map[string]interface{}{"$lt": 50}},
+			"many":   true,
+		})
+		onMsg(deleteNode, "{}")
+		data, _ = result.GetDataAsJson()
+		assert.Equal(t, float64(3), data["deletedCount"])
+
+		findOneNode.OnMsg(ctx, types.NewMsg(0, "TEST_MSG", types.JSON, metadata, "{}"))
+		assert.Equal(t, types.Failure, relationType)
+	})
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package external
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestRedisCacheNode(t *testing.T) {
+	var targetNodeType = "redisCache"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &RedisCacheNode{}, types.Configuration{
+			"server": "127.0.0.1:6379",
+		}, Registry)
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		s := miniredis.RunT(t)
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"server": s.Addr(),
+		}, Registry)
+		assert.Nil(t, err)
+		redisNode := node.(*RedisCacheNode)
+
+		c, err := redisNode.Get()
+		assert.Nil(t, err)
+		c2, _ := redisNode.Get()
+		assert.True(t, c == c2)
+		assert.Nil(t, c.Set("key", "value", ""))
+		value, _ := s.Get("key")
+		assert.Equal(t, `"value"`, value)
+
+		var relationType string
+		ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, r string, err error) {
+			relationType = r
+		})
+		msg := types.NewMsg(0, "TEST_MSG", types.JSON, types.NewMetadata(), "{}")
+		node.OnMsg(ctx, msg)
+		assert.Equal(t, types.Success, relationType)
+
+		s.Close()
+		node.OnMsg(ctx, msg)
+		assert.Equal(t, types.Failure, relationType)
+		node.Destroy()
+	})
+
+	t.Run("ConnectError", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"server": "127.0.0.1:1",
+		}, Registry)
+		assert.Nil(t, err)
+		var relationType string
+		ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, r string, err error) {
+			relationType = r
+		})
+		node.OnMsg(ctx, types.NewMsg(0, "TEST_MSG", types.JSON, types.NewMetadata(), "{}"))
+		assert.Equal(t, types.Failure, relationType)
+		node.Destroy()
+	})
+}
@@ -0,0 +1,150 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+//规则链节点配置示例：
+//{
+//        "id": "s1",
+//        "type": "dedup",
+//        "name": "去重",
+//        "configuration": {
+//          "key": "dedup:${metadata.deviceId}:${msg.seq}",
+//          "ttl": "5m",
+//          "level": "global"
+//        }
+//      }
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/el"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+const (
+	// DedupRelationDuplicate 重复消息的关系类型
+	DedupRelationDuplicate = "Duplicate"
+	// DedupLevelChain 在当前规则链命名空间的缓存中记录已处理的key
+	DedupLevelChain = "chain"
+	// DedupLevelGlobal 在全局缓存中记录已处理的key，可以跨规则链去重
+	DedupLevelGlobal = "global"
+)
+
+func init() {
+	Registry.Add(&DedupFilterNode{})
+}
+
+// DedupFilterNodeConfiguration 节点配置
+type DedupFilterNodeConfiguration struct {
+	//去重key，可以使用 ${metadata.key} 读取元数据中的变量或者使用 ${msg.key} 读取消息负荷中的变量进行替换
+	//例如：dedup:${metadata.deviceId}:${msg.seq}，默认使用消息原始数据 ${data}
+	Key string
+	//去重时间窗口，在该时间内相同key的消息视为重复消息，例如：10s、5m，默认1m
+	Ttl string
+	//缓存级别，chain：规则链缓存，global：全局缓存，默认chain
+	Level string
+}
+
+// DedupFilterNode 消息去重过滤器
+// 根据key模板计算消息的去重key，在ttl时间内第一次出现的消息发送到`True`链，重复的消息发送到`Duplicate`链。
+// 已处理的key保存在 ChainCache 或者 GlobalCache 中，规则链重新加载或者多个节点实例之间共享去重状态。
+type DedupFilterNode struct {
+	//节点配置
+	Config      DedupFilterNodeConfiguration
+	keyTemplate *el.MixedTemplate
+}
+
+// Type 组件类型
+func (x *DedupFilterNode) Type() string {
+	return "dedup"
+}
+
+func (x *DedupFilterNode) New() types.Node {
+	return &DedupFilterNode{Config: DedupFilterNodeConfiguration{
+		Key:   "dedup:${data}",
+		Ttl:   "1m",
+		Level: DedupLevelChain,
+	}}
+}
+
+// Init 初始化
+func (x *DedupFilterNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(x.Config.Key) == "" {
+		return fmt.Errorf("key can not be empty")
+	}
+	if x.Config.Ttl == "" {
+		x.Config.Ttl = "1m"
+	}
+	if d, err := time.ParseDuration(x.Config.Ttl); err != nil {
+		return err
+	} else if d <= 0 {
+		return fmt.Errorf("ttl must be greater than 0")
+	}
+	if x.Config.Level == "" {
+		x.Config.Level = DedupLevelChain
+	}
+	if x.Config.Level != DedupLevelChain && x.Config.Level != DedupLevelGlobal {
+		return fmt.Errorf("unsupported level:%s", x.Config.Level)
+	}
+	x.keyTemplate, err = el.NewMixedTemplate(x.Config.Key)
+	return err
+}
+
+// OnMsg 处理消息
+func (x *DedupFilterNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	var c types.Cache
+	if x.Config.Level == DedupLevelGlobal {
+		c = ctx.GlobalCache()
+	} else {
+		c = ctx.ChainCache()
+	}
+	if c == nil {
+		ctx.TellFailure(msg, types.ErrCacheNotInitialized)
+		return
+	}
+	key := x.keyTemplate.ExecuteAsString(base.NodeUtils.GetEvnAndMetadata(ctx, msg))
+
+	//使用SetIfAbsent保证判断和写入的原子性，共享缓存的多个节点实例之间同样有效
+	ok, err := c.SetIfAbsent(key, msg.Ts, x.Config.Ttl)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+	} else if ok {
+		ctx.TellNext(msg, types.True)
+	} else {
+		ctx.TellNext(msg, DedupRelationDuplicate)
+	}
+}
+
+// Destroy 销毁
+func (x *DedupFilterNode) Destroy() {
+}
+
+// Def 组件定义，重复的消息发送到`Duplicate`链
+func (x *DedupFilterNode) Def() types.ComponentForm {
+	relationTypes := []string{types.True, DedupRelationDuplicate, types.Failure}
+	return types.ComponentForm{
+		Type:          x.Type(),
+		RelationTypes: &relationTypes,
+	}
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package filter
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/cache"
+	"github.com/rulego/rulego/utils/reflect"
+)
+
+// slowHasCache 判断key是否存在时增加延迟，放大判断和写入之间的竞争
+type slowHasCache struct {
+	types.Cache
+}
+
+func (c slowHasCache) Has(key string) bool {
+	ok := c.Cache.Has(key)
+	time.Sleep(time.Millisecond)
+	return ok
+}
+
+func TestDedupFilterNode(t *testing.T) {
+	var targetNodeType = "dedup"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &DedupFilterNode{}, types.Configuration{
+			"key":   "dedup:${data}",
+			"ttl":   "1m",
+			"level": DedupLevelChain,
+		}, Registry)
+		form := reflect.GetComponentForm(&DedupFilterNode{})
+		assert.Equal(t, []string{types.True, DedupRelationDuplicate, types.Failure}, *form.RelationTypes)
+	})
+
+	t.Run("InitNode", func(t *testing.T) {
+		test.NodeInit(t, targetNodeType, types.Configuration{
+			"key":   "${metadata.deviceId}",
+			"ttl":   "10s",
+			"level": DedupLevelGlobal,
+		}, types.Configuration{
+			"key":   "${metadata.deviceId}",
+			"ttl":   "10s",
+			"level": DedupLevelGlobal,
+		}, Registry)
+	})
+
+	t.Run("InitError", func(t *testing.T) {
+		for _, config := range []types.Configuration{
+			{"key": ""},
+			{"ttl": "abc"},
+			{"ttl": "-1s"},
+			{"level": "node"},
+		} {
+			_, err := test.CreateAndInitNode(targetNodeType, config, Registry)
+			assert.NotNil(t, err)
+		}
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"key": "dedup:${metadata.deviceId}:${msg.seq}",
+			"ttl": "200ms",
+		}, Registry)
+		assert.Nil(t, err)
+
+		var relationTypes []string
+		ctx := test.NewRuleContext(types.NewConfig(), func(msg types.RuleMsg, relationType string, err error) {
+			relationTypes = append(relationTypes, relationType)
+		})
+		newMsg := func(deviceId, data string) types.RuleMsg {
+			metadata := types.NewMetadata()
+			metadata.PutValue("deviceId", deviceId)
+			return types.NewMsg(0, "TELEMETRY", types.JSON, metadata, data)
+		}
+		node.OnMsg(ctx, newMsg("dev1", `{"seq":1,"temperature":20}`))
+		node.OnMsg(ctx, newMsg("dev1", `{"seq":1,"temperature":20}`))
+		node.OnMsg(ctx, newMsg("dev1", `{"seq":2,"temperature":21}`))
+		node.OnMsg(ctx, newMsg("dev2", `{"seq":1,"temperature":20}`))
+		assert.Equal(t, []string{types.True, DedupRelationDuplicate, types.True, types.True}, relationTypes)
+		assert.True(t, ctx.ChainCache().Has("dedup:dev1:1"))
+
+		//超过ttl后不再视为重复消息
+		time.Sleep(time.Millisecond * 300)
+		node.OnMsg(ctx, newMsg("dev1", `{"seq":1,"temperature":20}`))
+		assert.Equal(t, types.True, relationTypes[4])
+	})
+
+	t.Run("SharedAcrossInstances", func(t *testing.T) {
+		config := types.NewConfig()
+		config.Cache = cache.NewMemoryCache(time.Minute)
+		configuration := types.Configuration{
+			"key":   "${id}",
+			"level": DedupLevelGlobal,
+		}
+		node1, err := test.CreateAndInitNode(targetNodeType, configuration, Registry)
+		assert.Nil(t, err)
+		node2, err := test.CreateAndInitNode(targetNodeType, configuration, Registry)
+		assert.Nil(t, err)
+
+		var relationTypes []string
+		callback := func(msg types.RuleMsg, relationType string, err error) {
+			relationTypes = append(relationTypes, relationType)
+		}
+		ctx1 := test.NewRuleContextFull(config, node1, nil, callback)
+		ctx2 := test.NewRuleContextFull(config, node2, nil, callback)
+		msg := types.NewMsg(0, "TELEMETRY", types.JSON, types.NewMetadata(), `{"temperature":20}`)
+		node1.OnMsg(ctx1, msg)
+		node2.OnMsg(ctx2, msg)
+		assert.Equal(t, []string{types.True, DedupRelationDuplicate}, relationTypes)
+		assert.True(t, config.Cache.Has(msg.Id))
+
+		//多个节点实例并发处理相同的消息，只有一条通过
+		var passed, duplicated int64
+		concurrentCallback := func(msg types.RuleMsg, relationType string, err error) {
+			if relationType == types.True {
+				atomic.AddInt64(&passed, 1)
+			} else if relationType == DedupRelationDuplicate {
+				atomic.AddInt64(&duplicated, 1)
+			}
+		}
+		config.Cache = slowHasCache{Cache: config.Cache}
+		ctx1 = test.NewRuleContextFull(config, node1, nil, concurrentCallback)
+		ctx2 = test.NewRuleContextFull(config, node2, nil, concurrentCallback)
+		msg = types.NewMsg(0, "TELEMETRY", types.JSON, types.NewMetadata(), `{"temperature":21}`)
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(2)
+			go func(msg types.RuleMsg) {
+				defer wg.Done()
+				<-start
+				node1.OnMsg(ctx1, msg)
+			}(msg.Copy())
+			go func(msg types.RuleMsg) {
+				defer wg.Done()
+				<-start
+				node2.OnMsg(ctx2, msg)
+			}(msg.Copy())
+		}
+		close(start)
+		wg.Wait()
+		assert.Equal(t, int64(1), passed)
+		assert.Equal(t, int64(99), duplicated)
+	})
+}
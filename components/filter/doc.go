@@ -18,8 +18,11 @@
 //
 // These components are designed to filter or route messages based on certain conditions:
 //
+// - DedupFilter: Suppresses duplicate messages seen within a TTL
 // - JsFilter: Filters messages using JavaScript conditions
 // - JsSwitch: Routes messages to different paths based on JavaScript logic
+// - LuaFilter: Filters messages using Lua conditions
+// - LuaSwitch: Routes messages to different paths based on Lua logic
 // - MsgTypeSwitch: Routes messages to different paths based on their type
 //
 // Each component is registered with the Registry, allowing them to be used
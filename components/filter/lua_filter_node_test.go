@@ -0,0 +1,89 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package filter
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestLuaFilterNode(t *testing.T) {
+	var targetNodeType = "luaFilter"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &LuaFilterNode{}, types.Configuration{
+			"script": "return msg.temperature > 50",
+		}, Registry)
+	})
+
+	t.Run("InitNode", func(t *testing.T) {
+		test.NodeInit(t, targetNodeType, types.Configuration{
+			"script": "return msgType == 'TEST'",
+		}, types.Configuration{
+			"script": "return msgType == 'TEST'",
+		}, Registry)
+		_, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"script": "return msg.temperature >",
+		}, Registry)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		node1, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"script": "return msg.temperature > 50 and metadata.productType == vars.productType",
+			"vars": map[string]string{
+				"productType": "test",
+			},
+		}, Registry)
+		assert.Nil(t, err)
+		node2, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"script": "return msg.a.b",
+		}, Registry)
+		assert.Nil(t, err)
+
+		metaData := types.NewMetadata()
+		metaData.PutValue("productType", "test")
+		var wg sync.WaitGroup
+		wg.Add(3)
+		test.NodeOnMsg(t, node1, []test.Msg{{
+			MetaData: metaData,
+			Data:     "{\"temperature\":60}",
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.True, relationType)
+		})
+		test.NodeOnMsg(t, node1, []test.Msg{{
+			MetaData: metaData,
+			Data:     "{\"temperature\":40}",
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.False, relationType)
+		})
+		test.NodeOnMsg(t, node2, []test.Msg{{
+			MetaData: metaData,
+			Data:     "{\"temperature\":40}",
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.Failure, relationType)
+			assert.NotNil(t, err)
+		})
+		wg.Wait()
+	})
+}
@@ -0,0 +1,138 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package filter
+
+//规则链节点配置示例：
+//{
+//        "id": "s2",
+//        "type": "luaSwitch",
+//        "name": "脚本路由",
+//        "debugMode": false,
+//        "configuration": {
+//          "script": "return {'one','two'}"
+//        }
+//      }
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/json"
+	"github.com/rulego/rulego/utils/lua"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+const (
+	// LuaSwitchFuncName lua函数名
+	LuaSwitchFuncName = "Switch"
+	// LuaSwitchFuncTemplate lua函数模板
+	LuaSwitchFuncTemplate = "function Switch(msg, metadata, msgType)\n%s\nend"
+)
+
+// LuaSwitchReturnFormatErr 如果脚本返回不是数组或者字符串错误
+var LuaSwitchReturnFormatErr = errors.New("return the value is not an array or string")
+
+func init() {
+	Registry.Add(&LuaSwitchNode{})
+}
+
+// LuaSwitchNodeConfiguration 节点配置
+type LuaSwitchNodeConfiguration struct {
+	//Script 配置函数体脚本内容
+	//完整脚本函数：
+	//function Switch(msg, metadata, msgType) ${Script} end
+	//return {'relationType1','relationType2'} 或者 'relationType1'
+	Script string
+}
+
+// LuaSwitchNode 节点执行已配置的lua脚本。脚本应返回消息应路由到的下一个链名称的数组(table)，也可以返回单个字符串。
+// 如果数组为空-消息不路由到下一个节点。
+// 消息体可以通过`msg`变量访问，如果消息的dataType是json类型，可以通过 `msg.XX`方式访问msg的字段。
+// 消息元数据可以通过`metadata`变量访问。例如 `metadata.customerName == 'Lala'`
+// 消息类型可以通过`msgType`变量访问.
+type LuaSwitchNode struct {
+	//节点配置
+	Config              LuaSwitchNodeConfiguration
+	luaEngine           *lua.LuaEngine
+	defaultRelationType string
+}
+
+// Type 组件类型
+func (x *LuaSwitchNode) Type() string {
+	return "luaSwitch"
+}
+
+func (x *LuaSwitchNode) New() types.Node {
+	return &LuaSwitchNode{Config: LuaSwitchNodeConfiguration{
+		Script: "return {'msgType1','msgType2'}",
+	}}
+}
+
+// Init 初始化
+func (x *LuaSwitchNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err == nil {
+		luaScript := fmt.Sprintf(LuaSwitchFuncTemplate, x.Config.Script)
+		x.luaEngine, err = lua.NewLuaEngine(ruleConfig, luaScript, base.NodeUtils.GetVars(configuration))
+		if v := ruleConfig.Properties.GetValue(KeyOtherRelationTypeName); v != "" {
+			x.defaultRelationType = v
+		} else {
+			x.defaultRelationType = KeyDefaultRelationType
+		}
+	}
+	return err
+}
+
+// OnMsg 处理消息
+func (x *LuaSwitchNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	var data interface{} = msg.GetData()
+	if msg.DataType == types.JSON {
+		var dataMap interface{}
+		if err := json.Unmarshal([]byte(msg.GetData()), &dataMap); err == nil {
+			data = dataMap
+		}
+	}
+
+	out, err := x.luaEngine.Execute(ctx, LuaSwitchFuncName, data, msg.Metadata.Values(), msg.Type)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	switch formatData := out.(type) {
+	case []interface{}:
+		for _, relationType := range formatData {
+			ctx.TellNextOrElse(msg, x.defaultRelationType, str.ToString(relationType))
+		}
+	case string:
+		ctx.TellNextOrElse(msg, x.defaultRelationType, formatData)
+	case map[string]interface{}:
+		//空table不路由到下一个节点
+		if len(formatData) != 0 {
+			ctx.TellFailure(msg, LuaSwitchReturnFormatErr)
+		}
+	default:
+		ctx.TellFailure(msg, LuaSwitchReturnFormatErr)
+	}
+}
+
+// Destroy 销毁
+func (x *LuaSwitchNode) Destroy() {
+	if x.luaEngine != nil {
+		x.luaEngine.Stop()
+	}
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package filter
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestLuaSwitchNode(t *testing.T) {
+	var targetNodeType = "luaSwitch"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &LuaSwitchNode{}, types.Configuration{
+			"script": "return {'msgType1','msgType2'}",
+		}, Registry)
+	})
+
+	t.Run("InitNodeDefault", func(t *testing.T) {
+		config := types.NewConfig()
+		node := LuaSwitchNode{}
+		err := node.Init(config, types.Configuration{})
+		assert.Nil(t, err)
+		assert.Equal(t, node.defaultRelationType, KeyDefaultRelationType)
+
+		config.Properties.PutValue(KeyOtherRelationTypeName, "Default")
+		err = node.Init(config, types.Configuration{})
+		assert.Nil(t, err)
+		assert.Equal(t, node.defaultRelationType, "Default")
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		node1, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"script": "return {'one','two'}",
+		}, Registry)
+		assert.Nil(t, err)
+		node2, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"script": "if msg.temperature > 50 then return 'high' end\nreturn {}",
+		}, Registry)
+		assert.Nil(t, err)
+		node3, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"script": "return 1",
+		}, Registry)
+		assert.Nil(t, err)
+
+		metaData := types.NewMetadata()
+		var wg sync.WaitGroup
+		wg.Add(4)
+		test.NodeOnMsg(t, node1, []test.Msg{{
+			MetaData: metaData,
+			Data:     "AA",
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.True(t, relationType == "one" || relationType == "two")
+		})
+		test.NodeOnMsg(t, node2, []test.Msg{{
+			MetaData: metaData,
+			Data:     "{\"temperature\":60}",
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, "high", relationType)
+		})
+		test.NodeOnMsg(t, node3, []test.Msg{{
+			MetaData: metaData,
+			Data:     "AA",
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.Failure, relationType)
+			assert.Equal(t, LuaSwitchReturnFormatErr.Error(), err.Error())
+		})
+		wg.Wait()
+	})
+}
@@ -0,0 +1,386 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rpcplugin
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+)
+
+// Options 插件进程选项
+type Options struct {
+	// Transport 传输方式 stdio/unix，默认stdio
+	Transport string
+	// Args 启动插件的命令行参数
+	Args []string
+	// StartTimeout 等待插件握手的最大时间，默认10秒
+	StartTimeout time.Duration
+	// CallTimeout 单次RPC调用的最大时间，默认60秒
+	CallTimeout time.Duration
+	// RestartDelay 插件崩溃后第一次重启的等待时间，之后每次翻倍，默认1秒
+	RestartDelay time.Duration
+	// MaxRestartDelay 重启等待时间上限，默认30秒
+	MaxRestartDelay time.Duration
+	// MaxRestarts 最大连续重启次数，0表示不限制
+	MaxRestarts int
+	// MinUptime 插件运行超过该时间后退出，才清零重启次数和重启等待时间，默认10秒
+	// 避免握手成功后在初始化或者处理消息时立即崩溃的插件按最小等待时间无限重启
+	MinUptime time.Duration
+	// Logger 插件stderr输出和重启日志，默认 types.DefaultLogger()
+	Logger types.Logger
+}
+
+func (o Options) withDefaults() Options {
+	if o.Transport == "" {
+		o.Transport = TransportStdio
+	}
+	if o.StartTimeout <= 0 {
+		o.StartTimeout = time.Second * 10
+	}
+	if o.CallTimeout <= 0 {
+		o.CallTimeout = time.Second * 60
+	}
+	if o.RestartDelay <= 0 {
+		o.RestartDelay = time.Second
+	}
+	if o.MaxRestartDelay <= 0 {
+		o.MaxRestartDelay = time.Second * 30
+	}
+	if o.MinUptime <= 0 {
+		o.MinUptime = time.Second * 10
+	}
+	if o.Logger == nil {
+		o.Logger = types.DefaultLogger()
+	}
+	return o
+}
+
+// Client 管理插件进程：启动、握手、RPC调用、崩溃后自动重启并重新初始化节点实例
+type Client struct {
+	file       string
+	opts       Options
+	mu         sync.RWMutex
+	cmd        *exec.Cmd
+	rpcClient  *rpc.Client
+	components []types.ComponentForm
+	//已初始化的节点实例，插件重启后重新初始化
+	instances map[string]InitArgs
+	stopped   bool
+	restarts  int
+	//下一次重启的等待时间
+	restartDelay time.Duration
+	//插件进程最后一次启动时间
+	startedAt time.Time
+	//插件进程启动次数
+	generation int
+}
+
+// NewClient 创建插件客户端
+func NewClient(file string, opts Options) *Client {
+	return &Client{
+		file:      file,
+		opts:      opts.withDefaults(),
+		instances: make(map[string]InitArgs),
+	}
+}
+
+// Start 启动插件进程并获取组件列表
+func (c *Client) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rpcClient != nil {
+		return nil
+	}
+	c.stopped = false
+	return c.start()
+}
+
+// Stop 停止插件进程，不再重启
+func (c *Client) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopped = true
+	c.kill()
+}
+
+// Components 插件提供的组件定义
+func (c *Client) Components() []types.ComponentForm {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.components
+}
+
+// Available 插件进程是否可用
+func (c *Client) Available() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.rpcClient != nil
+}
+
+// Generation 插件进程启动次数，每次重启加1
+func (c *Client) Generation() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.generation
+}
+
+// InitInstance 在插件进程中创建节点实例，插件重启后会自动重新创建
+func (c *Client) InitInstance(args InitArgs) error {
+	if err := c.Call(ServiceName+".Init", args, &Empty{}); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.instances[args.InstanceId] = args
+	c.mu.Unlock()
+	return nil
+}
+
+// DestroyInstance 销毁插件进程中的节点实例
+func (c *Client) DestroyInstance(instanceId string) {
+	c.mu.Lock()
+	delete(c.instances, instanceId)
+	c.mu.Unlock()
+	_ = c.Call(ServiceName+".Destroy", InstanceArgs{InstanceId: instanceId}, &Empty{})
+}
+
+// Call 调用插件RPC方法，插件不可用时返回 ErrPluginUnavailable
+func (c *Client) Call(serviceMethod string, args interface{}, reply interface{}) error {
+	c.mu.RLock()
+	rpcClient := c.rpcClient
+	c.mu.RUnlock()
+	if rpcClient == nil {
+		return ErrPluginUnavailable
+	}
+	call := rpcClient.Go(serviceMethod, args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-call.Done:
+		if errors.Is(call.Error, rpc.ErrShutdown) || errors.Is(call.Error, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("%w: %s", ErrPluginUnavailable, call.Error.Error())
+		}
+		return call.Error
+	case <-time.After(c.opts.CallTimeout):
+		return fmt.Errorf("call %s timeout after %s", serviceMethod, c.opts.CallTimeout)
+	}
+}
+
+// start 启动进程、握手并建立RPC连接，调用方需要持有写锁
+func (c *Client) start() error {
+	cmd := exec.Command(c.file, c.opts.Args...)
+	cmd.Env = append(os.Environ(),
+		EnvMagicCookie+"="+MagicCookieValue,
+		EnvProtocolVersion+"="+strconv.Itoa(ProtocolVersion),
+		EnvTransport+"="+c.opts.Transport,
+	)
+	var socket string
+	if c.opts.Transport == TransportUnix {
+		socket = filepath.Join(os.TempDir(), fmt.Sprintf("rulego-plugin-%d-%d.sock", os.Getpid(), time.Now().UnixNano()))
+		cmd.Env = append(cmd.Env, EnvSocket+"="+socket)
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err = cmd.Start(); err != nil {
+		return err
+	}
+	go c.forwardLog(stderr)
+
+	reader := bufio.NewReader(stdout)
+	conn, err := c.handshake(reader, stdin)
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return err
+	}
+	rpcClient := jsonrpc.NewClient(conn)
+	var reply ComponentsReply
+	if err = rpcClient.Call(ServiceName+".Components", Empty{}, &reply); err != nil {
+		_ = rpcClient.Close()
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return err
+	}
+	c.cmd = cmd
+	c.rpcClient = rpcClient
+	c.components = reply.Components
+	c.generation++
+	c.startedAt = time.Now()
+	go c.wait(cmd, rpcClient)
+	return nil
+}
+
+// handshake 读取插件输出的握手行，校验协议版本并建立连接
+func (c *Client) handshake(reader *bufio.Reader, stdin io.WriteCloser) (io.ReadWriteCloser, error) {
+	lineCh := make(chan string, 1)
+	errCh := make(chan error, 1)
+	go func() {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			errCh <- fmt.Errorf("plugin exited before handshake: %w", err)
+			return
+		}
+		lineCh <- strings.TrimSpace(line)
+	}()
+	var line string
+	select {
+	case line = <-lineCh:
+	case err := <-errCh:
+		return nil, err
+	case <-time.After(c.opts.StartTimeout):
+		return nil, fmt.Errorf("plugin handshake timeout after %s", c.opts.StartTimeout)
+	}
+	parts := strings.Split(line, "|")
+	if len(parts) < 3 || parts[0] != HandshakePrefix {
+		return nil, fmt.Errorf("invalid plugin handshake: %s", line)
+	}
+	if parts[1] != strconv.Itoa(ProtocolVersion) {
+		return nil, fmt.Errorf("%w: engine=%d plugin=%s", ErrIncompatibleVersion, ProtocolVersion, parts[1])
+	}
+	switch parts[2] {
+	case TransportStdio:
+		return &pipeConn{Reader: reader, WriteCloser: stdin}, nil
+	case TransportUnix:
+		if len(parts) < 4 {
+			return nil, fmt.Errorf("invalid plugin handshake: %s", line)
+		}
+		return net.DialTimeout("unix", parts[3], c.opts.StartTimeout)
+	default:
+		return nil, fmt.Errorf("unsupported plugin transport: %s", parts[2])
+	}
+}
+
+// wait 等待进程退出，如果不是主动停止则自动重启
+func (c *Client) wait(cmd *exec.Cmd, rpcClient *rpc.Client) {
+	err := cmd.Wait()
+	c.mu.Lock()
+	if c.cmd != cmd {
+		c.mu.Unlock()
+		return
+	}
+	_ = rpcClient.Close()
+	c.rpcClient = nil
+	c.cmd = nil
+	stopped := c.stopped
+	if time.Since(c.startedAt) >= c.opts.MinUptime {
+		//稳定运行后退出，重新开始计算重启次数和等待时间
+		c.restarts = 0
+		c.restartDelay = 0
+	}
+	c.mu.Unlock()
+	if stopped {
+		return
+	}
+	c.opts.Logger.Printf("plugin %s exited unexpectedly: %v, restarting", c.file, err)
+	go c.restart()
+}
+
+// restart 按退避时间重启插件，并重新初始化节点实例
+func (c *Client) restart() {
+	for {
+		c.mu.Lock()
+		delay := c.nextRestartDelay()
+		c.mu.Unlock()
+		time.Sleep(delay)
+		c.mu.Lock()
+		if c.stopped || c.rpcClient != nil {
+			c.mu.Unlock()
+			return
+		}
+		if c.opts.MaxRestarts > 0 && c.restarts >= c.opts.MaxRestarts {
+			c.mu.Unlock()
+			c.opts.Logger.Printf("plugin %s reached max restarts %d, giving up", c.file, c.opts.MaxRestarts)
+			return
+		}
+		c.restarts++
+		err := c.start()
+		var instances []InitArgs
+		if err == nil {
+			for _, args := range c.instances {
+				instances = append(instances, args)
+			}
+		}
+		c.mu.Unlock()
+		if err == nil {
+			for _, args := range instances {
+				if err := c.Call(ServiceName+".Init", args, &Empty{}); err != nil {
+					c.opts.Logger.Printf("plugin %s reinit instance %s error: %v", c.file, args.InstanceId, err)
+				}
+			}
+			return
+		}
+		c.opts.Logger.Printf("plugin %s restart error: %v", c.file, err)
+	}
+}
+
+// nextRestartDelay 返回本次重启的等待时间，并翻倍作为下一次的等待时间，调用方需要持有写锁
+func (c *Client) nextRestartDelay() time.Duration {
+	if c.restartDelay <= 0 {
+		c.restartDelay = c.opts.RestartDelay
+	}
+	delay := c.restartDelay
+	c.restartDelay *= 2
+	if c.restartDelay > c.opts.MaxRestartDelay {
+		c.restartDelay = c.opts.MaxRestartDelay
+	}
+	return delay
+}
+
+// kill 结束进程，调用方需要持有写锁
+func (c *Client) kill() {
+	if c.rpcClient != nil {
+		_ = c.rpcClient.Close()
+		c.rpcClient = nil
+	}
+	if c.cmd != nil && c.cmd.Process != nil {
+		_ = c.cmd.Process.Kill()
+	}
+	c.cmd = nil
+}
+
+func (c *Client) forwardLog(stderr io.Reader) {
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		c.opts.Logger.Printf("plugin %s: %s", filepath.Base(c.file), scanner.Text())
+	}
+}
+
+// pipeConn 把插件进程的stdout/stdin组合成连接
+type pipeConn struct {
+	io.Reader
+	io.WriteCloser
+}
@@ -0,0 +1,250 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rpcplugin
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/cache"
+)
+
+// ErrNotSupported 插件进程内不支持的操作
+var ErrNotSupported = errors.New("not supported in plugin process")
+
+// pluginContext 插件进程内的规则上下文，记录组件的 TellNext/TellFailure 调用，返回给引擎执行
+// 插件进程内没有规则链，TellFlow/TellNode/TellChainNode 等调用会以 ErrNotSupported 结束
+type pluginContext struct {
+	config     types.Config
+	instanceId string
+	context    context.Context
+	endFunc    types.OnEndFunc
+	callbacks  map[string]interface{}
+	out        types.RuleMsg
+	err        error
+	actions    []Action
+	done       chan struct{}
+	doneOnce   sync.Once
+	sync.Mutex
+}
+
+func newPluginContext(config types.Config, instanceId string) *pluginContext {
+	return &pluginContext{
+		config:     config,
+		instanceId: instanceId,
+		context:    context.Background(),
+		callbacks:  make(map[string]interface{}),
+		done:       make(chan struct{}),
+	}
+}
+
+func (ctx *pluginContext) addAction(msg types.RuleMsg, err error, relationTypes ...string) {
+	ctx.Lock()
+	action := Action{Msg: toMsg(msg), RelationTypes: relationTypes}
+	if err != nil {
+		action.Err = err.Error()
+	}
+	ctx.actions = append(ctx.actions, action)
+	ctx.out = msg
+	ctx.err = err
+	ctx.Unlock()
+	ctx.doneOnce.Do(func() {
+		close(ctx.done)
+	})
+}
+
+func (ctx *pluginContext) getActions() []Action {
+	ctx.Lock()
+	defer ctx.Unlock()
+	return append([]Action(nil), ctx.actions...)
+}
+
+func (ctx *pluginContext) TellSuccess(msg types.RuleMsg) {
+	ctx.addAction(msg, nil, types.Success)
+}
+
+func (ctx *pluginContext) TellFailure(msg types.RuleMsg, err error) {
+	if err == nil {
+		err = errors.New("unknown error")
+	}
+	ctx.addAction(msg, err)
+}
+
+func (ctx *pluginContext) TellNext(msg types.RuleMsg, relationTypes ...string) {
+	ctx.addAction(msg, nil, relationTypes...)
+}
+
+func (ctx *pluginContext) TellSelf(msg types.RuleMsg, delayMs int64) {
+	ctx.TellFailure(msg, ErrNotSupported)
+}
+
+func (ctx *pluginContext) TellNextOrElse(msg types.RuleMsg, defaultRelationType string, relationTypes ...string) {
+	ctx.addAction(msg, nil, relationTypes...)
+}
+
+func (ctx *pluginContext) TellFlow(_ context.Context, _ string, msg types.RuleMsg, endFunc types.OnEndFunc, onAllNodeCompleted func()) {
+	ctx.notSupported(msg, endFunc, onAllNodeCompleted)
+}
+
+func (ctx *pluginContext) TellNode(_ context.Context, _ string, msg types.RuleMsg, _ bool, onEnd types.OnEndFunc, onAllNodeCompleted func()) {
+	ctx.notSupported(msg, onEnd, onAllNodeCompleted)
+}
+
+func (ctx *pluginContext) TellChainNode(_ context.Context, _, _ string, msg types.RuleMsg, _ bool, onEnd types.OnEndFunc, onAllNodeCompleted func()) {
+	ctx.notSupported(msg, onEnd, onAllNodeCompleted)
+}
+
+func (ctx *pluginContext) notSupported(msg types.RuleMsg, onEnd types.OnEndFunc, onAllNodeCompleted func()) {
+	if onEnd != nil {
+		onEnd(ctx, msg, ErrNotSupported, types.Failure)
+	}
+	if onAllNodeCompleted != nil {
+		onAllNodeCompleted()
+	}
+}
+
+func (ctx *pluginContext) NewMsg(msgType string, metaData *types.Metadata, data string) types.RuleMsg {
+	return types.NewMsg(0, msgType, types.JSON, metaData, data)
+}
+
+func (ctx *pluginContext) GetSelfId() string {
+	return ctx.instanceId
+}
+
+func (ctx *pluginContext) Self() types.NodeCtx {
+	return nil
+}
+
+func (ctx *pluginContext) From() types.NodeCtx {
+	return nil
+}
+
+func (ctx *pluginContext) RuleChain() types.NodeCtx {
+	return nil
+}
+
+func (ctx *pluginContext) Config() types.Config {
+	return ctx.config
+}
+
+func (ctx *pluginContext) SubmitTack(task func()) {
+	go task()
+}
+
+func (ctx *pluginContext) SubmitTask(task func()) {
+	go task()
+}
+
+func (ctx *pluginContext) SetEndFunc(f types.OnEndFunc) types.RuleContext {
+	ctx.endFunc = f
+	return ctx
+}
+
+func (ctx *pluginContext) GetEndFunc() types.OnEndFunc {
+	return ctx.endFunc
+}
+
+func (ctx *pluginContext) SetContext(c context.Context) types.RuleContext {
+	ctx.context = c
+	return ctx
+}
+
+func (ctx *pluginContext) GetContext() context.Context {
+	return ctx.context
+}
+
+func (ctx *pluginContext) SetOnAllNodeCompleted(func()) {
+}
+
+func (ctx *pluginContext) DoOnEnd(msg types.RuleMsg, err error, relationType string) {
+	if err != nil {
+		ctx.TellFailure(msg, err)
+	} else {
+		ctx.TellNext(msg, relationType)
+	}
+}
+
+func (ctx *pluginContext) SetCallbackFunc(functionName string, f interface{}) {
+	ctx.Lock()
+	defer ctx.Unlock()
+	ctx.callbacks[functionName] = f
+}
+
+func (ctx *pluginContext) GetCallbackFunc(functionName string) interface{} {
+	ctx.Lock()
+	defer ctx.Unlock()
+	return ctx.callbacks[functionName]
+}
+
+func (ctx *pluginContext) OnDebug(string, string, string, types.RuleMsg, string, error) {
+}
+
+func (ctx *pluginContext) SetExecuteNode(string, ...string) {
+}
+
+func (ctx *pluginContext) TellCollect(types.RuleMsg, func(msgList []types.WrapperMsg)) bool {
+	return false
+}
+
+func (ctx *pluginContext) GetOut() types.RuleMsg {
+	ctx.Lock()
+	defer ctx.Unlock()
+	return ctx.out
+}
+
+func (ctx *pluginContext) GetErr() error {
+	ctx.Lock()
+	defer ctx.Unlock()
+	return ctx.err
+}
+
+// GlobalCache 插件进程内的缓存，不和引擎共享
+func (ctx *pluginContext) GlobalCache() types.Cache {
+	return cache.DefaultCache
+}
+
+// ChainCache 插件进程内的缓存，不和引擎共享
+func (ctx *pluginContext) ChainCache() types.Cache {
+	return cache.DefaultCache
+}
+
+func (ctx *pluginContext) GetEnv(msg types.RuleMsg, useMetadata bool) map[string]interface{} {
+	evn := map[string]interface{}{
+		types.IdKey:       msg.Id,
+		types.TsKey:       msg.Ts,
+		types.DataKey:     msg.GetData(),
+		types.MsgTypeKey:  msg.Type,
+		types.DataTypeKey: msg.DataType,
+		types.MsgKey:      msg.GetData(),
+	}
+	if msg.DataType == types.JSON {
+		if jsonData, err := msg.GetDataAsJson(); err == nil {
+			evn[types.MsgKey] = jsonData
+		}
+	}
+	if msg.Metadata != nil {
+		metadataValues := msg.Metadata.Values()
+		if useMetadata {
+			for k, v := range metadataValues {
+				evn[k] = v
+			}
+		}
+		evn[types.MetadataKey] = metadataValues
+	}
+	return evn
+}
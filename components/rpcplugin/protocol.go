@@ -0,0 +1,158 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+// Package rpcplugin 提供进程外组件插件，是 Go plugin(.so) 的替代方案。
+//
+// 插件是一个独立的可执行文件，引擎启动它并通过本地RPC(jsonrpc，基于stdio或者Unix socket)通信，
+// 插件进程崩溃不会影响引擎进程，引擎会自动重启插件并重新初始化已创建的节点实例。
+//
+// 插件端，使用普通的组件实现，在 main 函数调用 Serve：
+//
+//	func main() {
+//		if err := rpcplugin.Serve(&UpperNode{}, &TimeNode{}); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+//
+// 引擎端，通过 RegisterPlugin 注册，文件路径使用 rpc:// 前缀：
+//
+//	rulego.Registry.RegisterPlugin("myPlugins", "rpc://./plugins/upper")
+//
+// 或者加载目录下所有可执行文件：
+//
+//	rpcplugin.LoadDir(rulego.Registry, "./plugins")
+//
+// 握手：引擎通过环境变量传递 MagicCookie、协议版本和传输方式，插件启动后向stdout输出一行：
+//
+//	RULEGO_PLUGIN|<协议版本>|stdio
+//	RULEGO_PLUGIN|<协议版本>|unix|<socket路径>
+//
+// 协议版本不一致则拒绝加载。使用stdio传输时，插件不能向stdout输出日志，需要使用stderr，stderr会转发到引擎日志。
+package rpcplugin
+
+import (
+	"errors"
+
+	"github.com/rulego/rulego/api/types"
+)
+
+const (
+	// ProtocolVersion 插件协议版本，引擎和插件不一致则拒绝加载
+	ProtocolVersion = 1
+	// Scheme RegisterPlugin 文件路径前缀，表示进程外插件
+	Scheme = "rpc://"
+	// HandshakePrefix 握手行前缀
+	HandshakePrefix = "RULEGO_PLUGIN"
+
+	// EnvMagicCookie 环境变量：用于插件判断是否由引擎启动
+	EnvMagicCookie = "RULEGO_PLUGIN_MAGIC_COOKIE"
+	// MagicCookieValue 环境变量 EnvMagicCookie 的值
+	MagicCookieValue = "7c1a9e52b3d44f0e8a6d2b9f5e3c1a70"
+	// EnvProtocolVersion 环境变量：引擎支持的协议版本
+	EnvProtocolVersion = "RULEGO_PLUGIN_PROTOCOL_VERSION"
+	// EnvTransport 环境变量：传输方式 stdio/unix
+	EnvTransport = "RULEGO_PLUGIN_TRANSPORT"
+	// EnvSocket 环境变量：unix 传输方式的socket路径
+	EnvSocket = "RULEGO_PLUGIN_SOCKET"
+
+	// TransportStdio 通过插件进程的stdin/stdout通信
+	TransportStdio = "stdio"
+	// TransportUnix 通过Unix socket通信
+	TransportUnix = "unix"
+
+	// ServiceName RPC服务名
+	ServiceName = "Plugin"
+)
+
+var (
+	// ErrNotPlugin 可执行文件不是由引擎启动
+	ErrNotPlugin = errors.New("this binary is a rulego plugin and must be launched by the rule engine")
+	// ErrPluginUnavailable 插件进程未运行，例如崩溃后正在重启
+	ErrPluginUnavailable = errors.New("plugin process is not available")
+	// ErrIncompatibleVersion 协议版本不一致
+	ErrIncompatibleVersion = errors.New("incompatible plugin protocol version")
+)
+
+// Empty 空参数
+type Empty struct{}
+
+// Msg 传输的消息
+type Msg struct {
+	Id       string            `json:"id"`
+	Ts       int64             `json:"ts"`
+	Type     string            `json:"type"`
+	DataType string            `json:"dataType"`
+	Data     string            `json:"data"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// InitArgs 创建节点实例参数
+type InitArgs struct {
+	InstanceId    string              `json:"instanceId"`
+	Type          string              `json:"type"`
+	Configuration types.Configuration `json:"configuration"`
+	Properties    map[string]string   `json:"properties"`
+}
+
+// InstanceArgs 节点实例参数
+type InstanceArgs struct {
+	InstanceId string `json:"instanceId"`
+}
+
+// OnMsgArgs 处理消息参数
+type OnMsgArgs struct {
+	InstanceId string `json:"instanceId"`
+	Msg        Msg    `json:"msg"`
+	// Timeout 等待组件调用 TellNext/TellFailure 的最大时间，单位毫秒
+	Timeout int64 `json:"timeout"`
+}
+
+// Action 组件调用 TellNext/TellSuccess/TellFailure 的记录
+type Action struct {
+	Msg           Msg      `json:"msg"`
+	RelationTypes []string `json:"relationTypes"`
+	Err           string   `json:"err"`
+}
+
+// OnMsgReply 处理消息结果
+type OnMsgReply struct {
+	Actions []Action `json:"actions"`
+}
+
+// ComponentsReply 插件提供的组件列表
+type ComponentsReply struct {
+	Components []types.ComponentForm `json:"components"`
+}
+
+func toMsg(msg types.RuleMsg) Msg {
+	var metadata map[string]string
+	if msg.Metadata != nil {
+		metadata = msg.Metadata.Values()
+	}
+	return Msg{
+		Id:       msg.Id,
+		Ts:       msg.Ts,
+		Type:     msg.Type,
+		DataType: string(msg.DataType),
+		Data:     msg.GetData(),
+		Metadata: metadata,
+	}
+}
+
+func fromMsg(msg Msg) types.RuleMsg {
+	ruleMsg := types.NewMsg(msg.Ts, msg.Type, types.DataType(msg.DataType), types.BuildMetadata(msg.Metadata), msg.Data)
+	ruleMsg.Id = msg.Id
+	return ruleMsg
+}
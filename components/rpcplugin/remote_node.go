@@ -0,0 +1,178 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rpcplugin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+)
+
+var instanceSeq int64
+
+// RemoteNode 代理插件进程中的组件，实现 types.Node
+// 节点配置原样传给插件进程中的组件，以 $ 开头的引擎内部配置除外
+type RemoteNode struct {
+	client *Client
+	def    types.ComponentForm
+	//插件进程中的节点实例ID
+	instanceId string
+	timeout    time.Duration
+}
+
+// NewRemoteNode 创建插件组件代理
+func NewRemoteNode(client *Client, def types.ComponentForm) *RemoteNode {
+	return &RemoteNode{client: client, def: def}
+}
+
+// Type 组件类型
+func (x *RemoteNode) Type() string {
+	return x.def.Type
+}
+
+func (x *RemoteNode) New() types.Node {
+	return &RemoteNode{client: x.client, def: x.def}
+}
+
+// Init 在插件进程中创建节点实例
+func (x *RemoteNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	var config = make(types.Configuration)
+	for k, v := range configuration {
+		if !strings.HasPrefix(k, "$") {
+			config[k] = v
+		}
+	}
+	x.instanceId = strconv.Itoa(os.Getpid()) + "-" + strconv.FormatInt(atomic.AddInt64(&instanceSeq, 1), 10)
+	x.timeout = x.client.opts.CallTimeout
+	return x.client.InitInstance(InitArgs{
+		InstanceId:    x.instanceId,
+		Type:          x.def.Type,
+		Configuration: config,
+		Properties:    ruleConfig.Properties.Values(),
+	})
+}
+
+// OnMsg 把消息发送到插件进程处理，并按插件组件的调用路由到下一个节点
+// 插件进程崩溃或者正在重启时发送到 Failure 链
+func (x *RemoteNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	var reply OnMsgReply
+	err := x.client.Call(ServiceName+".OnMsg", OnMsgArgs{
+		InstanceId: x.instanceId,
+		Msg:        toMsg(msg),
+		Timeout:    x.timeout.Milliseconds(),
+	}, &reply)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	for _, action := range reply.Actions {
+		outMsg := fromMsg(action.Msg)
+		if action.Err != "" {
+			ctx.TellFailure(outMsg, errors.New(action.Err))
+		} else {
+			ctx.TellNext(outMsg, action.RelationTypes...)
+		}
+	}
+}
+
+// Destroy 销毁插件进程中的节点实例
+func (x *RemoteNode) Destroy() {
+	if x.instanceId != "" {
+		x.client.DestroyInstance(x.instanceId)
+	}
+}
+
+// Def 组件定义，由插件进程中的组件生成
+func (x *RemoteNode) Def() types.ComponentForm {
+	return x.def
+}
+
+// Close 停止插件进程，组件从注册器卸载时调用
+func (x *RemoteNode) Close() error {
+	x.client.Stop()
+	return nil
+}
+
+// PluginRegistry 进程外插件，实现 types.PluginRegistry
+type PluginRegistry struct {
+	client *Client
+}
+
+// NewPluginRegistry 创建进程外插件，file 为插件可执行文件路径
+func NewPluginRegistry(file string, opts Options) *PluginRegistry {
+	return &PluginRegistry{client: NewClient(file, opts)}
+}
+
+// Init 启动插件进程
+func (p *PluginRegistry) Init() error {
+	return p.client.Start()
+}
+
+// Components 插件进程提供的组件
+func (p *PluginRegistry) Components() []types.Node {
+	var nodes []types.Node
+	for _, def := range p.client.Components() {
+		nodes = append(nodes, NewRemoteNode(p.client, def))
+	}
+	return nodes
+}
+
+// Stop 停止插件进程
+func (p *PluginRegistry) Stop() {
+	p.client.Stop()
+}
+
+// LoadDir 加载目录下所有可执行文件作为进程外插件，插件名为文件名
+// 某个插件加载失败不影响其他插件，返回所有错误
+func LoadDir(registry types.ComponentRegistry, dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var errs []string
+	for _, entry := range entries {
+		file := filepath.Join(dir, entry.Name())
+		if entry.IsDir() || !isExecutable(file) {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if err := registry.RegisterPlugin(name, Scheme+file); err != nil {
+			errs = append(errs, name+": "+err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func isExecutable(file string) bool {
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(filepath.Ext(file), ".exe")
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		return false
+	}
+	return info.Mode().IsRegular() && info.Mode().Perm()&0111 != 0
+}
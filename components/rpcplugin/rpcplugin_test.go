@@ -0,0 +1,308 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rpcplugin_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/rpcplugin"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/maps"
+)
+
+// TestMain 由引擎启动时，测试二进制文件作为插件运行
+func TestMain(m *testing.M) {
+	if os.Getenv(rpcplugin.EnvMagicCookie) == rpcplugin.MagicCookieValue {
+		if err := rpcplugin.Serve(&UpperNode{}, &CrashNode{}, &AsyncNode{}, &CrashLaterNode{}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+type UpperNodeConfiguration struct {
+	Suffix string
+}
+
+// UpperNode 转大写并追加后缀
+type UpperNode struct {
+	Config UpperNodeConfiguration
+}
+
+func (x *UpperNode) Type() string {
+	return "test/upper"
+}
+
+func (x *UpperNode) New() types.Node {
+	return &UpperNode{Config: UpperNodeConfiguration{Suffix: "!"}}
+}
+
+func (x *UpperNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	return maps.Map2Struct(configuration, &x.Config)
+}
+
+func (x *UpperNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	msg.SetData(strings.ToUpper(msg.GetData()) + x.Config.Suffix)
+	msg.Metadata.PutValue("pid", strconv.Itoa(os.Getpid()))
+	msg.Metadata.PutValue("env", ctx.Config().Properties.GetValue("env"))
+	ctx.TellSuccess(msg)
+}
+
+func (x *UpperNode) Destroy() {
+}
+
+// CrashNode 处理消息时插件进程崩溃
+type CrashNode struct {
+}
+
+func (x *CrashNode) Type() string {
+	return "test/crash"
+}
+
+func (x *CrashNode) New() types.Node {
+	return &CrashNode{}
+}
+
+func (x *CrashNode) Init(types.Config, types.Configuration) error {
+	return nil
+}
+
+func (x *CrashNode) OnMsg(types.RuleContext, types.RuleMsg) {
+	os.Exit(2)
+}
+
+func (x *CrashNode) Destroy() {
+}
+
+// CrashLaterNode 初始化成功后插件进程很快崩溃
+type CrashLaterNode struct {
+}
+
+func (x *CrashLaterNode) Type() string {
+	return "test/crashLater"
+}
+
+func (x *CrashLaterNode) New() types.Node {
+	return &CrashLaterNode{}
+}
+
+func (x *CrashLaterNode) Init(types.Config, types.Configuration) error {
+	time.AfterFunc(time.Millisecond*30, func() {
+		os.Exit(3)
+	})
+	return nil
+}
+
+func (x *CrashLaterNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	ctx.TellSuccess(msg)
+}
+
+func (x *CrashLaterNode) Destroy() {
+}
+
+// AsyncNode 异步路由到多个关系
+type AsyncNode struct {
+}
+
+func (x *AsyncNode) Type() string {
+	return "test/async"
+}
+
+func (x *AsyncNode) New() types.Node {
+	return &AsyncNode{}
+}
+
+func (x *AsyncNode) Init(types.Config, types.Configuration) error {
+	return nil
+}
+
+func (x *AsyncNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		ctx.TellNext(msg, "a", "b")
+	}()
+}
+
+func (x *AsyncNode) Destroy() {
+}
+
+func TestRpcPlugin(t *testing.T) {
+	for _, transport := range []string{rpcplugin.TransportStdio, rpcplugin.TransportUnix} {
+		t.Run(transport, func(t *testing.T) {
+			testPlugin(t, transport)
+		})
+	}
+}
+
+func testPlugin(t *testing.T, transport string) {
+	registry := rpcplugin.NewPluginRegistry(os.Args[0], rpcplugin.Options{
+		Transport:    transport,
+		RestartDelay: time.Millisecond * 50,
+	})
+	assert.Nil(t, registry.Init())
+	defer registry.Stop()
+
+	nodes := map[string]types.Node{}
+	for _, node := range registry.Components() {
+		nodes[node.Type()] = node
+	}
+	assert.Equal(t, 4, len(nodes))
+	def := nodes["test/upper"].(types.ComponentDefGetter).Def()
+	assert.Equal(t, "UpperNode", def.Label)
+	assert.Equal(t, "suffix", def.Fields[0].Name)
+	assert.Equal(t, "!", def.Fields[0].DefaultValue)
+
+	config := types.NewConfig()
+	config.Properties.PutValue("env", "prod")
+	upperNode := nodes["test/upper"].New()
+	assert.Nil(t, upperNode.Init(config, types.Configuration{"suffix": "?"}))
+	crashNode := nodes["test/crash"].New()
+	assert.Nil(t, crashNode.Init(config, types.Configuration{}))
+	asyncNode := nodes["test/async"].New()
+	assert.Nil(t, asyncNode.Init(config, types.Configuration{}))
+
+	msgs := []test.Msg{{
+		MetaData: types.NewMetadata(),
+		DataType: types.TEXT,
+		Data:     "abc",
+	}}
+	var pid string
+	var wg sync.WaitGroup
+	wg.Add(3)
+	test.NodeOnMsg(t, upperNode, msgs, func(msg types.RuleMsg, relationType string, err error) {
+		defer wg.Done()
+		assert.Equal(t, types.Success, relationType)
+		assert.Equal(t, "ABC?", msg.GetData())
+		assert.Equal(t, types.TEXT, msg.DataType)
+		assert.Equal(t, "prod", msg.Metadata.GetValue("env"))
+		pid = msg.Metadata.GetValue("pid")
+	})
+	var relations []string
+	var lock sync.Mutex
+	test.NodeOnMsg(t, asyncNode, msgs, func(msg types.RuleMsg, relationType string, err error) {
+		defer wg.Done()
+		lock.Lock()
+		relations = append(relations, relationType)
+		lock.Unlock()
+	})
+	wg.Wait()
+	assert.Equal(t, []string{"a", "b"}, relations)
+	assert.True(t, pid != "" && pid != strconv.Itoa(os.Getpid()))
+
+	//插件进程崩溃，引擎进程不受影响
+	wg.Add(1)
+	test.NodeOnMsg(t, crashNode, msgs, func(msg types.RuleMsg, relationType string, err error) {
+		defer wg.Done()
+		assert.Equal(t, types.Failure, relationType)
+		assert.NotNil(t, err)
+	})
+	wg.Wait()
+
+	//自动重启并重新初始化节点实例
+	deadline := time.Now().Add(time.Second * 5)
+	var newPid string
+	for time.Now().Before(deadline) && newPid == "" {
+		time.Sleep(time.Millisecond * 50)
+		wg.Add(1)
+		test.NodeOnMsg(t, upperNode, msgs, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			if relationType == types.Success {
+				assert.Equal(t, "ABC?", msg.GetData())
+				newPid = msg.Metadata.GetValue("pid")
+			}
+		})
+		wg.Wait()
+	}
+	assert.True(t, newPid != "" && newPid != pid)
+
+	upperNode.Destroy()
+	wg.Add(1)
+	test.NodeOnMsg(t, upperNode, msgs, func(msg types.RuleMsg, relationType string, err error) {
+		defer wg.Done()
+		assert.Equal(t, types.Failure, relationType)
+	})
+	wg.Wait()
+}
+
+func TestRpcPluginRestartLimit(t *testing.T) {
+	client := rpcplugin.NewClient(os.Args[0], rpcplugin.Options{
+		RestartDelay: time.Millisecond * 20,
+		MaxRestarts:  3,
+	})
+	assert.Nil(t, client.Start())
+	defer client.Stop()
+	start := time.Now()
+	//重新初始化后插件再次崩溃，重启次数不会清零，达到上限后不再重启
+	assert.Nil(t, client.InitInstance(rpcplugin.InitArgs{InstanceId: "1", Type: "test/crashLater"}))
+	deadline := time.Now().Add(time.Second * 5)
+	for time.Now().Before(deadline) && (client.Available() || client.Generation() < 4) {
+		time.Sleep(time.Millisecond * 10)
+	}
+	assert.Equal(t, 4, client.Generation())
+	assert.False(t, client.Available())
+	//重启等待时间翻倍：20ms、40ms、80ms
+	assert.True(t, time.Since(start) >= time.Millisecond*140)
+	time.Sleep(time.Millisecond * 300)
+	assert.Equal(t, 4, client.Generation())
+}
+
+func TestRpcPluginHandshake(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "bad")
+	assert.Nil(t, os.WriteFile(script, []byte("#!/bin/sh\necho 'RULEGO_PLUGIN|99|stdio'\nsleep 5\n"), 0755))
+	err := rpcplugin.NewPluginRegistry(script, rpcplugin.Options{}).Init()
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), rpcplugin.ErrIncompatibleVersion.Error()))
+
+	assert.Nil(t, os.WriteFile(script, []byte("#!/bin/sh\nexit 1\n"), 0755))
+	assert.NotNil(t, rpcplugin.NewPluginRegistry(script, rpcplugin.Options{}).Init())
+
+	assert.Equal(t, rpcplugin.ErrNotPlugin, rpcplugin.Serve())
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.Symlink(os.Args[0], filepath.Join(dir, "myPlugin")))
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("not a plugin"), 0644))
+
+	registry := new(engine.RuleComponentRegistry)
+	assert.Nil(t, rpcplugin.LoadDir(registry, dir))
+	forms := registry.GetComponentForms()
+	_, ok := forms["test/upper"]
+	assert.True(t, ok)
+	node, err := registry.NewNode("test/upper")
+	assert.Nil(t, err)
+	assert.Nil(t, node.Init(types.NewConfig(), types.Configuration{}))
+	node.Destroy()
+
+	//重复加载，组件已经存在
+	assert.NotNil(t, rpcplugin.LoadDir(registry, dir))
+	assert.Nil(t, registry.Unregister("myPlugin"))
+	_, err = registry.NewNode("test/upper")
+	assert.NotNil(t, err)
+}
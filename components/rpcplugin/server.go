@@ -0,0 +1,168 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rpcplugin
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/reflect"
+)
+
+// DefaultOnMsgTimeout 默认等待组件响应的最大时间
+const DefaultOnMsgTimeout = time.Second * 30
+
+// Serve 在插件进程中提供组件服务，阻塞直到连接关闭
+// 如果不是由引擎启动，返回 ErrNotPlugin
+func Serve(nodes ...types.Node) error {
+	if os.Getenv(EnvMagicCookie) != MagicCookieValue {
+		return ErrNotPlugin
+	}
+	if v := os.Getenv(EnvProtocolVersion); v != "" && v != strconv.Itoa(ProtocolVersion) {
+		return fmt.Errorf("%w: engine=%s plugin=%d", ErrIncompatibleVersion, v, ProtocolVersion)
+	}
+	server := rpc.NewServer()
+	if err := server.RegisterName(ServiceName, NewService(nodes...)); err != nil {
+		return err
+	}
+	switch os.Getenv(EnvTransport) {
+	case TransportUnix:
+		socket := os.Getenv(EnvSocket)
+		_ = os.Remove(socket)
+		listener, err := net.Listen("unix", socket)
+		if err != nil {
+			return err
+		}
+		defer listener.Close()
+		fmt.Fprintf(os.Stdout, "%s|%d|%s|%s\n", HandshakePrefix, ProtocolVersion, TransportUnix, socket)
+		//引擎只建立一个连接，连接关闭后插件退出
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		server.ServeCodec(jsonrpc.NewServerCodec(conn))
+	default:
+		fmt.Fprintf(os.Stdout, "%s|%d|%s\n", HandshakePrefix, ProtocolVersion, TransportStdio)
+		server.ServeCodec(jsonrpc.NewServerCodec(&stdioConn{Reader: os.Stdin, Writer: os.Stdout}))
+	}
+	return nil
+}
+
+// stdioConn 把stdin/stdout组合成连接
+type stdioConn struct {
+	io.Reader
+	io.Writer
+}
+
+func (c *stdioConn) Close() error {
+	return nil
+}
+
+// Service 插件RPC服务，管理插件进程内的组件和节点实例
+type Service struct {
+	components map[string]types.Node
+	instances  sync.Map
+}
+
+// NewService 创建插件RPC服务
+func NewService(nodes ...types.Node) *Service {
+	s := &Service{components: make(map[string]types.Node)}
+	for _, node := range nodes {
+		s.components[node.Type()] = node
+	}
+	return s
+}
+
+// Components 返回插件提供的组件定义
+func (s *Service) Components(_ Empty, reply *ComponentsReply) error {
+	for _, node := range s.components {
+		reply.Components = append(reply.Components, reflect.GetComponentForm(node.New()))
+	}
+	return nil
+}
+
+// Init 创建并初始化节点实例
+func (s *Service) Init(args InitArgs, _ *Empty) error {
+	component, ok := s.components[args.Type]
+	if !ok {
+		return fmt.Errorf("component not found. componentType=%s", args.Type)
+	}
+	config := types.NewConfig()
+	for k, v := range args.Properties {
+		config.Properties.PutValue(k, v)
+	}
+	node := component.New()
+	if err := node.Init(config, args.Configuration); err != nil {
+		return err
+	}
+	//引擎重启插件后会使用相同的实例ID重新初始化
+	if old, ok := s.instances.Load(args.InstanceId); ok {
+		old.(*instance).node.Destroy()
+	}
+	s.instances.Store(args.InstanceId, &instance{node: node, config: config})
+	return nil
+}
+
+// OnMsg 使用节点实例处理消息，等待组件调用 TellNext/TellFailure 后返回
+func (s *Service) OnMsg(args OnMsgArgs, reply *OnMsgReply) error {
+	v, ok := s.instances.Load(args.InstanceId)
+	if !ok {
+		return fmt.Errorf("node instance not found. instanceId=%s", args.InstanceId)
+	}
+	inst := v.(*instance)
+	ctx := newPluginContext(inst.config, args.InstanceId)
+	timeout := DefaultOnMsgTimeout
+	if args.Timeout > 0 {
+		timeout = time.Duration(args.Timeout) * time.Millisecond
+	}
+	func() {
+		defer func() {
+			if caught := recover(); caught != nil {
+				ctx.TellFailure(fromMsg(args.Msg), fmt.Errorf("%v", caught))
+			}
+		}()
+		inst.node.OnMsg(ctx, fromMsg(args.Msg))
+	}()
+	select {
+	case <-ctx.done:
+	case <-time.After(timeout):
+		return fmt.Errorf("component did not respond within %s", timeout)
+	}
+	reply.Actions = ctx.getActions()
+	return nil
+}
+
+// Destroy 销毁节点实例
+func (s *Service) Destroy(args InstanceArgs, _ *Empty) error {
+	if v, ok := s.instances.LoadAndDelete(args.InstanceId); ok {
+		v.(*instance).node.Destroy()
+	}
+	return nil
+}
+
+// instance 节点实例
+type instance struct {
+	node   types.Node
+	config types.Config
+}
@@ -0,0 +1,444 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transform
+
+//规则链节点配置示例：
+//{
+//	"id": "s1",
+//	"type": "csvToJson",
+//	"name": "CSV转JSON",
+//	"configuration": {
+//		"delimiter": ",",
+//		"hasHeader": true,
+//		"inferTypes": true
+//	}
+//}
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/json"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+const (
+	// KeyRowIndex 逐行处理时，当前行的序号，与for节点的_loopIndex一致
+	KeyRowIndex = "_loopIndex"
+	// KeyRowCount 逐行处理完成后，处理的总行数
+	KeyRowCount = "_rowCount"
+)
+
+func init() {
+	Registry.Add(&CsvToJsonNode{})
+	Registry.Add(&JsonToCsvNode{})
+}
+
+// CsvToJsonNodeConfiguration 节点配置
+type CsvToJsonNodeConfiguration struct {
+	// Delimiter 字段分隔符，默认","，制表符使用"\t"
+	Delimiter string
+	// HasHeader 第一行是否是表头，true：使用第一行作为字段名，false：使用Headers或者col1、col2...作为字段名
+	HasHeader bool
+	// Headers 自定义字段名，如果有值则覆盖表头
+	Headers []string
+	// InferTypes 是否自动推断字段类型，true：数字、布尔值转换成对应的JSON类型，false：全部作为字符串
+	InferTypes bool
+	// TrimSpace 是否去除字段前后空格
+	TrimSpace bool
+	// Do 逐行处理的节点或者子规则链，语法与for节点一致，例如：s3 或者 chain:rule01
+	// 如果为空，则把所有行转换成JSON数组替换到msg，转到Success链
+	// 如果有值，则每读取一行就转成一条JSON对象消息交给该节点处理，并等待处理完成再读取下一行，适合处理大文件。
+	// 通过 metadata._loopIndex 获取当前行序号，所有行处理完成后原始消息通过Success链流转，metadata._rowCount 为处理的行数
+	Do string
+}
+
+// CsvToJsonNode 把CSV格式的消息负荷转换成JSON
+// 每一行转换成一个JSON对象，字段名来自表头、Headers配置或者col1、col2...
+type CsvToJsonNode struct {
+	//节点配置
+	Config CsvToJsonNodeConfiguration
+	//分隔符
+	comma rune
+	//逐行处理的节点或者子规则链
+	ruleNodeId types.RuleNodeId
+}
+
+// Type 组件类型
+func (x *CsvToJsonNode) Type() string {
+	return "csvToJson"
+}
+
+func (x *CsvToJsonNode) New() types.Node {
+	return &CsvToJsonNode{Config: CsvToJsonNodeConfiguration{
+		Delimiter:  ",",
+		HasHeader:  true,
+		InferTypes: true,
+	}}
+}
+
+// Init 初始化
+func (x *CsvToJsonNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if x.comma, err = parseDelimiter(x.Config.Delimiter); err != nil {
+		return err
+	}
+	x.Config.Do = strings.TrimSpace(x.Config.Do)
+	if x.Config.Do != "" {
+		x.ruleNodeId, err = parseDoTarget(x.Config.Do)
+	}
+	return err
+}
+
+// OnMsg 处理消息
+func (x *CsvToJsonNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	reader := csv.NewReader(strings.NewReader(msg.GetData()))
+	reader.Comma = x.comma
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = x.Config.TrimSpace
+	reader.ReuseRecord = true
+
+	headers := x.Config.Headers
+	if x.Config.HasHeader {
+		record, err := reader.Read()
+		if err == io.EOF {
+			record = nil
+		} else if err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		if len(headers) == 0 {
+			for _, item := range record {
+				headers = append(headers, x.trim(item))
+			}
+		}
+	}
+
+	var rows []interface{}
+	var index = 0
+	var ctxWithCancel context.Context
+	var cancelFunc context.CancelFunc
+	if x.Config.Do != "" {
+		ctxWithCancel, cancelFunc = context.WithCancel(ctx.GetContext())
+		defer cancelFunc()
+	}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		row := x.toRow(headers, record)
+		if x.Config.Do == "" {
+			rows = append(rows, row)
+		} else {
+			rowMsg := msg.Copy()
+			rowMsg.DataType = types.JSON
+			data, err := json.Marshal(row)
+			if err != nil {
+				ctx.TellFailure(msg, err)
+				return
+			}
+			rowMsg.SetData(string(data))
+			rowMsg.Metadata.PutValue(KeyRowIndex, strconv.Itoa(index))
+			if err := executeDoTarget(ctxWithCancel, ctx, x.ruleNodeId, rowMsg); err != nil {
+				ctx.TellFailure(msg, err)
+				return
+			}
+		}
+		index++
+	}
+
+	if x.Config.Do == "" {
+		if rows == nil {
+			rows = []interface{}{}
+		}
+		data, err := json.Marshal(rows)
+		if err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+		msg.DataType = types.JSON
+		msg.SetData(string(data))
+	} else {
+		msg.Metadata.PutValue(KeyRowCount, strconv.Itoa(index))
+	}
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁
+func (x *CsvToJsonNode) Destroy() {
+}
+
+func (x *CsvToJsonNode) trim(v string) string {
+	if x.Config.TrimSpace {
+		return strings.TrimSpace(v)
+	}
+	return v
+}
+
+// toRow 把一行记录转换成map，多出表头的字段使用colN作为字段名
+func (x *CsvToJsonNode) toRow(headers []string, record []string) map[string]interface{} {
+	row := make(map[string]interface{}, len(record))
+	for i, item := range record {
+		var key string
+		if i < len(headers) && headers[i] != "" {
+			key = headers[i]
+		} else {
+			key = "col" + strconv.Itoa(i+1)
+		}
+		item = x.trim(item)
+		if x.Config.InferTypes {
+			row[key] = inferType(item)
+		} else {
+			row[key] = item
+		}
+	}
+	return row
+}
+
+// JsonToCsvNodeConfiguration 节点配置
+type JsonToCsvNodeConfiguration struct {
+	// Delimiter 字段分隔符，默认","，制表符使用"\t"
+	Delimiter string
+	// Headers 输出的列以及顺序，如果为空，则使用所有对象字段名按字母排序
+	Headers []string
+	// WriteHeader 是否输出表头
+	WriteHeader bool
+}
+
+// JsonToCsvNode 把JSON数组转换成CSV格式的文本
+// 数组元素可以是对象或者数组，对象按Headers取列值，数组按顺序输出
+// 如果消息负荷是单个JSON对象，则当作只有一行处理
+type JsonToCsvNode struct {
+	//节点配置
+	Config JsonToCsvNodeConfiguration
+	//分隔符
+	comma rune
+}
+
+// Type 组件类型
+func (x *JsonToCsvNode) Type() string {
+	return "jsonToCsv"
+}
+
+func (x *JsonToCsvNode) New() types.Node {
+	return &JsonToCsvNode{Config: JsonToCsvNodeConfiguration{
+		Delimiter:   ",",
+		WriteHeader: true,
+	}}
+}
+
+// Init 初始化
+func (x *JsonToCsvNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	x.comma, err = parseDelimiter(x.Config.Delimiter)
+	return err
+}
+
+// OnMsg 处理消息
+func (x *JsonToCsvNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(msg.GetData()), &data); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	var list []interface{}
+	switch v := data.(type) {
+	case []interface{}:
+		list = v
+	case map[string]interface{}:
+		list = []interface{}{v}
+	default:
+		ctx.TellFailure(msg, errors.New("data must be a JSON array or object"))
+		return
+	}
+
+	headers := x.Config.Headers
+	if len(headers) == 0 {
+		headers = collectHeaders(list)
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Comma = x.comma
+	if x.Config.WriteHeader && len(headers) > 0 {
+		if err := writer.Write(headers); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	for _, item := range list {
+		var record []string
+		switch row := item.(type) {
+		case map[string]interface{}:
+			record = make([]string, len(headers))
+			for i, h := range headers {
+				if v, ok := row[h]; ok && v != nil {
+					record[i] = str.ToString(v)
+				}
+			}
+		case []interface{}:
+			for _, v := range row {
+				if v == nil {
+					record = append(record, "")
+				} else {
+					record = append(record, str.ToString(v))
+				}
+			}
+		default:
+			record = []string{str.ToString(row)}
+		}
+		if err := writer.Write(record); err != nil {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.DataType = types.TEXT
+	msg.SetData(buf.String())
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁
+func (x *JsonToCsvNode) Destroy() {
+}
+
+// collectHeaders 收集所有对象的字段名，按字母排序
+func collectHeaders(list []interface{}) []string {
+	var keys = make(map[string]struct{})
+	for _, item := range list {
+		if row, ok := item.(map[string]interface{}); ok {
+			for k := range row {
+				keys[k] = struct{}{}
+			}
+		}
+	}
+	var headers []string
+	for k := range keys {
+		headers = append(headers, k)
+	}
+	sort.Strings(headers)
+	return headers
+}
+
+// parseDelimiter 解析分隔符，默认逗号
+func parseDelimiter(delimiter string) (rune, error) {
+	switch delimiter {
+	case "":
+		return ',', nil
+	case "\\t", "\t":
+		return '\t', nil
+	}
+	runes := []rune(delimiter)
+	if len(runes) != 1 || runes[0] == '"' || runes[0] == '\r' || runes[0] == '\n' {
+		return 0, fmt.Errorf("invalid delimiter: %s", delimiter)
+	}
+	return runes[0], nil
+}
+
+// inferType 推断字符串值的类型，支持布尔、整数和浮点数，否则原样返回
+// NaN、Inf等非有限数值无法转换成JSON，保留为字符串
+func inferType(v string) interface{} {
+	if v == "" {
+		return v
+	}
+	switch v {
+	case "true", "TRUE", "True":
+		return true
+	case "false", "FALSE", "False":
+		return false
+	}
+	//保留前导0的编号，例如：00123
+	if len(v) > 1 && v[0] == '0' && v[1] != '.' {
+		return v
+	}
+	if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(v, 64); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+		return f
+	}
+	return v
+}
+
+// parseDoTarget 解析处理节点，语法与for节点一致：nodeId 或者 chain:chainId
+func parseDoTarget(do string) (types.RuleNodeId, error) {
+	values := strings.Split(do, ":")
+	switch len(values) {
+	case 1:
+		return types.RuleNodeId{Id: strings.TrimSpace(values[0]), Type: types.NODE}, nil
+	case 2:
+		if strings.TrimSpace(values[0]) == "chain" {
+			return types.RuleNodeId{Id: strings.TrimSpace(values[1]), Type: types.CHAIN}, nil
+		}
+		return types.RuleNodeId{Id: strings.TrimSpace(values[1]), Type: types.NODE}, nil
+	default:
+		return types.RuleNodeId{}, fmt.Errorf("do variable should be nodeId or chain:chainId style")
+	}
+}
+
+// executeDoTarget 同步执行处理节点或者子规则链，直到该分支执行完成
+func executeDoTarget(ctxWithCancel context.Context, ctx types.RuleContext, ruleNodeId types.RuleNodeId, msg types.RuleMsg) error {
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var returnErr error
+	var lock sync.Mutex
+	onEnd := func(ctx types.RuleContext, msg types.RuleMsg, err error, relationType string) {
+		if err != nil {
+			lock.Lock()
+			returnErr = err
+			lock.Unlock()
+		}
+	}
+	if ruleNodeId.Type == types.CHAIN {
+		ctx.TellFlow(ctx.GetContext(), ruleNodeId.Id, msg, onEnd, func() {
+			wg.Done()
+		})
+	} else {
+		ctx.TellNode(ctx.GetContext(), ruleNodeId.Id, msg, false, onEnd, func() {
+			wg.Done()
+		})
+	}
+	wg.Wait()
+	if returnErr != nil {
+		return returnErr
+	}
+	return ctxWithCancel.Err()
+}
@@ -0,0 +1,237 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transform
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/json"
+)
+
+func TestCsvToJsonNode(t *testing.T) {
+	var targetNodeType = "csvToJson"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &CsvToJsonNode{}, types.Configuration{
+			"delimiter":  ",",
+			"hasHeader":  true,
+			"inferTypes": true,
+		}, Registry)
+	})
+
+	t.Run("InitNode", func(t *testing.T) {
+		test.NodeInit(t, targetNodeType, types.Configuration{
+			"delimiter": ";",
+			"hasHeader": false,
+		}, types.Configuration{
+			"delimiter": ";",
+			"hasHeader": false,
+		}, Registry)
+		_, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"delimiter": ";;",
+		}, Registry)
+		assert.NotNil(t, err)
+		_, err = test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"do": "a:b:c",
+		}, Registry)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		node1, err := test.CreateAndInitNode(targetNodeType, types.Configuration{}, Registry)
+		assert.Nil(t, err)
+		node2, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"delimiter":  "\\t",
+			"hasHeader":  false,
+			"inferTypes": false,
+			"headers":    []string{"name"},
+		}, Registry)
+		assert.Nil(t, err)
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		test.NodeOnMsg(t, node1, []test.Msg{{
+			MetaData: types.NewMetadata(),
+			DataType: types.TEXT,
+			Data:     "name,temperature,online,code\nlala,25.5,true,007\nbb,30,false,1",
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, types.JSON, msg.DataType)
+			var rows []map[string]interface{}
+			assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &rows))
+			assert.Equal(t, 2, len(rows))
+			assert.Equal(t, "lala", rows[0]["name"])
+			assert.Equal(t, 25.5, rows[0]["temperature"])
+			assert.Equal(t, true, rows[0]["online"])
+			assert.Equal(t, "007", rows[0]["code"])
+			assert.Equal(t, float64(30), rows[1]["temperature"])
+		})
+		test.NodeOnMsg(t, node2, []test.Msg{{
+			MetaData: types.NewMetadata(),
+			DataType: types.TEXT,
+			Data:     "lala\t25\nbb\t30",
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, `[{"col2":"25","name":"lala"},{"col2":"30","name":"bb"}]`, msg.GetData())
+		})
+		wg.Wait()
+	})
+
+	t.Run("OnMsgDo", func(t *testing.T) {
+		var count int32
+		childrenNode, err := test.CreateAndInitNode("exprTransform", types.Configuration{
+			"expr": "msg.name",
+		}, Registry)
+		assert.Nil(t, err)
+		childrenNodes := map[string]types.Node{
+			"node1": childrenNode,
+		}
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"do": "node1",
+		}, Registry)
+		assert.Nil(t, err)
+		notFoundNode, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"do": "notfound",
+		}, Registry)
+		assert.Nil(t, err)
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		data := "name,temperature\nlala,25\nbb,30\ncc,41"
+		test.NodeOnMsgWithChildren(t, node, []test.Msg{{
+			MetaData: types.NewMetadata(),
+			DataType: types.TEXT,
+			Data:     data,
+		}}, childrenNodes, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			atomic.AddInt32(&count, 1)
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, "3", msg.Metadata.GetValue(KeyRowCount))
+			assert.Equal(t, data, msg.GetData())
+		})
+		test.NodeOnMsgWithChildren(t, notFoundNode, []test.Msg{{
+			MetaData: types.NewMetadata(),
+			DataType: types.TEXT,
+			Data:     data,
+		}}, childrenNodes, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.Failure, relationType)
+			assert.NotNil(t, err)
+		})
+		wg.Wait()
+		time.Sleep(time.Millisecond * 20)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+	})
+
+	t.Run("OnMsgNotFinite", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{}, Registry)
+		assert.Nil(t, err)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		test.NodeOnMsg(t, node, []test.Msg{{
+			MetaData: types.NewMetadata(),
+			DataType: types.TEXT,
+			Data:     "a,b,c,d\nNaN,+Inf,-Infinity,1e400",
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, `[{"a":"NaN","b":"+Inf","c":"-Infinity","d":"1e400"}]`, msg.GetData())
+		})
+		wg.Wait()
+	})
+
+	t.Run("OnMsgError", func(t *testing.T) {
+		node, err := test.CreateAndInitNode(targetNodeType, types.Configuration{}, Registry)
+		assert.Nil(t, err)
+		var wg sync.WaitGroup
+		wg.Add(1)
+		test.NodeOnMsg(t, node, []test.Msg{{
+			MetaData: types.NewMetadata(),
+			DataType: types.TEXT,
+			Data:     "name,temperature\n\"lala,25",
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.Failure, relationType)
+		})
+		wg.Wait()
+	})
+}
+
+func TestJsonToCsvNode(t *testing.T) {
+	var targetNodeType = "jsonToCsv"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &JsonToCsvNode{}, types.Configuration{
+			"delimiter":   ",",
+			"writeHeader": true,
+		}, Registry)
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		node1, err := test.CreateAndInitNode(targetNodeType, types.Configuration{}, Registry)
+		assert.Nil(t, err)
+		node2, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"delimiter":   ";",
+			"headers":     []string{"temperature", "name"},
+			"writeHeader": false,
+		}, Registry)
+		assert.Nil(t, err)
+
+		var wg sync.WaitGroup
+		wg.Add(4)
+		data := `[{"name":"lala","temperature":25.5},{"name":"b,b","temperature":30,"online":true}]`
+		test.NodeOnMsg(t, node1, []test.Msg{{
+			MetaData: types.NewMetadata(),
+			Data:     data,
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, types.TEXT, msg.DataType)
+			assert.Equal(t, "name,online,temperature\nlala,,25.5\n\"b,b\",true,30\n", msg.GetData())
+		})
+		test.NodeOnMsg(t, node2, []test.Msg{{
+			MetaData: types.NewMetadata(),
+			Data:     data,
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, "25.5;lala\n30;b,b\n", msg.GetData())
+		})
+		test.NodeOnMsg(t, node1, []test.Msg{{
+			MetaData: types.NewMetadata(),
+			Data:     `[[1,"a"],[2,null]]`,
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, "1,a\n2,\n", msg.GetData())
+		})
+		test.NodeOnMsg(t, node1, []test.Msg{{
+			MetaData: types.NewMetadata(),
+			Data:     `"aa"`,
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.Failure, relationType)
+		})
+		wg.Wait()
+	})
+}
@@ -5,6 +5,10 @@
 // - ExprTransformNode: Transforms data using expression language
 // - JsTransformNode: Transforms data using JavaScript
 // - TemplateNode: Transforms data using a text/template
+// - CsvToJsonNode/JsonToCsvNode: Converts between CSV text and JSON
+// - XmlToJsonNode/JsonToXmlNode: Converts between XML text and JSON
+// - JsonMappingNode: Reshapes JSON using a declarative mapping spec compiled at Init
+// - LuaTransformNode: Transforms data using Lua
 //
 // Each component is registered with the Registry, allowing them to be used
 // within rule chains. These components enable complex data manipulations and
@@ -0,0 +1,439 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transform
+
+//规则链节点配置示例：
+//{
+//	"id": "s1",
+//	"type": "jsonMapping",
+//	"name": "JSON映射",
+//	"configuration": {
+//		"mapping": {
+//			"device.id": "msg.deviceId",
+//			"device.owner": "metadata.owner",
+//			"device.region": {"expr": "vars.region", "default": "cn"},
+//			"alarm": {"expr": "msg.temperature", "if": "msg.temperature > 50"},
+//			"readings": {
+//				"forEach": "msg.items",
+//				"as": "item",
+//				"filter": "item.value != nil",
+//				"mapping": {"ts": "item.t", "value": "item.value * 10"}
+//			}
+//		}
+//	}
+//}
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// 映射规则指令字段
+const (
+	// MappingKeyExpr 取值表达式
+	MappingKeyExpr = "expr"
+	// MappingKeyValue 常量值
+	MappingKeyValue = "value"
+	// MappingKeyDefault 取值结果为nil时的默认值
+	MappingKeyDefault = "default"
+	// MappingKeyIf 条件表达式，结果为false时不输出该字段
+	MappingKeyIf = "if"
+	// MappingKeyForEach 遍历的数组表达式
+	MappingKeyForEach = "forEach"
+	// MappingKeyAs 遍历时当前元素的变量名，默认item
+	MappingKeyAs = "as"
+	// MappingKeyFilter 遍历时元素的过滤表达式
+	MappingKeyFilter = "filter"
+	// MappingKeyMapping 嵌套的映射规则
+	MappingKeyMapping = "mapping"
+	// MappingDefaultItemName 遍历时当前元素的默认变量名
+	MappingDefaultItemName = "item"
+	// MappingIndexName 遍历时当前元素序号的变量名
+	MappingIndexName = "index"
+)
+
+var mappingDirectiveKeys = []string{MappingKeyExpr, MappingKeyValue, MappingKeyDefault, MappingKeyIf,
+	MappingKeyForEach, MappingKeyAs, MappingKeyFilter, MappingKeyMapping}
+
+func init() {
+	Registry.Add(&JsonMappingNode{})
+}
+
+// JsonMappingNodeConfiguration 节点配置
+type JsonMappingNodeConfiguration struct {
+	// Mapping 映射规则，格式(目标字段路径:规则)
+	// 目标字段路径使用.分隔表示嵌套对象，例如：device.id
+	// 规则可以是expr表达式字符串，也可以是对象，对象支持以下字段：
+	//   - expr: 取值表达式
+	//   - value: 常量值
+	//   - default: 取值结果为nil时的默认值
+	//   - if: 条件表达式，结果为false时不输出该字段
+	//   - forEach: 数组表达式，遍历每个元素生成数组，通过`item`(或者as指定的变量名)访问当前元素，通过`index`访问序号
+	//   - as: 遍历时当前元素的变量名
+	//   - filter: 遍历时元素的过滤表达式
+	//   - mapping: 嵌套的映射规则，生成对象。和forEach一起使用时，每个元素生成一个对象
+	// 对象包含任意一个上述字段时作为规则，不能包含其他字段，否则初始化失败；不包含上述字段时作为嵌套对象的映射规则。
+	// 嵌套对象的字段名和上述字段同名时，使用mapping包装，例如：{"device":{"mapping":{"id":"msg.id","value":"msg.temp"}}}，
+	// 或者使用字段路径，例如："device.value": "msg.temp"
+	// 表达式可以通过msg、metadata、vars(规则链变量)、global(全局属性)、id、ts、type、dataType、data访问消息和变量
+	Mapping map[string]interface{}
+	// IgnoreNil 结果为nil的字段是否不输出
+	IgnoreNil bool
+}
+
+// JsonMappingNode 使用声明式的映射规则重组JSON消息
+// 映射规则在初始化时编译，执行时不需要脚本引擎，适合非开发人员维护嵌套结构和数组的转换
+// 转换结果替换msg，dataType设置为JSON，转到Success链；表达式执行失败转到Failure链
+type JsonMappingNode struct {
+	//节点配置
+	Config JsonMappingNodeConfiguration
+	//编译后的映射规则
+	fields []*mappingField
+	//规则链变量
+	vars map[string]interface{}
+	//全局属性
+	global map[string]string
+}
+
+// mappingField 编译后的字段映射规则
+type mappingField struct {
+	path         []string
+	hasValue     bool
+	value        interface{}
+	program      *vm.Program
+	defaultValue interface{}
+	condition    *vm.Program
+	forEach      *vm.Program
+	as           string
+	filter       *vm.Program
+	children     []*mappingField
+}
+
+// Type 组件类型
+func (x *JsonMappingNode) Type() string {
+	return "jsonMapping"
+}
+
+func (x *JsonMappingNode) New() types.Node {
+	return &JsonMappingNode{Config: JsonMappingNodeConfiguration{
+		Mapping: map[string]interface{}{
+			"name": "msg.name",
+		},
+	}}
+}
+
+// Init 初始化，编译映射规则
+func (x *JsonMappingNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err != nil {
+		return err
+	}
+	if len(x.Config.Mapping) == 0 {
+		return errors.New("mapping can not empty")
+	}
+	if x.fields, err = compileMapping(x.Config.Mapping); err != nil {
+		return err
+	}
+	if v := base.NodeUtils.GetVars(configuration); v != nil {
+		x.vars = v
+	}
+	x.global = ruleConfig.Properties.Values()
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *JsonMappingNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	evn := base.NodeUtils.GetEvn(ctx, msg)
+	if x.vars != nil {
+		evn[types.Vars] = x.vars[types.Vars]
+	}
+	if len(x.global) > 0 {
+		evn[types.Global] = x.global
+	}
+	var exprVm = vm.VM{}
+	result, err := x.apply(&exprVm, x.fields, evn)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.DataType = types.JSON
+	msg.SetData(str.ToString(result))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁
+func (x *JsonMappingNode) Destroy() {
+}
+
+// apply 按映射规则生成对象
+func (x *JsonMappingNode) apply(exprVm *vm.VM, fields []*mappingField, evn map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	for _, field := range fields {
+		if field.condition != nil {
+			if ok, err := runBool(exprVm, field.condition, evn); err != nil {
+				return nil, fmt.Errorf("field %s: %w", strings.Join(field.path, "."), err)
+			} else if !ok {
+				continue
+			}
+		}
+		value, err := x.evalField(exprVm, field, evn)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", strings.Join(field.path, "."), err)
+		}
+		if value == nil {
+			value = field.defaultValue
+		}
+		if value == nil && x.Config.IgnoreNil {
+			continue
+		}
+		setPath(result, field.path, value)
+	}
+	return result, nil
+}
+
+func (x *JsonMappingNode) evalField(exprVm *vm.VM, field *mappingField, evn map[string]interface{}) (interface{}, error) {
+	if field.forEach != nil {
+		return x.evalForEach(exprVm, field, evn)
+	}
+	return x.evalItem(exprVm, field, evn)
+}
+
+func (x *JsonMappingNode) evalItem(exprVm *vm.VM, field *mappingField, evn map[string]interface{}) (interface{}, error) {
+	if field.children != nil {
+		return x.apply(exprVm, field.children, evn)
+	} else if field.program != nil {
+		return exprVm.Run(field.program, evn)
+	} else if field.hasValue {
+		return field.value, nil
+	}
+	return nil, nil
+}
+
+// evalForEach 遍历数组，每个元素按照规则生成新的元素
+func (x *JsonMappingNode) evalForEach(exprVm *vm.VM, field *mappingField, evn map[string]interface{}) (interface{}, error) {
+	out, err := exprVm.Run(field.forEach, evn)
+	if err != nil {
+		return nil, err
+	}
+	var list []interface{}
+	switch v := out.(type) {
+	case nil:
+		return []interface{}{}, nil
+	case []interface{}:
+		list = v
+	case []map[string]interface{}:
+		for _, item := range v {
+			list = append(list, item)
+		}
+	case []string:
+		for _, item := range v {
+			list = append(list, item)
+		}
+	case []int:
+		for _, item := range v {
+			list = append(list, item)
+		}
+	case []float64:
+		for _, item := range v {
+			list = append(list, item)
+		}
+	default:
+		return nil, fmt.Errorf("forEach must be an array, got %T", out)
+	}
+	//保存同名变量，遍历完成后恢复，支持嵌套遍历
+	oldItem, hasOldItem := evn[field.as]
+	oldIndex, hasOldIndex := evn[MappingIndexName]
+	defer func() {
+		restoreVar(evn, field.as, oldItem, hasOldItem)
+		restoreVar(evn, MappingIndexName, oldIndex, hasOldIndex)
+	}()
+
+	result := make([]interface{}, 0, len(list))
+	for i, item := range list {
+		evn[field.as] = item
+		evn[MappingIndexName] = i
+		if field.filter != nil {
+			if ok, err := runBool(exprVm, field.filter, evn); err != nil {
+				return nil, err
+			} else if !ok {
+				continue
+			}
+		}
+		var value interface{} = item
+		if field.children != nil || field.program != nil || field.hasValue {
+			if value, err = x.evalItem(exprVm, field, evn); err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+// compileMapping 编译映射规则，按字段路径排序保证输出稳定
+func compileMapping(mapping map[string]interface{}) ([]*mappingField, error) {
+	keys := make([]string, 0, len(mapping))
+	for k := range mapping {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var fields []*mappingField
+	for _, k := range keys {
+		field, err := compileMappingField(k, mapping[k])
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", k, err)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+func compileMappingField(path string, rule interface{}) (*mappingField, error) {
+	field := &mappingField{path: strings.Split(path, "."), as: MappingDefaultItemName}
+	var err error
+	switch v := rule.(type) {
+	case string:
+		field.program, err = compileExpr(v)
+		return field, err
+	case map[string]interface{}:
+		if !isMappingDirective(v) {
+			//没有指令字段，作为嵌套对象的映射规则
+			field.children, err = compileMapping(v)
+			return field, err
+		}
+		if err = checkMappingDirective(v); err != nil {
+			return nil, err
+		}
+		if exprV, ok := v[MappingKeyExpr]; ok {
+			if field.program, err = compileExpr(str.ToString(exprV)); err != nil {
+				return nil, err
+			}
+		}
+		if value, ok := v[MappingKeyValue]; ok {
+			field.hasValue = true
+			field.value = value
+		}
+		field.defaultValue = v[MappingKeyDefault]
+		if condition, ok := v[MappingKeyIf]; ok {
+			if field.condition, err = compileExpr(str.ToString(condition)); err != nil {
+				return nil, err
+			}
+		}
+		if forEach, ok := v[MappingKeyForEach]; ok {
+			if field.forEach, err = compileExpr(str.ToString(forEach)); err != nil {
+				return nil, err
+			}
+		}
+		if as, ok := v[MappingKeyAs]; ok && str.ToString(as) != "" {
+			field.as = str.ToString(as)
+		}
+		if filter, ok := v[MappingKeyFilter]; ok {
+			if field.filter, err = compileExpr(str.ToString(filter)); err != nil {
+				return nil, err
+			}
+		}
+		if children, ok := v[MappingKeyMapping]; ok {
+			childrenMapping, ok := children.(map[string]interface{})
+			if !ok {
+				return nil, errors.New("mapping must be an object")
+			}
+			if field.children, err = compileMapping(childrenMapping); err != nil {
+				return nil, err
+			}
+		}
+		return field, nil
+	default:
+		//其他类型作为常量
+		field.hasValue = true
+		field.value = v
+		return field, nil
+	}
+}
+
+func isMappingDirective(rule map[string]interface{}) bool {
+	for _, k := range mappingDirectiveKeys {
+		if _, ok := rule[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkMappingDirective 检查规则不包含指令以外的字段，避免嵌套对象的同名字段被当作指令，其他字段被忽略
+func checkMappingDirective(rule map[string]interface{}) error {
+	keys := make([]string, 0, len(rule))
+	for k := range rule {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !str.Contains(mappingDirectiveKeys, k) {
+			return fmt.Errorf("unknown key %s in mapping rule, wrap nested fields with %s", k, MappingKeyMapping)
+		}
+	}
+	return nil
+}
+
+func compileExpr(exprStr string) (*vm.Program, error) {
+	exprStr = strings.TrimSpace(exprStr)
+	if exprStr == "" {
+		return nil, errors.New("expr can not empty")
+	}
+	return expr.Compile(exprStr, expr.AllowUndefinedVariables())
+}
+
+func runBool(exprVm *vm.VM, program *vm.Program, evn map[string]interface{}) (bool, error) {
+	out, err := exprVm.Run(program, evn)
+	if err != nil {
+		return false, err
+	}
+	ok, _ := out.(bool)
+	return ok, nil
+}
+
+func restoreVar(evn map[string]interface{}, key string, value interface{}, exists bool) {
+	if exists {
+		evn[key] = value
+	} else {
+		delete(evn, key)
+	}
+}
+
+// setPath 按路径设置值，中间路径不存在或者不是对象时创建新的对象
+func setPath(result map[string]interface{}, path []string, value interface{}) {
+	current := result
+	for i, key := range path {
+		if i == len(path)-1 {
+			current[key] = value
+			return
+		}
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			current[key] = next
+		}
+		current = next
+	}
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transform
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestJsonMappingNode(t *testing.T) {
+	var targetNodeType = "jsonMapping"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &JsonMappingNode{}, types.Configuration{
+			"mapping": map[string]interface{}{
+				"name": "msg.name",
+			},
+		}, Registry)
+	})
+
+	t.Run("InitNode", func(t *testing.T) {
+		_, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"mapping": map[string]interface{}{},
+		}, Registry)
+		assert.NotNil(t, err)
+		_, err = test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"mapping": map[string]interface{}{
+				"name": "msg.name +",
+			},
+		}, Registry)
+		assert.NotNil(t, err)
+		_, err = test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"mapping": map[string]interface{}{
+				"list": map[string]interface{}{"forEach": "msg.items", "mapping": "aa"},
+			},
+		}, Registry)
+		assert.NotNil(t, err)
+		//嵌套对象包含和指令同名的字段，需要使用mapping包装
+		_, err = test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"mapping": map[string]interface{}{
+				"device": map[string]interface{}{"id": "msg.id", "value": "msg.temp"},
+			},
+		}, Registry)
+		assert.NotNil(t, err)
+		assert.True(t, strings.Contains(err.Error(), "unknown key id"))
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		node1, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"vars": map[string]string{
+				"region": "east",
+			},
+			"mapping": map[string]interface{}{
+				"device.id":      "msg.deviceId",
+				"device.owner":   "metadata.owner",
+				"device.region":  "vars.region",
+				"device.model":   map[string]interface{}{"expr": "msg.model", "default": "unknown"},
+				"device.version": map[string]interface{}{"value": 2},
+				"alarm":          map[string]interface{}{"expr": "msg.temperature", "if": "msg.temperature > 50"},
+				"normal":         map[string]interface{}{"value": true, "if": "msg.temperature <= 50"},
+				"readings": map[string]interface{}{
+					"forEach": "msg.items",
+					"filter":  "item.v != nil",
+					"mapping": map[string]interface{}{
+						"ts":    "item.t",
+						"value": "item.v * 10",
+						"seq":   "index",
+						"tags": map[string]interface{}{
+							"forEach": "item.tags",
+							"as":      "tag",
+							"expr":    "upper(tag)",
+						},
+					},
+				},
+				"ids":  map[string]interface{}{"forEach": "msg.items", "expr": "item.t"},
+				"meta": map[string]interface{}{"source": "type", "first": "msg.items[0].t"},
+			},
+		}, Registry)
+		assert.Nil(t, err)
+		node2, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"ignoreNil": true,
+			"mapping": map[string]interface{}{
+				"a":      "msg.notExist",
+				"b":      "msg.deviceId",
+				"device": map[string]interface{}{"mapping": map[string]interface{}{"id": "msg.deviceId", "value": "msg.temperature"}},
+			},
+		}, Registry)
+		assert.Nil(t, err)
+		node3, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"mapping": map[string]interface{}{
+				"a": map[string]interface{}{"forEach": "msg.deviceId"},
+			},
+		}, Registry)
+		assert.Nil(t, err)
+
+		metaData := types.NewMetadata()
+		metaData.PutValue("owner", "lala")
+		data := `{"deviceId":"d1","temperature":60,"items":[{"t":1,"v":1.5,"tags":["a","b"]},{"t":2},{"t":3,"v":2,"tags":[]}]}`
+		var wg sync.WaitGroup
+		wg.Add(3)
+		test.NodeOnMsg(t, node1, []test.Msg{{
+			MetaData: metaData,
+			MsgType:  "TELEMETRY",
+			Data:     data,
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, `{"alarm":60,"device":{"id":"d1","model":"unknown","owner":"lala","region":"east","version":2},"ids":[1,2,3],"meta":{"first":1,"source":"TELEMETRY"},"readings":[{"seq":0,"tags":["A","B"],"ts":1,"value":15},{"seq":2,"tags":[],"ts":3,"value":20}]}`, msg.GetData())
+		})
+		test.NodeOnMsg(t, node2, []test.Msg{{
+			MetaData: metaData,
+			Data:     data,
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, `{"b":"d1","device":{"id":"d1","value":60}}`, msg.GetData())
+		})
+		test.NodeOnMsg(t, node3, []test.Msg{{
+			MetaData: metaData,
+			Data:     data,
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.Failure, relationType)
+			assert.NotNil(t, err)
+		})
+		wg.Wait()
+	})
+}
@@ -0,0 +1,137 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package transform
+
+// 规则链节点配置示例：
+// {
+//   "id": "s2",
+//   "type": "luaTransform",
+//   "name": "转换",
+//   "debugMode": false,
+//   "configuration": {
+//     "script": "metadata.test='test02'\n msgType='TEST_MSG_TYPE2'\n msg.aa=66\n return {msg=msg,metadata=metadata,msgType=msgType}"
+//   }
+// }
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/base"
+	"github.com/rulego/rulego/utils/json"
+	"github.com/rulego/rulego/utils/lua"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+const (
+	// LuaTransformType 组件类型标识符
+	LuaTransformType = "luaTransform"
+	// LuaTransformFuncName lua函数名
+	LuaTransformFuncName = "Transform"
+	// LuaTransformFuncTemplate lua函数模板，用于包装用户脚本
+	LuaTransformFuncTemplate = "function Transform(msg, metadata, msgType)\n%s\nend"
+)
+
+// LuaTransformReturnFormatErr 脚本返回值格式错误
+var LuaTransformReturnFormatErr = errors.New("return the value is not a table")
+
+func init() {
+	Registry.Add(&LuaTransformNode{})
+}
+
+// LuaTransformNodeConfiguration 节点配置
+type LuaTransformNodeConfiguration struct {
+	//Script 配置函数体脚本内容
+	//完整脚本函数：
+	//function Transform(msg, metadata, msgType) ${Script} end
+	//return {msg=msg,metadata=metadata,msgType=msgType}
+	Script string
+}
+
+// LuaTransformNode 使用lua脚本更改消息metadata，msg或msgType
+// 脚本返回 table {msg=msg,metadata=metadata,msgType=msgType}，只更新返回的字段
+// 如果脚本执行成功则把转换后的消息发送到`Success`链, 否则发到`Failure`链。
+type LuaTransformNode struct {
+	//节点配置
+	Config    LuaTransformNodeConfiguration
+	luaEngine *lua.LuaEngine
+}
+
+// Type 组件类型
+func (x *LuaTransformNode) Type() string {
+	return LuaTransformType
+}
+
+func (x *LuaTransformNode) New() types.Node {
+	return &LuaTransformNode{Config: LuaTransformNodeConfiguration{
+		Script: "return {msg=msg,metadata=metadata,msgType=msgType}",
+	}}
+}
+
+// Init 初始化
+func (x *LuaTransformNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if err == nil {
+		luaScript := fmt.Sprintf(LuaTransformFuncTemplate, x.Config.Script)
+		x.luaEngine, err = lua.NewLuaEngine(ruleConfig, luaScript, base.NodeUtils.GetVars(configuration))
+	}
+	return err
+}
+
+// OnMsg 处理消息
+func (x *LuaTransformNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	var data interface{} = msg.GetData()
+	if msg.DataType == types.JSON {
+		var dataMap interface{}
+		if err := json.Unmarshal([]byte(msg.GetData()), &dataMap); err == nil {
+			data = dataMap
+		}
+	}
+
+	out, err := x.luaEngine.Execute(ctx, LuaTransformFuncName, data, msg.Metadata.Values(), msg.Type)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	formatData, ok := out.(map[string]interface{})
+	if !ok {
+		ctx.TellFailure(msg, LuaTransformReturnFormatErr)
+		return
+	}
+	if formatMsgType, ok := formatData[types.MsgTypeKey]; ok {
+		msg.Type = str.ToString(formatMsgType)
+	}
+	if formatMetaData, ok := formatData[types.MetadataKey]; ok {
+		msg.Metadata.ReplaceAll(str.ToStringMapString(formatMetaData))
+	}
+	if formatMsgData, ok := formatData[types.MsgKey]; ok {
+		if newValue, err := str.ToStringMaybeErr(formatMsgData); err == nil {
+			msg.SetData(newValue)
+		} else {
+			ctx.TellFailure(msg, err)
+			return
+		}
+	}
+	ctx.TellNext(msg, types.Success)
+}
+
+// Destroy 销毁
+func (x *LuaTransformNode) Destroy() {
+	if x.luaEngine != nil {
+		x.luaEngine.Stop()
+	}
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package transform
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestLuaTransformNode(t *testing.T) {
+	var targetNodeType = "luaTransform"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &LuaTransformNode{}, types.Configuration{
+			"script": "return {msg=msg,metadata=metadata,msgType=msgType}",
+		}, Registry)
+	})
+
+	t.Run("InitNode", func(t *testing.T) {
+		_, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"script": "return {msg=",
+		}, Registry)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		node1, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"script": "metadata.test = 'test02'\nmetadata.index = 52\nmsg.aa = 66\nmsg.region = vars.region\nreturn {msg=msg,metadata=metadata,msgType='TEST_MSG_TYPE2'}",
+			"vars": map[string]string{
+				"region": "east",
+			},
+		}, Registry)
+		assert.Nil(t, err)
+		node2, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"script": "return {msg=string.upper(msg)}",
+		}, Registry)
+		assert.Nil(t, err)
+		node3, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"script": "return 'aa'",
+		}, Registry)
+		assert.Nil(t, err)
+
+		var wg sync.WaitGroup
+		wg.Add(3)
+		test.NodeOnMsg(t, node1, []test.Msg{{
+			MetaData: types.NewMetadata(),
+			MsgType:  "TELEMETRY",
+			Data:     "{\"temperature\":41}",
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, "TEST_MSG_TYPE2", msg.Type)
+			assert.Equal(t, "test02", msg.Metadata.GetValue("test"))
+			assert.Equal(t, "52", msg.Metadata.GetValue("index"))
+			assert.Equal(t, "{\"aa\":66,\"region\":\"east\",\"temperature\":41}", msg.GetData())
+		})
+		test.NodeOnMsg(t, node2, []test.Msg{{
+			MetaData: types.NewMetadata(),
+			MsgType:  "TELEMETRY",
+			DataType: types.TEXT,
+			Data:     "aa",
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, "AA", msg.GetData())
+			assert.Equal(t, "TELEMETRY", msg.Type)
+		})
+		test.NodeOnMsg(t, node3, []test.Msg{{
+			MetaData: types.NewMetadata(),
+			Data:     "{}",
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.Failure, relationType)
+			assert.Equal(t, LuaTransformReturnFormatErr.Error(), err.Error())
+		})
+		wg.Wait()
+	})
+}
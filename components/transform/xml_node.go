@@ -0,0 +1,388 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transform
+
+//规则链节点配置示例：
+//{
+//	"id": "s1",
+//	"type": "xmlToJson",
+//	"name": "XML转JSON",
+//	"configuration": {
+//		"attrPrefix": "@",
+//		"textKey": "#text",
+//		"forceArray": ["item"]
+//	}
+//}
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/json"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/str"
+)
+
+const (
+	// DefaultXmlAttrPrefix 默认属性字段前缀
+	DefaultXmlAttrPrefix = "@"
+	// DefaultXmlTextKey 默认文本字段名
+	DefaultXmlTextKey = "#text"
+	// DefaultXmlRootName 默认根元素名称
+	DefaultXmlRootName = "root"
+	// DefaultXmlItemName 默认数组元素名称
+	DefaultXmlItemName = "item"
+)
+
+func init() {
+	Registry.Add(&XmlToJsonNode{})
+	Registry.Add(&JsonToXmlNode{})
+}
+
+// XmlToJsonNodeConfiguration 节点配置
+type XmlToJsonNodeConfiguration struct {
+	// AttrPrefix 属性转换成JSON字段时的前缀，默认"@"
+	AttrPrefix string
+	// TextKey 元素同时有属性或者子元素时，文本内容的字段名，默认"#text"
+	TextKey string
+	// KeepNamespace 是否保留命名空间前缀，true：字段名为prefix:name并保留xmlns属性，false：只保留本地名称
+	KeepNamespace bool
+	// ForceArray 总是转换成数组的元素名称列表，即使只出现一次
+	ForceArray []string
+	// InferTypes 是否自动推断文本和属性值的类型
+	InferTypes bool
+}
+
+// XmlToJsonNode 把XML格式的消息负荷转换成JSON
+// 元素转换成对象字段，属性使用AttrPrefix前缀，重复出现的同名元素转换成数组
+// 例如：<a id="1"><b>x</b><b>y</b></a> 转换成 {"a":{"@id":"1","b":["x","y"]}}
+type XmlToJsonNode struct {
+	//节点配置
+	Config     XmlToJsonNodeConfiguration
+	forceArray map[string]struct{}
+}
+
+// Type 组件类型
+func (x *XmlToJsonNode) Type() string {
+	return "xmlToJson"
+}
+
+func (x *XmlToJsonNode) New() types.Node {
+	return &XmlToJsonNode{Config: XmlToJsonNodeConfiguration{
+		AttrPrefix: DefaultXmlAttrPrefix,
+		TextKey:    DefaultXmlTextKey,
+	}}
+}
+
+// Init 初始化
+func (x *XmlToJsonNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if x.Config.TextKey == "" {
+		x.Config.TextKey = DefaultXmlTextKey
+	}
+	x.forceArray = make(map[string]struct{})
+	for _, item := range x.Config.ForceArray {
+		x.forceArray[item] = struct{}{}
+	}
+	return err
+}
+
+// OnMsg 处理消息
+func (x *XmlToJsonNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	result, err := x.decode(msg.GetData())
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.DataType = types.JSON
+	msg.SetData(str.ToString(result))
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁
+func (x *XmlToJsonNode) Destroy() {
+}
+
+// xmlElement 解析过程中的元素
+type xmlElement struct {
+	name     string
+	fields   map[string]interface{}
+	text     strings.Builder
+	hasChild bool
+}
+
+func (x *XmlToJsonNode) decode(data string) (map[string]interface{}, error) {
+	decoder := xml.NewDecoder(strings.NewReader(data))
+	decoder.Strict = false
+	root := &xmlElement{fields: make(map[string]interface{})}
+	stack := []*xmlElement{root}
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			elem := &xmlElement{name: x.name(t.Name), fields: make(map[string]interface{})}
+			for _, attr := range t.Attr {
+				if !x.Config.KeepNamespace && (attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns") {
+					continue
+				}
+				elem.fields[x.Config.AttrPrefix+x.name(attr.Name)] = x.value(attr.Value)
+			}
+			stack[len(stack)-1].hasChild = true
+			stack = append(stack, elem)
+		case xml.EndElement:
+			if len(stack) < 2 {
+				return nil, errors.New("unexpected end element: " + x.name(t.Name))
+			}
+			elem := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			x.addField(stack[len(stack)-1].fields, elem.name, x.elementValue(elem))
+		case xml.CharData:
+			stack[len(stack)-1].text.Write(t)
+		}
+	}
+	if len(stack) != 1 {
+		return nil, errors.New("unexpected EOF: unclosed element " + stack[len(stack)-1].name)
+	}
+	if len(root.fields) == 0 {
+		return nil, errors.New("xml has no root element")
+	}
+	return root.fields, nil
+}
+
+// elementValue 元素只有文本时返回文本值，否则返回对象
+func (x *XmlToJsonNode) elementValue(elem *xmlElement) interface{} {
+	text := strings.TrimSpace(elem.text.String())
+	if len(elem.fields) == 0 && !elem.hasChild {
+		return x.value(text)
+	}
+	if text != "" {
+		elem.fields[x.Config.TextKey] = x.value(text)
+	}
+	return elem.fields
+}
+
+// addField 添加字段，同名字段转换成数组
+func (x *XmlToJsonNode) addField(fields map[string]interface{}, name string, value interface{}) {
+	if old, ok := fields[name]; ok {
+		if list, ok := old.([]interface{}); ok {
+			fields[name] = append(list, value)
+		} else {
+			fields[name] = []interface{}{old, value}
+		}
+	} else if _, ok := x.forceArray[name]; ok {
+		fields[name] = []interface{}{value}
+	} else {
+		fields[name] = value
+	}
+}
+
+func (x *XmlToJsonNode) name(name xml.Name) string {
+	if x.Config.KeepNamespace && name.Space != "" {
+		return name.Space + ":" + name.Local
+	}
+	return name.Local
+}
+
+func (x *XmlToJsonNode) value(v string) interface{} {
+	if x.Config.InferTypes {
+		return inferType(v)
+	}
+	return v
+}
+
+// JsonToXmlNodeConfiguration 节点配置
+type JsonToXmlNodeConfiguration struct {
+	// RootName 根元素名称，如果JSON对象只有一个字段并且是对象，则该字段作为根元素，否则使用该名称包装
+	RootName string
+	// ItemName 顶层数组元素的名称
+	ItemName string
+	// AttrPrefix 带有该前缀的字段转换成属性，默认"@"
+	AttrPrefix string
+	// TextKey 该字段转换成元素文本内容，默认"#text"
+	TextKey string
+	// Indent 是否格式化输出
+	Indent bool
+	// Declaration 是否输出XML声明头
+	Declaration bool
+}
+
+// JsonToXmlNode 把JSON转换成XML格式的文本
+// 对象字段转换成子元素，AttrPrefix前缀的字段转换成属性，数组转换成重复的同名元素
+// 命名空间通过字段名前缀表达，例如：{"soap:Envelope":{"@xmlns:soap":"http://schemas.xmlsoap.org/soap/envelope/"}}
+type JsonToXmlNode struct {
+	//节点配置
+	Config JsonToXmlNodeConfiguration
+}
+
+// Type 组件类型
+func (x *JsonToXmlNode) Type() string {
+	return "jsonToXml"
+}
+
+func (x *JsonToXmlNode) New() types.Node {
+	return &JsonToXmlNode{Config: JsonToXmlNodeConfiguration{
+		RootName:   DefaultXmlRootName,
+		ItemName:   DefaultXmlItemName,
+		AttrPrefix: DefaultXmlAttrPrefix,
+		TextKey:    DefaultXmlTextKey,
+	}}
+}
+
+// Init 初始化
+func (x *JsonToXmlNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	err := maps.Map2Struct(configuration, &x.Config)
+	if x.Config.RootName == "" {
+		x.Config.RootName = DefaultXmlRootName
+	}
+	if x.Config.ItemName == "" {
+		x.Config.ItemName = DefaultXmlItemName
+	}
+	if x.Config.TextKey == "" {
+		x.Config.TextKey = DefaultXmlTextKey
+	}
+	return err
+}
+
+// OnMsg 处理消息
+func (x *JsonToXmlNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(msg.GetData()), &data); err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	var buf bytes.Buffer
+	if x.Config.Declaration {
+		buf.WriteString(xml.Header)
+	}
+	encoder := xml.NewEncoder(&buf)
+	if x.Config.Indent {
+		encoder.Indent("", "  ")
+	}
+	var err error
+	if v, ok := data.(map[string]interface{}); ok && len(v) == 1 {
+		for k, item := range v {
+			if _, isList := item.([]interface{}); !isList && !x.isSpecialKey(k) {
+				err = x.encodeElement(encoder, k, item)
+			} else {
+				err = x.encodeElement(encoder, x.Config.RootName, data)
+			}
+		}
+	} else {
+		err = x.encodeElement(encoder, x.Config.RootName, data)
+	}
+	if err == nil {
+		err = encoder.Flush()
+	}
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	msg.DataType = types.TEXT
+	msg.SetData(buf.String())
+	ctx.TellSuccess(msg)
+}
+
+// Destroy 销毁
+func (x *JsonToXmlNode) Destroy() {
+}
+
+func (x *JsonToXmlNode) isSpecialKey(k string) bool {
+	return k == x.Config.TextKey || (x.Config.AttrPrefix != "" && strings.HasPrefix(k, x.Config.AttrPrefix))
+}
+
+// encodeElement 把值编码成名称为name的元素
+func (x *JsonToXmlNode) encodeElement(encoder *xml.Encoder, name string, value interface{}) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var children []string
+		var text interface{}
+		for _, k := range keys {
+			if k == x.Config.TextKey {
+				text = v[k]
+			} else if x.Config.AttrPrefix != "" && strings.HasPrefix(k, x.Config.AttrPrefix) {
+				start.Attr = append(start.Attr, xml.Attr{
+					Name:  xml.Name{Local: k[len(x.Config.AttrPrefix):]},
+					Value: x.toString(v[k]),
+				})
+			} else {
+				children = append(children, k)
+			}
+		}
+		if err := encoder.EncodeToken(start); err != nil {
+			return err
+		}
+		if text != nil {
+			if err := encoder.EncodeToken(xml.CharData(x.toString(text))); err != nil {
+				return err
+			}
+		}
+		for _, k := range children {
+			if list, ok := v[k].([]interface{}); ok {
+				for _, item := range list {
+					if err := x.encodeElement(encoder, k, item); err != nil {
+						return err
+					}
+				}
+			} else if err := x.encodeElement(encoder, k, v[k]); err != nil {
+				return err
+			}
+		}
+		return encoder.EncodeToken(start.End())
+	case []interface{}:
+		if err := encoder.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if err := x.encodeElement(encoder, x.Config.ItemName, item); err != nil {
+				return err
+			}
+		}
+		return encoder.EncodeToken(start.End())
+	default:
+		if err := encoder.EncodeToken(start); err != nil {
+			return err
+		}
+		if v != nil {
+			if err := encoder.EncodeToken(xml.CharData(x.toString(v))); err != nil {
+				return err
+			}
+		}
+		return encoder.EncodeToken(start.End())
+	}
+}
+
+func (x *JsonToXmlNode) toString(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return str.ToString(v)
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transform
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestXmlToJsonNode(t *testing.T) {
+	var targetNodeType = "xmlToJson"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &XmlToJsonNode{}, types.Configuration{
+			"attrPrefix": "@",
+			"textKey":    "#text",
+		}, Registry)
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		node1, err := test.CreateAndInitNode(targetNodeType, types.Configuration{}, Registry)
+		assert.Nil(t, err)
+		node2, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"keepNamespace": true,
+			"inferTypes":    true,
+			"forceArray":    []string{"m:price"},
+		}, Registry)
+		assert.Nil(t, err)
+
+		var wg sync.WaitGroup
+		wg.Add(4)
+		test.NodeOnMsg(t, node1, []test.Msg{{
+			MetaData: types.NewMetadata(),
+			DataType: types.TEXT,
+			Data:     `<?xml version="1.0"?><order id="1"><item sku="a">apple</item><item sku="b">pear</item><total>2</total><note/></order>`,
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, types.JSON, msg.DataType)
+			assert.Equal(t, `{"order":{"@id":"1","item":[{"#text":"apple","@sku":"a"},{"#text":"pear","@sku":"b"}],"note":"","total":"2"}}`, msg.GetData())
+		})
+		soap := `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/" xmlns:m="http://example.com/stock"><soap:Body><m:price>34.5</m:price></soap:Body></soap:Envelope>`
+		test.NodeOnMsg(t, node1, []test.Msg{{
+			MetaData: types.NewMetadata(),
+			DataType: types.TEXT,
+			Data:     soap,
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, `{"Envelope":{"Body":{"price":"34.5"}}}`, msg.GetData())
+		})
+		test.NodeOnMsg(t, node2, []test.Msg{{
+			MetaData: types.NewMetadata(),
+			DataType: types.TEXT,
+			Data:     soap,
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, `{"soap:Envelope":{"@xmlns:m":"http://example.com/stock","@xmlns:soap":"http://schemas.xmlsoap.org/soap/envelope/","soap:Body":{"m:price":[34.5]}}}`, msg.GetData())
+		})
+		test.NodeOnMsg(t, node1, []test.Msg{{
+			MetaData: types.NewMetadata(),
+			DataType: types.TEXT,
+			Data:     `<a><b></a>`,
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.Failure, relationType)
+		})
+		wg.Wait()
+	})
+}
+
+func TestJsonToXmlNode(t *testing.T) {
+	var targetNodeType = "jsonToXml"
+
+	t.Run("NewNode", func(t *testing.T) {
+		test.NodeNew(t, targetNodeType, &JsonToXmlNode{}, types.Configuration{
+			"rootName":   "root",
+			"itemName":   "item",
+			"attrPrefix": "@",
+			"textKey":    "#text",
+		}, Registry)
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		node1, err := test.CreateAndInitNode(targetNodeType, types.Configuration{}, Registry)
+		assert.Nil(t, err)
+		node2, err := test.CreateAndInitNode(targetNodeType, types.Configuration{
+			"rootName":    "devices",
+			"declaration": true,
+		}, Registry)
+		assert.Nil(t, err)
+
+		var wg sync.WaitGroup
+		wg.Add(4)
+		test.NodeOnMsg(t, node1, []test.Msg{{
+			MetaData: types.NewMetadata(),
+			Data:     `{"order":{"@id":"1","item":[{"#text":"apple","@sku":"a"},{"#text":"pear","@sku":"b"}],"total":2}}`,
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, types.TEXT, msg.DataType)
+			assert.Equal(t, `<order id="1"><item sku="a">apple</item><item sku="b">pear</item><total>2</total></order>`, msg.GetData())
+		})
+		test.NodeOnMsg(t, node1, []test.Msg{{
+			MetaData: types.NewMetadata(),
+			Data:     `{"soap:Envelope":{"@xmlns:soap":"http://schemas.xmlsoap.org/soap/envelope/","soap:Body":{"price":"a<b"}}}`,
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, `<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><price>a&lt;b</price></soap:Body></soap:Envelope>`, msg.GetData())
+		})
+		test.NodeOnMsg(t, node2, []test.Msg{{
+			MetaData: types.NewMetadata(),
+			Data:     `[{"id":"d1"},{"id":"d2"}]`,
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<devices><item><id>d1</id></item><item><id>d2</id></item></devices>", msg.GetData())
+		})
+		test.NodeOnMsg(t, node1, []test.Msg{{
+			MetaData: types.NewMetadata(),
+			Data:     `{aa`,
+		}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.Failure, relationType)
+		})
+		wg.Wait()
+	})
+}
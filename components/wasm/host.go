@@ -0,0 +1,121 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wasm
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// 宿主函数，组件通过 import "rulego" 模块调用，字符串参数都是 (ptr, size) 形式：
+//   - set_data(ptr, size)：修改消息内容
+//   - set_msg_type(ptr, size)：修改消息类型
+//   - set_data_type(ptr, size)：修改消息数据类型，JSON/TEXT/BINARY
+//   - set_metadata(keyPtr, keySize, valuePtr, valueSize)：修改消息元数据
+//   - tell_next(ptr, size)：把当前消息发送到指定关系类型的下一个节点，可以调用多次
+//   - tell_failure(ptr, size)：以指定错误信息发送到Failure链
+//   - log(ptr, size)：打印日志
+//
+// on_msg 返回后宿主才会按调用顺序执行 tell_next/tell_failure，
+// 如果组件没有调用它们，on_msg 返回0发送到Success链，否则发送到Failure链。
+const (
+	HostFuncSetData     = "set_data"
+	HostFuncSetMsgType  = "set_msg_type"
+	HostFuncSetDataType = "set_data_type"
+	HostFuncSetMetadata = "set_metadata"
+	HostFuncTellNext    = "tell_next"
+	HostFuncTellFailure = "tell_failure"
+	HostFuncLog         = "log"
+)
+
+type callStateKey struct{}
+
+// callState 一次 on_msg 调用的状态
+type callState struct {
+	msg     types.RuleMsg
+	logger  types.Logger
+	actions []action
+}
+
+// action 组件调用 tell_next/tell_failure 记录的动作
+type action struct {
+	msg          types.RuleMsg
+	relationType string
+	err          error
+}
+
+func withCallState(ctx context.Context, state *callState) context.Context {
+	return context.WithValue(ctx, callStateKey{}, state)
+}
+
+func getCallState(ctx context.Context) *callState {
+	state, _ := ctx.Value(callStateKey{}).(*callState)
+	return state
+}
+
+func readString(m api.Module, ptr, size uint32) string {
+	buf, ok := m.Memory().Read(ptr, size)
+	if !ok {
+		panic(errors.New("read out of memory range"))
+	}
+	return string(buf)
+}
+
+func instantiateHostModule(ctx context.Context, r wazero.Runtime) error {
+	_, err := r.NewHostModuleBuilder(HostModuleName).
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module, ptr, size uint32) {
+		if state := getCallState(ctx); state != nil {
+			state.msg.SetData(readString(m, ptr, size))
+		}
+	}).Export(HostFuncSetData).
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module, ptr, size uint32) {
+		if state := getCallState(ctx); state != nil {
+			state.msg.Type = readString(m, ptr, size)
+		}
+	}).Export(HostFuncSetMsgType).
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module, ptr, size uint32) {
+		if state := getCallState(ctx); state != nil {
+			state.msg.DataType = types.DataType(readString(m, ptr, size))
+		}
+	}).Export(HostFuncSetDataType).
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module, keyPtr, keySize, valuePtr, valueSize uint32) {
+		if state := getCallState(ctx); state != nil {
+			state.msg.Metadata.PutValue(readString(m, keyPtr, keySize), readString(m, valuePtr, valueSize))
+		}
+	}).Export(HostFuncSetMetadata).
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module, ptr, size uint32) {
+		if state := getCallState(ctx); state != nil {
+			state.actions = append(state.actions, action{msg: state.msg.Copy(), relationType: readString(m, ptr, size)})
+		}
+	}).Export(HostFuncTellNext).
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module, ptr, size uint32) {
+		if state := getCallState(ctx); state != nil {
+			state.actions = append(state.actions, action{msg: state.msg.Copy(), err: errors.New(readString(m, ptr, size))})
+		}
+	}).Export(HostFuncTellFailure).
+		NewFunctionBuilder().WithFunc(func(ctx context.Context, m api.Module, ptr, size uint32) {
+		if state := getCallState(ctx); state != nil && state.logger != nil {
+			state.logger.Printf("wasm: %s", readString(m, ptr, size))
+		}
+	}).Export(HostFuncLog).
+		Instantiate(ctx)
+	return err
+}
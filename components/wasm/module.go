@@ -0,0 +1,248 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package wasm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/json"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+const (
+	// FileExt WASM组件文件后缀
+	FileExt = ".wasm"
+	// HostModuleName 宿主函数所在的模块名
+	HostModuleName = "rulego"
+	// DefaultMemoryLimitPages 默认每个实例最大内存页数，每页64KiB，默认64MiB
+	DefaultMemoryLimitPages = 1024
+
+	// FuncAlloc 组件导出的内存分配函数 alloc(size i32) i32
+	FuncAlloc = "alloc"
+	// FuncDealloc 组件导出的内存释放函数(可选) dealloc(ptr i32, size i32)
+	FuncDealloc = "dealloc"
+	// FuncOnMsg 组件导出的消息处理函数 on_msg(ptr i32, size i32) i32，返回非0表示处理失败
+	FuncOnMsg = "on_msg"
+	// FuncComponentInfo 组件导出的组件定义函数(可选) component_info() i64，返回 ptr<<32|size
+	FuncComponentInfo = "component_info"
+)
+
+var (
+	// ErrExecutionTimeout 执行超时错误
+	ErrExecutionTimeout = errors.New("execution timeout")
+	// ErrMissingExport 组件缺少必须的导出函数
+	ErrMissingExport = errors.New("wasm module must export memory, alloc and on_msg")
+)
+
+// Options WASM模块加载选项
+type Options struct {
+	// MemoryLimitPages 每个实例最大内存页数，每页64KiB，默认 DefaultMemoryLimitPages
+	MemoryLimitPages uint32
+	// PoolSize 实例池大小，默认CPU核数
+	PoolSize int
+}
+
+// ComponentInfo 组件定义，由组件导出函数 component_info 提供，JSON格式
+// 例如：{"type":"wasm/upper","label":"转大写","category":"wasm","relationTypes":["Success","Failure"],"defaults":{"prefix":"a"}}
+type ComponentInfo struct {
+	types.ComponentForm
+	// Defaults 组件默认配置
+	Defaults types.Configuration `json:"defaults"`
+}
+
+// Module 已编译的WASM模块，维护一个实例池，同一模块的所有节点共享
+type Module struct {
+	file     string
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	pool     chan api.Module
+	info     ComponentInfo
+}
+
+// LoadModule 从文件加载并编译WASM模块
+func LoadModule(file string, opts Options) (*Module, error) {
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	m, err := NewModule(buf, opts)
+	if err != nil {
+		return nil, err
+	}
+	m.file = file
+	if m.info.Type == "" {
+		m.info.Type = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+	return m, nil
+}
+
+// NewModule 编译WASM模块
+// 模块可以导入 wasi_snapshot_preview1 和 rulego 宿主函数，如果导出 _initialize 会在实例化后调用(reactor模式)
+func NewModule(wasmBytes []byte, opts Options) (*Module, error) {
+	if opts.MemoryLimitPages == 0 {
+		opts.MemoryLimitPages = DefaultMemoryLimitPages
+	}
+	if opts.PoolSize <= 0 {
+		opts.PoolSize = runtime.NumCPU()
+	}
+	ctx := context.Background()
+	r := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(opts.MemoryLimitPages).
+		WithCloseOnContextDone(true))
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, r); err != nil {
+		_ = r.Close(ctx)
+		return nil, err
+	}
+	if err := instantiateHostModule(ctx, r); err != nil {
+		_ = r.Close(ctx)
+		return nil, err
+	}
+	compiled, err := r.CompileModule(ctx, wasmBytes)
+	if err != nil {
+		_ = r.Close(ctx)
+		return nil, err
+	}
+	exports := compiled.ExportedFunctions()
+	if _, ok := exports[FuncAlloc]; !ok {
+		_ = r.Close(ctx)
+		return nil, ErrMissingExport
+	}
+	if _, ok := exports[FuncOnMsg]; !ok {
+		_ = r.Close(ctx)
+		return nil, ErrMissingExport
+	}
+	if _, ok := compiled.ExportedMemories()["memory"]; !ok {
+		_ = r.Close(ctx)
+		return nil, ErrMissingExport
+	}
+	m := &Module{
+		runtime:  r,
+		compiled: compiled,
+		pool:     make(chan api.Module, opts.PoolSize),
+	}
+	instance, err := m.instantiate(ctx)
+	if err != nil {
+		_ = r.Close(ctx)
+		return nil, err
+	}
+	if _, ok := exports[FuncComponentInfo]; ok {
+		if err = m.readComponentInfo(ctx, instance); err != nil {
+			_ = r.Close(ctx)
+			return nil, err
+		}
+	}
+	m.put(instance)
+	return m, nil
+}
+
+// Info 组件定义
+func (m *Module) Info() ComponentInfo {
+	return m.info
+}
+
+// Close 关闭模块，释放所有实例
+func (m *Module) Close() error {
+	return m.runtime.Close(context.Background())
+}
+
+// Call 在一个实例上执行 on_msg，input 写入实例内存
+// 执行超时或者异常的实例会被丢弃，不放回实例池
+func (m *Module) Call(ctx context.Context, input []byte) (uint32, error) {
+	instance, err := m.get(ctx)
+	if err != nil {
+		return 0, err
+	}
+	results, err := instance.ExportedFunction(FuncAlloc).Call(ctx, uint64(len(input)))
+	if err != nil {
+		m.discard(instance)
+		return 0, m.wrapErr(ctx, err)
+	}
+	ptr := uint32(results[0])
+	if !instance.Memory().Write(ptr, input) {
+		m.discard(instance)
+		return 0, fmt.Errorf("write input out of memory range ptr=%d size=%d", ptr, len(input))
+	}
+	results, err = instance.ExportedFunction(FuncOnMsg).Call(ctx, uint64(ptr), uint64(len(input)))
+	if err != nil {
+		m.discard(instance)
+		return 0, m.wrapErr(ctx, err)
+	}
+	if dealloc := instance.ExportedFunction(FuncDealloc); dealloc != nil {
+		if _, err = dealloc.Call(ctx, uint64(ptr), uint64(len(input))); err != nil {
+			m.discard(instance)
+			return 0, m.wrapErr(ctx, err)
+		}
+	}
+	m.put(instance)
+	return uint32(results[0]), nil
+}
+
+func (m *Module) wrapErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ErrExecutionTimeout
+	}
+	return err
+}
+
+func (m *Module) get(ctx context.Context) (api.Module, error) {
+	select {
+	case instance := <-m.pool:
+		return instance, nil
+	default:
+		return m.instantiate(ctx)
+	}
+}
+
+func (m *Module) put(instance api.Module) {
+	select {
+	case m.pool <- instance:
+	default:
+		m.discard(instance)
+	}
+}
+
+func (m *Module) discard(instance api.Module) {
+	_ = instance.Close(context.Background())
+}
+
+func (m *Module) instantiate(ctx context.Context) (api.Module, error) {
+	return m.runtime.InstantiateModule(ctx, m.compiled, wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize"))
+}
+
+func (m *Module) readComponentInfo(ctx context.Context, instance api.Module) error {
+	results, err := instance.ExportedFunction(FuncComponentInfo).Call(ctx)
+	if err != nil {
+		return err
+	}
+	ptr, size := uint32(results[0]>>32), uint32(results[0])
+	buf, ok := instance.Memory().Read(ptr, size)
+	if !ok {
+		return fmt.Errorf("read component info out of memory range ptr=%d size=%d", ptr, size)
+	}
+	return json.Unmarshal(buf, &m.info)
+}
@@ -0,0 +1,240 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package wasm 提供纯Go实现的WebAssembly组件运行时，基于 wazero。
+// 每个 .wasm 文件是一个组件，可以跨平台加载，不受Go plugin 必须同一工具链和仅支持Linux的限制。
+//
+// 组件ABI：
+//   - 必须导出 memory、alloc(size i32) i32、on_msg(ptr i32, size i32) i32
+//   - 可选导出 dealloc(ptr i32, size i32)、component_info() i64(返回 ptr<<32|size，内容为 ComponentInfo JSON)
+//   - 可以导入 "rulego" 模块的宿主函数修改消息和路由，见 host.go
+//
+// on_msg 的输入是JSON：{"msg":{"id":"","ts":0,"type":"","dataType":"","data":""},"metadata":{},"config":{}}
+// 其中config为规则链中该节点的配置。
+//
+// 资源限制：
+//   - 每个实例内存上限由 Options.MemoryLimitPages 限制，超出后 memory.grow 失败
+//   - wazero 不提供指令计数(fuel)，每次调用的执行预算使用 Config.ScriptMaxExecutionTime 作为执行时长上限，
+//     节点可以通过 maxExecutionTime 配置覆盖，超时后实例被终止并丢弃
+//
+// 注册：
+//
+//	rulego.Registry.RegisterPlugin("myWasm", "./upper.wasm")
+//
+// 或者：
+//
+//	registry := wasm.NewPluginRegistry("./upper.wasm", wasm.Options{MemoryLimitPages: 256})
+//	_ = registry.Init()
+//	for _, node := range registry.Components() {
+//		_ = rulego.Registry.Register(node)
+//	}
+package wasm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/json"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// KeyMaxExecutionTime 节点配置：单次调用最大执行时长，例如：500ms
+const KeyMaxExecutionTime = "maxExecutionTime"
+
+// WasmNode WASM组件节点，同一个 .wasm 文件的所有节点共享一个 Module
+type WasmNode struct {
+	module *Module
+	//实例化的节点配置
+	configuration    types.Configuration
+	maxExecutionTime time.Duration
+	logger           types.Logger
+}
+
+// NewWasmNode 通过已编译的模块创建组件
+func NewWasmNode(module *Module) *WasmNode {
+	return &WasmNode{module: module}
+}
+
+// Type 组件类型
+func (x *WasmNode) Type() string {
+	return x.module.info.Type
+}
+
+func (x *WasmNode) New() types.Node {
+	return &WasmNode{module: x.module}
+}
+
+// Init 初始化
+func (x *WasmNode) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	x.configuration = make(types.Configuration)
+	for k, v := range x.module.info.Defaults {
+		x.configuration[k] = v
+	}
+	for k, v := range configuration {
+		//忽略引擎注入的内部对象
+		if !strings.HasPrefix(k, "$") {
+			x.configuration[k] = v
+		}
+	}
+	x.maxExecutionTime = ruleConfig.ScriptMaxExecutionTime
+	if v, ok := configuration[KeyMaxExecutionTime]; ok {
+		d, err := time.ParseDuration(str.ToString(v))
+		if err != nil {
+			return err
+		}
+		x.maxExecutionTime = d
+	}
+	x.logger = ruleConfig.Logger
+	return nil
+}
+
+// OnMsg 处理消息
+func (x *WasmNode) OnMsg(ctx types.RuleContext, msg types.RuleMsg) {
+	input, err := json.Marshal(map[string]interface{}{
+		"msg": map[string]interface{}{
+			"id":       msg.Id,
+			"ts":       msg.Ts,
+			"type":     msg.Type,
+			"dataType": msg.DataType,
+			"data":     msg.GetData(),
+		},
+		"metadata": msg.Metadata.Values(),
+		"config":   x.configuration,
+	})
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	state := &callState{msg: msg.Copy(), logger: x.logger}
+	callCtx := withCallState(context.Background(), state)
+	if x.maxExecutionTime > 0 {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithTimeout(callCtx, x.maxExecutionTime)
+		defer cancel()
+	}
+	code, err := x.module.Call(callCtx, input)
+	if err != nil {
+		ctx.TellFailure(msg, err)
+		return
+	}
+	if len(state.actions) == 0 {
+		if code != 0 {
+			ctx.TellFailure(state.msg, fmt.Errorf("%s returned code %d", FuncOnMsg, code))
+		} else {
+			ctx.TellSuccess(state.msg)
+		}
+		return
+	}
+	for _, item := range state.actions {
+		if item.err != nil {
+			ctx.TellFailure(item.msg, item.err)
+		} else {
+			ctx.TellNext(item.msg, item.relationType)
+		}
+	}
+}
+
+// Destroy 销毁，模块由 PluginRegistry 管理，这里不关闭
+func (x *WasmNode) Destroy() {
+}
+
+// Close 关闭共享的模块，释放运行时和实例池
+// 注销插件或者插件组件类型重复注册失败时，由组件注册器调用
+func (x *WasmNode) Close() error {
+	return x.module.Close()
+}
+
+// Def 组件定义
+func (x *WasmNode) Def() types.ComponentForm {
+	def := x.module.info.ComponentForm
+	if def.Category == "" {
+		def.Category = "wasm"
+	}
+	if def.Label == "" {
+		def.Label = def.Type
+	}
+	if def.RelationTypes == nil {
+		relationTypes := []string{types.Success, types.Failure}
+		def.RelationTypes = &relationTypes
+	}
+	if len(def.Fields) == 0 && len(x.module.info.Defaults) != 0 {
+		var fields types.ComponentFormFieldList
+		var keys []string
+		for k := range x.module.info.Defaults {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fields = append(fields, types.ComponentFormField{
+				Name:         k,
+				Type:         fieldType(x.module.info.Defaults[k]),
+				DefaultValue: x.module.info.Defaults[k],
+				Label:        k,
+			})
+		}
+		def.Fields = fields
+	}
+	return def
+}
+
+func fieldType(v interface{}) string {
+	switch v.(type) {
+	case bool:
+		return "bool"
+	case float64, int, int64:
+		return "number"
+	case map[string]interface{}:
+		return "map"
+	case []interface{}:
+		return "array"
+	default:
+		return "string"
+	}
+}
+
+// PluginRegistry 从 .wasm 文件加载组件，实现 types.PluginRegistry
+type PluginRegistry struct {
+	file   string
+	opts   Options
+	module *Module
+}
+
+// NewPluginRegistry 创建 WASM 组件插件
+func NewPluginRegistry(file string, opts Options) *PluginRegistry {
+	return &PluginRegistry{file: file, opts: opts}
+}
+
+// Init 加载并编译 .wasm 文件
+func (p *PluginRegistry) Init() error {
+	module, err := LoadModule(p.file, p.opts)
+	if err != nil {
+		return err
+	}
+	p.module = module
+	return nil
+}
+
+// Components 返回 .wasm 文件提供的组件
+func (p *PluginRegistry) Components() []types.Node {
+	if p.module == nil {
+		return nil
+	}
+	return []types.Node{NewWasmNode(p.module)}
+}
@@ -0,0 +1,304 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package wasm_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/components/wasm"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/json"
+	"github.com/rulego/rulego/utils/reflect"
+)
+
+// 测试用的组件信息，位于模块内存偏移 infoOffset
+const (
+	infoOffset = 64
+	testInfo   = `{"type":"wasm/echo","label":"Echo","relationTypes":["Success","Failure"],"defaults":{"prefix":"a"}}`
+)
+
+// on_msg 函数体
+var (
+	// echo: set_data(input); set_metadata("by","wasm"); tell_next("Success")
+	onMsgEcho = concat([]byte{0x20, 0x00, 0x20, 0x01, 0x10, 0x00},
+		i32Const(32), i32Const(2), i32Const(40), i32Const(4), []byte{0x10, 0x02},
+		i32Const(16), i32Const(7), []byte{0x10, 0x01},
+		i32Const(0), []byte{0x0b})
+	// fail: tell_failure("boom")
+	onMsgFail = concat(i32Const(48), i32Const(4), []byte{0x10, 0x03}, i32Const(0), []byte{0x0b})
+	// code: return 7
+	onMsgCode = concat(i32Const(7), []byte{0x0b})
+	// loop: 死循环
+	onMsgLoop = concat([]byte{0x03, 0x40, 0x0c, 0x00, 0x0b}, i32Const(0), []byte{0x0b})
+	// grow: memory.grow(100) 失败则 tell_failure("boom")
+	onMsgGrow = concat(i32Const(100), []byte{0x40, 0x00}, i32Const(-1), []byte{0x46, 0x04, 0x40},
+		i32Const(48), i32Const(4), []byte{0x10, 0x03, 0x0b}, i32Const(0), []byte{0x0b})
+)
+
+func TestWasmNode(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("LoadError", func(t *testing.T) {
+		_, err := wasm.NewModule([]byte("not wasm"), wasm.Options{})
+		assert.NotNil(t, err)
+		_, err = wasm.NewModule(buildModule(nil, false), wasm.Options{})
+		assert.Equal(t, wasm.ErrMissingExport, err)
+		err = wasm.NewPluginRegistry(filepath.Join(dir, "notFound.wasm"), wasm.Options{}).Init()
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Def", func(t *testing.T) {
+		module, err := wasm.NewModule(buildModule(onMsgEcho, true), wasm.Options{})
+		assert.Nil(t, err)
+		defer module.Close()
+		node := wasm.NewWasmNode(module)
+		assert.Equal(t, "wasm/echo", node.Type())
+		form := reflect.GetComponentForm(node)
+		assert.Equal(t, "wasm/echo", form.Type)
+		assert.Equal(t, "Echo", form.Label)
+		assert.Equal(t, "wasm", form.Category)
+		assert.Equal(t, []string{types.Success, types.Failure}, *form.RelationTypes)
+		assert.Equal(t, 1, len(form.Fields))
+		assert.Equal(t, "prefix", form.Fields[0].Name)
+		assert.Equal(t, "a", form.Fields[0].DefaultValue)
+
+		file := filepath.Join(dir, "noInfo.wasm")
+		assert.Nil(t, os.WriteFile(file, buildModule(onMsgEcho, false), 0644))
+		registry := wasm.NewPluginRegistry(file, wasm.Options{})
+		assert.Nil(t, registry.Init())
+		assert.Equal(t, "noInfo", registry.Components()[0].Type())
+	})
+
+	t.Run("OnMsg", func(t *testing.T) {
+		config := types.NewConfig()
+		echoNode := newNode(t, onMsgEcho, wasm.Options{}, config, types.Configuration{"prefix": "b"})
+		failNode := newNode(t, onMsgFail, wasm.Options{}, config, types.Configuration{})
+		codeNode := newNode(t, onMsgCode, wasm.Options{}, config, types.Configuration{})
+		loopNode := newNode(t, onMsgLoop, wasm.Options{}, config, types.Configuration{"maxExecutionTime": "50ms"})
+		growNode := newNode(t, onMsgGrow, wasm.Options{MemoryLimitPages: 16}, config, types.Configuration{})
+
+		metaData := types.NewMetadata()
+		metaData.PutValue("productType", "test")
+		msgs := []test.Msg{{
+			MetaData: metaData,
+			MsgType:  "TELEMETRY",
+			Data:     "{\"temperature\":41}",
+		}}
+		var wg sync.WaitGroup
+		wg.Add(5)
+		test.NodeOnMsg(t, echoNode, msgs, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.Success, relationType)
+			assert.Equal(t, "wasm", msg.Metadata.GetValue("by"))
+			var input map[string]interface{}
+			assert.Nil(t, json.Unmarshal([]byte(msg.GetData()), &input))
+			assert.Equal(t, map[string]interface{}{"prefix": "b"}, input["config"])
+			assert.Equal(t, map[string]interface{}{"productType": "test"}, input["metadata"])
+			assert.Equal(t, "{\"temperature\":41}", input["msg"].(map[string]interface{})["data"])
+			assert.Equal(t, "TELEMETRY", input["msg"].(map[string]interface{})["type"])
+		})
+		test.NodeOnMsg(t, failNode, msgs, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.Failure, relationType)
+			assert.Equal(t, "boom", err.Error())
+		})
+		test.NodeOnMsg(t, codeNode, msgs, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.Failure, relationType)
+			assert.Equal(t, "on_msg returned code 7", err.Error())
+		})
+		test.NodeOnMsg(t, loopNode, msgs, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.Failure, relationType)
+			assert.Equal(t, wasm.ErrExecutionTimeout, err)
+		})
+		test.NodeOnMsg(t, growNode, msgs, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.Failure, relationType)
+			assert.Equal(t, "boom", err.Error())
+		})
+		wg.Wait()
+
+		//超时的实例被丢弃后仍然可以继续处理
+		wg.Add(1)
+		test.NodeOnMsg(t, loopNode, msgs, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, wasm.ErrExecutionTimeout, err)
+		})
+		wg.Wait()
+	})
+
+	t.Run("RegisterPlugin", func(t *testing.T) {
+		file := filepath.Join(dir, "echo.wasm")
+		assert.Nil(t, os.WriteFile(file, buildModule(onMsgEcho, true), 0644))
+		registry := new(engine.RuleComponentRegistry)
+		assert.Nil(t, registry.RegisterPlugin("wasmEcho", file))
+		assert.NotNil(t, registry.RegisterPlugin("wasmEcho2", file))
+		forms := registry.GetComponentForms()
+		form, ok := forms["wasm/echo"]
+		assert.True(t, ok)
+		assert.Equal(t, "Echo", form.Label)
+		node, err := registry.NewNode("wasm/echo")
+		assert.Nil(t, err)
+		assert.Nil(t, node.Init(types.NewConfig(), types.Configuration{}))
+		var _ io.Closer = node.(*wasm.WasmNode)
+		assert.Nil(t, registry.Unregister("wasmEcho"))
+		_, err = registry.NewNode("wasm/echo")
+		assert.NotNil(t, err)
+
+		//注销后模块已经关闭
+		var wg sync.WaitGroup
+		wg.Add(1)
+		test.NodeOnMsg(t, node, []test.Msg{{MetaData: types.NewMetadata(), DataType: types.JSON, Data: "{}"}}, func(msg types.RuleMsg, relationType string, err error) {
+			defer wg.Done()
+			assert.Equal(t, types.Failure, relationType)
+		})
+		wg.Wait()
+	})
+}
+
+func newNode(t *testing.T, onMsg []byte, opts wasm.Options, config types.Config, configuration types.Configuration) types.Node {
+	module, err := wasm.NewModule(buildModule(onMsg, false), opts)
+	assert.Nil(t, err)
+	node := wasm.NewWasmNode(module).New()
+	assert.Nil(t, node.Init(config, configuration))
+	return node
+}
+
+// buildModule 构造测试用的WASM模块
+// 导入 rulego.set_data、tell_next、set_metadata、tell_failure，导出 memory、alloc、on_msg 和可选的 component_info
+// 如果 onMsg 为空，只导出 memory
+func buildModule(onMsg []byte, withInfo bool) []byte {
+	typeSec := vec(
+		[]byte{0x60, 0x02, 0x7f, 0x7f, 0x00},
+		[]byte{0x60, 0x04, 0x7f, 0x7f, 0x7f, 0x7f, 0x00},
+		[]byte{0x60, 0x01, 0x7f, 0x01, 0x7f},
+		[]byte{0x60, 0x02, 0x7f, 0x7f, 0x01, 0x7f},
+		[]byte{0x60, 0x00, 0x01, 0x7e},
+	)
+	importSec := vec(
+		concat(name("rulego"), name("set_data"), []byte{0x00, 0x00}),
+		concat(name("rulego"), name("tell_next"), []byte{0x00, 0x00}),
+		concat(name("rulego"), name("set_metadata"), []byte{0x00, 0x01}),
+		concat(name("rulego"), name("tell_failure"), []byte{0x00, 0x00}),
+	)
+	blob := make([]byte, 256)
+	copy(blob[16:], "Success")
+	copy(blob[32:], "by")
+	copy(blob[40:], "wasm")
+	copy(blob[48:], "boom")
+	copy(blob[infoOffset:], testInfo)
+	dataSec := vec(concat([]byte{0x00}, i32Const(0), []byte{0x0b}, vec(blobItems(blob)...)))
+	memorySec := vec([]byte{0x00, 0x01})
+	globalSec := vec(concat([]byte{0x7f, 0x01}, i32Const(1024), []byte{0x0b}))
+
+	var funcs, exports, codes [][]byte
+	exports = append(exports, concat(name("memory"), []byte{0x02, 0x00}))
+	if onMsg != nil {
+		// alloc: 返回 heap，heap += size
+		alloc := []byte{0x23, 0x00, 0x23, 0x00, 0x20, 0x00, 0x6a, 0x24, 0x00, 0x0b}
+		funcs = append(funcs, []byte{0x02}, []byte{0x03})
+		codes = append(codes, body(alloc), body(onMsg))
+		exports = append(exports, concat(name("alloc"), []byte{0x00, 0x04}), concat(name("on_msg"), []byte{0x00, 0x05}))
+		if withInfo {
+			info := concat([]byte{0x42}, sleb(int64(infoOffset)<<32|int64(len(testInfo))), []byte{0x0b})
+			funcs = append(funcs, []byte{0x04})
+			codes = append(codes, body(info))
+			exports = append(exports, concat(name("component_info"), []byte{0x00, 0x06}))
+		}
+	}
+	return concat([]byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00},
+		section(1, typeSec),
+		section(2, importSec),
+		section(3, vec(funcs...)),
+		section(5, memorySec),
+		section(6, globalSec),
+		section(7, vec(exports...)),
+		section(10, vec(codes...)),
+		section(11, dataSec),
+	)
+}
+
+func blobItems(blob []byte) [][]byte {
+	items := make([][]byte, len(blob))
+	for i, b := range blob {
+		items[i] = []byte{b}
+	}
+	return items
+}
+
+func body(code []byte) []byte {
+	b := concat([]byte{0x00}, code)
+	return concat(uleb(uint64(len(b))), b)
+}
+
+func section(id byte, content []byte) []byte {
+	return concat([]byte{id}, uleb(uint64(len(content))), content)
+}
+
+func vec(items ...[]byte) []byte {
+	return concat(uleb(uint64(len(items))), concat(items...))
+}
+
+func name(s string) []byte {
+	return concat(uleb(uint64(len(s))), []byte(s))
+}
+
+func i32Const(v int32) []byte {
+	return concat([]byte{0x41}, sleb(int64(v)))
+}
+
+func concat(parts ...[]byte) []byte {
+	var b []byte
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+func uleb(v uint64) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			c |= 0x80
+		}
+		b = append(b, c)
+		if v == 0 {
+			return b
+		}
+	}
+}
+
+func sleb(v int64) []byte {
+	var b []byte
+	for {
+		c := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && c&0x40 == 0) || (v == -1 && c&0x40 != 0) {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
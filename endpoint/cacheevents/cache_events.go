@@ -0,0 +1,424 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package cacheevents implements an endpoint triggered by cache expiry and delete events.
+// The router's 'from' is a key prefix, empty or '*' matches all keys. For example:
+//
+//	router := impl.NewRouter().From("heartbeat:").To("chain:offline").End()
+//
+// Keys written by cacheSet with level chain are stored in the global cache as
+// "<chainId>:<key>", so the prefix must include the rule chain id.
+//
+// Combined with cacheSet and a ttl, inactivity detection becomes simple:
+// every heartbeat refreshes "heartbeat:${deviceId}" with ttl 5m, and the expiry
+// event of the key triggers the offline rule chain.
+package cacheevents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/cache"
+	"github.com/rulego/rulego/utils/json"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/runtime"
+	"github.com/rulego/rulego/utils/str"
+)
+
+// Type 组件类型
+const Type = types.EndpointTypePrefix + "cacheEvents"
+
+// MatchAll 匹配所有key
+const MatchAll = "*"
+
+// 消息类型
+const (
+	// MsgTypeExpired 缓存过期消息类型
+	MsgTypeExpired = "CACHE_EXPIRED"
+	// MsgTypeDeleted 缓存删除消息类型
+	MsgTypeDeleted = "CACHE_DELETED"
+	// MsgTypeEvicted 缓存超过容量限制被淘汰消息类型
+	MsgTypeEvicted = "CACHE_EVICTED"
+)
+
+// 消息元数据key
+const (
+	KeyCacheKey   = "key"
+	KeyCacheEvent = "event"
+)
+
+// Endpoint 别名
+type Endpoint = CacheEvents
+
+// expiredCleaner 支持主动清理过期key的缓存
+type expiredCleaner interface {
+	DeleteExpired()
+}
+
+// RequestMessage 缓存事件请求消息
+type RequestMessage struct {
+	headers textproto.MIMEHeader
+	event   types.CacheEvent
+	body    []byte
+	msg     *types.RuleMsg
+	err     error
+}
+
+// Body 事件JSON：{"key":"","value":"","event":"expired","ts":0}
+func (r *RequestMessage) Body() []byte {
+	if r.body == nil {
+		r.body = eventBody(r.event)
+	}
+	return r.body
+}
+
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+// From 缓存key
+func (r *RequestMessage) From() string {
+	return r.event.Key
+}
+
+// GetParam 获取参数，支持 key、event
+func (r *RequestMessage) GetParam(key string) string {
+	switch key {
+	case KeyCacheKey:
+		return r.event.Key
+	case KeyCacheEvent:
+		return r.event.Type
+	}
+	return ""
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		metadata := types.NewMetadata()
+		metadata.PutValue(KeyCacheKey, r.event.Key)
+		metadata.PutValue(KeyCacheEvent, r.event.Type)
+		msgType := MsgTypeExpired
+		switch r.event.Type {
+		case types.CacheEventDeleted:
+			msgType = MsgTypeDeleted
+		case types.CacheEventEvicted:
+			msgType = MsgTypeEvicted
+		}
+		ruleMsg := types.NewMsg(0, msgType, types.JSON, metadata, string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+// SetStatusCode 不提供设置状态码
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+// ResponseMessage 响应消息
+type ResponseMessage struct {
+	headers textproto.MIMEHeader
+	body    []byte
+	msg     *types.RuleMsg
+	err     error
+}
+
+func (r *ResponseMessage) Body() []byte {
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+// From 不提供获取来源
+func (r *ResponseMessage) From() string {
+	return ""
+}
+
+// GetParam 不提供获取参数
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	return r.msg
+}
+
+// SetStatusCode 不提供设置状态码
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+func (r *ResponseMessage) SetError(err error) {
+	r.err = err
+}
+
+func (r *ResponseMessage) GetError() error {
+	return r.err
+}
+
+// Config 配置
+type Config struct {
+	// 监听的事件类型，多个使用逗号分隔：expired、deleted、evicted，默认expired
+	Events string
+	// 主动清理过期key的时间间隔，例如：1s。为空则依赖缓存自身的GC周期，过期事件可能延迟一个GC周期
+	CheckInterval string
+}
+
+// CacheEvents 缓存事件端点，订阅 types.Config.Cache 的过期/删除/淘汰事件，按照key前缀路由到规则链
+type CacheEvents struct {
+	impl.BaseEndpoint
+	id         string
+	Config     Config
+	RuleConfig types.Config
+	cache      types.Cache
+	events     map[string]bool
+	interval   time.Duration
+	routers    map[string]endpoint.Router
+	listenerId string
+	stop       chan struct{}
+	sync.RWMutex
+}
+
+// New 创建一个新的 CacheEvents Endpoint 实例
+func New(ruleConfig types.Config) *CacheEvents {
+	uuId, _ := uuid.NewV4()
+	return &CacheEvents{RuleConfig: ruleConfig, id: uuId.String()}
+}
+
+// Type 组件类型
+func (ep *CacheEvents) Type() string {
+	return Type
+}
+
+func (ep *CacheEvents) New() types.Node {
+	uuId, _ := uuid.NewV4()
+	return &CacheEvents{id: uuId.String(), Config: Config{Events: types.CacheEventExpired}}
+}
+
+// Init 初始化
+func (ep *CacheEvents) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	if err := maps.Map2Struct(configuration, &ep.Config); err != nil {
+		return err
+	}
+	ep.RuleConfig = ruleConfig
+	ep.cache = ruleConfig.Cache
+	if ep.cache == nil {
+		ep.cache = cache.DefaultCache
+	}
+	if _, ok := ep.cache.(types.CacheNotifier); !ok {
+		return errors.New("cache does not support events")
+	}
+	if ep.Config.Events == "" {
+		ep.Config.Events = types.CacheEventExpired
+	}
+	ep.events = make(map[string]bool)
+	for _, item := range strings.Split(ep.Config.Events, ",") {
+		item = strings.TrimSpace(item)
+		if item != types.CacheEventExpired && item != types.CacheEventDeleted && item != types.CacheEventEvicted {
+			return fmt.Errorf("unsupported event:%s", item)
+		}
+		ep.events[item] = true
+	}
+	ep.interval = 0
+	if ep.Config.CheckInterval != "" {
+		d, err := time.ParseDuration(ep.Config.CheckInterval)
+		if err != nil {
+			return err
+		} else if d <= 0 {
+			return errors.New("checkInterval must be greater than 0")
+		}
+		ep.interval = d
+	}
+	return nil
+}
+
+// Destroy 销毁
+func (ep *CacheEvents) Destroy() {
+	_ = ep.Close()
+}
+
+func (ep *CacheEvents) Close() error {
+	ep.Lock()
+	defer ep.Unlock()
+	if ep.listenerId != "" {
+		if notifier, ok := ep.cache.(types.CacheNotifier); ok {
+			notifier.RemoveListener(ep.listenerId)
+		}
+		ep.listenerId = ""
+	}
+	if ep.stop != nil {
+		close(ep.stop)
+		ep.stop = nil
+	}
+	ep.BaseEndpoint.Destroy()
+	return nil
+}
+
+func (ep *CacheEvents) Id() string {
+	return ep.id
+}
+
+func (ep *CacheEvents) AddRouter(router endpoint.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router can not nil")
+	}
+	if router.GetFrom() == nil {
+		return "", errors.New("from can not nil")
+	}
+	ep.CheckAndSetRouterId(router)
+	ep.Lock()
+	defer ep.Unlock()
+	if ep.routers == nil {
+		ep.routers = make(map[string]endpoint.Router)
+	}
+	if _, ok := ep.routers[router.GetId()]; ok {
+		return router.GetId(), fmt.Errorf("duplicate router %s", router.GetFrom().ToString())
+	}
+	ep.routers[router.GetId()] = router
+	return router.GetId(), nil
+}
+
+func (ep *CacheEvents) RemoveRouter(routerId string, params ...interface{}) error {
+	ep.Lock()
+	defer ep.Unlock()
+	if _, ok := ep.routers[routerId]; !ok {
+		return fmt.Errorf("router: %s not found", routerId)
+	}
+	delete(ep.routers, routerId)
+	return nil
+}
+
+// Start 订阅缓存事件
+func (ep *CacheEvents) Start() error {
+	if ep.cache == nil {
+		return errors.New("cache events endpoint has not been initialized yet")
+	}
+	ep.Lock()
+	defer ep.Unlock()
+	if ep.listenerId != "" {
+		return nil
+	}
+	ep.listenerId = ep.cache.(types.CacheNotifier).AddListener(ep.handler)
+	if cleaner, ok := ep.cache.(expiredCleaner); ok && ep.interval > 0 {
+		ep.stop = make(chan struct{})
+		go ep.check(cleaner, ep.interval, ep.stop)
+	}
+	return nil
+}
+
+func (ep *CacheEvents) Printf(format string, v ...interface{}) {
+	if ep.RuleConfig.Logger != nil {
+		ep.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+// check 定时清理过期key，触发过期事件
+func (ep *CacheEvents) check(cleaner expiredCleaner, interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			cleaner.DeleteExpired()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// handler 处理缓存事件，交给所有匹配key前缀的路由处理
+func (ep *CacheEvents) handler(event types.CacheEvent) {
+	if !ep.events[event.Type] {
+		return
+	}
+	defer func() {
+		//捕捉异常
+		if e := recover(); e != nil {
+			ep.Printf("cache events endpoint handler err :\n%v", runtime.Stack())
+		}
+	}()
+	ep.RLock()
+	var routers []endpoint.Router
+	for _, router := range ep.routers {
+		from := router.GetFrom().ToString()
+		if from == "" || from == MatchAll || strings.HasPrefix(event.Key, from) {
+			routers = append(routers, router)
+		}
+	}
+	ep.RUnlock()
+	for _, router := range routers {
+		exchange := &endpoint.Exchange{
+			In:  &RequestMessage{event: event},
+			Out: &ResponseMessage{},
+		}
+		ep.DoProcess(context.Background(), router, exchange)
+	}
+}
+
+// eventBody 事件转换成JSON
+func eventBody(event types.CacheEvent) []byte {
+	body := map[string]interface{}{
+		KeyCacheKey:   event.Key,
+		"value":       event.Value,
+		KeyCacheEvent: event.Type,
+		"ts":          event.Ts,
+	}
+	if data, err := json.Marshal(body); err == nil {
+		return data
+	}
+	body["value"] = str.ToString(event.Value)
+	data, _ := json.Marshal(body)
+	return data
+}
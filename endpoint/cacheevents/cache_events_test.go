@@ -0,0 +1,151 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cacheevents
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/cache"
+)
+
+// 测试请求/响应消息
+func TestMessage(t *testing.T) {
+	t.Run("Request", func(t *testing.T) {
+		var request = &RequestMessage{}
+		test.EndpointMessage(t, request)
+	})
+	t.Run("Response", func(t *testing.T) {
+		var response = &ResponseMessage{}
+		test.EndpointMessage(t, response)
+	})
+}
+
+func TestCacheEventsEndpoint(t *testing.T) {
+	t.Run("InitError", func(t *testing.T) {
+		for _, configuration := range []types.Configuration{
+			{"events": "set"},
+			{"checkInterval": "abc"},
+			{"checkInterval": "-1s"},
+		} {
+			ep := &Endpoint{}
+			assert.NotNil(t, ep.Init(types.NewConfig(), configuration))
+		}
+	})
+
+	t.Run("Route", func(t *testing.T) {
+		c := cache.NewMemoryCache(time.Minute)
+		config := types.NewConfig(types.WithCache(c))
+		ep := &Endpoint{}
+		err := ep.Init(config, types.Configuration{"events": "expired,deleted", "checkInterval": "20ms"})
+		assert.Nil(t, err)
+		assert.Equal(t, Type, ep.Type())
+
+		var mu sync.Mutex
+		var heartbeat, all []types.RuleMsg
+		_, err = ep.AddRouter(impl.NewRouter().From("heartbeat:").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			heartbeat = append(heartbeat, *exchange.In.GetMsg())
+			assert.Equal(t, exchange.In.GetMsg().Metadata.GetValue(KeyCacheKey), exchange.In.GetParam(KeyCacheKey))
+			return true
+		}).End())
+		assert.Nil(t, err)
+		allId, err := ep.AddRouter(impl.NewRouter().SetId("all").From(MatchAll).Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			all = append(all, *exchange.In.GetMsg())
+			return true
+		}).End())
+		assert.Nil(t, err)
+		_, err = ep.AddRouter(impl.NewRouter().SetId("all").From(MatchAll).End())
+		assert.NotNil(t, err)
+
+		err = ep.Start()
+		assert.Nil(t, err)
+
+		_ = c.Set("heartbeat:d1", "online", "50ms")
+		_ = c.Set("other:d1", 1, "")
+		_ = c.Delete("other:d1")
+		time.Sleep(time.Millisecond * 200)
+
+		mu.Lock()
+		assert.Equal(t, 1, len(heartbeat))
+		assert.Equal(t, MsgTypeExpired, heartbeat[0].Type)
+		assert.Equal(t, "heartbeat:d1", heartbeat[0].Metadata.GetValue(KeyCacheKey))
+		assert.Equal(t, types.CacheEventExpired, heartbeat[0].Metadata.GetValue(KeyCacheEvent))
+		data, _ := heartbeat[0].GetDataAsJson()
+		assert.Equal(t, "online", data["value"])
+		assert.Equal(t, 2, len(all))
+		assert.Equal(t, MsgTypeDeleted, all[0].Type)
+		mu.Unlock()
+
+		err = ep.RemoveRouter(allId)
+		assert.Nil(t, err)
+		assert.NotNil(t, ep.RemoveRouter(allId))
+
+		ep.Destroy()
+		_ = c.Set("heartbeat:d2", "online", "")
+		_ = c.Delete("heartbeat:d2")
+		mu.Lock()
+		assert.Equal(t, 1, len(heartbeat))
+		mu.Unlock()
+	})
+
+	t.Run("IgnoreDeleted", func(t *testing.T) {
+		c := cache.NewMemoryCache(time.Minute)
+		ep := New(types.NewConfig(types.WithCache(c)))
+		err := ep.Init(ep.RuleConfig, nil)
+		assert.Nil(t, err)
+		var count int
+		_, _ = ep.AddRouter(impl.NewRouter().From("").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+			count++
+			return true
+		}).End())
+		assert.Nil(t, ep.Start())
+		_ = c.Set("key", "v", "")
+		_ = c.Delete("key")
+		assert.Equal(t, 0, count)
+		ep.Destroy()
+	})
+
+	t.Run("Evicted", func(t *testing.T) {
+		c := cache.NewMemoryCache(time.Minute, cache.WithMaxEntries(1))
+		ep := New(types.NewConfig(types.WithCache(c)))
+		err := ep.Init(ep.RuleConfig, types.Configuration{"events": "evicted"})
+		assert.Nil(t, err)
+		var msgs []types.RuleMsg
+		_, _ = ep.AddRouter(impl.NewRouter().From("").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+			msgs = append(msgs, *exchange.In.GetMsg())
+			return true
+		}).End())
+		assert.Nil(t, ep.Start())
+		_ = c.Set("k1", "v", "")
+		_ = c.Set("k2", "v", "")
+		_ = c.Delete("k2")
+		assert.Equal(t, 1, len(msgs))
+		assert.Equal(t, MsgTypeEvicted, msgs[0].Type)
+		assert.Equal(t, "k1", msgs[0].Metadata.GetValue(KeyCacheKey))
+		ep.Destroy()
+	})
+}
@@ -0,0 +1,712 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package dbpoll implements an endpoint that polls a database table for new rows.
+// The router's 'from' is a cron expression with the same format as the schedule endpoint. For example:
+//
+//	router := impl.NewRouter().From("*/5 * * * * *").To("chain:orders").End()
+//
+// Each poll runs the configured query with the last watermark bound to every
+// ":watermark" placeholder, for example:
+//
+//	select id, status from orders where id > :watermark order by id limit 100
+//
+// Placeholders inside string literals and comments, "::watermark" casts and
+// longer identifiers such as ":watermarkTime" are left untouched.
+//
+// The watermark is the value of the watermark column of the last emitted row.
+// It is only advanced after the rule chain finishes without error, so rows
+// whose processing failed are polled again. The rule chain is always executed
+// synchronously.
+//
+// The watermark must survive restarts, otherwise every row after the initial
+// watermark is emitted again. Set WatermarkTable to store it in a table of the
+// polled database, or provide a persistent types.Config.Cache. The default
+// in-process memory cache loses the watermark when the process exits, and the
+// endpoint logs a warning when it is used.
+package dbpoll
+
+import (
+	"context"
+	"database/sql"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/gofrs/uuid/v5"
+	_ "github.com/lib/pq"
+	"github.com/robfig/cron/v3"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/endpoint/schedule"
+	"github.com/rulego/rulego/utils/cache"
+	"github.com/rulego/rulego/utils/json"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/runtime"
+	"github.com/rulego/rulego/utils/str"
+	_ "modernc.org/sqlite"
+)
+
+// Type 组件类型
+const Type = types.EndpointTypePrefix + "dbPoll"
+
+// WatermarkPlaceholder 查询语句中的水位占位符
+const WatermarkPlaceholder = ":watermark"
+
+// WatermarkKeyPrefix 水位在缓存中的key前缀
+const WatermarkKeyPrefix = "dbPoll:"
+
+// 消息类型
+const (
+	// MsgTypeRow 每行一条消息，消息负荷为行JSON对象
+	MsgTypeRow = "DB_POLL_ROW"
+	// MsgTypeBatch 每次查询一条消息，消息负荷为行JSON数组
+	MsgTypeBatch = "DB_POLL_BATCH"
+)
+
+// 消息元数据key
+const (
+	// KeyWatermark 消息对应的水位，批量模式为最后一行的水位
+	KeyWatermark = "watermark"
+	// KeyRowCount 消息包含的行数
+	KeyRowCount = "rowCount"
+)
+
+// Endpoint 别名
+type Endpoint = DbPoll
+
+// RequestMessage 查询结果请求消息
+type RequestMessage struct {
+	headers   textproto.MIMEHeader
+	body      []byte
+	msgType   string
+	watermark interface{}
+	rowCount  int
+	msg       *types.RuleMsg
+	err       error
+}
+
+func (r *RequestMessage) Body() []byte {
+	return r.body
+}
+
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+// From 不提供获取来源
+func (r *RequestMessage) From() string {
+	return ""
+}
+
+// GetParam 获取参数，支持 watermark、rowCount
+func (r *RequestMessage) GetParam(key string) string {
+	switch key {
+	case KeyWatermark:
+		return str.ToString(r.watermark)
+	case KeyRowCount:
+		return strconv.Itoa(r.rowCount)
+	}
+	return ""
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		metadata := types.NewMetadata()
+		metadata.PutValue(KeyWatermark, r.GetParam(KeyWatermark))
+		metadata.PutValue(KeyRowCount, r.GetParam(KeyRowCount))
+		ruleMsg := types.NewMsg(0, r.msgType, types.JSON, metadata, string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+// SetStatusCode 不提供设置状态码
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+// ResponseMessage 响应消息，记录规则链执行结果
+type ResponseMessage struct {
+	headers textproto.MIMEHeader
+	body    []byte
+	msg     *types.RuleMsg
+	err     error
+	mu      sync.Mutex
+}
+
+func (r *ResponseMessage) Body() []byte {
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+// From 不提供获取来源
+func (r *ResponseMessage) From() string {
+	return ""
+}
+
+// GetParam 不提供获取参数
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	return r.msg
+}
+
+// SetStatusCode 不提供设置状态码
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError 设置错误，规则链多个分支结束时只保留第一个错误
+func (r *ResponseMessage) SetError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil || err == nil {
+		r.err = err
+	}
+}
+
+func (r *ResponseMessage) GetError() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Config 配置
+type Config struct {
+	// DriverName 数据库驱动名称，mysql、postgres或sqlite
+	DriverName string
+	// Dsn 数据库连接配置，参考sql.Open参数
+	Dsn string
+	// PoolSize 连接池大小
+	PoolSize int
+	// Query 查询语句，使用 :watermark 引用上一次的水位，需要按照水位列升序排序，例如：
+	// select id, status from orders where id > :watermark order by id limit 100
+	Query string
+	// WatermarkColumn 水位列，查询结果必须包含该列
+	WatermarkColumn string
+	// InitialWatermark 没有持久化的水位时使用的初始水位，例如：0
+	InitialWatermark interface{}
+	// WatermarkKey 水位的key，为空则根据驱动、连接配置和查询语句生成
+	// 修改查询语句后需要保留原有水位，则需要配置该字段
+	WatermarkKey string
+	// WatermarkTable 水位持久化表名，配置后水位保存在被轮询的数据库中，表不存在则自动创建。
+	// 为空则保存在 types.Config.Cache，默认的内存缓存在进程重启后丢失水位
+	WatermarkTable string
+	// Batch 是否批量发送，true:每次查询结果作为一条消息，消息负荷为数组，false:每行一条消息
+	Batch bool
+}
+
+// DbPoll 数据库轮询端点，按照cron表达式定时查询新增的行，并路由到规则链
+type DbPoll struct {
+	impl.BaseEndpoint
+	id         string
+	Config     Config
+	RuleConfig types.Config
+	cron       *cron.Cron
+	db         *sql.DB
+	cache      types.Cache
+	//转换后的查询语句
+	query string
+	//水位占位符数量
+	paramCount   int
+	watermarkKey string
+	watermark    interface{}
+	loaded       bool
+	//是否正在轮询，上一次轮询未结束则跳过本次轮询
+	polling int32
+	//保护水位和数据库连接
+	mu sync.Mutex
+}
+
+// New 创建一个新的 DbPoll Endpoint 实例
+func New(ruleConfig types.Config) *DbPoll {
+	uuId, _ := uuid.NewV4()
+	return &DbPoll{RuleConfig: ruleConfig, cron: schedule.NewCron(), id: uuId.String()}
+}
+
+// Type 组件类型
+func (ep *DbPoll) Type() string {
+	return Type
+}
+
+func (ep *DbPoll) New() types.Node {
+	uuId, _ := uuid.NewV4()
+	return &DbPoll{cron: schedule.NewCron(), id: uuId.String(), Config: Config{DriverName: "mysql"}}
+}
+
+// Init 初始化
+func (ep *DbPoll) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	if err := maps.Map2Struct(configuration, &ep.Config); err != nil {
+		return err
+	}
+	ep.RuleConfig = ruleConfig
+	if ep.Config.DriverName == "" {
+		ep.Config.DriverName = "mysql"
+	}
+	if strings.TrimSpace(ep.Config.Query) == "" {
+		return errors.New("query can not empty")
+	}
+	if ep.Config.WatermarkColumn == "" {
+		return errors.New("watermarkColumn can not empty")
+	}
+	ep.query, ep.paramCount = parseWatermark(ep.Config.Query, ep.Config.DriverName)
+	if ep.paramCount == 0 {
+		return fmt.Errorf("query must contain %s placeholder", WatermarkPlaceholder)
+	}
+
+	ep.watermarkKey = ep.Config.WatermarkKey
+	if ep.watermarkKey == "" {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(ep.Config.DriverName + "\n" + ep.Config.Dsn + "\n" + ep.Config.Query))
+		ep.watermarkKey = fmt.Sprintf("%x", h.Sum64())
+	}
+	ep.watermarkKey = WatermarkKeyPrefix + ep.watermarkKey
+
+	ep.cache = ruleConfig.Cache
+	if ep.cache == nil {
+		ep.cache = cache.DefaultCache
+	}
+	if _, ok := ep.cache.(*cache.MemoryCache); ok && ep.Config.WatermarkTable == "" {
+		ep.Printf("dbPoll endpoint warn: watermark is kept in memory cache and will be lost on restart, configure watermarkTable or a persistent cache")
+	}
+	ep.loaded = false
+	return nil
+}
+
+// Destroy 销毁
+func (ep *DbPoll) Destroy() {
+	_ = ep.Close()
+}
+
+func (ep *DbPoll) Close() error {
+	if ep.cron != nil {
+		<-ep.cron.Stop().Done()
+		ep.cron = nil
+	}
+	ep.mu.Lock()
+	if ep.db != nil {
+		_ = ep.db.Close()
+		ep.db = nil
+	}
+	ep.mu.Unlock()
+	ep.BaseEndpoint.Destroy()
+	return nil
+}
+
+func (ep *DbPoll) Id() string {
+	return ep.id
+}
+
+// AddRouter 添加路由，from为cron表达式。规则链强制同步执行，执行结束后才能确定是否推进水位
+func (ep *DbPoll) AddRouter(router endpoint.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router can not nil")
+	}
+	if router.GetFrom() == nil {
+		return "", errors.New("from can not nil")
+	}
+	if ep.cron == nil {
+		ep.cron = schedule.NewCron()
+	}
+	if to := router.GetFrom().GetTo(); to != nil {
+		to.Wait()
+	}
+	id, err := ep.cron.AddFunc(router.GetFrom().ToString(), func() {
+		ep.Poll(router)
+	})
+	if err != nil {
+		return "", err
+	}
+	idStr := strconv.Itoa(int(id))
+	router.SetId(idStr)
+	return idStr, nil
+}
+
+func (ep *DbPoll) RemoveRouter(routeId string, params ...interface{}) error {
+	entryID, err := strconv.Atoi(routeId)
+	if err != nil {
+		return fmt.Errorf("%s it is an illegal routing id", routeId)
+	}
+	if ep.cron != nil {
+		ep.cron.Remove(cron.EntryID(entryID))
+	}
+	return nil
+}
+
+// Start 连接数据库并开始定时轮询
+func (ep *DbPoll) Start() error {
+	if ep.cron == nil {
+		return errors.New("cron has not been initialized yet")
+	}
+	if _, err := ep.getDb(); err != nil {
+		return err
+	}
+	ep.cron.Start()
+	return nil
+}
+
+// Watermark 返回当前水位
+func (ep *DbPoll) Watermark() interface{} {
+	watermark, err := ep.currentWatermark()
+	if err != nil {
+		ep.Printf("dbPoll endpoint load watermark err:%v", err)
+	}
+	return watermark
+}
+
+func (ep *DbPoll) Printf(format string, v ...interface{}) {
+	if ep.RuleConfig.Logger != nil {
+		ep.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+// Poll 执行一次轮询，把新增的行交给路由处理。上一次轮询未结束则直接返回
+func (ep *DbPoll) Poll(router endpoint.Router) {
+	if !atomic.CompareAndSwapInt32(&ep.polling, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&ep.polling, 0)
+	defer func() {
+		//捕捉异常
+		if e := recover(); e != nil {
+			ep.Printf("dbPoll endpoint handler err :\n%v", runtime.Stack())
+		}
+	}()
+	rows, err := ep.queryRows()
+	if err != nil {
+		ep.Printf("dbPoll endpoint query err:%v", err)
+		return
+	}
+	if len(rows) == 0 {
+		return
+	}
+	if _, ok := rows[0][ep.Config.WatermarkColumn]; !ok {
+		ep.Printf("dbPoll endpoint err: watermark column %s not found", ep.Config.WatermarkColumn)
+		return
+	}
+	if ep.Config.Batch {
+		watermark := rows[len(rows)-1][ep.Config.WatermarkColumn]
+		if err = ep.process(router, MsgTypeBatch, rows, watermark, len(rows)); err == nil {
+			ep.advance(watermark)
+		}
+		return
+	}
+	for _, row := range rows {
+		watermark := row[ep.Config.WatermarkColumn]
+		if err = ep.process(router, MsgTypeRow, row, watermark, 1); err != nil {
+			//失败的行和后续的行在下一次轮询重新处理
+			return
+		}
+		ep.advance(watermark)
+	}
+}
+
+// process 交给路由处理，返回规则链执行错误
+func (ep *DbPoll) process(router endpoint.Router, msgType string, data interface{}, watermark interface{}, rowCount int) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	exchange := &endpoint.Exchange{
+		In: &RequestMessage{
+			body:      body,
+			msgType:   msgType,
+			watermark: watermark,
+			rowCount:  rowCount,
+		},
+		Out: &ResponseMessage{}}
+	ep.DoProcess(context.Background(), router, exchange)
+	if err = exchange.Out.GetError(); err != nil {
+		ep.Printf("dbPoll endpoint process err:%v", err)
+	}
+	return err
+}
+
+// getDb 获取数据库连接
+func (ep *DbPoll) getDb() (*sql.DB, error) {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	if ep.db != nil {
+		return ep.db, nil
+	}
+	db, err := sql.Open(ep.Config.DriverName, ep.Config.Dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(ep.Config.PoolSize)
+	db.SetMaxIdleConns(ep.Config.PoolSize / 2)
+	if err = db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	if ep.Config.WatermarkTable != "" {
+		createSql := "CREATE TABLE IF NOT EXISTS " + ep.Config.WatermarkTable +
+			" (watermark_key VARCHAR(255) NOT NULL PRIMARY KEY, watermark TEXT)"
+		if _, err = db.Exec(createSql); err != nil {
+			_ = db.Close()
+			return nil, err
+		}
+	}
+	ep.db = db
+	return db, nil
+}
+
+// queryRows 使用当前水位查询新增的行
+func (ep *DbPoll) queryRows() ([]map[string]interface{}, error) {
+	db, err := ep.getDb()
+	if err != nil {
+		return nil, err
+	}
+	watermark, err := ep.currentWatermark()
+	if err != nil {
+		return nil, err
+	}
+	params := make([]interface{}, ep.paramCount)
+	for i := range params {
+		params[i] = watermark
+	}
+	rows, err := db.Query(ep.query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var result []map[string]interface{}
+	values := make([]interface{}, len(columns))
+	for rows.Next() {
+		for i := range values {
+			values[i] = new(interface{})
+		}
+		if err = rows.Scan(values...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			v := *(values[i].(*interface{}))
+			// 如果值是 []byte 类型，转换成 string 类型
+			if b, ok := v.([]byte); ok {
+				v = string(b)
+			}
+			row[column] = v
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// currentWatermark 返回当前水位，第一次调用时从持久化存储加载
+func (ep *DbPoll) currentWatermark() (interface{}, error) {
+	if ep.Config.WatermarkTable != "" {
+		//水位表在被轮询的数据库中
+		if _, err := ep.getDb(); err != nil {
+			return nil, err
+		}
+	}
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	if err := ep.loadWatermark(); err != nil {
+		return nil, err
+	}
+	return ep.watermark, nil
+}
+
+// loadWatermark 从水位表或者缓存加载水位，调用方需要持有锁
+func (ep *DbPoll) loadWatermark() error {
+	if ep.loaded {
+		return nil
+	}
+	ep.watermark = ep.Config.InitialWatermark
+	if ep.Config.WatermarkTable != "" {
+		if ep.db == nil {
+			return errors.New("database is not connected")
+		}
+		var value sql.NullString
+		query := str.ConvertDollarPlaceholder("SELECT watermark FROM "+ep.Config.WatermarkTable+" WHERE watermark_key = ?", ep.Config.DriverName)
+		err := ep.db.QueryRow(query, ep.watermarkKey).Scan(&value)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if value.Valid {
+			if ep.watermark, err = decodeWatermark(value.String); err != nil {
+				return err
+			}
+		}
+	} else if v := ep.cache.Get(ep.watermarkKey); v != nil {
+		ep.watermark = v
+	}
+	ep.loaded = true
+	return nil
+}
+
+// advance 推进并持久化水位
+func (ep *DbPoll) advance(watermark interface{}) {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	ep.watermark = watermark
+	ep.loaded = true
+	if err := ep.saveWatermark(watermark); err != nil {
+		ep.Printf("dbPoll endpoint save watermark err:%v", err)
+	}
+}
+
+// saveWatermark 保存水位到水位表或者缓存，调用方需要持有锁
+func (ep *DbPoll) saveWatermark(watermark interface{}) error {
+	if ep.Config.WatermarkTable == "" {
+		return ep.cache.Set(ep.watermarkKey, watermark, "")
+	}
+	if ep.db == nil {
+		return errors.New("database is not connected")
+	}
+	value, err := stdjson.Marshal(watermark)
+	if err != nil {
+		return err
+	}
+	updateSql := str.ConvertDollarPlaceholder("UPDATE "+ep.Config.WatermarkTable+" SET watermark = ? WHERE watermark_key = ?", ep.Config.DriverName)
+	result, err := ep.db.Exec(updateSql, string(value), ep.watermarkKey)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+	insertSql := str.ConvertDollarPlaceholder("INSERT INTO "+ep.Config.WatermarkTable+" (watermark_key, watermark) VALUES (?, ?)", ep.Config.DriverName)
+	_, err = ep.db.Exec(insertSql, ep.watermarkKey, string(value))
+	return err
+}
+
+// decodeWatermark 解析水位表中JSON格式的水位，整数转换成int64
+func decodeWatermark(value string) (interface{}, error) {
+	decoder := stdjson.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	if number, ok := v.(stdjson.Number); ok {
+		if i, err := number.Int64(); err == nil {
+			return i, nil
+		}
+		return number.Float64()
+	}
+	return v, nil
+}
+
+// parseWatermark 把查询语句中的 :watermark 占位符转换成驱动的占位符，返回转换后的语句和占位符数量。
+// 忽略引号和注释中的内容、:: 类型转换以及以 :watermark 开头的其他标识符
+func parseWatermark(query, driverName string) (string, int) {
+	var sb strings.Builder
+	count := 0
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := i + 1
+			for end < len(query) && query[end] != c {
+				if query[end] == '\\' && end+1 < len(query) {
+					end++
+				}
+				end++
+			}
+			if end < len(query) {
+				end++
+			}
+			sb.WriteString(query[i:end])
+			i = end - 1
+			continue
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			sb.WriteString(query[i : i+end])
+			i += end - 1
+			continue
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				end = len(query) - i
+			} else {
+				end += 4
+			}
+			sb.WriteString(query[i : i+end])
+			i += end - 1
+			continue
+		case c == ':' && strings.HasPrefix(query[i:], WatermarkPlaceholder) && (i == 0 || query[i-1] != ':'):
+			next := i + len(WatermarkPlaceholder)
+			if next >= len(query) || !isIdentChar(query[next]) {
+				count++
+				if driverName == "postgres" {
+					sb.WriteString("$" + strconv.Itoa(count))
+				} else {
+					sb.WriteByte('?')
+				}
+				i = next - 1
+				continue
+			}
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String(), count
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
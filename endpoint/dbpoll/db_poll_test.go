@@ -0,0 +1,397 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dbpoll
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/cache"
+)
+
+// 处理id为failId的消息时抛出异常
+var chainDsl = `{
+  "ruleChain": {"id": "dbPollChain", "name": "dbPoll"},
+  "metadata": {
+    "nodes": [
+      {
+        "id": "s1",
+        "type": "jsTransform",
+        "configuration": {
+          "jsScript": "if (String(msg.id) === metadata.failId || (Array.isArray(msg) && msg.length > 1 && metadata.failId)) { throw 'process failed' } return {'msg':msg,'metadata':metadata,'msgType':msgType};"
+        }
+      }
+    ],
+    "connections": []
+  }
+}`
+
+// 测试请求/响应消息
+func TestMessage(t *testing.T) {
+	t.Run("Request", func(t *testing.T) {
+		var request = &RequestMessage{}
+		test.EndpointMessage(t, request)
+	})
+	t.Run("Response", func(t *testing.T) {
+		var response = &ResponseMessage{}
+		test.EndpointMessage(t, response)
+	})
+}
+
+// testLogger 记录日志内容
+type testLogger struct {
+	mu   sync.Mutex
+	logs []string
+}
+
+func (l *testLogger) Printf(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.logs = append(l.logs, fmt.Sprintf(format, v...))
+}
+
+func newTestDb(t *testing.T) (string, *sql.DB) {
+	dsn := filepath.Join(t.TempDir(), "test.db")
+	db, err := sql.Open("sqlite", dsn)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+	_, err = db.Exec("CREATE TABLE orders (id INTEGER PRIMARY KEY, status TEXT NOT NULL)")
+	assert.Nil(t, err)
+	for i := 1; i <= 3; i++ {
+		_, err = db.Exec("INSERT INTO orders (id, status) VALUES (?, ?)", i, "new")
+		assert.Nil(t, err)
+	}
+	return dsn, db
+}
+
+func TestDbPollEndpoint(t *testing.T) {
+	t.Run("InitError", func(t *testing.T) {
+		for _, configuration := range []types.Configuration{
+			{"watermarkColumn": "id"},
+			{"query": "select * from orders", "watermarkColumn": "id"},
+			{"query": "select * from orders where id > :watermark"},
+		} {
+			ep := &Endpoint{}
+			assert.NotNil(t, ep.Init(types.NewConfig(), configuration))
+		}
+		ep := &Endpoint{}
+		err := ep.Init(types.NewConfig(), types.Configuration{
+			"driverName":      "postgres",
+			"query":           "select * from orders where id > :watermark or (id = :watermark and 1=1)",
+			"watermarkColumn": "id",
+			"watermarkKey":    "orders",
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, "select * from orders where id > $1 or (id = $2 and 1=1)", ep.query)
+		assert.Equal(t, 2, ep.paramCount)
+		assert.Equal(t, WatermarkKeyPrefix+"orders", ep.watermarkKey)
+
+		//内存缓存不能持久化水位
+		logger := &testLogger{}
+		ep = &Endpoint{}
+		err = ep.Init(types.NewConfig(types.WithLogger(logger)), types.Configuration{
+			"query":           "select * from orders where id > :watermark",
+			"watermarkColumn": "id",
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(logger.logs))
+		logger.logs = nil
+		err = ep.Init(types.NewConfig(types.WithLogger(logger)), types.Configuration{
+			"query":           "select * from orders where id > :watermark",
+			"watermarkColumn": "id",
+			"watermarkTable":  "poll_watermark",
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(logger.logs))
+	})
+
+	t.Run("ParseWatermark", func(t *testing.T) {
+		query, count := parseWatermark("select * from t where id > :watermark and note <> ':watermark' -- :watermark\n"+
+			"and ts > :watermarkTime and id::watermark is null /* :watermark */ or id = :watermark", "mysql")
+		assert.Equal(t, "select * from t where id > ? and note <> ':watermark' -- :watermark\n"+
+			"and ts > :watermarkTime and id::watermark is null /* :watermark */ or id = ?", query)
+		assert.Equal(t, 2, count)
+		query, count = parseWatermark(`select '?', 'it\'s :watermark' from t where id > :watermark and id < :watermark+10`, "postgres")
+		assert.Equal(t, `select '?', 'it\'s :watermark' from t where id > $1 and id < $2+10`, query)
+		assert.Equal(t, 2, count)
+		query, count = parseWatermark("select ':watermark", "mysql")
+		assert.Equal(t, "select ':watermark", query)
+		assert.Equal(t, 0, count)
+	})
+
+	t.Run("WatermarkTable", func(t *testing.T) {
+		dsn, db := newTestDb(t)
+		newEndpoint := func() *DbPoll {
+			//每次使用新的内存缓存，模拟进程重启
+			config := engine.NewConfig(types.WithCache(cache.NewMemoryCache(time.Minute)), types.WithDefaultPool())
+			ep := New(config)
+			err := ep.Init(config, types.Configuration{
+				"driverName":       "sqlite",
+				"dsn":              dsn,
+				"query":            "select id from orders where id > :watermark order by id",
+				"watermarkColumn":  "id",
+				"initialWatermark": 0,
+				"watermarkTable":   "poll_watermark",
+			})
+			assert.Nil(t, err)
+			return ep
+		}
+		var count int
+		router := impl.NewRouter().From("@every 1h").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+			count++
+			return true
+		}).End()
+
+		ep := newEndpoint()
+		assert.Equal(t, 0, ep.Watermark())
+		ep.Poll(router)
+		assert.Equal(t, 3, count)
+		assert.Equal(t, int64(3), ep.Watermark())
+		ep.Destroy()
+
+		ep = newEndpoint()
+		defer ep.Destroy()
+		assert.Equal(t, int64(3), ep.Watermark())
+		_, err := db.Exec("INSERT INTO orders (id, status) VALUES (4, 'new')")
+		assert.Nil(t, err)
+		ep.Poll(router)
+		assert.Equal(t, 4, count)
+		var value string
+		assert.Nil(t, db.QueryRow("SELECT watermark FROM poll_watermark").Scan(&value))
+		assert.Equal(t, "4", value)
+	})
+
+	t.Run("Rows", func(t *testing.T) {
+		dsn, db := newTestDb(t)
+		c := cache.NewMemoryCache(time.Minute)
+		config := engine.NewConfig(types.WithCache(c), types.WithDefaultPool())
+		ruleEngine, err := engine.New("dbPollChain", []byte(chainDsl), engine.WithConfig(config))
+		assert.Nil(t, err)
+		defer ruleEngine.Stop()
+
+		ep := New(config)
+		err = ep.Init(config, types.Configuration{
+			"driverName":       "sqlite",
+			"dsn":              dsn,
+			"query":            "select id, status from orders where id > :watermark order by id limit 10",
+			"watermarkColumn":  "id",
+			"initialWatermark": 0,
+			"watermarkKey":     "orders",
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, Type, ep.Type())
+
+		var mu sync.Mutex
+		var msgs []types.RuleMsg
+		failId := "2"
+		router := impl.NewRouter().From("@every 1h").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+			msg := exchange.In.GetMsg()
+			msg.Metadata.PutValue("failId", failId)
+			mu.Lock()
+			msgs = append(msgs, *msg)
+			mu.Unlock()
+			return true
+		}).To("chain:dbPollChain").End()
+		routerId, err := ep.AddRouter(router)
+		assert.Nil(t, err)
+		assert.True(t, router.GetFrom().GetTo().IsWait())
+		assert.Nil(t, ep.Start())
+
+		//第2行处理失败，水位停留在第1行
+		ep.Poll(router)
+		assert.Equal(t, 2, len(msgs))
+		assert.Equal(t, MsgTypeRow, msgs[0].Type)
+		assert.Equal(t, `{"id":1,"status":"new"}`, msgs[0].GetData())
+		assert.Equal(t, "1", msgs[0].Metadata.GetValue(KeyWatermark))
+		assert.Equal(t, "1", msgs[0].Metadata.GetValue(KeyRowCount))
+		assert.Equal(t, int64(1), ep.Watermark())
+		assert.Equal(t, int64(1), c.Get(WatermarkKeyPrefix+"orders"))
+
+		//下一次轮询从失败的行开始
+		failId = ""
+		ep.Poll(router)
+		assert.Equal(t, 4, len(msgs))
+		assert.Equal(t, `{"id":2,"status":"new"}`, msgs[2].GetData())
+		assert.Equal(t, int64(3), ep.Watermark())
+
+		//没有新增的行
+		ep.Poll(router)
+		assert.Equal(t, 4, len(msgs))
+
+		_, err = db.Exec("INSERT INTO orders (id, status) VALUES (4, 'new')")
+		assert.Nil(t, err)
+		ep.Poll(router)
+		assert.Equal(t, 5, len(msgs))
+		assert.Equal(t, "4", msgs[4].Metadata.GetValue(KeyWatermark))
+		assert.Nil(t, ep.RemoveRouter(routerId))
+		assert.NotNil(t, ep.RemoveRouter("abc"))
+		ep.Destroy()
+
+		//重启后从持久化的水位继续
+		ep = New(config)
+		err = ep.Init(config, types.Configuration{
+			"driverName":       "sqlite",
+			"dsn":              dsn,
+			"query":            "select id, status from orders where id > :watermark order by id",
+			"watermarkColumn":  "id",
+			"initialWatermark": 0,
+			"watermarkKey":     "orders",
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(4), ep.Watermark())
+		ep.Destroy()
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		dsn, _ := newTestDb(t)
+		c := cache.NewMemoryCache(time.Minute)
+		config := engine.NewConfig(types.WithCache(c), types.WithDefaultPool())
+		ruleEngine, err := engine.New("dbPollChain", []byte(chainDsl), engine.WithConfig(config))
+		assert.Nil(t, err)
+		defer ruleEngine.Stop()
+
+		ep := New(config)
+		err = ep.Init(config, types.Configuration{
+			"driverName":       "sqlite",
+			"dsn":              dsn,
+			"query":            "select id, status from orders where id > :watermark order by id limit 2",
+			"watermarkColumn":  "id",
+			"initialWatermark": 0,
+			"batch":            true,
+		})
+		assert.Nil(t, err)
+		defer ep.Destroy()
+
+		var msgs []types.RuleMsg
+		failId := "1"
+		var out *endpoint.Exchange
+		router := impl.NewRouter().From("@every 1h").Transform(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+			msg := exchange.In.GetMsg()
+			msg.Metadata.PutValue("failId", failId)
+			msgs = append(msgs, *msg)
+			return true
+		}).To("chain:dbPollChain").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+			out = exchange
+			return true
+		}).End()
+		_, err = ep.AddRouter(router)
+		assert.Nil(t, err)
+
+		//整批处理失败，不推进水位
+		ep.Poll(router)
+		assert.Equal(t, 1, len(msgs))
+		assert.Equal(t, MsgTypeBatch, msgs[0].Type)
+		assert.Equal(t, `[{"id":1,"status":"new"},{"id":2,"status":"new"}]`, msgs[0].GetData())
+		assert.Equal(t, "2", msgs[0].Metadata.GetValue(KeyRowCount))
+		assert.NotNil(t, out.Out.GetError())
+		assert.Equal(t, 0, ep.Watermark())
+
+		failId = ""
+		ep.Poll(router)
+		assert.Equal(t, int64(2), ep.Watermark())
+		ep.Poll(router)
+		assert.Equal(t, 3, len(msgs))
+		assert.Equal(t, `[{"id":3,"status":"new"}]`, msgs[2].GetData())
+		assert.Equal(t, int64(3), ep.Watermark())
+	})
+
+	t.Run("ChainNotFound", func(t *testing.T) {
+		dsn, _ := newTestDb(t)
+		config := engine.NewConfig(types.WithCache(cache.NewMemoryCache(time.Minute)), types.WithDefaultPool())
+		ep := New(config)
+		err := ep.Init(config, types.Configuration{
+			"driverName":       "sqlite",
+			"dsn":              dsn,
+			"query":            "select id from orders where id > :watermark order by id",
+			"watermarkColumn":  "id",
+			"initialWatermark": 0,
+		})
+		assert.Nil(t, err)
+		defer ep.Destroy()
+		router := impl.NewRouter().From("@every 1h").To("chain:notFound").End()
+		_, err = ep.AddRouter(router)
+		assert.Nil(t, err)
+		ep.Poll(router)
+		assert.Equal(t, 0, ep.Watermark())
+	})
+
+	t.Run("Schedule", func(t *testing.T) {
+		dsn, _ := newTestDb(t)
+		config := engine.NewConfig(types.WithCache(cache.NewMemoryCache(time.Minute)), types.WithDefaultPool())
+		ep := New(config)
+		err := ep.Init(config, types.Configuration{
+			"driverName":       "sqlite",
+			"dsn":              dsn,
+			"query":            "select id from orders where id > :watermark order by id",
+			"watermarkColumn":  "id",
+			"initialWatermark": 0,
+		})
+		assert.Nil(t, err)
+		var mu sync.Mutex
+		var count int
+		_, err = ep.AddRouter(impl.NewRouter().From("*/1 * * * * *").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+			mu.Lock()
+			count++
+			mu.Unlock()
+			return true
+		}).End())
+		assert.Nil(t, err)
+		_, err = ep.AddRouter(impl.NewRouter().From("invalid").End())
+		assert.NotNil(t, err)
+		assert.Nil(t, ep.Start())
+		time.Sleep(time.Millisecond * 2100)
+		ep.Destroy()
+		mu.Lock()
+		assert.Equal(t, 3, count)
+		mu.Unlock()
+		assert.Equal(t, int64(3), ep.Watermark())
+	})
+
+	t.Run("WatermarkColumnNotFound", func(t *testing.T) {
+		dsn, _ := newTestDb(t)
+		config := types.NewConfig(types.WithCache(cache.NewMemoryCache(time.Minute)))
+		ep := New(config)
+		err := ep.Init(config, types.Configuration{
+			"driverName":       "sqlite",
+			"dsn":              dsn,
+			"query":            "select status from orders where id > :watermark",
+			"watermarkColumn":  "id",
+			"initialWatermark": 0,
+		})
+		assert.Nil(t, err)
+		defer ep.Destroy()
+		var count int
+		router := impl.NewRouter().From("@every 1h").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+			count++
+			return true
+		}).End()
+		ep.Poll(router)
+		assert.Equal(t, 0, count)
+	})
+}
@@ -0,0 +1,844 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package file implements an endpoint that watches files matching a glob pattern.
+// The router's 'from' is a glob pattern, see filepath.Match. For example:
+//
+//	router := impl.NewRouter().From("/var/log/app/*.log").To("chain:logs").End()
+//
+// Directories are polled at a fixed interval, only the standard library is used,
+// so it also works on network shares where file system notifications are unavailable.
+//
+// Two modes are supported:
+//
+//   - tail: every complete line appended to a matching file is a message. Rotation
+//     (the path now points to another file) and truncation (the file shrank) are
+//     detected, lines written to a rotated file before the rotation are not lost.
+//     Offsets are persisted in types.Config.Cache together with a fingerprint of
+//     the beginning of the file, so reading resumes after a restart.
+//   - file: every new matching file is one message. A file is processed once its
+//     size and modification time are unchanged between two polls, then moved to
+//     the done folder if the rule chain succeeded, or to the error folder otherwise.
+//
+// The rule chain is always executed synchronously.
+package file
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/cache"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/runtime"
+)
+
+// Type 组件类型
+const Type = types.EndpointTypePrefix + "file"
+
+// 模式
+const (
+	// ModeTail 按行读取文件追加的内容
+	ModeTail = "tail"
+	// ModeFile 每个新文件作为一条消息
+	ModeFile = "file"
+)
+
+// 消息类型
+const (
+	// MsgTypeLine 文件行消息类型
+	MsgTypeLine = "FILE_LINE"
+	// MsgTypeFile 整个文件消息类型
+	MsgTypeFile = "FILE"
+)
+
+// 消息元数据key
+const (
+	// KeyPath 文件路径
+	KeyPath = "path"
+	// KeyName 文件名
+	KeyName = "name"
+	// KeyDir 文件所在目录
+	KeyDir = "dir"
+	// KeyOffset tail模式下，行结束后的偏移量
+	KeyOffset = "offset"
+	// KeySize file模式下，文件大小
+	KeySize = "size"
+	// KeyModTime file模式下，文件修改时间，unix毫秒
+	KeyModTime = "modTime"
+)
+
+// KeyPrefix 持久化状态在缓存中的key前缀
+const KeyPrefix = "file:"
+
+// fingerprintSize 计算文件指纹使用的最大字节数
+const fingerprintSize = 1024
+
+// Endpoint 别名
+type Endpoint = File
+
+// RequestMessage 文件请求消息
+type RequestMessage struct {
+	headers  textproto.MIMEHeader
+	body     []byte
+	msgType  string
+	metadata map[string]string
+	msg      *types.RuleMsg
+	err      error
+}
+
+func (r *RequestMessage) Body() []byte {
+	return r.body
+}
+
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+// From 文件路径
+func (r *RequestMessage) From() string {
+	return r.GetParam(KeyPath)
+}
+
+// GetParam 获取参数，支持 path、name、dir、offset、size、modTime
+func (r *RequestMessage) GetParam(key string) string {
+	if r.metadata == nil {
+		return ""
+	}
+	return r.metadata[key]
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		ruleMsg := types.NewMsg(0, r.msgType, types.TEXT, types.BuildMetadata(r.metadata), string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+// SetStatusCode 不提供设置状态码
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+// ResponseMessage 响应消息，记录规则链执行结果
+type ResponseMessage struct {
+	headers textproto.MIMEHeader
+	body    []byte
+	msg     *types.RuleMsg
+	err     error
+	mu      sync.Mutex
+}
+
+func (r *ResponseMessage) Body() []byte {
+	return r.body
+}
+
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+// From 不提供获取来源
+func (r *ResponseMessage) From() string {
+	return ""
+}
+
+// GetParam 不提供获取参数
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	return r.msg
+}
+
+// SetStatusCode 不提供设置状态码
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *ResponseMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+// SetError 设置错误，规则链多个分支结束时只保留第一个错误
+func (r *ResponseMessage) SetError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil || err == nil {
+		r.err = err
+	}
+}
+
+func (r *ResponseMessage) GetError() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Config 配置
+type Config struct {
+	// Mode 模式：tail(按行读取追加的内容)、file(每个新文件作为一条消息)，默认tail
+	Mode string
+	// PollInterval 轮询间隔，例如：1s，默认1s
+	PollInterval string
+	// FromBeginning tail模式下，启动时已经存在且没有持久化偏移量的文件是否从头读取，默认从末尾开始读取
+	// 启动后新出现的文件总是从头读取
+	FromBeginning bool
+	// MaxLineSize tail模式下，行的最大字节数，超过则直接作为一行发送，默认1MB
+	MaxLineSize int
+	// DoneDir file模式下，规则链处理成功后，文件移动到该目录
+	// DoneDir 和 ErrorDir 为空时，文件保持不动，处理过的文件记录在缓存中，不会重复处理
+	DoneDir string
+	// ErrorDir file模式下，规则链处理失败后，文件移动到该目录
+	ErrorDir string
+	// MaxFileSize file模式下，文件的最大字节数，超过则不读取内容，直接作为失败处理，默认10MB
+	MaxFileSize int64
+}
+
+// File 文件端点，轮询匹配glob的文件，按行或者按文件路由到规则链
+type File struct {
+	impl.BaseEndpoint
+	id         string
+	Config     Config
+	RuleConfig types.Config
+	cache      types.Cache
+	interval   time.Duration
+	routers    map[string]*fileRouter
+	stop       chan struct{}
+	//保护routers
+	sync.RWMutex
+	//扫描和路由状态修改串行执行
+	scanMu sync.Mutex
+}
+
+// fileRouter 路由和匹配文件的状态
+type fileRouter struct {
+	router endpoint.Router
+	//glob表达式
+	pattern string
+	//是否已经扫描过，tail模式下第一次扫描发现的文件根据FromBeginning决定读取位置
+	scanned bool
+	//tail模式下正在读取的文件
+	tails map[string]*tailFile
+	//file模式下等待稳定的文件
+	pending map[string]fileStat
+}
+
+// tailFile tail模式下正在读取的文件
+type tailFile struct {
+	path string
+	file *os.File
+	info os.FileInfo
+	//已经发送的行结束后的偏移量
+	offset int64
+	//不完整的行
+	partial []byte
+}
+
+// fileStat 文件大小和修改时间
+type fileStat struct {
+	size    int64
+	modTime time.Time
+}
+
+// New 创建一个新的 File Endpoint 实例
+func New(ruleConfig types.Config) *File {
+	uuId, _ := uuid.NewV4()
+	return &File{RuleConfig: ruleConfig, id: uuId.String()}
+}
+
+// Type 组件类型
+func (ep *File) Type() string {
+	return Type
+}
+
+func (ep *File) New() types.Node {
+	uuId, _ := uuid.NewV4()
+	return &File{id: uuId.String(), Config: Config{Mode: ModeTail, PollInterval: "1s"}}
+}
+
+// Init 初始化
+func (ep *File) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	if err := maps.Map2Struct(configuration, &ep.Config); err != nil {
+		return err
+	}
+	ep.RuleConfig = ruleConfig
+	if ep.Config.Mode == "" {
+		ep.Config.Mode = ModeTail
+	}
+	if ep.Config.Mode != ModeTail && ep.Config.Mode != ModeFile {
+		return fmt.Errorf("unsupported mode:%s", ep.Config.Mode)
+	}
+	ep.interval = time.Second
+	if ep.Config.PollInterval != "" {
+		d, err := time.ParseDuration(ep.Config.PollInterval)
+		if err != nil {
+			return err
+		} else if d <= 0 {
+			return errors.New("pollInterval must be greater than 0")
+		}
+		ep.interval = d
+	}
+	if ep.Config.MaxLineSize <= 0 {
+		ep.Config.MaxLineSize = 1024 * 1024
+	}
+	if ep.Config.MaxFileSize <= 0 {
+		ep.Config.MaxFileSize = 10 * 1024 * 1024
+	}
+	for _, dir := range []string{ep.Config.DoneDir, ep.Config.ErrorDir} {
+		if dir != "" {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+		}
+	}
+	ep.cache = ruleConfig.Cache
+	if ep.cache == nil {
+		ep.cache = cache.DefaultCache
+	}
+	return nil
+}
+
+// Destroy 销毁
+func (ep *File) Destroy() {
+	_ = ep.Close()
+}
+
+func (ep *File) Close() error {
+	ep.Lock()
+	if ep.stop != nil {
+		close(ep.stop)
+		ep.stop = nil
+	}
+	routers := ep.routers
+	ep.routers = nil
+	ep.Unlock()
+
+	ep.scanMu.Lock()
+	for _, item := range routers {
+		ep.closeRouter(item)
+	}
+	ep.scanMu.Unlock()
+	ep.BaseEndpoint.Destroy()
+	return nil
+}
+
+func (ep *File) Id() string {
+	return ep.id
+}
+
+// AddRouter 添加路由，from为glob表达式。规则链强制同步执行，执行结束后才能确定文件的处理结果
+func (ep *File) AddRouter(router endpoint.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router can not nil")
+	}
+	if router.GetFrom() == nil {
+		return "", errors.New("from can not nil")
+	}
+	pattern := router.GetFrom().ToString()
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return "", err
+	}
+	if to := router.GetFrom().GetTo(); to != nil {
+		to.Wait()
+	}
+	ep.CheckAndSetRouterId(router)
+	ep.Lock()
+	defer ep.Unlock()
+	if ep.routers == nil {
+		ep.routers = make(map[string]*fileRouter)
+	}
+	if _, ok := ep.routers[router.GetId()]; ok {
+		return router.GetId(), fmt.Errorf("duplicate router %s", pattern)
+	}
+	ep.routers[router.GetId()] = &fileRouter{
+		router:  router,
+		pattern: pattern,
+		tails:   make(map[string]*tailFile),
+		pending: make(map[string]fileStat),
+	}
+	return router.GetId(), nil
+}
+
+func (ep *File) RemoveRouter(routerId string, params ...interface{}) error {
+	ep.Lock()
+	item, ok := ep.routers[routerId]
+	if ok {
+		delete(ep.routers, routerId)
+	}
+	ep.Unlock()
+	if !ok {
+		return fmt.Errorf("router: %s not found", routerId)
+	}
+	ep.scanMu.Lock()
+	ep.closeRouter(item)
+	ep.scanMu.Unlock()
+	return nil
+}
+
+// Start 开始轮询
+func (ep *File) Start() error {
+	if ep.cache == nil {
+		return errors.New("file endpoint has not been initialized yet")
+	}
+	ep.Lock()
+	defer ep.Unlock()
+	if ep.stop != nil {
+		return nil
+	}
+	ep.stop = make(chan struct{})
+	go ep.watch(ep.interval, ep.stop)
+	return nil
+}
+
+func (ep *File) Printf(format string, v ...interface{}) {
+	if ep.RuleConfig.Logger != nil {
+		ep.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+// watch 定时扫描
+func (ep *File) watch(interval time.Duration, stop chan struct{}) {
+	ep.scan()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ep.scan()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// scan 扫描所有路由匹配的文件
+func (ep *File) scan() {
+	ep.RLock()
+	routers := make([]*fileRouter, 0, len(ep.routers))
+	for _, item := range ep.routers {
+		routers = append(routers, item)
+	}
+	ep.RUnlock()
+
+	ep.scanMu.Lock()
+	defer ep.scanMu.Unlock()
+	for _, item := range routers {
+		ep.RLock()
+		_, ok := ep.routers[item.router.GetId()]
+		ep.RUnlock()
+		if !ok {
+			continue
+		}
+		if ep.Config.Mode == ModeFile {
+			ep.scanFiles(item)
+		} else {
+			ep.scanTails(item)
+		}
+		item.scanned = true
+	}
+}
+
+// match 返回匹配glob的文件，按照路径排序
+func (ep *File) match(item *fileRouter) []string {
+	paths, err := filepath.Glob(item.pattern)
+	if err != nil {
+		ep.Printf("file endpoint glob err:%v", err)
+		return nil
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// scanTails tail模式扫描：读取已经打开的文件，处理轮转和截断，再打开新出现的文件
+func (ep *File) scanTails(item *fileRouter) {
+	var rotated []*tailFile
+	for path, tail := range item.tails {
+		ep.readTail(item, tail)
+		info, err := os.Stat(path)
+		if err != nil || !os.SameFile(info, tail.info) {
+			//文件被删除或者轮转，发送剩余的不完整行后关闭
+			ep.flushPartial(item, tail)
+			_ = tail.file.Close()
+			delete(item.tails, path)
+			rotated = append(rotated, tail)
+		}
+	}
+	for _, path := range ep.match(item) {
+		if _, ok := item.tails[path]; ok {
+			continue
+		}
+		tail, err := ep.openTail(item, path, rotated)
+		if err != nil {
+			ep.Printf("file endpoint open file:%s err:%v", path, err)
+			continue
+		}
+		if tail == nil {
+			continue
+		}
+		item.tails[path] = tail
+		ep.readTail(item, tail)
+	}
+}
+
+// openTail 打开文件并确定读取位置，目录返回nil
+func (ep *File) openTail(item *fileRouter, path string, rotated []*tailFile) (*tailFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		_ = f.Close()
+		return nil, err
+	}
+	tail := &tailFile{path: path, file: f, info: info}
+	var found bool
+	for _, old := range rotated {
+		//轮转后的文件也匹配glob，从原来的位置继续读取
+		if os.SameFile(info, old.info) {
+			tail.offset = old.offset
+			found = true
+			break
+		}
+	}
+	if !found {
+		if offset, ok := ep.loadOffset(item, f, info.Size(), path); ok {
+			tail.offset = offset
+		} else if !item.scanned && !ep.Config.FromBeginning {
+			tail.offset = info.Size()
+		}
+	}
+	if _, err = f.Seek(tail.offset, io.SeekStart); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return tail, nil
+}
+
+// readTail 读取文件新增的内容，发送完整的行
+func (ep *File) readTail(item *fileRouter, tail *tailFile) {
+	if info, err := tail.file.Stat(); err == nil {
+		readOffset := tail.offset + int64(len(tail.partial))
+		if info.Size() < readOffset {
+			//文件被截断，从头读取
+			tail.offset = 0
+			tail.partial = nil
+			if _, err = tail.file.Seek(0, io.SeekStart); err != nil {
+				ep.Printf("file endpoint seek file:%s err:%v", tail.path, err)
+				return
+			}
+		}
+		tail.info = info
+	}
+	buf := make([]byte, 32*1024)
+	var emitted bool
+	for {
+		n, err := tail.file.Read(buf)
+		if n > 0 {
+			tail.partial = append(tail.partial, buf[:n]...)
+			for {
+				index := bytes.IndexByte(tail.partial, '\n')
+				if index < 0 && len(tail.partial) < ep.Config.MaxLineSize {
+					break
+				} else if index < 0 || index >= ep.Config.MaxLineSize {
+					//超长的行按最大字节数切分
+					index = ep.Config.MaxLineSize - 1
+				}
+				line := tail.partial[:index+1]
+				tail.partial = tail.partial[index+1:]
+				tail.offset += int64(len(line))
+				ep.processLine(item, tail, line)
+				emitted = true
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	if len(tail.partial) == 0 {
+		tail.partial = nil
+	}
+	if emitted {
+		ep.saveOffset(item, tail)
+	}
+}
+
+// flushPartial 文件关闭前发送不完整的行
+func (ep *File) flushPartial(item *fileRouter, tail *tailFile) {
+	if len(tail.partial) == 0 {
+		return
+	}
+	line := tail.partial
+	tail.partial = nil
+	tail.offset += int64(len(line))
+	ep.processLine(item, tail, line)
+	ep.saveOffset(item, tail)
+}
+
+func (ep *File) processLine(item *fileRouter, tail *tailFile, line []byte) {
+	line = bytes.TrimRight(line, "\r\n")
+	metadata := pathMetadata(tail.path)
+	metadata[KeyOffset] = strconv.FormatInt(tail.offset, 10)
+	ep.process(item.router, MsgTypeLine, line, metadata)
+}
+
+// scanFiles file模式扫描：大小和修改时间没有变化的新文件作为一条消息
+func (ep *File) scanFiles(item *fileRouter) {
+	seen := make(map[string]bool)
+	for _, path := range ep.match(item) {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		seen[path] = true
+		stat := fileStat{size: info.Size(), modTime: info.ModTime()}
+		if ep.isProcessed(item, path, stat) {
+			delete(item.pending, path)
+			continue
+		}
+		if last, ok := item.pending[path]; !ok || last != stat {
+			//等待文件写入完成
+			item.pending[path] = stat
+			continue
+		}
+		delete(item.pending, path)
+		ep.processFile(item, path, stat)
+	}
+	for path := range item.pending {
+		if !seen[path] {
+			delete(item.pending, path)
+		}
+	}
+}
+
+// processFile 处理文件，根据结果移动到完成或者失败目录
+func (ep *File) processFile(item *fileRouter, path string, stat fileStat) {
+	metadata := pathMetadata(path)
+	metadata[KeySize] = strconv.FormatInt(stat.size, 10)
+	metadata[KeyModTime] = strconv.FormatInt(stat.modTime.UnixMilli(), 10)
+	var err error
+	if stat.size > ep.Config.MaxFileSize {
+		err = fmt.Errorf("file size %d exceeds the limit %d", stat.size, ep.Config.MaxFileSize)
+		ep.Printf("file endpoint process file:%s err:%v", path, err)
+	} else {
+		var data []byte
+		if data, err = os.ReadFile(path); err != nil {
+			ep.Printf("file endpoint read file:%s err:%v", path, err)
+			return
+		}
+		err = ep.process(item.router, MsgTypeFile, data, metadata)
+	}
+	dir := ep.Config.DoneDir
+	if err != nil {
+		dir = ep.Config.ErrorDir
+	}
+	if dir == "" {
+		ep.markProcessed(item, path, stat)
+		return
+	}
+	if err = moveFile(path, dir); err != nil {
+		ep.Printf("file endpoint move file:%s err:%v", path, err)
+		ep.markProcessed(item, path, stat)
+	}
+}
+
+// process 交给路由处理，返回规则链执行错误
+func (ep *File) process(router endpoint.Router, msgType string, body []byte, metadata map[string]string) (err error) {
+	defer func() {
+		//捕捉异常
+		if e := recover(); e != nil {
+			ep.Printf("file endpoint handler err :\n%v", runtime.Stack())
+			err = fmt.Errorf("%v", e)
+		}
+	}()
+	exchange := &endpoint.Exchange{
+		In: &RequestMessage{
+			body:     body,
+			msgType:  msgType,
+			metadata: metadata,
+		},
+		Out: &ResponseMessage{}}
+	ep.DoProcess(context.Background(), router, exchange)
+	return exchange.Out.GetError()
+}
+
+// closeRouter 关闭路由打开的文件
+func (ep *File) closeRouter(item *fileRouter) {
+	for path, tail := range item.tails {
+		_ = tail.file.Close()
+		delete(item.tails, path)
+	}
+}
+
+// stateKey 文件状态在缓存中的key
+func (ep *File) stateKey(item *fileRouter, path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return KeyPrefix + item.router.GetId() + ":" + path
+}
+
+// saveOffset 持久化偏移量，格式：偏移量:指纹长度:指纹
+func (ep *File) saveOffset(item *fileRouter, tail *tailFile) {
+	fpLen := tail.offset
+	if fpLen > fingerprintSize {
+		fpLen = fingerprintSize
+	}
+	fp, err := fingerprint(tail.file, fpLen)
+	if err != nil {
+		ep.Printf("file endpoint fingerprint file:%s err:%v", tail.path, err)
+		return
+	}
+	value := fmt.Sprintf("%d:%d:%d", tail.offset, fpLen, fp)
+	if err = ep.cache.Set(ep.stateKey(item, tail.path), value, ""); err != nil {
+		ep.Printf("file endpoint save offset err:%v", err)
+	}
+}
+
+// loadOffset 加载持久化的偏移量，没有持久化的偏移量返回false
+// 文件被截断或者指纹不一致(文件已经被替换)，从头读取
+func (ep *File) loadOffset(item *fileRouter, f *os.File, size int64, path string) (int64, bool) {
+	value, ok := ep.cache.Get(ep.stateKey(item, path)).(string)
+	if !ok {
+		return 0, false
+	}
+	values := strings.Split(value, ":")
+	if len(values) != 3 {
+		return 0, false
+	}
+	offset, err1 := strconv.ParseInt(values[0], 10, 64)
+	fpLen, err2 := strconv.ParseInt(values[1], 10, 64)
+	expected, err3 := strconv.ParseUint(values[2], 10, 32)
+	if err1 != nil || err2 != nil || err3 != nil || offset > size {
+		return 0, true
+	}
+	if fp, err := fingerprint(f, fpLen); err != nil || fp != uint32(expected) {
+		return 0, true
+	}
+	return offset, true
+}
+
+// isProcessed file模式下，文件是否已经处理过
+func (ep *File) isProcessed(item *fileRouter, path string, stat fileStat) bool {
+	value, ok := ep.cache.Get(ep.stateKey(item, path)).(string)
+	return ok && value == stat.String()
+}
+
+// markProcessed file模式下，记录处理过的文件
+func (ep *File) markProcessed(item *fileRouter, path string, stat fileStat) {
+	if err := ep.cache.Set(ep.stateKey(item, path), stat.String(), ""); err != nil {
+		ep.Printf("file endpoint save state err:%v", err)
+	}
+}
+
+func (s fileStat) String() string {
+	return fmt.Sprintf("%d:%d", s.size, s.modTime.UnixNano())
+}
+
+// fingerprint 计算文件开头length字节的crc32
+func fingerprint(f *os.File, length int64) (uint32, error) {
+	if length <= 0 {
+		return 0, nil
+	}
+	buf := make([]byte, length)
+	if _, err := f.ReadAt(buf, 0); err != nil {
+		return 0, err
+	}
+	return crc32.ChecksumIEEE(buf), nil
+}
+
+// pathMetadata 文件路径相关的元数据
+func pathMetadata(path string) map[string]string {
+	return map[string]string{
+		KeyPath: path,
+		KeyName: filepath.Base(path),
+		KeyDir:  filepath.Dir(path),
+	}
+}
+
+// moveFile 移动文件到目录，目标文件已经存在则在文件名后添加时间戳
+func moveFile(path, dir string) error {
+	name := filepath.Base(path)
+	target := filepath.Join(dir, name)
+	if _, err := os.Stat(target); err == nil {
+		ext := filepath.Ext(name)
+		target = filepath.Join(dir, fmt.Sprintf("%s.%d%s", strings.TrimSuffix(name, ext), time.Now().UnixNano(), ext))
+	}
+	if err := os.Rename(path, target); err == nil {
+		return nil
+	}
+	//跨设备移动，复制后删除
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(dst, src); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(target)
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	_ = src.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,327 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/cache"
+)
+
+// 消息内容包含fail时抛出异常
+var chainDsl = `{
+  "ruleChain": {"id": "fileChain", "name": "file"},
+  "metadata": {
+    "nodes": [
+      {
+        "id": "s1",
+        "type": "jsTransform",
+        "configuration": {
+          "jsScript": "if (String(msg).indexOf('fail') >= 0) { throw 'process failed' } return {'msg':msg,'metadata':metadata,'msgType':msgType};"
+        }
+      }
+    ],
+    "connections": []
+  }
+}`
+
+// 测试请求/响应消息
+func TestMessage(t *testing.T) {
+	t.Run("Request", func(t *testing.T) {
+		var request = &RequestMessage{}
+		test.EndpointMessage(t, request)
+	})
+	t.Run("Response", func(t *testing.T) {
+		var response = &ResponseMessage{}
+		test.EndpointMessage(t, response)
+	})
+}
+
+// lineCollector 收集消息
+type lineCollector struct {
+	mu   sync.Mutex
+	msgs []types.RuleMsg
+}
+
+func (c *lineCollector) router(from string) endpoint.Router {
+	return impl.NewRouter().From(from).Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+		c.mu.Lock()
+		c.msgs = append(c.msgs, *exchange.In.GetMsg())
+		c.mu.Unlock()
+		return true
+	}).End()
+}
+
+func (c *lineCollector) data() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var result []string
+	for _, msg := range c.msgs {
+		result = append(result, msg.GetData())
+	}
+	return result
+}
+
+func (c *lineCollector) reset() {
+	c.mu.Lock()
+	c.msgs = nil
+	c.mu.Unlock()
+}
+
+func appendFile(t *testing.T, path, content string) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	assert.Nil(t, err)
+	_, err = f.WriteString(content)
+	assert.Nil(t, err)
+	assert.Nil(t, f.Close())
+}
+
+func newEndpoint(t *testing.T, config types.Config, configuration types.Configuration) *Endpoint {
+	ep := New(config)
+	assert.Nil(t, ep.Init(config, configuration))
+	return ep
+}
+
+func TestFileEndpoint(t *testing.T) {
+	t.Run("InitError", func(t *testing.T) {
+		for _, configuration := range []types.Configuration{
+			{"mode": "abc"},
+			{"pollInterval": "abc"},
+			{"pollInterval": "-1s"},
+		} {
+			ep := &Endpoint{}
+			assert.NotNil(t, ep.Init(types.NewConfig(), configuration))
+		}
+		ep := &Endpoint{}
+		assert.NotNil(t, ep.Start())
+		assert.Nil(t, ep.Init(types.NewConfig(), types.Configuration{}))
+		assert.Equal(t, ModeTail, ep.Config.Mode)
+		assert.Equal(t, time.Second, ep.interval)
+		assert.Equal(t, Type, ep.Type())
+		_, err := ep.AddRouter(impl.NewRouter().From("[").End())
+		assert.NotNil(t, err)
+		_, err = ep.AddRouter(nil)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Tail", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+		appendFile(t, path, "old line\n")
+		config := types.NewConfig(types.WithCache(cache.NewMemoryCache(time.Minute)))
+		ep := newEndpoint(t, config, types.Configuration{})
+		var c lineCollector
+		router := c.router(filepath.Join(dir, "*.log"))
+		routerId, err := ep.AddRouter(router)
+		assert.Nil(t, err)
+
+		//启动时已经存在的文件从末尾开始读取
+		ep.scan()
+		assert.Equal(t, 0, len(c.data()))
+
+		//不完整的行等待换行符
+		appendFile(t, path, "line1\r\nline2\nline3")
+		ep.scan()
+		assert.Equal(t, []string{"line1", "line2"}, c.data())
+		assert.Equal(t, MsgTypeLine, c.msgs[0].Type)
+		assert.Equal(t, path, c.msgs[0].Metadata.GetValue(KeyPath))
+		assert.Equal(t, "app.log", c.msgs[0].Metadata.GetValue(KeyName))
+		assert.Equal(t, dir, c.msgs[0].Metadata.GetValue(KeyDir))
+		assert.Equal(t, "16", c.msgs[0].Metadata.GetValue(KeyOffset))
+		appendFile(t, path, "-end\n")
+		ep.scan()
+		assert.Equal(t, []string{"line1", "line2", "line3-end"}, c.data())
+
+		//启动后新出现的文件从头读取
+		c.reset()
+		newPath := filepath.Join(dir, "new.log")
+		appendFile(t, newPath, "new1\n")
+		appendFile(t, filepath.Join(dir, "other.txt"), "ignored\n")
+		ep.scan()
+		assert.Equal(t, []string{"new1"}, c.data())
+
+		//截断
+		c.reset()
+		assert.Nil(t, os.WriteFile(path, []byte("a\n"), 0644))
+		ep.scan()
+		assert.Equal(t, []string{"a"}, c.data())
+
+		//轮转：轮转前写入的行不会丢失，新文件从头读取
+		c.reset()
+		appendFile(t, path, "before rotate\n")
+		assert.Nil(t, os.Rename(path, filepath.Join(dir, "app.log.1")))
+		appendFile(t, path, "after rotate\n")
+		ep.scan()
+		assert.Equal(t, []string{"before rotate", "after rotate"}, c.data())
+
+		//轮转后的文件仍然匹配glob，从原来的位置继续读取
+		c.reset()
+		assert.Nil(t, os.Rename(newPath, filepath.Join(dir, "new.1.log")))
+		appendFile(t, filepath.Join(dir, "new.1.log"), "new2\n")
+		ep.scan()
+		assert.Equal(t, []string{"new2"}, c.data())
+
+		assert.Nil(t, ep.RemoveRouter(routerId))
+		assert.NotNil(t, ep.RemoveRouter(routerId))
+		ep.Destroy()
+
+		//重启后从持久化的偏移量继续读取
+		c.reset()
+		appendFile(t, path, "after restart\n")
+		ep = newEndpoint(t, config, types.Configuration{})
+		_, err = ep.AddRouter(router)
+		assert.Nil(t, err)
+		ep.scan()
+		assert.Equal(t, []string{"after restart"}, c.data())
+		ep.Destroy()
+
+		//文件被替换，指纹不一致，从头读取
+		c.reset()
+		assert.Nil(t, os.WriteFile(path, []byte("replaced content\nreplaced\n"), 0644))
+		ep = newEndpoint(t, config, types.Configuration{})
+		_, err = ep.AddRouter(router)
+		assert.Nil(t, err)
+		ep.scan()
+		assert.Equal(t, []string{"replaced content", "replaced"}, c.data())
+		ep.Destroy()
+	})
+
+	t.Run("TailFromBeginning", func(t *testing.T) {
+		dir := t.TempDir()
+		appendFile(t, filepath.Join(dir, "app.log"), "line1\nline2\n")
+		config := types.NewConfig(types.WithCache(cache.NewMemoryCache(time.Minute)))
+		ep := newEndpoint(t, config, types.Configuration{
+			"fromBeginning": true,
+			"maxLineSize":   4,
+		})
+		defer ep.Destroy()
+		var c lineCollector
+		_, err := ep.AddRouter(c.router(filepath.Join(dir, "*.log")))
+		assert.Nil(t, err)
+		ep.scan()
+		assert.Equal(t, []string{"line", "1", "line", "2"}, c.data())
+	})
+
+	t.Run("Watch", func(t *testing.T) {
+		dir := t.TempDir()
+		config := types.NewConfig(types.WithCache(cache.NewMemoryCache(time.Minute)))
+		ep := newEndpoint(t, config, types.Configuration{"pollInterval": "10ms"})
+		var c lineCollector
+		_, err := ep.AddRouter(c.router(filepath.Join(dir, "*.log")))
+		assert.Nil(t, err)
+		assert.Nil(t, ep.Start())
+		assert.Nil(t, ep.Start())
+		time.Sleep(time.Millisecond * 30)
+		appendFile(t, filepath.Join(dir, "app.log"), "line1\n")
+		time.Sleep(time.Millisecond * 100)
+		ep.Destroy()
+		assert.Equal(t, []string{"line1"}, c.data())
+	})
+
+	t.Run("File", func(t *testing.T) {
+		dir := t.TempDir()
+		doneDir := filepath.Join(dir, "done")
+		errorDir := filepath.Join(dir, "error")
+		config := engine.NewConfig(types.WithCache(cache.NewMemoryCache(time.Minute)), types.WithDefaultPool())
+		ruleEngine, err := engine.New("fileChain", []byte(chainDsl), engine.WithConfig(config))
+		assert.Nil(t, err)
+		defer ruleEngine.Stop()
+
+		ep := newEndpoint(t, config, types.Configuration{
+			"mode":     ModeFile,
+			"doneDir":  doneDir,
+			"errorDir": errorDir,
+		})
+		defer ep.Destroy()
+		var c lineCollector
+		router := impl.NewRouter().From(filepath.Join(dir, "*.csv")).Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+			c.mu.Lock()
+			c.msgs = append(c.msgs, *exchange.In.GetMsg())
+			c.mu.Unlock()
+			return true
+		}).To("chain:fileChain").End()
+		_, err = ep.AddRouter(router)
+		assert.Nil(t, err)
+		assert.True(t, router.GetFrom().GetTo().IsWait())
+
+		okPath := filepath.Join(dir, "a.csv")
+		failPath := filepath.Join(dir, "b.csv")
+		assert.Nil(t, os.WriteFile(okPath, []byte("id,name\n1,a\n"), 0644))
+		assert.Nil(t, os.WriteFile(failPath, []byte("id,name\n1,fail\n"), 0644))
+
+		//第一次扫描等待文件写入完成
+		ep.scan()
+		assert.Equal(t, 0, len(c.data()))
+		ep.scan()
+		assert.Equal(t, []string{"id,name\n1,a\n", "id,name\n1,fail\n"}, c.data())
+		assert.Equal(t, MsgTypeFile, c.msgs[0].Type)
+		assert.Equal(t, "a.csv", c.msgs[0].Metadata.GetValue(KeyName))
+		assert.Equal(t, "12", c.msgs[0].Metadata.GetValue(KeySize))
+
+		_, err = os.Stat(okPath)
+		assert.True(t, os.IsNotExist(err))
+		_, err = os.Stat(filepath.Join(doneDir, "a.csv"))
+		assert.Nil(t, err)
+		_, err = os.Stat(filepath.Join(errorDir, "b.csv"))
+		assert.Nil(t, err)
+
+		//同名文件不覆盖
+		assert.Nil(t, os.WriteFile(okPath, []byte("id,name\n2,b\n"), 0644))
+		ep.scan()
+		ep.scan()
+		entries, err := os.ReadDir(doneDir)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(entries))
+	})
+
+	t.Run("FileWithoutDoneDir", func(t *testing.T) {
+		dir := t.TempDir()
+		config := types.NewConfig(types.WithCache(cache.NewMemoryCache(time.Minute)))
+		ep := newEndpoint(t, config, types.Configuration{"mode": ModeFile, "maxFileSize": 5})
+		defer ep.Destroy()
+		var c lineCollector
+		_, err := ep.AddRouter(c.router(filepath.Join(dir, "*")))
+		assert.Nil(t, err)
+		assert.Nil(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+		path := filepath.Join(dir, "a.txt")
+		assert.Nil(t, os.WriteFile(path, []byte("abc"), 0644))
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, "large.txt"), []byte("abcdefg"), 0644))
+		ep.scan()
+		ep.scan()
+		assert.Equal(t, []string{"abc"}, c.data())
+
+		//处理过的文件不会重复处理，文件修改后重新处理
+		ep.scan()
+		assert.Equal(t, 1, len(c.data()))
+		assert.Nil(t, os.WriteFile(path, []byte("abcd"), 0644))
+		ep.scan()
+		ep.scan()
+		assert.Equal(t, []string{"abc", "abcd"}, c.data())
+		_, err = os.Stat(path)
+		assert.Nil(t, err)
+	})
+}
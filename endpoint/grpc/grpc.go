@@ -0,0 +1,615 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package grpc provides a gRPC server endpoint that exposes rule chains as gRPC methods.
+// Services are described by a descriptor set, no generated code is needed:
+//
+//	protoc --include_imports --descriptor_set_out=service.pb service.proto
+//
+// The router's 'from' is the full method name, for example:
+//
+//	router := impl.NewRouter().From("helloworld.Greeter/SayHello").To("chain:greeter").End()
+//
+// The request message is converted to a JSON RuleMsg whose type is the full method name,
+// and the incoming gRPC metadata is copied into the message metadata.
+// The rule chain is always executed synchronously:
+//
+//   - unary methods respond with the first message the rule chain ends with, converted from JSON,
+//     or with the error if the rule chain fails.
+//   - server-streaming methods send every message the rule chain ends with as soon as it arrives,
+//     for example each branch or each item of a for node, the stream ends when the rule chain completes.
+//
+// Client-streaming and bidirectional-streaming methods are not supported.
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/utils/maps"
+	"github.com/rulego/rulego/utils/protoutil"
+	"github.com/rulego/rulego/utils/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Type 组件类型
+const Type = types.EndpointTypePrefix + "grpc"
+
+// KeyMethod 消息元数据中的完整方法名，例如：/helloworld.Greeter/SayHello
+const KeyMethod = "grpcMethod"
+
+// Endpoint 别名
+type Endpoint = Grpc
+
+var _ endpoint.Endpoint = (*Endpoint)(nil)
+
+// RequestMessage gRPC请求消息
+type RequestMessage struct {
+	ctx      context.Context
+	method   string
+	headers  textproto.MIMEHeader
+	body     []byte
+	msg      *types.RuleMsg
+	err      error
+	metadata *types.Metadata
+}
+
+func (r *RequestMessage) Body() []byte {
+	return r.body
+}
+
+// Headers 请求的gRPC元数据
+func (r *RequestMessage) Headers() textproto.MIMEHeader {
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+// From 完整方法名
+func (r *RequestMessage) From() string {
+	return r.method
+}
+
+// GetParam 获取请求的gRPC元数据
+func (r *RequestMessage) GetParam(key string) string {
+	return r.Headers().Get(key)
+}
+
+func (r *RequestMessage) SetMsg(msg *types.RuleMsg) {
+	r.msg = msg
+}
+
+func (r *RequestMessage) GetMsg() *types.RuleMsg {
+	if r.msg == nil {
+		if r.metadata == nil {
+			r.metadata = types.NewMetadata()
+		}
+		ruleMsg := types.NewMsg(0, strings.TrimPrefix(r.method, "/"), types.JSON, r.metadata, string(r.Body()))
+		r.msg = &ruleMsg
+	}
+	return r.msg
+}
+
+// SetStatusCode 不提供设置状态码
+func (r *RequestMessage) SetStatusCode(statusCode int) {
+}
+
+func (r *RequestMessage) SetBody(body []byte) {
+	r.body = body
+}
+
+func (r *RequestMessage) SetError(err error) {
+	r.err = err
+}
+
+func (r *RequestMessage) GetError() error {
+	return r.err
+}
+
+// Context 请求上下文
+func (r *RequestMessage) Context() context.Context {
+	return r.ctx
+}
+
+// ResponseMessage gRPC响应消息
+// 一元方法使用规则链第一条结束的消息作为响应，服务端流式方法立即发送每条结束的消息
+type ResponseMessage struct {
+	method  string
+	headers textproto.MIMEHeader
+	body    []byte
+	msg     *types.RuleMsg
+	err     error
+	//服务端流式方法发送响应
+	send func(data []byte) error
+	mu   sync.Mutex
+}
+
+func (r *ResponseMessage) Body() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.body
+}
+
+// Headers 响应的gRPC元数据，在发送第一条响应前设置有效
+func (r *ResponseMessage) Headers() textproto.MIMEHeader {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.headers == nil {
+		r.headers = make(map[string][]string)
+	}
+	return r.headers
+}
+
+// From 完整方法名
+func (r *ResponseMessage) From() string {
+	return r.method
+}
+
+// GetParam 不提供获取参数
+func (r *ResponseMessage) GetParam(key string) string {
+	return ""
+}
+
+// SetMsg 设置规则链处理结果，服务端流式方法立即发送
+func (r *ResponseMessage) SetMsg(msg *types.RuleMsg) {
+	if msg == nil {
+		return
+	}
+	if r.send != nil {
+		r.sendData([]byte(msg.GetData()))
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.msg == nil {
+		r.msg = msg
+	}
+}
+
+func (r *ResponseMessage) GetMsg() *types.RuleMsg {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.msg
+}
+
+// SetStatusCode 不提供设置状态码，通过 SetError 设置 status.Error 返回指定的状态码
+func (r *ResponseMessage) SetStatusCode(statusCode int) {
+}
+
+// SetBody 设置JSON格式的响应，优先于规则链处理结果，服务端流式方法立即发送
+func (r *ResponseMessage) SetBody(body []byte) {
+	if r.send != nil {
+		r.sendData(body)
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.body = body
+}
+
+// SetError 设置错误，只保留第一个错误
+func (r *ResponseMessage) SetError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil || err == nil {
+		r.err = err
+	}
+}
+
+func (r *ResponseMessage) GetError() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// sendData 服务端流式方法发送响应，规则链多个分支并发结束时串行发送
+func (r *ResponseMessage) sendData(data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.send(data); err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+// Config gRPC服务配置
+type Config struct {
+	// Server 服务地址，默认:9090
+	Server string `json:"server"`
+	// DescriptorSet 描述服务的descriptor set文件路径，多个使用逗号隔开
+	// 使用 protoc --include_imports --descriptor_set_out=service.pb service.proto 生成
+	DescriptorSet string `json:"descriptorSet"`
+	// CertFile 证书文件，和CertKeyFile同时配置则开启TLS
+	CertFile string `json:"certFile"`
+	// CertKeyFile 证书私钥文件
+	CertKeyFile string `json:"certKeyFile"`
+	// Reflection 是否开启服务反射，开启后客户端可以查询已经添加路由的服务
+	Reflection bool `json:"reflection"`
+	// UseProtoNames 请求转换成JSON时是否使用proto文件中的字段名，默认使用lowerCamelCase
+	UseProtoNames bool `json:"useProtoNames"`
+	// EmitUnpopulated 请求转换成JSON时是否输出默认值的字段
+	EmitUnpopulated bool `json:"emitUnpopulated"`
+}
+
+// Grpc gRPC接收端端点
+type Grpc struct {
+	impl.BaseEndpoint
+	id         string
+	Config     Config
+	RuleConfig types.Config
+	files      *protoregistry.Files
+	server     *grpc.Server
+	//key：完整方法名，例如：/helloworld.Greeter/SayHello
+	methods map[string]*grpcRouter
+	addr    net.Addr
+	started bool
+}
+
+// grpcRouter 路由和方法描述
+type grpcRouter struct {
+	router endpoint.Router
+	method protoreflect.MethodDescriptor
+}
+
+// Type 组件类型
+func (x *Grpc) Type() string {
+	return Type
+}
+
+func (x *Grpc) Id() string {
+	return x.id
+}
+
+func (x *Grpc) New() types.Node {
+	return &Grpc{Config: Config{Server: ":9090"}}
+}
+
+// Init 初始化
+func (x *Grpc) Init(ruleConfig types.Config, configuration types.Configuration) error {
+	if err := maps.Map2Struct(configuration, &x.Config); err != nil {
+		return err
+	}
+	x.RuleConfig = ruleConfig
+	if x.Config.Server == "" {
+		x.Config.Server = ":9090"
+	}
+	x.id = x.Config.Server
+	var paths []string
+	for _, item := range strings.Split(x.Config.DescriptorSet, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			paths = append(paths, item)
+		}
+	}
+	if len(paths) == 0 {
+		return errors.New("descriptorSet can not be empty")
+	}
+	files, err := protoutil.LoadDescriptorSet(paths...)
+	if err != nil {
+		return err
+	}
+	x.files = files
+	return nil
+}
+
+// Destroy 销毁
+func (x *Grpc) Destroy() {
+	_ = x.Close()
+}
+
+// Close 停止服务，等待正在处理的请求结束，最多等待5秒
+func (x *Grpc) Close() error {
+	x.Lock()
+	server := x.server
+	x.server = nil
+	x.started = false
+	x.Unlock()
+	if server != nil {
+		done := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			server.Stop()
+		}
+	}
+	x.BaseEndpoint.Destroy()
+	return nil
+}
+
+// AddRouter 添加路由，from为完整方法名。规则链强制同步执行，执行结束后才能响应
+func (x *Grpc) AddRouter(router endpoint.Router, params ...interface{}) (string, error) {
+	if router == nil {
+		return "", errors.New("router can not nil")
+	}
+	if router.GetFrom() == nil {
+		return "", errors.New("from can not nil")
+	}
+	if x.files == nil {
+		return "", errors.New("grpc endpoint has not been initialized yet")
+	}
+	md, err := protoutil.FindMethod(x.files, router.GetFrom().ToString())
+	if err != nil {
+		return "", err
+	}
+	if md.IsStreamingClient() {
+		return "", fmt.Errorf("client streaming method %s is not supported", md.FullName())
+	}
+	if to := router.GetFrom().GetTo(); to != nil {
+		to.Wait()
+	}
+	fullMethod := protoutil.FullMethod(md)
+	if router.GetId() == "" {
+		router.SetId(fullMethod)
+	}
+	x.Lock()
+	defer x.Unlock()
+	if x.methods == nil {
+		x.methods = make(map[string]*grpcRouter)
+	}
+	if _, ok := x.methods[fullMethod]; ok {
+		return router.GetId(), fmt.Errorf("duplicate router %s", fullMethod)
+	}
+	if x.RouterStorage == nil {
+		x.RouterStorage = make(map[string]endpoint.Router)
+	}
+	x.RouterStorage[router.GetId()] = router
+	x.methods[fullMethod] = &grpcRouter{router: router, method: md}
+	return router.GetId(), nil
+}
+
+func (x *Grpc) RemoveRouter(routerId string, params ...interface{}) error {
+	routerId = strings.TrimSpace(routerId)
+	x.Lock()
+	defer x.Unlock()
+	router, ok := x.RouterStorage[routerId]
+	if !ok {
+		return fmt.Errorf("router: %s not found", routerId)
+	}
+	delete(x.RouterStorage, routerId)
+	for method, item := range x.methods {
+		if item.router == router {
+			delete(x.methods, method)
+		}
+	}
+	return nil
+}
+
+// Start 启动服务，启动后仍然可以添加或者删除路由
+func (x *Grpc) Start() error {
+	if x.files == nil {
+		return errors.New("grpc endpoint has not been initialized yet")
+	}
+	x.Lock()
+	defer x.Unlock()
+	if x.started {
+		return nil
+	}
+	var opts = []grpc.ServerOption{grpc.UnknownServiceHandler(x.handler)}
+	if x.Config.CertFile != "" && x.Config.CertKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(x.Config.CertFile, x.Config.CertKeyFile)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{cert}})))
+	}
+	ln, err := net.Listen("tcp", x.Config.Server)
+	if err != nil {
+		return err
+	}
+	server := grpc.NewServer(opts...)
+	if x.Config.Reflection {
+		reflectionServer := reflection.NewServerV1(reflection.ServerOptions{
+			Services:           serviceInfoProvider{x},
+			DescriptorResolver: x.files,
+		})
+		reflectionv1.RegisterServerReflectionServer(server, reflectionServer)
+		reflectionv1alpha.RegisterServerReflectionServer(server, reflection.NewServer(reflection.ServerOptions{
+			Services:           serviceInfoProvider{x},
+			DescriptorResolver: x.files,
+		}))
+	}
+	x.server = server
+	x.addr = ln.Addr()
+	x.started = true
+	go func() {
+		if err := server.Serve(ln); err != nil {
+			x.Printf("grpc endpoint serve error:%v", err)
+		}
+	}()
+	x.Printf("started grpc server on %s", ln.Addr().String())
+	return nil
+}
+
+// Addr 服务监听的地址，服务启动后有效
+func (x *Grpc) Addr() net.Addr {
+	x.RLock()
+	defer x.RUnlock()
+	return x.addr
+}
+
+func (x *Grpc) Printf(format string, v ...interface{}) {
+	if x.RuleConfig.Logger != nil {
+		x.RuleConfig.Logger.Printf(format, v...)
+	}
+}
+
+// handler 处理所有方法的请求
+func (x *Grpc) handler(srv interface{}, stream grpc.ServerStream) (err error) {
+	defer func() {
+		//捕捉异常
+		if e := recover(); e != nil {
+			x.Printf("grpc endpoint handler err :\n%v", runtime.Stack())
+			err = status.Errorf(codes.Internal, "%v", e)
+		}
+	}()
+	fullMethod, ok := grpc.MethodFromServerStream(stream)
+	if !ok {
+		return status.Error(codes.Internal, "method not found in the stream")
+	}
+	x.RLock()
+	item, ok := x.methods[fullMethod]
+	x.RUnlock()
+	if !ok || item.router.IsDisable() {
+		return status.Errorf(codes.Unimplemented, "unknown method %s", fullMethod)
+	}
+	md := item.method
+
+	req, err := protoutil.NewMessage(md.Input(), nil)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if err = stream.RecvMsg(protoadapt.MessageV1Of(req)); err != nil {
+		return err
+	}
+	body, err := protoutil.ToJson(req, x.Config.UseProtoNames, x.Config.EmitUnpopulated)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	ctx := stream.Context()
+	headers := make(textproto.MIMEHeader)
+	msgMetadata := types.NewMetadata()
+	if incoming, ok := metadata.FromIncomingContext(ctx); ok {
+		for key, values := range incoming {
+			if len(values) > 0 && !strings.HasPrefix(key, ":") {
+				headers[key] = values
+				msgMetadata.PutValue(key, values[0])
+			}
+		}
+	}
+	msgMetadata.PutValue(KeyMethod, fullMethod)
+
+	var sendErr error
+	out := &ResponseMessage{method: fullMethod}
+	if md.IsStreamingServer() {
+		var headerSent bool
+		out.send = func(data []byte) error {
+			if sendErr != nil {
+				return sendErr
+			}
+			if !headerSent {
+				headerSent = true
+				if outgoing := toMD(out.headers); len(outgoing) > 0 {
+					_ = stream.SetHeader(outgoing)
+				}
+			}
+			if sendErr = x.sendMsg(stream, md.Output(), data); sendErr != nil {
+				x.Printf("grpc endpoint send %s error:%v", fullMethod, sendErr)
+			}
+			return sendErr
+		}
+	}
+	exchange := &endpoint.Exchange{
+		In: &RequestMessage{
+			ctx:      ctx,
+			method:   fullMethod,
+			headers:  headers,
+			body:     body,
+			metadata: msgMetadata,
+		},
+		Out: out,
+	}
+	x.DoProcess(ctx, item.router, exchange)
+
+	if md.IsStreamingServer() {
+		return toStatusError(out.GetError())
+	}
+	data := out.Body()
+	if data == nil {
+		if msg := out.GetMsg(); msg != nil {
+			data = []byte(msg.GetData())
+		} else if err = out.GetError(); err != nil {
+			return toStatusError(err)
+		}
+	}
+	if outgoing := toMD(out.Headers()); len(outgoing) > 0 {
+		_ = stream.SetHeader(outgoing)
+	}
+	return x.sendMsg(stream, md.Output(), data)
+}
+
+// sendMsg 把JSON转换成响应消息发送
+func (x *Grpc) sendMsg(stream grpc.ServerStream, md protoreflect.MessageDescriptor, data []byte) error {
+	resp, err := protoutil.NewMessage(md, data)
+	if err != nil {
+		return status.Errorf(codes.Internal, "invalid response: %v", err)
+	}
+	return stream.SendMsg(protoadapt.MessageV1Of(resp))
+}
+
+// toStatusError 非gRPC状态的错误转换成Internal错误
+func toStatusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// toMD 转换成gRPC元数据
+func toMD(headers textproto.MIMEHeader) metadata.MD {
+	md := metadata.MD{}
+	for key, values := range headers {
+		md.Append(key, values...)
+	}
+	return md
+}
+
+// serviceInfoProvider 提供已经添加路由的服务给反射服务
+type serviceInfoProvider struct {
+	endpoint *Grpc
+}
+
+func (p serviceInfoProvider) GetServiceInfo() map[string]grpc.ServiceInfo {
+	p.endpoint.RLock()
+	defer p.endpoint.RUnlock()
+	var services = make(map[string]grpc.ServiceInfo)
+	for _, item := range p.endpoint.methods {
+		name := string(item.method.Parent().FullName())
+		info := services[name]
+		info.Methods = append(info.Methods, grpc.MethodInfo{
+			Name:           string(item.method.Name()),
+			IsServerStream: item.method.IsStreamingServer(),
+		})
+		services[name] = info
+	}
+	return services
+}
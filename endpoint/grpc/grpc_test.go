@@ -0,0 +1,286 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpc
+
+import (
+	"context"
+	"io"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/impl"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/protoutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const descriptorSet = "../../testdata/grpc/greeter.pb"
+
+// name为fail时抛出异常
+var chainDsl = `{
+  "ruleChain": {"id": "greeterChain", "name": "greeter"},
+  "metadata": {
+    "nodes": [
+      {
+        "id": "s1",
+        "type": "jsTransform",
+        "configuration": {
+          "jsScript": "if (msg.name === 'fail') { throw 'process failed' } return {'msg':{'message':'hello '+msg.name+' '+metadata.token+' '+msgType,'index':1},'metadata':metadata,'msgType':msgType};"
+        }
+      },
+      {
+        "id": "s2",
+        "type": "jsTransform",
+        "configuration": {
+          "jsScript": "msg.index = 2; return {'msg':msg,'metadata':metadata,'msgType':msgType};"
+        }
+      }
+    ],
+    "connections": [
+      {"fromId": "s1", "toId": "s2", "type": "Success"}
+    ]
+  }
+}`
+
+// s1的两个分支各输出一条消息
+var streamChainDsl = `{
+  "ruleChain": {"id": "streamChain", "name": "stream"},
+  "metadata": {
+    "nodes": [
+      {
+        "id": "s1",
+        "type": "jsTransform",
+        "configuration": {
+          "jsScript": "return {'msg':{'message':'hello '+msg.name},'metadata':metadata,'msgType':msgType};"
+        }
+      },
+      {
+        "id": "s2",
+        "type": "jsTransform",
+        "configuration": {
+          "jsScript": "msg.index = 1; return {'msg':msg,'metadata':metadata,'msgType':msgType};"
+        }
+      },
+      {
+        "id": "s3",
+        "type": "jsTransform",
+        "configuration": {
+          "jsScript": "msg.index = 2; return {'msg':msg,'metadata':metadata,'msgType':msgType};"
+        }
+      }
+    ],
+    "connections": [
+      {"fromId": "s1", "toId": "s2", "type": "Success"},
+      {"fromId": "s1", "toId": "s3", "type": "Success"}
+    ]
+  }
+}`
+
+// 测试请求/响应消息
+func TestMessage(t *testing.T) {
+	t.Run("Request", func(t *testing.T) {
+		var request = &RequestMessage{}
+		test.EndpointMessage(t, request)
+	})
+	t.Run("Response", func(t *testing.T) {
+		var response = &ResponseMessage{}
+		test.EndpointMessage(t, response)
+	})
+}
+
+type greeterClient struct {
+	conn  *grpc.ClientConn
+	files *protoregistry.Files
+}
+
+func newGreeterClient(t *testing.T, addr string) *greeterClient {
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	files, err := protoutil.LoadDescriptorSet(descriptorSet)
+	assert.Nil(t, err)
+	return &greeterClient{conn: conn, files: files}
+}
+
+func (c *greeterClient) call(ctx context.Context, t *testing.T, method string, req string) (string, error) {
+	md, err := protoutil.FindMethod(c.files, method)
+	assert.Nil(t, err)
+	in, err := protoutil.NewMessage(md.Input(), []byte(req))
+	assert.Nil(t, err)
+	out := dynamicpb.NewMessage(md.Output())
+	if err = c.conn.Invoke(ctx, protoutil.FullMethod(md), protoadapt.MessageV1Of(in), protoadapt.MessageV1Of(out)); err != nil {
+		return "", err
+	}
+	data, err := protoutil.ToJson(out, false, false)
+	assert.Nil(t, err)
+	return string(data), nil
+}
+
+func (c *greeterClient) stream(t *testing.T, method string, req string) ([]string, error) {
+	md, err := protoutil.FindMethod(c.files, method)
+	assert.Nil(t, err)
+	stream, err := c.conn.NewStream(context.Background(), &grpc.StreamDesc{ServerStreams: true}, protoutil.FullMethod(md))
+	assert.Nil(t, err)
+	in, err := protoutil.NewMessage(md.Input(), []byte(req))
+	assert.Nil(t, err)
+	assert.Nil(t, stream.SendMsg(protoadapt.MessageV1Of(in)))
+	assert.Nil(t, stream.CloseSend())
+	var result []string
+	for {
+		out := dynamicpb.NewMessage(md.Output())
+		if err = stream.RecvMsg(protoadapt.MessageV1Of(out)); err == io.EOF {
+			return result, nil
+		} else if err != nil {
+			return result, err
+		}
+		data, err := protoutil.ToJson(out, false, false)
+		assert.Nil(t, err)
+		result = append(result, string(data))
+	}
+}
+
+func TestGrpcEndpoint(t *testing.T) {
+	config := engine.NewConfig(types.WithDefaultPool())
+	ruleEngine, err := engine.New("greeterChain", []byte(chainDsl), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer ruleEngine.Stop()
+	streamEngine, err := engine.New("streamChain", []byte(streamChainDsl), engine.WithConfig(config))
+	assert.Nil(t, err)
+	defer streamEngine.Stop()
+
+	t.Run("InitError", func(t *testing.T) {
+		ep := &Endpoint{}
+		assert.NotNil(t, ep.Init(config, types.Configuration{}))
+		assert.NotNil(t, ep.Init(config, types.Configuration{"descriptorSet": "not_found.pb"}))
+		assert.NotNil(t, ep.Start())
+		_, err := ep.AddRouter(impl.NewRouter().From("rulego.test.Greeter/SayHello").End())
+		assert.NotNil(t, err)
+	})
+
+	ep := &Endpoint{}
+	err = ep.Init(config, types.Configuration{
+		"server":        "127.0.0.1:0",
+		"descriptorSet": descriptorSet,
+		"reflection":    true,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, Type, ep.Type())
+	assert.Nil(t, ep.Start())
+	assert.Nil(t, ep.Start())
+	defer ep.Destroy()
+	client := newGreeterClient(t, ep.Addr().String())
+
+	router := impl.NewRouter().From("rulego.test.Greeter/SayHello").To("chain:greeterChain").End()
+	routerId, err := ep.AddRouter(router)
+	assert.Nil(t, err)
+	assert.Equal(t, "/rulego.test.Greeter/SayHello", routerId)
+	assert.True(t, router.GetFrom().GetTo().IsWait())
+
+	t.Run("AddRouterError", func(t *testing.T) {
+		for _, from := range []string{"rulego.test.Greeter/Nothing", "rulego.test.Greeter/Chat", "/rulego.test.Greeter/SayHello"} {
+			_, err := ep.AddRouter(impl.NewRouter().From(from).End())
+			assert.NotNil(t, err)
+		}
+		_, err := ep.AddRouter(nil)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("Unary", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "token", "abc")
+		resp, err := client.call(ctx, t, "rulego.test.Greeter/SayHello", `{"name":"lala"}`)
+		assert.Nil(t, err)
+		assert.Equal(t, `{"message":"hello lala abc rulego.test.Greeter/SayHello","index":2}`, resp)
+
+		_, err = client.call(ctx, t, "rulego.test.Greeter/SayHello", `{"name":"fail"}`)
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+
+	t.Run("Unimplemented", func(t *testing.T) {
+		_, err := client.call(context.Background(), t, "rulego.test.Greeter/StreamHello", `{"name":"lala"}`)
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+
+	t.Run("ServerStream", func(t *testing.T) {
+		_, err := ep.AddRouter(impl.NewRouter().From("rulego.test.Greeter/StreamHello").To("chain:streamChain").End())
+		assert.Nil(t, err)
+		result, err := client.stream(t, "rulego.test.Greeter/StreamHello", `{"name":"lala"}`)
+		assert.Nil(t, err)
+		sort.Strings(result)
+		assert.Equal(t, []string{`{"message":"hello lala","index":1}`, `{"message":"hello lala","index":2}`}, result)
+		assert.Nil(t, ep.RemoveRouter("/rulego.test.Greeter/StreamHello"))
+
+		//规则链不存在
+		_, err = ep.AddRouter(impl.NewRouter().From("rulego.test.Greeter/StreamHello").To("chain:notFound").End())
+		assert.Nil(t, err)
+		_, err = client.stream(t, "rulego.test.Greeter/StreamHello", `{"name":"lala"}`)
+		assert.Equal(t, codes.Internal, status.Code(err))
+		assert.Nil(t, ep.RemoveRouter("/rulego.test.Greeter/StreamHello"))
+	})
+
+	t.Run("Process", func(t *testing.T) {
+		assert.Nil(t, ep.RemoveRouter(routerId))
+		assert.NotNil(t, ep.RemoveRouter(routerId))
+		_, err := ep.AddRouter(impl.NewRouter().From("rulego.test.Greeter/SayHello").Process(func(router endpoint.Router, exchange *endpoint.Exchange) bool {
+			exchange.Out.Headers().Set("x-name", exchange.In.GetMsg().Metadata.GetValue(KeyMethod))
+			exchange.Out.SetBody([]byte(`{"message":"from process"}`))
+			return true
+		}).End())
+		assert.Nil(t, err)
+		var header metadata.MD
+		md, err := protoutil.FindMethod(client.files, "rulego.test.Greeter/SayHello")
+		assert.Nil(t, err)
+		in, _ := protoutil.NewMessage(md.Input(), nil)
+		out := dynamicpb.NewMessage(md.Output())
+		err = client.conn.Invoke(context.Background(), "/rulego.test.Greeter/SayHello", protoadapt.MessageV1Of(in), protoadapt.MessageV1Of(out), grpc.Header(&header))
+		assert.Nil(t, err)
+		data, _ := protoutil.ToJson(out, false, false)
+		assert.Equal(t, `{"message":"from process"}`, string(data))
+		assert.Equal(t, []string{"/rulego.test.Greeter/SayHello"}, header.Get("x-name"))
+	})
+
+	t.Run("Reflection", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		stream, err := reflectionpb.NewServerReflectionClient(client.conn).ServerReflectionInfo(ctx)
+		assert.Nil(t, err)
+		assert.Nil(t, stream.Send(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+		}))
+		resp, err := stream.Recv()
+		assert.Nil(t, err)
+		var services []string
+		for _, item := range resp.GetListServicesResponse().GetService() {
+			services = append(services, item.GetName())
+		}
+		assert.Equal(t, []string{"rulego.test.Greeter"}, services)
+	})
+}
@@ -580,8 +580,8 @@ func (ce *ChainExecutor) Execute(ctx context.Context, router endpoint.Router, ex
 			}
 		} else {
 			//找不到规则链返回错误
+			exchange.Out.SetError(fmt.Errorf("chainId=%s not found error", toChainId))
 			for _, process := range toFlow.GetProcessList() {
-				exchange.Out.SetError(fmt.Errorf("chainId=%s not found error", toChainId))
 				if !process(router, exchange) {
 					break
 				}
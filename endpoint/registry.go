@@ -20,6 +20,10 @@ import (
 	"fmt"
 	"github.com/rulego/rulego/api/types"
 	"github.com/rulego/rulego/api/types/endpoint"
+	"github.com/rulego/rulego/endpoint/cacheevents"
+	"github.com/rulego/rulego/endpoint/dbpoll"
+	"github.com/rulego/rulego/endpoint/file"
+	"github.com/rulego/rulego/endpoint/grpc"
 	"github.com/rulego/rulego/endpoint/mqtt"
 	"github.com/rulego/rulego/endpoint/net"
 	"github.com/rulego/rulego/endpoint/rest"
@@ -37,6 +41,10 @@ func init() {
 	_ = Registry.Register(&net.Endpoint{})
 	_ = Registry.Register(&websocket.Endpoint{})
 	_ = Registry.Register(&schedule.Endpoint{})
+	_ = Registry.Register(&cacheevents.Endpoint{})
+	_ = Registry.Register(&dbpoll.Endpoint{})
+	_ = Registry.Register(&file.Endpoint{})
+	_ = Registry.Register(&grpc.Endpoint{})
 }
 
 // Registry is the default registry for endpoint components.
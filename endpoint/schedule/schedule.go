@@ -57,6 +57,11 @@ const Type = types.EndpointTypePrefix + "schedule"
 // Endpoint 别名
 type Endpoint = Schedule
 
+// NewCron 创建支持秒级字段的cron调度器，其他按照cron表达式定时触发的endpoint复用相同的表达式格式
+func NewCron() *cron.Cron {
+	return cron.New(cron.WithSeconds())
+}
+
 // RequestMessage http请求消息
 type RequestMessage struct {
 	headers textproto.MIMEHeader
@@ -177,7 +182,7 @@ type Schedule struct {
 // New 创建一个新的Schedule Endpoint 实例
 func New(ruleConfig types.Config) *Schedule {
 	uuId, _ := uuid.NewV4()
-	return &Schedule{RuleConfig: ruleConfig, cron: cron.New(cron.WithSeconds()), id: uuId.String()}
+	return &Schedule{RuleConfig: ruleConfig, cron: NewCron(), id: uuId.String()}
 }
 
 // Type 组件类型
@@ -187,7 +192,7 @@ func (schedule *Schedule) Type() string {
 
 func (schedule *Schedule) New() types.Node {
 	uuId, _ := uuid.NewV4()
-	return &Schedule{cron: cron.New(cron.WithSeconds()), id: uuId.String()}
+	return &Schedule{cron: NewCron(), id: uuId.String()}
 }
 
 // Init 初始化
@@ -222,7 +227,7 @@ func (schedule *Schedule) AddRouter(router endpoint.Router, params ...interface{
 		return "", errors.New("from can not nil")
 	}
 	if schedule.cron == nil {
-		schedule.cron = cron.New(cron.WithSeconds())
+		schedule.cron = NewCron()
 	}
 	//获取cron表达式
 	from := router.GetFrom().ToString()
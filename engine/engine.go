@@ -323,6 +323,15 @@ func (e *RuleEngine) GetMetrics() *metrics.EngineMetrics {
 	return nil
 }
 
+// GetCacheMetrics returns the metrics of Config.Cache, which is shared by all rule engines using it.
+// It returns nil if the cache does not implement types.CacheMetricsProvider.
+func (e *RuleEngine) GetCacheMetrics() *metrics.CacheMetrics {
+	if provider, ok := e.Config.Cache.(types.CacheMetricsProvider); ok {
+		return provider.Metrics()
+	}
+	return nil
+}
+
 // OnMsgWithEndFunc is a deprecated method that asynchronously processes a message using the rule engine.
 // The endFunc callback is used to obtain the results after the rule chain execution is complete.
 // Note: If the rule chain has multiple endpoints, the callback function will be executed multiple times.
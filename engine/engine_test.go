@@ -1333,6 +1333,67 @@ func TestReload(t *testing.T) {
 	}))
 }
 
+// TestReloadBatchNode 测试规则链重新加载时，未满的批次移交给新实例并发送到新的下游节点
+func TestReloadBatchNode(t *testing.T) {
+	var ruleChainFile = `{
+          "ruleChain": {
+            "id": "testReloadBatch",
+            "name": "testReloadBatch"
+          },
+          "metadata": {
+            "nodes": [
+              {
+                "id": "s1",
+                "type": "batch",
+                "configuration": {
+                  "interval": "200ms"
+                }
+              },
+              {
+                "id": "s2",
+                "type": "functions",
+                "configuration": {
+                  "functionName": "%s"
+                }
+              }
+            ],
+            "connections": [
+              {
+                "fromId": "s1",
+                "toId": "s2",
+                "type": "Success"
+              }
+            ]
+          }
+        }`
+	received := make(chan string, 2)
+	action.Functions.Register("batchReloadOld", func(ctx types.RuleContext, msg types.RuleMsg) {
+		received <- "old:" + msg.Metadata.GetValue(action.BatchMetadataSize)
+		ctx.TellSuccess(msg)
+	})
+	action.Functions.Register("batchReloadNew", func(ctx types.RuleContext, msg types.RuleMsg) {
+		received <- "new:" + msg.Metadata.GetValue(action.BatchMetadataSize)
+		ctx.TellSuccess(msg)
+	})
+	ruleEngine, err := New("testReloadBatch", []byte(fmt.Sprintf(ruleChainFile, "batchReloadOld")))
+	assert.Nil(t, err)
+	defer Del("testReloadBatch")
+
+	for i := 0; i < 2; i++ {
+		ruleEngine.OnMsg(types.NewMsg(0, "TEST_MSG_TYPE1", types.JSON, types.NewMetadata(), "{\"temperature\":41}"))
+	}
+	time.Sleep(time.Millisecond * 50)
+	err = ruleEngine.ReloadSelf([]byte(fmt.Sprintf(ruleChainFile, "batchReloadNew")))
+	assert.Nil(t, err)
+
+	select {
+	case v := <-received:
+		assert.Equal(t, "new:2", v)
+	case <-time.After(time.Second):
+		t.Fatal("batch not received after reload")
+	}
+}
+
 func TestUseVars(t *testing.T) {
 	var ruleChainFile = `{
           "ruleChain": {
@@ -18,12 +18,14 @@ package engine
 
 import (
 	"errors"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/rulego/rulego/api/types"
 	"github.com/rulego/rulego/components/action"
 	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/cache"
 )
 
 func TestMetricsAspect(t *testing.T) {
@@ -82,3 +84,49 @@ func TestMetricsAspect(t *testing.T) {
 	assert.Equal(t, int64(4), metrics.Success)
 
 }
+
+func TestCacheMetrics(t *testing.T) {
+	ruleChainFile := `
+	{
+		"ruleChain": {"id": "testCacheMetrics"},
+		"metadata": {
+		  "nodes": [
+			{
+			  "id": "s1",
+			  "type": "cacheSet",
+			  "configuration": {
+				"items": [{"level": "chain", "key": "${metadata.id}", "value": "${msg.value}"}]
+			  }
+			}
+		  ]
+		}
+	}`
+	memoryCache := cache.NewMemoryCache(time.Minute,
+		cache.WithMaxEntries(100),
+		cache.WithNamespaceQuota("testCacheMetrics"+types.NamespaceSeparator, cache.Quota{MaxEntries: 2}))
+	config := NewConfig(types.WithCache(memoryCache))
+	ruleEngine, err := New("testCacheMetrics", []byte(ruleChainFile), WithConfig(config))
+	assert.Nil(t, err)
+	defer ruleEngine.Stop()
+
+	for i := 0; i < 3; i++ {
+		metaData := types.NewMetadata()
+		metaData.PutValue("id", strconv.Itoa(i))
+		ruleEngine.OnMsgAndWait(types.NewMsg(0, "TEST_MSG_TYPE1", types.JSON, metaData, `{"value":1}`))
+	}
+	memoryCache.Get("testCacheMetrics:2")
+	memoryCache.Get("testCacheMetrics:0")
+
+	metrics := ruleEngine.GetCacheMetrics().Get()
+	assert.Equal(t, int64(2), metrics.Entries)
+	assert.Equal(t, int64(1), metrics.Evictions)
+	assert.Equal(t, int64(1), metrics.Hits)
+	assert.Equal(t, int64(1), metrics.Misses)
+	assert.True(t, metrics.Bytes > 0)
+
+	//缓存不支持指标
+	ruleEngine2, err := New("testCacheMetrics2", []byte(ruleChainFile), WithConfig(NewConfig(types.WithCache(cache.NewNamespaceCache(memoryCache, "ns:")))))
+	assert.Nil(t, err)
+	defer ruleEngine2.Stop()
+	assert.Nil(t, ruleEngine2.GetCacheMetrics())
+}
@@ -24,9 +24,13 @@ import (
 	"github.com/rulego/rulego/components/external"
 	"github.com/rulego/rulego/components/filter"
 	"github.com/rulego/rulego/components/flow"
+	"github.com/rulego/rulego/components/rpcplugin"
 	"github.com/rulego/rulego/components/transform"
+	"github.com/rulego/rulego/components/wasm"
 	"github.com/rulego/rulego/utils/reflect"
+	"io"
 	"plugin"
+	"strings"
 	"sync"
 )
 
@@ -88,6 +92,7 @@ func (r *RuleComponentRegistry) RegisterPlugin(name string, file string) error {
 	components := builder.Components()
 	for _, node := range components {
 		if _, ok := r.components[node.Type()]; ok {
+			closePlugin(components)
 			return errors.New("the component already exists. componentType=" + node.Type())
 		}
 	}
@@ -117,6 +122,7 @@ func (r *RuleComponentRegistry) Unregister(componentType string) error {
 			// Delete the plugin from the map
 			delete(r.components, node.Type())
 		}
+		closePlugin(nodes)
 		delete(r.plugins, componentType)
 		removed = true
 	}
@@ -178,7 +184,25 @@ type PluginComponentRegistry struct {
 }
 
 // Init initializes the plugin component registry by loading the plugin from a file.
+// Files prefixed with rpc:// are started as out-of-process plugins, files with the .wasm extension
+// are loaded by the WASM runtime, others by the Go plugin mechanism.
 func (p *PluginComponentRegistry) Init() error {
+	if strings.HasPrefix(p.file, rpcplugin.Scheme) {
+		rpcRegistry := rpcplugin.NewPluginRegistry(strings.TrimPrefix(p.file, rpcplugin.Scheme), rpcplugin.Options{})
+		if err := rpcRegistry.Init(); err != nil {
+			return err
+		}
+		p.registry = rpcRegistry
+		return nil
+	}
+	if strings.HasSuffix(p.file, wasm.FileExt) {
+		wasmRegistry := wasm.NewPluginRegistry(p.file, wasm.Options{})
+		if err := wasmRegistry.Init(); err != nil {
+			return err
+		}
+		p.registry = wasmRegistry
+		return nil
+	}
 	pluginRegistry, err := loadPlugin(p.file)
 	if err != nil {
 		return err
@@ -216,3 +240,12 @@ func loadPlugin(file string) (types.PluginRegistry, error) {
 	//pm.plugins[name] = plugin
 	return plugin, nil
 }
+
+// closePlugin releases the resources held by plugin components, such as out-of-process plugin processes.
+func closePlugin(nodes []types.Node) {
+	for _, node := range nodes {
+		if closer, ok := node.(io.Closer); ok {
+			_ = closer.Close()
+		}
+	}
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package node_pool
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/engine"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/cache/redis"
+)
+
+// TestRedisCacheSharedNode 测试redis缓存共享节点，多个规则引擎复用同一个连接作为缓存
+func TestRedisCacheSharedNode(t *testing.T) {
+	s := miniredis.RunT(t)
+	var redisDsl = `
+		{
+	       "id": "redis_cache",
+	       "type": "redisCache",
+	       "name": "redis缓存",
+	       "configuration": {
+	         "server": "` + s.Addr() + `"
+	       }
+	     }`
+
+	pool := NewNodePool(engine.NewConfig())
+	nodeDef, err := pool.Config.Parser.DecodeRuleNode([]byte(redisDsl))
+	assert.Nil(t, err)
+	_, err = pool.NewFromRuleNode(nodeDef)
+	assert.Nil(t, err)
+	defer pool.Stop()
+
+	instance1, err := pool.GetInstance("redis_cache")
+	assert.Nil(t, err)
+	instance2, err := pool.GetInstance("redis_cache")
+	assert.Nil(t, err)
+	redisCache, ok := instance1.(*redis.RedisCache)
+	assert.True(t, ok)
+	assert.True(t, instance1 == instance2)
+
+	ruleChainFile := `
+		{
+		"ruleChain": {
+		  "id": "redisCacheRule01",
+		  "name": "redisCacheRule01"
+		},
+		"metadata": {
+		  "nodes": [
+			{
+			  "id": "s1",
+			  "type": "cacheIncr",
+			  "configuration": {
+				"level": "global",
+				"key": "count:${metadata.deviceId}"
+			  }
+			},
+			{
+			  "id": "s2",
+			  "type": "cacheSet",
+			  "configuration": {
+				"items": [{"level": "chain", "key": "last:${metadata.deviceId}", "value": "${msg.temperature}"}]
+			  }
+			},
+			{
+			  "id": "s3",
+			  "type": "redisCache",
+			  "configuration": {
+				"server": "ref://redis_cache"
+			  }
+			}
+		  ],
+		  "connections": [
+			{"fromId": "s1", "toId": "s2", "type": "Success"},
+			{"fromId": "s2", "toId": "s3", "type": "Success"}
+		  ]
+		}
+	}`
+	config := engine.NewConfig(types.WithCache(redisCache))
+	config.NetPool = pool
+	ruleEngine1, err := engine.New("redisCacheRule01", []byte(ruleChainFile), engine.WithConfig(config))
+	assert.Nil(t, err)
+	ruleEngine2, err := engine.New("redisCacheRule02", []byte(strings.Replace(ruleChainFile, "redisCacheRule01", "redisCacheRule02", 1)), engine.WithConfig(config))
+	assert.Nil(t, err)
+
+	metadata := types.NewMetadata()
+	metadata.PutValue("deviceId", "d1")
+	msg := types.NewMsg(0, "TELEMETRY_MSG", types.JSON, metadata, `{"temperature":35}`)
+	results := make(chan string, 1)
+	onEnd := types.WithOnEnd(func(ctx types.RuleContext, msg types.RuleMsg, err error, relationType string) {
+		results <- relationType
+	})
+	ruleEngine1.OnMsg(msg, onEnd)
+	assert.Equal(t, types.Success, waitResult(t, results))
+	ruleEngine2.OnMsg(msg, onEnd)
+	assert.Equal(t, types.Success, waitResult(t, results))
+
+	//全局缓存在多个规则引擎之间共享
+	value, _ := s.Get("count:d1")
+	assert.Equal(t, "2", value)
+	//规则链缓存使用规则链ID命名空间
+	value, _ = s.Get("redisCacheRule01:last:d1")
+	assert.Equal(t, "35", value)
+	assert.True(t, s.Exists("redisCacheRule02:last:d1"))
+
+	//规则链销毁时删除该规则链命名空间下的缓存，不影响共享连接
+	ruleEngine1.Stop()
+	assert.False(t, s.Exists("redisCacheRule01:last:d1"))
+	ruleEngine2.OnMsg(msg, onEnd)
+	assert.Equal(t, types.Success, waitResult(t, results))
+	assert.Equal(t, int64(3), redisCache.Get("count:d1"))
+	ruleEngine2.Stop()
+
+	//redis不可用
+	s.Close()
+	ruleEngine3, err := engine.New("redisCacheRule03", []byte(ruleChainFile), engine.WithConfig(config))
+	assert.Nil(t, err)
+	ruleEngine3.OnMsg(msg, onEnd)
+	assert.Equal(t, types.Failure, waitResult(t, results))
+	ruleEngine3.Stop()
+}
+
+func waitResult(t *testing.T, results chan string) string {
+	select {
+	case relationType := <-results:
+		return relationType
+	case <-time.After(time.Second * 3):
+		t.Fatal("timeout")
+		return ""
+	}
+}
@@ -214,7 +214,9 @@ func (ctx *NodeTestRuleContext) SetOnAllNodeCompleted(onAllNodeCompleted func())
 }
 
 func (ctx *NodeTestRuleContext) DoOnEnd(msg types.RuleMsg, err error, relationType string) {
-
+	if ctx.onEndFunc != nil {
+		ctx.onEndFunc(ctx, msg, err, relationType)
+	}
 }
 
 // SetCallbackFunc 设置回调函数
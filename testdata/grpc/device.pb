@@ -0,0 +1,20 @@
+
+
+google/protobuf/timestamp.protogoogle.protobuf";
+	Timestamp
+seconds (Rseconds
+nanos (RnanosB
+com.google.protobufBTimestampProtoPZ2google.golang.org/protobuf/types/known/timestamppbGPBGoogle.Protobuf.WellKnownTypesbproto3
+
+common.protorulego.commongoogle/protobuf/timestamp.proto"g
+Reply
+message (	Rmessage
+index (Rindex.
+time (2.google.protobuf.TimestampRtimebproto3
+
+device.protorulego.testcommon.proto"9
+DeviceRequest
+name (	Rname
+count (Rcount2F
+Device<
+SayHello.rulego.test.DeviceRequest.rulego.common.Replybproto3
\ No newline at end of file
@@ -0,0 +1,21 @@
+
+
+google/protobuf/timestamp.protogoogle.protobuf";
+	Timestamp
+seconds (Rseconds
+nanos (RnanosB
+com.google.protobufBTimestampProtoPZ2google.golang.org/protobuf/types/known/timestamppbGPBGoogle.Protobuf.WellKnownTypesbproto3
+
+greeter.protorulego.testgoogle/protobuf/timestamp.proto"8
+HelloRequest
+name (	Rname
+count (Rcount"l
+
+HelloReply
+message (	Rmessage
+index (Rindex.
+time (2.google.protobuf.TimestampRtime2
+Greeter>
+SayHello.rulego.test.HelloRequest.rulego.test.HelloReplyC
+StreamHello.rulego.test.HelloRequest.rulego.test.HelloReply0>
+Chat.rulego.test.HelloRequest.rulego.test.HelloReply(0bproto3
\ No newline at end of file
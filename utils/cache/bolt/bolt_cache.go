@@ -0,0 +1,845 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package bolt provides an embedded on-disk implementation of types.Cache based on bbolt.
+//
+// It is intended for edge deployments without Redis: state stored in GlobalCache/ChainCache,
+// e.g. by the delay, dedup and window nodes, survives process restarts:
+//
+//	c, err := bolt.NewBoltCache("/var/lib/rulego/cache.db", bolt.Config{})
+//	config := rulego.NewConfig(types.WithCache(c))
+//
+// Every write is committed in its own fsync'ed transaction, so acknowledged writes survive crashes.
+// Values are stored as JSON, integers are decoded as int64 and other numbers as float64.
+package bolt
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/utils/cache"
+	"go.etcd.io/bbolt"
+)
+
+// ErrClosed is the error returned when the cache is used after Close.
+var ErrClosed = errors.New("bolt cache is closed")
+
+// bucketName is the bucket storing all cache entries.
+var bucketName = []byte("cache")
+
+// compactSuffix is the suffix of the temporary file used by Compact.
+const compactSuffix = ".compact"
+
+// headerSize is the size of the entry header: 8 bytes expiration and 1 byte kind.
+const headerSize = 9
+
+// gcBatchSize is the maximum number of expired keys deleted per transaction.
+const gcBatchSize = 1000
+
+// Kinds of stored values.
+const (
+	kindValue byte = iota
+	kindList
+	kindSet
+	kindHash
+)
+
+var _ types.Cache = (*BoltCache)(nil)
+
+// Config is the bolt cache configuration.
+type Config struct {
+	// GcInterval is the interval for deleting expired keys, default 1 minute, negative disables GC.
+	// Expired keys are never returned even if they have not been deleted yet.
+	GcInterval time.Duration `json:"gcInterval"`
+	// CompactInterval is the interval for compacting the database file, 0 disables periodic compaction.
+	CompactInterval time.Duration `json:"compactInterval"`
+	// OpenTimeout is the time to wait for the file lock held by another process, default 1 second.
+	OpenTimeout time.Duration `json:"openTimeout"`
+}
+
+// entry is a decoded cache entry.
+type entry struct {
+	// expiration is the unix nano expiration time, 0 means never expires
+	expiration int64
+	kind       byte
+	payload    []byte
+}
+
+func (e entry) marshal() []byte {
+	data := make([]byte, headerSize+len(e.payload))
+	binary.BigEndian.PutUint64(data, uint64(e.expiration))
+	data[8] = e.kind
+	copy(data[headerSize:], e.payload)
+	return data
+}
+
+func (e entry) expired(now int64) bool {
+	return e.expiration > 0 && e.expiration <= now
+}
+
+// unmarshalEntry decodes an entry, the payload is copied because bbolt data
+// is only valid during the transaction.
+func unmarshalEntry(data []byte) (entry, bool) {
+	if len(data) < headerSize {
+		return entry{}, false
+	}
+	payload := make([]byte, len(data)-headerSize)
+	copy(payload, data[headerSize:])
+	return entry{
+		expiration: int64(binary.BigEndian.Uint64(data)),
+		kind:       data[8],
+		payload:    payload,
+	}, true
+}
+
+// BoltCache is a types.Cache stored in a bbolt database file.
+type BoltCache struct {
+	// mu guards db, it is write locked while Compact replaces the database file
+	mu     sync.RWMutex
+	db     *bbolt.DB
+	path   string
+	config Config
+	closed bool
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewBoltCache opens or creates the database file at path.
+// A leftover file of an interrupted compaction is removed, the original file is intact in that case.
+func NewBoltCache(path string, config Config) (*BoltCache, error) {
+	if config.GcInterval == 0 {
+		config.GcInterval = time.Minute
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = time.Second
+	}
+	_ = os.Remove(path + compactSuffix)
+	c := &BoltCache{path: path, config: config, stop: make(chan struct{})}
+	db, err := c.open(path)
+	if err != nil {
+		return nil, err
+	}
+	c.db = db
+	if config.GcInterval > 0 {
+		c.startLoop(config.GcInterval, func() { _ = c.DeleteExpired() })
+	}
+	if config.CompactInterval > 0 {
+		c.startLoop(config.CompactInterval, func() { _ = c.Compact() })
+	}
+	return c, nil
+}
+
+func (c *BoltCache) open(path string) (*bbolt.DB, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: c.config.OpenTimeout})
+	if err != nil {
+		return nil, err
+	}
+	if err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketName)
+		return err
+	}); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func (c *BoltCache) startLoop(interval time.Duration, fn func()) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fn()
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Path returns the path of the database file.
+func (c *BoltCache) Path() string {
+	return c.path
+}
+
+// Close stops the background tasks and closes the database file.
+func (c *BoltCache) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.stop)
+	err := c.db.Close()
+	c.mu.Unlock()
+	c.wg.Wait()
+	return err
+}
+
+// view runs fn in a read-only transaction.
+func (c *BoltCache) view(fn func(b *bbolt.Bucket, now int64) error) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return ErrClosed
+	}
+	return c.db.View(func(tx *bbolt.Tx) error {
+		return fn(tx.Bucket(bucketName), time.Now().UnixNano())
+	})
+}
+
+// update runs fn in a read-write transaction, the transaction is committed if fn returns nil.
+func (c *BoltCache) update(fn func(b *bbolt.Bucket, now int64) error) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return ErrClosed
+	}
+	return c.db.Update(func(tx *bbolt.Tx) error {
+		return fn(tx.Bucket(bucketName), time.Now().UnixNano())
+	})
+}
+
+// liveEntry returns the entry of the key if it exists and has not expired.
+func liveEntry(b *bbolt.Bucket, key string, now int64) (entry, bool) {
+	e, ok := unmarshalEntry(b.Get([]byte(key)))
+	if !ok || e.expired(now) {
+		return entry{}, false
+	}
+	return e, true
+}
+
+func put(b *bbolt.Bucket, key string, e entry) error {
+	return b.Put([]byte(key), e.marshal())
+}
+
+// Set stores a key-value pair, ttl like "10s" or "1m", empty means never expires.
+func (c *BoltCache) Set(key string, value interface{}, ttl string) error {
+	expiration, err := parseExpiration(ttl)
+	if err != nil {
+		return err
+	}
+	payload, err := cache.EncodeValue(value)
+	if err != nil {
+		return err
+	}
+	return c.update(func(b *bbolt.Bucket, now int64) error {
+		return put(b, key, entry{expiration: expiration, kind: kindValue, payload: payload})
+	})
+}
+
+// Get returns the value of a key, nil if the key does not exist or has expired.
+// Lists are returned as []interface{}, sets as sorted []string and hashes as map[string]interface{}.
+func (c *BoltCache) Get(key string) interface{} {
+	var value interface{}
+	_ = c.view(func(b *bbolt.Bucket, now int64) error {
+		if e, ok := liveEntry(b, key, now); ok {
+			value = exportEntry(e)
+		}
+		return nil
+	})
+	return value
+}
+
+// Has reports whether the key exists and has not expired.
+func (c *BoltCache) Has(key string) bool {
+	var found bool
+	_ = c.view(func(b *bbolt.Bucket, now int64) error {
+		_, found = liveEntry(b, key, now)
+		return nil
+	})
+	return found
+}
+
+// Delete removes a key.
+func (c *BoltCache) Delete(key string) error {
+	return c.update(func(b *bbolt.Bucket, now int64) error {
+		return b.Delete([]byte(key))
+	})
+}
+
+// DeleteByPrefix removes all keys with the given prefix in a single transaction.
+func (c *BoltCache) DeleteByPrefix(prefix string) error {
+	return c.update(func(b *bbolt.Bucket, now int64) error {
+		var keys [][]byte
+		scanPrefix(b, prefix, func(k, v []byte) {
+			keys = append(keys, append([]byte(nil), k...))
+		})
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetByPrefix returns all live key-value pairs with the given prefix.
+func (c *BoltCache) GetByPrefix(prefix string) map[string]interface{} {
+	result := make(map[string]interface{})
+	_ = c.view(func(b *bbolt.Bucket, now int64) error {
+		scanPrefix(b, prefix, func(k, v []byte) {
+			if e, ok := unmarshalEntry(v); ok && !e.expired(now) {
+				result[string(k)] = exportEntry(e)
+			}
+		})
+		return nil
+	})
+	return result
+}
+
+// scanPrefix calls fn for each key with the given prefix in key order.
+func scanPrefix(b *bbolt.Bucket, prefix string, fn func(k, v []byte)) {
+	p := []byte(prefix)
+	cursor := b.Cursor()
+	for k, v := cursor.Seek(p); k != nil && hasPrefix(k, p); k, v = cursor.Next() {
+		fn(k, v)
+	}
+}
+
+func hasPrefix(k, prefix []byte) bool {
+	return len(k) >= len(prefix) && string(k[:len(prefix)]) == string(prefix)
+}
+
+// DeleteExpired removes expired keys, it is called periodically according to Config.GcInterval.
+func (c *BoltCache) DeleteExpired() error {
+	for {
+		var deleted int
+		err := c.update(func(b *bbolt.Bucket, now int64) error {
+			var keys [][]byte
+			cursor := b.Cursor()
+			for k, v := cursor.First(); k != nil && len(keys) < gcBatchSize; k, v = cursor.Next() {
+				if e, ok := unmarshalEntry(v); !ok || e.expired(now) {
+					keys = append(keys, append([]byte(nil), k...))
+				}
+			}
+			for _, k := range keys {
+				if err := b.Delete(k); err != nil {
+					return err
+				}
+			}
+			deleted = len(keys)
+			return nil
+		})
+		if err != nil || deleted < gcBatchSize {
+			return err
+		}
+	}
+}
+
+// Compact deletes expired keys and rewrites the database file to release the free pages.
+// The data is copied to a temporary file which atomically replaces the original file,
+// so the original file is intact if the process crashes during compaction.
+// Other operations are blocked during compaction.
+func (c *BoltCache) Compact() error {
+	if err := c.DeleteExpired(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+	tmpPath := c.path + compactSuffix
+	_ = os.Remove(tmpPath)
+	dst, err := bbolt.Open(tmpPath, 0600, &bbolt.Options{Timeout: c.config.OpenTimeout})
+	if err != nil {
+		return err
+	}
+	if err = bbolt.Compact(dst, c.db, 0); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err = c.db.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	renameErr := os.Rename(tmpPath, c.path)
+	if renameErr != nil {
+		_ = os.Remove(tmpPath)
+	}
+	// reopen the compacted file, or the original file if renaming failed
+	db, err := c.open(c.path)
+	if err != nil {
+		c.closed = true
+		close(c.stop)
+		return err
+	}
+	c.db = db
+	return renameErr
+}
+
+// Incr atomically increments the integer value of a key by delta.
+// A missing or expired key is treated as 0 and created with the given ttl,
+// the expiration of an existing key is kept.
+func (c *BoltCache) Incr(key string, delta int64, ttl string) (int64, error) {
+	expiration, err := parseExpiration(ttl)
+	if err != nil {
+		return 0, err
+	}
+	var value int64
+	err = c.update(func(b *bbolt.Bucket, now int64) error {
+		if e, ok := liveEntry(b, key, now); ok {
+			if e.kind != kindValue {
+				return types.ErrCacheWrongType
+			}
+			if value, ok = toInt64(cache.DecodeValue(e.payload)); !ok {
+				return types.ErrCacheWrongType
+			}
+			expiration = e.expiration
+		}
+		value += delta
+		return put(b, key, entry{expiration: expiration, kind: kindValue, payload: []byte(strconv.FormatInt(value, 10))})
+	})
+	return value, err
+}
+
+// Decr atomically decrements the integer value of a key by delta.
+func (c *BoltCache) Decr(key string, delta int64, ttl string) (int64, error) {
+	return c.Incr(key, -delta, ttl)
+}
+
+// CompareAndSet atomically sets the value of a key only if its current value equals expected.
+// A nil expected value means the key must not exist.
+func (c *BoltCache) CompareAndSet(key string, expected, value interface{}, ttl string) (bool, error) {
+	expiration, err := parseExpiration(ttl)
+	if err != nil {
+		return false, err
+	}
+	payload, err := cache.EncodeValue(value)
+	if err != nil {
+		return false, err
+	}
+	var matched bool
+	err = c.update(func(b *bbolt.Bucket, now int64) error {
+		e, found := liveEntry(b, key, now)
+		if expected == nil {
+			matched = !found
+		} else {
+			matched = found && cache.ValuesEqual(exportEntry(e), expected)
+		}
+		if !matched {
+			return nil
+		}
+		return put(b, key, entry{expiration: expiration, kind: kindValue, payload: payload})
+	})
+	return matched && err == nil, err
+}
+
+// SetIfAbsent atomically stores a key-value pair only if the key does not exist or has expired.
+func (c *BoltCache) SetIfAbsent(key string, value interface{}, ttl string) (bool, error) {
+	return c.CompareAndSet(key, nil, value, ttl)
+}
+
+// Expire updates the time-to-live of an existing key, an empty or 0 ttl removes the expiration.
+func (c *BoltCache) Expire(key string, ttl string) (bool, error) {
+	expiration, err := parseExpiration(ttl)
+	if err != nil {
+		return false, err
+	}
+	var found bool
+	err = c.update(func(b *bbolt.Bucket, now int64) error {
+		var e entry
+		if e, found = liveEntry(b, key, now); !found {
+			return nil
+		}
+		e.expiration = expiration
+		return put(b, key, e)
+	})
+	return found && err == nil, err
+}
+
+// TTL returns the remaining time-to-live of a key, 0 if the key never expires.
+func (c *BoltCache) TTL(key string) (time.Duration, bool) {
+	var ttl time.Duration
+	var found bool
+	_ = c.view(func(b *bbolt.Bucket, now int64) error {
+		var e entry
+		if e, found = liveEntry(b, key, now); found && e.expiration > 0 {
+			ttl = time.Duration(e.expiration - now)
+		}
+		return nil
+	})
+	return ttl, found
+}
+
+// collection reads the collection of the given kind stored at key into v.
+// It returns the entry and false if the key does not exist.
+func collection(b *bbolt.Bucket, key string, kind byte, now int64, v interface{}) (entry, bool, error) {
+	e, found := liveEntry(b, key, now)
+	if !found {
+		return entry{}, false, nil
+	}
+	if e.kind != kind {
+		return entry{}, false, types.ErrCacheWrongType
+	}
+	return e, true, json.Unmarshal(e.payload, v)
+}
+
+// putCollection stores the collection v at key keeping the expiration of e, empty collections are deleted.
+func putCollection(b *bbolt.Bucket, key string, e entry, kind byte, size int, v interface{}) error {
+	if size == 0 {
+		return b.Delete([]byte(key))
+	}
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return put(b, key, entry{expiration: e.expiration, kind: kind, payload: payload})
+}
+
+// push adds values to the head or tail of the list stored at key.
+func (c *BoltCache) push(key string, head bool, values []interface{}) (int64, error) {
+	encoded := make([]json.RawMessage, len(values))
+	for i, value := range values {
+		data, err := cache.EncodeValue(value)
+		if err != nil {
+			return 0, err
+		}
+		encoded[i] = data
+	}
+	var length int64
+	err := c.update(func(b *bbolt.Bucket, now int64) error {
+		var items []json.RawMessage
+		e, _, err := collection(b, key, kindList, now, &items)
+		if err != nil {
+			return err
+		}
+		if head {
+			reversed := make([]json.RawMessage, 0, len(encoded)+len(items))
+			for i := len(encoded) - 1; i >= 0; i-- {
+				reversed = append(reversed, encoded[i])
+			}
+			items = append(reversed, items...)
+		} else {
+			items = append(items, encoded...)
+		}
+		length = int64(len(items))
+		return putCollection(b, key, e, kindList, len(items), items)
+	})
+	return length, err
+}
+
+// pop removes and returns the first or last element of the list stored at key.
+func (c *BoltCache) pop(key string, head bool) (interface{}, error) {
+	var value interface{}
+	err := c.update(func(b *bbolt.Bucket, now int64) error {
+		var items []json.RawMessage
+		e, found, err := collection(b, key, kindList, now, &items)
+		if err != nil || !found || len(items) == 0 {
+			return err
+		}
+		if head {
+			value = cache.DecodeValue(items[0])
+			items = items[1:]
+		} else {
+			value = cache.DecodeValue(items[len(items)-1])
+			items = items[:len(items)-1]
+		}
+		return putCollection(b, key, e, kindList, len(items), items)
+	})
+	return value, err
+}
+
+// LPush inserts values at the head of the list stored at key.
+// Values are inserted one after another, so LPush(key, "a", "b") results in ["b", "a"].
+func (c *BoltCache) LPush(key string, values ...interface{}) (int64, error) {
+	return c.push(key, true, values)
+}
+
+// RPush appends values to the tail of the list stored at key.
+func (c *BoltCache) RPush(key string, values ...interface{}) (int64, error) {
+	return c.push(key, false, values)
+}
+
+// LPop removes and returns the first element of the list stored at key.
+func (c *BoltCache) LPop(key string) (interface{}, error) {
+	return c.pop(key, true)
+}
+
+// RPop removes and returns the last element of the list stored at key.
+func (c *BoltCache) RPop(key string) (interface{}, error) {
+	return c.pop(key, false)
+}
+
+// LRange returns the elements of the list between start and stop, both inclusive.
+// Negative indexes count from the tail, -1 is the last element.
+func (c *BoltCache) LRange(key string, start, stop int64) ([]interface{}, error) {
+	var result []interface{}
+	err := c.view(func(b *bbolt.Bucket, now int64) error {
+		var items []json.RawMessage
+		_, found, err := collection(b, key, kindList, now, &items)
+		if err != nil || !found {
+			return err
+		}
+		length := int64(len(items))
+		if start < 0 {
+			start += length
+		}
+		if stop < 0 {
+			stop += length
+		}
+		if start < 0 {
+			start = 0
+		}
+		if stop >= length {
+			stop = length - 1
+		}
+		result = []interface{}{}
+		for i := start; i <= stop; i++ {
+			result = append(result, cache.DecodeValue(items[i]))
+		}
+		return nil
+	})
+	return result, err
+}
+
+// LLen returns the length of the list stored at key.
+func (c *BoltCache) LLen(key string) (int64, error) {
+	var length int64
+	err := c.view(func(b *bbolt.Bucket, now int64) error {
+		var items []json.RawMessage
+		_, _, err := collection(b, key, kindList, now, &items)
+		length = int64(len(items))
+		return err
+	})
+	return length, err
+}
+
+// updateSet applies fn to the members of the set stored at key and returns the number of changed members.
+func (c *BoltCache) updateSet(key string, fn func(members map[string]struct{}) int64) (int64, error) {
+	var changed int64
+	err := c.update(func(b *bbolt.Bucket, now int64) error {
+		var list []string
+		e, _, err := collection(b, key, kindSet, now, &list)
+		if err != nil {
+			return err
+		}
+		members := make(map[string]struct{}, len(list))
+		for _, member := range list {
+			members[member] = struct{}{}
+		}
+		if changed = fn(members); changed == 0 {
+			return nil
+		}
+		return putCollection(b, key, e, kindSet, len(members), sortedMembers(members))
+	})
+	return changed, err
+}
+
+// SAdd adds members to the set stored at key and returns the number of members added.
+func (c *BoltCache) SAdd(key string, members ...string) (int64, error) {
+	return c.updateSet(key, func(set map[string]struct{}) int64 {
+		var added int64
+		for _, member := range members {
+			if _, ok := set[member]; !ok {
+				set[member] = struct{}{}
+				added++
+			}
+		}
+		return added
+	})
+}
+
+// SRem removes members from the set stored at key and returns the number of members removed.
+func (c *BoltCache) SRem(key string, members ...string) (int64, error) {
+	return c.updateSet(key, func(set map[string]struct{}) int64 {
+		var removed int64
+		for _, member := range members {
+			if _, ok := set[member]; ok {
+				delete(set, member)
+				removed++
+			}
+		}
+		return removed
+	})
+}
+
+// SMembers returns the sorted members of the set stored at key.
+func (c *BoltCache) SMembers(key string) ([]string, error) {
+	var members []string
+	err := c.view(func(b *bbolt.Bucket, now int64) error {
+		_, _, err := collection(b, key, kindSet, now, &members)
+		return err
+	})
+	return members, err
+}
+
+// SIsMember reports whether member belongs to the set stored at key.
+func (c *BoltCache) SIsMember(key string, member string) (bool, error) {
+	members, err := c.SMembers(key)
+	if err != nil {
+		return false, err
+	}
+	i := sort.SearchStrings(members, member)
+	return i < len(members) && members[i] == member, nil
+}
+
+// HSet sets the field of the hash stored at key.
+func (c *BoltCache) HSet(key string, field string, value interface{}) error {
+	data, err := cache.EncodeValue(value)
+	if err != nil {
+		return err
+	}
+	return c.update(func(b *bbolt.Bucket, now int64) error {
+		hash := map[string]json.RawMessage{}
+		e, _, err := collection(b, key, kindHash, now, &hash)
+		if err != nil {
+			return err
+		}
+		hash[field] = data
+		return putCollection(b, key, e, kindHash, len(hash), hash)
+	})
+}
+
+// HGet returns the field of the hash stored at key, nil if the field does not exist.
+func (c *BoltCache) HGet(key string, field string) (interface{}, error) {
+	var value interface{}
+	err := c.view(func(b *bbolt.Bucket, now int64) error {
+		var hash map[string]json.RawMessage
+		_, _, err := collection(b, key, kindHash, now, &hash)
+		if data, ok := hash[field]; ok {
+			value = cache.DecodeValue(data)
+		}
+		return err
+	})
+	return value, err
+}
+
+// HDel removes fields from the hash stored at key and returns the number of fields removed.
+func (c *BoltCache) HDel(key string, fields ...string) (int64, error) {
+	var removed int64
+	err := c.update(func(b *bbolt.Bucket, now int64) error {
+		var hash map[string]json.RawMessage
+		e, found, err := collection(b, key, kindHash, now, &hash)
+		if err != nil || !found {
+			return err
+		}
+		for _, field := range fields {
+			if _, ok := hash[field]; ok {
+				delete(hash, field)
+				removed++
+			}
+		}
+		if removed == 0 {
+			return nil
+		}
+		return putCollection(b, key, e, kindHash, len(hash), hash)
+	})
+	return removed, err
+}
+
+// HGetAll returns all fields of the hash stored at key.
+func (c *BoltCache) HGetAll(key string) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	err := c.view(func(b *bbolt.Bucket, now int64) error {
+		var hash map[string]json.RawMessage
+		_, _, err := collection(b, key, kindHash, now, &hash)
+		for field, data := range hash {
+			result[field] = cache.DecodeValue(data)
+		}
+		return err
+	})
+	return result, err
+}
+
+// exportEntry decodes an entry to the value returned by Get.
+func exportEntry(e entry) interface{} {
+	switch e.kind {
+	case kindList:
+		var items []json.RawMessage
+		_ = json.Unmarshal(e.payload, &items)
+		result := make([]interface{}, len(items))
+		for i, item := range items {
+			result[i] = cache.DecodeValue(item)
+		}
+		return result
+	case kindSet:
+		var members []string
+		_ = json.Unmarshal(e.payload, &members)
+		return members
+	case kindHash:
+		var hash map[string]json.RawMessage
+		_ = json.Unmarshal(e.payload, &hash)
+		result := make(map[string]interface{}, len(hash))
+		for field, data := range hash {
+			result[field] = cache.DecodeValue(data)
+		}
+		return result
+	default:
+		return cache.DecodeValue(e.payload)
+	}
+}
+
+func sortedMembers(members map[string]struct{}) []string {
+	result := make([]string, 0, len(members))
+	for member := range members {
+		result = append(result, member)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// toInt64 converts a decoded value to int64, floats must be integral.
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case float64:
+		if v != math.Trunc(v) || v > math.MaxInt64 || v < math.MinInt64 {
+			return 0, false
+		}
+		return int64(v), true
+	case string:
+		i, err := strconv.ParseInt(v, 10, 64)
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// parseExpiration converts a ttl like "10s" to the unix nano expiration time, 0 means never expires.
+func parseExpiration(ttl string) (int64, error) {
+	if ttl == "" {
+		return 0, nil
+	}
+	dur, err := time.ParseDuration(ttl)
+	if err != nil {
+		return 0, err
+	}
+	if dur > 0 {
+		return time.Now().Add(dur).UnixNano(), nil
+	}
+	return 0, nil
+}
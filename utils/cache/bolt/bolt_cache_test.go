@@ -0,0 +1,379 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bolt
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/cache"
+	"go.etcd.io/bbolt"
+)
+
+func newTestCache(t *testing.T, config Config) (*BoltCache, string) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	c, err := NewBoltCache(path, config)
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		_ = c.Close()
+	})
+	return c, path
+}
+
+func TestNewBoltCache(t *testing.T) {
+	_, err := NewBoltCache(filepath.Join(t.TempDir(), "notFound", "cache.db"), Config{})
+	assert.NotNil(t, err)
+
+	c, path := newTestCache(t, Config{})
+	//文件被其他实例锁定
+	_, err = NewBoltCache(path, Config{OpenTimeout: 100 * time.Millisecond})
+	assert.NotNil(t, err)
+
+	assert.Nil(t, c.Close())
+	assert.Nil(t, c.Close())
+	assert.Equal(t, ErrClosed, c.Set("a", 1, ""))
+	assert.Nil(t, c.Get("a"))
+	_, err = c.Incr("a", 1, "")
+	assert.Equal(t, ErrClosed, err)
+}
+
+func TestBoltCache(t *testing.T) {
+	c, _ := newTestCache(t, Config{})
+
+	t.Run("SetAndGet", func(t *testing.T) {
+		assert.Nil(t, c.Set("str", "value1", ""))
+		assert.Nil(t, c.Set("int", 10, ""))
+		assert.Nil(t, c.Set("float", 1.5, ""))
+		assert.Nil(t, c.Set("map", map[string]interface{}{"a": 1, "b": "x"}, ""))
+		assert.Equal(t, "value1", c.Get("str"))
+		assert.Equal(t, int64(10), c.Get("int"))
+		assert.Equal(t, 1.5, c.Get("float"))
+		assert.Equal(t, map[string]interface{}{"a": int64(1), "b": "x"}, c.Get("map"))
+		assert.Nil(t, c.Get("notFound"))
+		assert.True(t, c.Has("str"))
+		assert.False(t, c.Has("notFound"))
+
+		assert.NotNil(t, c.Set("ttl", 1, "abc"))
+		assert.Nil(t, c.Set("ttl", 1, "50ms"))
+		assert.True(t, c.Has("ttl"))
+		time.Sleep(80 * time.Millisecond)
+		assert.False(t, c.Has("ttl"))
+		assert.Nil(t, c.Get("ttl"))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		assert.Nil(t, c.Set("del", 1, ""))
+		assert.Nil(t, c.Delete("del"))
+		assert.False(t, c.Has("del"))
+		assert.Nil(t, c.Delete("notFound"))
+	})
+
+	t.Run("Prefix", func(t *testing.T) {
+		assert.Nil(t, c.Set("p:a", 1, ""))
+		assert.Nil(t, c.Set("p:b", 2, ""))
+		assert.Nil(t, c.Set("p:expired", 3, "10ms"))
+		assert.Nil(t, c.Set("pa", 4, ""))
+		_, _ = c.RPush("p:list", "x")
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(t, map[string]interface{}{
+			"p:a":    int64(1),
+			"p:b":    int64(2),
+			"p:list": []interface{}{"x"},
+		}, c.GetByPrefix("p:"))
+		assert.Equal(t, 0, len(c.GetByPrefix("notFound")))
+
+		assert.Nil(t, c.DeleteByPrefix("p:"))
+		assert.Equal(t, 0, len(c.GetByPrefix("p:")))
+		assert.True(t, c.Has("pa"))
+	})
+}
+
+func TestBoltCache_Persistence(t *testing.T) {
+	c, path := newTestCache(t, Config{})
+	assert.Nil(t, c.Set("a", "1", ""))
+	assert.Nil(t, c.Set("b", 2, "1h"))
+	assert.Nil(t, c.Set("expired", 3, "10ms"))
+	_, _ = c.RPush("list", 1, "x")
+	_, _ = c.SAdd("set", "m1")
+	assert.Nil(t, c.HSet("hash", "f", 1.5))
+	assert.Nil(t, c.Close())
+
+	time.Sleep(20 * time.Millisecond)
+	c, err := NewBoltCache(path, Config{})
+	assert.Nil(t, err)
+	defer c.Close()
+	assert.Equal(t, "1", c.Get("a"))
+	assert.Equal(t, int64(2), c.Get("b"))
+	ttl, ok := c.TTL("b")
+	assert.True(t, ok)
+	assert.True(t, ttl > 59*time.Minute && ttl <= time.Hour)
+	assert.False(t, c.Has("expired"))
+	assert.Equal(t, []interface{}{int64(1), "x"}, c.Get("list"))
+	assert.Equal(t, []string{"m1"}, c.Get("set"))
+	assert.Equal(t, map[string]interface{}{"f": 1.5}, c.Get("hash"))
+}
+
+func TestBoltCache_GcAndCompact(t *testing.T) {
+	c, path := newTestCache(t, Config{GcInterval: 20 * time.Millisecond})
+	for i := 0; i < gcBatchSize+10; i++ {
+		assert.Nil(t, c.Set(fmt.Sprintf("k%d", i), i, "10ms"))
+	}
+	assert.Nil(t, c.Set("keep", "value", ""))
+	time.Sleep(100 * time.Millisecond)
+	var count int
+	_ = c.view(func(b *bbolt.Bucket, now int64) error {
+		count = b.Stats().KeyN
+		return nil
+	})
+	assert.Equal(t, 1, count)
+
+	for i := 0; i < 2000; i++ {
+		assert.Nil(t, c.Set(fmt.Sprintf("big%d", i), fmt.Sprintf("%01000d", i), ""))
+	}
+	assert.Nil(t, c.DeleteByPrefix("big"))
+	info, _ := os.Stat(path)
+	sizeBefore := info.Size()
+	assert.Nil(t, c.Compact())
+	info, _ = os.Stat(path)
+	assert.True(t, info.Size() < sizeBefore)
+	_, err := os.Stat(path + compactSuffix)
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, "value", c.Get("keep"))
+	assert.Nil(t, c.Set("afterCompact", 1, ""))
+	assert.Equal(t, int64(1), c.Get("afterCompact"))
+
+	//中断的压缩残留文件在打开时被删除
+	assert.Nil(t, c.Close())
+	assert.Nil(t, os.WriteFile(path+compactSuffix, []byte("partial"), 0600))
+	c, err = NewBoltCache(path, Config{CompactInterval: 20 * time.Millisecond})
+	assert.Nil(t, err)
+	_, err = os.Stat(path + compactSuffix)
+	assert.True(t, os.IsNotExist(err))
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, "value", c.Get("keep"))
+	assert.Nil(t, c.Close())
+}
+
+func TestBoltCache_Atomic(t *testing.T) {
+	c, _ := newTestCache(t, Config{})
+
+	t.Run("Incr", func(t *testing.T) {
+		v, err := c.Incr("counter", 1, "")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), v)
+		v, err = c.Incr("counter", 5, "")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(6), v)
+		v, err = c.Decr("counter", 2, "")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(4), v)
+		assert.Equal(t, int64(4), c.Get("counter"))
+
+		assert.Nil(t, c.Set("strCounter", "10", ""))
+		v, err = c.Incr("strCounter", 1, "")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(11), v)
+
+		assert.Nil(t, c.Set("str", "abc", ""))
+		_, err = c.Incr("str", 1, "")
+		assert.Equal(t, types.ErrCacheWrongType, err)
+		_, err = c.Incr("counter", 1, "abc")
+		assert.NotNil(t, err)
+
+		//ttl只在创建时生效
+		_, _ = c.Incr("ttlCounter", 1, "1h")
+		_, _ = c.Incr("ttlCounter", 1, "")
+		ttl, ok := c.TTL("ttlCounter")
+		assert.True(t, ok)
+		assert.True(t, ttl > 0)
+	})
+
+	t.Run("IncrConcurrent", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, _ = c.Incr("concurrent", 1, "")
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int64(20), c.Get("concurrent"))
+	})
+
+	t.Run("CompareAndSet", func(t *testing.T) {
+		ok, err := c.CompareAndSet("cas", nil, "v1", "")
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		ok, _ = c.CompareAndSet("cas", nil, "v2", "")
+		assert.False(t, ok)
+		ok, _ = c.CompareAndSet("cas", "v0", "v2", "")
+		assert.False(t, ok)
+		ok, _ = c.CompareAndSet("cas", "v1", "v2", "")
+		assert.True(t, ok)
+		assert.Equal(t, "v2", c.Get("cas"))
+		ok, _ = c.CompareAndSet("notFound", "v1", "v2", "")
+		assert.False(t, ok)
+
+		assert.Nil(t, c.Set("casInt", 1, ""))
+		ok, _ = c.CompareAndSet("casInt", 1, 2, "")
+		assert.True(t, ok)
+	})
+
+	t.Run("SetIfAbsent", func(t *testing.T) {
+		ok, err := c.SetIfAbsent("absent", 1, "20ms")
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		ok, _ = c.SetIfAbsent("absent", 2, "")
+		assert.False(t, ok)
+		time.Sleep(30 * time.Millisecond)
+		ok, _ = c.SetIfAbsent("absent", 3, "")
+		assert.True(t, ok)
+		assert.Equal(t, int64(3), c.Get("absent"))
+	})
+
+	t.Run("ExpireAndTTL", func(t *testing.T) {
+		assert.Nil(t, c.Set("expire", 1, ""))
+		ttl, ok := c.TTL("expire")
+		assert.True(t, ok)
+		assert.Equal(t, time.Duration(0), ttl)
+
+		ok, err := c.Expire("expire", "1m")
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		ttl, _ = c.TTL("expire")
+		assert.True(t, ttl > 0 && ttl <= time.Minute)
+
+		ok, _ = c.Expire("expire", "")
+		assert.True(t, ok)
+		ttl, ok = c.TTL("expire")
+		assert.True(t, ok)
+		assert.Equal(t, time.Duration(0), ttl)
+
+		ok, _ = c.Expire("notFound", "1m")
+		assert.False(t, ok)
+		_, ok = c.TTL("notFound")
+		assert.False(t, ok)
+		_, err = c.Expire("expire", "abc")
+		assert.NotNil(t, err)
+	})
+}
+
+func TestBoltCache_Collections(t *testing.T) {
+	c, _ := newTestCache(t, Config{})
+
+	t.Run("List", func(t *testing.T) {
+		n, err := c.RPush("list", "a", "b")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), n)
+		n, _ = c.LPush("list", 1, 2)
+		assert.Equal(t, int64(4), n)
+		values, _ := c.LRange("list", 0, -1)
+		assert.Equal(t, []interface{}{int64(2), int64(1), "a", "b"}, values)
+		values, _ = c.LRange("list", -2, 10)
+		assert.Equal(t, []interface{}{"a", "b"}, values)
+		values, _ = c.LRange("list", 3, 1)
+		assert.Equal(t, 0, len(values))
+
+		v, _ := c.LPop("list")
+		assert.Equal(t, int64(2), v)
+		v, _ = c.RPop("list")
+		assert.Equal(t, "b", v)
+		n, _ = c.LLen("list")
+		assert.Equal(t, int64(2), n)
+		_, _ = c.LPop("list")
+		_, _ = c.LPop("list")
+		assert.False(t, c.Has("list"))
+		v, err = c.LPop("list")
+		assert.Nil(t, err)
+		assert.Nil(t, v)
+	})
+
+	t.Run("Set", func(t *testing.T) {
+		n, err := c.SAdd("set", "b", "a", "b")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), n)
+		members, _ := c.SMembers("set")
+		assert.Equal(t, []string{"a", "b"}, members)
+		ok, _ := c.SIsMember("set", "a")
+		assert.True(t, ok)
+		ok, _ = c.SIsMember("set", "c")
+		assert.False(t, ok)
+		n, _ = c.SRem("set", "a", "c")
+		assert.Equal(t, int64(1), n)
+		n, _ = c.SRem("set", "b")
+		assert.Equal(t, int64(1), n)
+		assert.False(t, c.Has("set"))
+	})
+
+	t.Run("Hash", func(t *testing.T) {
+		assert.Nil(t, c.HSet("hash", "f1", 1))
+		assert.Nil(t, c.HSet("hash", "f2", "v2"))
+		v, _ := c.HGet("hash", "f1")
+		assert.Equal(t, int64(1), v)
+		v, _ = c.HGet("hash", "notFound")
+		assert.Nil(t, v)
+		all, _ := c.HGetAll("hash")
+		assert.Equal(t, map[string]interface{}{"f1": int64(1), "f2": "v2"}, all)
+		n, _ := c.HDel("hash", "f1", "notFound")
+		assert.Equal(t, int64(1), n)
+		n, _ = c.HDel("hash", "f2")
+		assert.Equal(t, int64(1), n)
+		assert.False(t, c.Has("hash"))
+	})
+
+	t.Run("KeepExpiration", func(t *testing.T) {
+		_, _ = c.RPush("ttlList", 1)
+		ok, _ := c.Expire("ttlList", "1h")
+		assert.True(t, ok)
+		_, _ = c.RPush("ttlList", 2)
+		ttl, _ := c.TTL("ttlList")
+		assert.True(t, ttl > 0)
+	})
+
+	t.Run("WrongType", func(t *testing.T) {
+		assert.Nil(t, c.Set("str", "abc", ""))
+		_, err := c.RPush("str", 1)
+		assert.Equal(t, types.ErrCacheWrongType, err)
+		_, err = c.SAdd("str", "a")
+		assert.Equal(t, types.ErrCacheWrongType, err)
+		assert.Equal(t, types.ErrCacheWrongType, c.HSet("str", "f", 1))
+		_, _ = c.RPush("list", 1)
+		_, err = c.Incr("list", 1, "")
+		assert.Equal(t, types.ErrCacheWrongType, err)
+	})
+}
+
+func TestBoltCache_Namespace(t *testing.T) {
+	c, _ := newTestCache(t, Config{})
+	chainCache := cache.NewNamespaceCache(c, "chain1:")
+	assert.Nil(t, chainCache.Set("a", 1, ""))
+	_, _ = chainCache.Incr("counter", 1, "")
+	assert.Equal(t, int64(1), c.Get("chain1:a"))
+	assert.Equal(t, int64(1), c.Get("chain1:counter"))
+	assert.Nil(t, c.Set("chain2:a", 2, ""))
+
+	assert.Nil(t, chainCache.DeleteByPrefix(""))
+	assert.False(t, c.Has("chain1:a"))
+	assert.True(t, c.Has("chain2:a"))
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+
+	json2 "github.com/rulego/rulego/utils/json"
+)
+
+// EncodeValue encodes a value as JSON for caches that store values out of process.
+func EncodeValue(value interface{}) ([]byte, error) {
+	return json2.Marshal(value)
+}
+
+// DecodeValue decodes a value encoded by EncodeValue.
+// Integers are decoded as int64 and other numbers as float64, so counters keep their type.
+// Data that is not valid JSON, e.g. written by other clients, is returned as string.
+func DecodeValue(data []byte) interface{} {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		return string(data)
+	}
+	return convertNumber(value)
+}
+
+// convertNumber converts json.Number to int64 or float64.
+func convertNumber(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = convertNumber(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = convertNumber(item)
+		}
+	}
+	return value
+}
@@ -17,10 +17,13 @@
 package cache
 
 import (
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/api/types/metrics"
 )
 
 var DefaultCache = NewMemoryCache(time.Minute * 5)
@@ -33,6 +36,13 @@ type MemoryCache struct {
 	stopGc     chan struct{} // Channel to signal GC to stop
 	ticker     *time.Ticker  // Ticker for GC
 	gcInterval time.Duration // GC interval duration
+	// listeners receive expiry, delete and eviction events
+	listeners  map[string]func(event types.CacheEvent)
+	listenerMu sync.RWMutex
+	listenerId int64
+	// tracker enforces size limits, nil if the cache is unbounded
+	tracker *usageTracker
+	metrics *metrics.CacheMetrics
 }
 
 // item represents a cached item with its value and expiration time.
@@ -48,15 +58,22 @@ type item struct {
 // - An empty items map
 // - A stopGc channel for controlling garbage collection
 // Note: Garbage collection is not started automatically, call StartGC() to enable it.
-func NewMemoryCache(gcInterval time.Duration) *MemoryCache {
+// The cache is unbounded unless limits are set by options, e.g.:
+//
+//	NewMemoryCache(time.Minute, WithMaxEntries(10000), WithEvictionPolicy(EvictionLFU))
+func NewMemoryCache(gcInterval time.Duration, opts ...MemoryCacheOption) *MemoryCache {
 	c := &MemoryCache{
 		items:      make(map[string]item),
 		stopGc:     make(chan struct{}),
 		gcInterval: time.Minute * 5, // Default 5 minute
+		metrics:    metrics.NewCacheMetrics(),
 	}
 	if gcInterval > 0 {
 		c.gcInterval = gcInterval
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
 	// GC is no longer started automatically
 	return c
 }
@@ -73,36 +90,54 @@ func NewMemoryCache(gcInterval time.Duration) *MemoryCache {
 // If ttl is 0, the item will not expire.
 // ttl should be a string (e.g. "10m").
 func (c *MemoryCache) Set(key string, value interface{}, ttl string) error {
-	var expiration int64
-	var dur time.Duration
-	var err error
-
-	if ttl != "" {
-		dur, err = time.ParseDuration(ttl)
-		if err != nil {
-			return err
-		}
-	}
-
-	if dur > 0 {
-		expiration = time.Now().Add(dur).UnixNano()
+	expiration, err := parseExpiration(ttl)
+	if err != nil {
+		return err
 	}
 
 	c.mu.Lock()
-	c.items[key] = item{
+	var events []types.CacheEvent
+	if old, found := c.items[key]; found && old.expiration > 0 && time.Now().UnixNano() > old.expiration {
+		// The old item expired but has not been collected yet, report it before overwriting
+		events = c.appendEvent(events, types.CacheEventExpired, key, old.value)
+	}
+	c.storeItem(key, item{
 		value:      value,
 		expiration: expiration,
+	})
+	events = c.evict(events, key)
+	c.unlockAndNotify(events, expiration)
+
+	return nil
+}
+
+// parseExpiration converts a ttl string to an expiration Unix nano timestamp, 0 means never expire.
+func parseExpiration(ttl string) (int64, error) {
+	if ttl == "" {
+		return 0, nil
+	}
+	dur, err := time.ParseDuration(ttl)
+	if err != nil {
+		return 0, err
 	}
+	if dur > 0 {
+		return time.Now().Add(dur).UnixNano(), nil
+	}
+	return 0, nil
+}
+
+// unlockAndNotify releases the write lock, publishes events and starts GC if an expirable
+// item was written and GC is not running. It must be called while holding c.mu.
+func (c *MemoryCache) unlockAndNotify(events []types.CacheEvent, expiration int64) {
 	// If an expirable item was added and GC is not running (ticker is nil),
 	// set flag to start GC after releasing the lock.
 	shouldStartGC := expiration > 0 && c.ticker == nil
 	c.mu.Unlock()
 
+	c.notify(events)
 	if shouldStartGC {
 		c.StartGC() // StartGC handles its own locking
 	}
-
-	return nil
 }
 
 // Get retrieves a value from the cache by its key.
@@ -120,16 +155,20 @@ func (c *MemoryCache) Get(key string) interface{} {
 
 	it, found := c.items[key]
 	if !found {
+		c.metrics.IncrementMisses()
 		return nil
 	}
 
 	if it.expiration > 0 && time.Now().UnixNano() > it.expiration {
 		// Item has expired
 		// We can also delete it here, but the GC will take care of it
+		c.metrics.IncrementMisses()
 		return nil
 	}
 
-	return it.value
+	c.metrics.IncrementHits()
+	c.tracker.touch(key)
+	return exportValue(it.value)
 }
 
 // Has checks if a prefixed key exists in the cache
@@ -156,8 +195,13 @@ func (c *MemoryCache) Has(key string) bool {
 
 func (c *MemoryCache) Delete(key string) error {
 	c.mu.Lock()
-	defer c.mu.Unlock()
-	delete(c.items, key)
+	var events []types.CacheEvent
+	if it, found := c.items[key]; found {
+		events = c.appendRemovedEvent(events, key, it, time.Now().UnixNano())
+		c.removeItem(key)
+	}
+	c.mu.Unlock()
+	c.notify(events)
 	return nil
 }
 
@@ -169,12 +213,16 @@ func (c *MemoryCache) Delete(key string) error {
 //   - error: Always nil in current implementation
 func (c *MemoryCache) DeleteByPrefix(prefix string) error {
 	c.mu.Lock()
-	defer c.mu.Unlock()
-	for k := range c.items {
+	var events []types.CacheEvent
+	now := time.Now().UnixNano()
+	for k, it := range c.items {
 		if len(k) >= len(prefix) && k[:len(prefix)] == prefix {
-			delete(c.items, k)
+			events = c.appendRemovedEvent(events, k, it, now)
+			c.removeItem(k)
 		}
 	}
+	c.mu.Unlock()
+	c.notify(events)
 	return nil
 }
 
@@ -194,7 +242,7 @@ func (c *MemoryCache) GetByPrefix(prefix string) map[string]interface{} {
 	for k, v := range c.items {
 		if len(k) >= len(prefix) && k[:len(prefix)] == prefix {
 			if v.expiration == 0 || now <= v.expiration {
-				result[k] = v.value
+				result[k] = exportValue(v.value)
 			}
 		}
 	}
@@ -295,6 +343,7 @@ func (c *MemoryCache) deleteExpired() {
 	// Step 2: Delete collected keys in batches under a write lock.
 	// Batching helps to avoid holding the write lock for too long if there are many expired keys.
 	const batchSize = 300 // Number of keys to delete in each batch.
+	var events []types.CacheEvent
 	for i := 0; i < len(expiredKeys); i += batchSize {
 		c.mu.Lock()
 		// Determine the end of the current batch
@@ -309,10 +358,13 @@ func (c *MemoryCache) deleteExpired() {
 			// This is important because the item might have been updated or deleted
 			// by another goroutine between the RUnlock (after collecting keys) and this Lock.
 			if item, found := c.items[k]; found && item.expiration > 0 && now > item.expiration {
-				delete(c.items, k)
+				events = c.appendEvent(events, types.CacheEventExpired, k, item.value)
+				c.removeItem(k)
 			}
 		}
 		c.mu.Unlock()
+		c.notify(events)
+		events = nil
 		// Consider a small sleep here if GC is aggressive or many batches,
 		// to yield to other goroutines, e.g., time.Sleep(time.Millisecond).
 		// For now, keeping it simple without the sleep.
@@ -334,6 +386,84 @@ func (c *MemoryCache) deleteExpired() {
 	}
 }
 
+// DeleteExpired removes all expired items immediately and publishes their expiry events,
+// without waiting for the next GC cycle.
+func (c *MemoryCache) DeleteExpired() {
+	c.deleteExpired()
+}
+
+// Metrics returns the hit, miss and eviction statistics of the cache, Entries and Bytes are updated on each call.
+// Bytes is only accounted if limits are set.
+func (c *MemoryCache) Metrics() *metrics.CacheMetrics {
+	c.mu.RLock()
+	c.metrics.SetEntries(int64(len(c.items)))
+	c.mu.RUnlock()
+	c.metrics.SetBytes(c.tracker.totalBytes())
+	return c.metrics
+}
+
+// AddListener registers a listener for expiry, delete and eviction events.
+// Expiry events are published when expired items are collected by GC or DeleteExpired,
+// or when an expired item is overwritten before it has been collected.
+func (c *MemoryCache) AddListener(listener func(event types.CacheEvent)) string {
+	id := strconv.FormatInt(atomic.AddInt64(&c.listenerId, 1), 10)
+	c.listenerMu.Lock()
+	defer c.listenerMu.Unlock()
+	if c.listeners == nil {
+		c.listeners = make(map[string]func(event types.CacheEvent))
+	}
+	c.listeners[id] = listener
+	return id
+}
+
+// RemoveListener removes the listener with the given id.
+func (c *MemoryCache) RemoveListener(id string) {
+	c.listenerMu.Lock()
+	defer c.listenerMu.Unlock()
+	delete(c.listeners, id)
+}
+
+// hasListeners reports whether any listener is registered.
+func (c *MemoryCache) hasListeners() bool {
+	c.listenerMu.RLock()
+	defer c.listenerMu.RUnlock()
+	return len(c.listeners) > 0
+}
+
+// appendEvent appends an event if there are listeners, events are only built when needed.
+func (c *MemoryCache) appendEvent(events []types.CacheEvent, eventType, key string, value interface{}) []types.CacheEvent {
+	if !c.hasListeners() {
+		return events
+	}
+	return append(events, types.CacheEvent{Type: eventType, Key: key, Value: exportValue(value), Ts: time.Now().UnixMilli()})
+}
+
+// appendRemovedEvent appends a delete event, or an expiry event if the removed item had already expired.
+func (c *MemoryCache) appendRemovedEvent(events []types.CacheEvent, key string, it item, now int64) []types.CacheEvent {
+	if it.expiration > 0 && now > it.expiration {
+		return c.appendEvent(events, types.CacheEventExpired, key, it.value)
+	}
+	return c.appendEvent(events, types.CacheEventDeleted, key, it.value)
+}
+
+// notify publishes events to all listeners. It must be called without holding c.mu.
+func (c *MemoryCache) notify(events []types.CacheEvent) {
+	if len(events) == 0 {
+		return
+	}
+	c.listenerMu.RLock()
+	listeners := make([]func(event types.CacheEvent), 0, len(c.listeners))
+	for _, listener := range c.listeners {
+		listeners = append(listeners, listener)
+	}
+	c.listenerMu.RUnlock()
+	for _, event := range events {
+		for _, listener := range listeners {
+			listener(event)
+		}
+	}
+}
+
 // NamespaceCache is a namespace-based cache wrapper
 // It implements the Cache interface, adding namespace prefix functionality to the underlying cache
 // Key features:
@@ -448,8 +578,44 @@ func (c *NamespaceCache) GetByPrefix(prefix string) map[string]interface{} {
 	return newResult
 }
 
+// AddListener registers a listener for events of keys in this namespace.
+// The namespace prefix is removed from event keys. If the underlying cache does not
+// implement types.CacheNotifier, an empty id is returned and no events are published.
+func (c *NamespaceCache) AddListener(listener func(event types.CacheEvent)) string {
+	if c == nil || c.Cache == nil {
+		return ""
+	}
+	notifier, ok := c.Cache.(types.CacheNotifier)
+	if !ok {
+		return ""
+	}
+	return notifier.AddListener(func(event types.CacheEvent) {
+		if len(event.Key) >= len(c.Namespace) && event.Key[:len(c.Namespace)] == c.Namespace {
+			event.Key = event.Key[len(c.Namespace):]
+			listener(event)
+		}
+	})
+}
+
+// RemoveListener removes the listener with the given id.
+func (c *NamespaceCache) RemoveListener(id string) {
+	if c == nil || c.Cache == nil {
+		return
+	}
+	if notifier, ok := c.Cache.(types.CacheNotifier); ok {
+		notifier.RemoveListener(id)
+	}
+}
+
 // Ensure NamespaceCache implements the Cache interface.
 var _ types.Cache = (*NamespaceCache)(nil)
 
 // Ensure MemoryCache implements the Cache interface.
 var _ types.Cache = (*MemoryCache)(nil)
+
+// Ensure MemoryCache implements the CacheMetricsProvider interface.
+var _ types.CacheMetricsProvider = (*MemoryCache)(nil)
+
+// Ensure MemoryCache and NamespaceCache implement the CacheNotifier interface.
+var _ types.CacheNotifier = (*MemoryCache)(nil)
+var _ types.CacheNotifier = (*NamespaceCache)(nil)
@@ -0,0 +1,530 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cache
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rulego/rulego/api/types"
+)
+
+// EvictionPolicy selects the entry evicted when a size limit of a MemoryCache is exceeded.
+type EvictionPolicy string
+
+const (
+	// EvictionLRU evicts the least recently used entry.
+	EvictionLRU EvictionPolicy = "lru"
+	// EvictionLFU evicts the least frequently used entry, ties are broken by recency.
+	EvictionLFU EvictionPolicy = "lfu"
+)
+
+const (
+	// entryOverhead is the estimated bookkeeping size of an entry in bytes.
+	entryOverhead = 64
+	// elemOverhead is the estimated bookkeeping size of a collection element in bytes.
+	elemOverhead = 16
+)
+
+// Quota limits the number of entries and the estimated size of a namespace, 0 means no limit.
+type Quota struct {
+	MaxEntries int64 `json:"maxEntries"`
+	MaxBytes   int64 `json:"maxBytes"`
+}
+
+func (q Quota) exceeded(entries, bytes int64) bool {
+	return (q.MaxEntries > 0 && entries > q.MaxEntries) || (q.MaxBytes > 0 && bytes > q.MaxBytes)
+}
+
+// MemoryCacheOption configures a MemoryCache.
+type MemoryCacheOption func(c *MemoryCache)
+
+// WithMaxEntries limits the number of entries, 0 means no limit.
+func WithMaxEntries(maxEntries int64) MemoryCacheOption {
+	return func(c *MemoryCache) {
+		c.trackerOrNew().quota.MaxEntries = maxEntries
+	}
+}
+
+// WithMaxBytes limits the estimated size of all entries in bytes, 0 means no limit.
+// Sizes are estimated from keys and values, so the limit is approximate.
+func WithMaxBytes(maxBytes int64) MemoryCacheOption {
+	return func(c *MemoryCache) {
+		c.trackerOrNew().quota.MaxBytes = maxBytes
+	}
+}
+
+// WithEvictionPolicy sets the eviction policy, default EvictionLRU.
+func WithEvictionPolicy(policy EvictionPolicy) MemoryCacheOption {
+	return func(c *MemoryCache) {
+		c.trackerOrNew().setPolicy(policy)
+	}
+}
+
+// WithNamespaceQuota limits the keys starting with namespace, see MemoryCache.SetNamespaceQuota.
+func WithNamespaceQuota(namespace string, quota Quota) MemoryCacheOption {
+	return func(c *MemoryCache) {
+		c.trackerOrNew().setNamespaceQuota(namespace, quota)
+	}
+}
+
+// trackerOrNew returns the usage tracker, creating it if needed.
+// It must be called during construction or while holding the write lock.
+func (c *MemoryCache) trackerOrNew() *usageTracker {
+	if c.tracker == nil {
+		c.tracker = newUsageTracker()
+		for key, it := range c.items {
+			c.tracker.set(key, entrySize(key, it.value))
+		}
+	}
+	return c.tracker
+}
+
+// SetNamespaceQuota limits the entries whose key starts with namespace, e.g. the ChainCache of
+// rule chain "chain01" uses namespace "chain01"+types.NamespaceSeparator.
+// When a write exceeds the quota, entries of that namespace are evicted according to the eviction policy.
+// An entry belongs to the longest matching namespace. A zero quota removes the namespace limit.
+func (c *MemoryCache) SetNamespaceQuota(namespace string, quota Quota) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.trackerOrNew().setNamespaceQuota(namespace, quota)
+}
+
+// evict removes entries until all limits are met and appends their eviction events.
+// The entry of key, which has just been written, is only evicted if no other entry over the limit is left.
+// It must be called while holding the write lock.
+func (c *MemoryCache) evict(events []types.CacheEvent, key string) []types.CacheEvent {
+	for _, k := range c.tracker.victims(key) {
+		if it, ok := c.items[k]; ok {
+			delete(c.items, k)
+			events = c.appendEvent(events, types.CacheEventEvicted, k, it.value)
+			c.metrics.IncrementEvictions()
+		}
+	}
+	return events
+}
+
+// removeItem removes an item and stops tracking its usage. It must be called while holding the write lock.
+func (c *MemoryCache) removeItem(key string) {
+	delete(c.items, key)
+	c.tracker.remove(key)
+}
+
+// storeItem stores an item and records its size. It must be called while holding the write lock.
+func (c *MemoryCache) storeItem(key string, it item) {
+	c.items[key] = it
+	c.tracker.set(key, entrySize(key, it.value))
+}
+
+// entryMeta holds the usage of an entry.
+type entryMeta struct {
+	key  string
+	size int64
+	freq int64
+	// seq is the access sequence number, higher is more recent
+	seq int64
+	// index is the position in the global heap
+	index int
+	// nsIndex is the position in the namespace heap
+	nsIndex int
+	ns      *namespaceUsage
+}
+
+// entryHeap orders entries by eviction priority, the first entry is evicted first.
+type entryHeap struct {
+	entries []*entryMeta
+	lfu     bool
+	// namespace reports whether this is a namespace heap, which uses entryMeta.nsIndex
+	namespace bool
+}
+
+func (h *entryHeap) Len() int {
+	return len(h.entries)
+}
+
+func (h *entryHeap) Less(i, j int) bool {
+	a, b := h.entries[i], h.entries[j]
+	if h.lfu && a.freq != b.freq {
+		return a.freq < b.freq
+	}
+	return a.seq < b.seq
+}
+
+func (h *entryHeap) Swap(i, j int) {
+	h.entries[i], h.entries[j] = h.entries[j], h.entries[i]
+	h.setIndex(i)
+	h.setIndex(j)
+}
+
+func (h *entryHeap) Push(x interface{}) {
+	h.entries = append(h.entries, x.(*entryMeta))
+	h.setIndex(len(h.entries) - 1)
+}
+
+func (h *entryHeap) Pop() interface{} {
+	last := len(h.entries) - 1
+	meta := h.entries[last]
+	h.entries[last] = nil
+	h.entries = h.entries[:last]
+	return meta
+}
+
+func (h *entryHeap) setIndex(i int) {
+	if h.namespace {
+		h.entries[i].nsIndex = i
+	} else {
+		h.entries[i].index = i
+	}
+}
+
+func (h *entryHeap) indexOf(meta *entryMeta) int {
+	if h.namespace {
+		return meta.nsIndex
+	}
+	return meta.index
+}
+
+// namespaceUsage holds the quota and usage of a namespace.
+type namespaceUsage struct {
+	namespace string
+	quota     Quota
+	entries   int64
+	bytes     int64
+	heap      *entryHeap
+}
+
+// usageTracker tracks the size and usage of entries to enforce limits.
+// It has its own lock, so that reads holding the read lock of the cache can record accesses.
+type usageTracker struct {
+	mu      sync.Mutex
+	lfu     bool
+	quota   Quota
+	entries map[string]*entryMeta
+	heap    *entryHeap
+	bytes   int64
+	seq     int64
+	// namespaces is sorted by namespace length descending, so the first match is the longest
+	namespaces []*namespaceUsage
+}
+
+func newUsageTracker() *usageTracker {
+	return &usageTracker{
+		entries: make(map[string]*entryMeta),
+		heap:    &entryHeap{},
+	}
+}
+
+func (t *usageTracker) setPolicy(policy EvictionPolicy) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lfu = policy == EvictionLFU
+	t.heap.lfu = t.lfu
+	heap.Init(t.heap)
+	for _, ns := range t.namespaces {
+		ns.heap.lfu = t.lfu
+		heap.Init(ns.heap)
+	}
+}
+
+func (t *usageTracker) setNamespaceQuota(namespace string, quota Quota) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, ns := range t.namespaces {
+		if ns.namespace == namespace {
+			if quota.MaxEntries <= 0 && quota.MaxBytes <= 0 {
+				t.namespaces = append(t.namespaces[:i], t.namespaces[i+1:]...)
+				t.reassignNamespaces()
+			} else {
+				ns.quota = quota
+			}
+			return
+		}
+	}
+	if quota.MaxEntries <= 0 && quota.MaxBytes <= 0 {
+		return
+	}
+	t.namespaces = append(t.namespaces, &namespaceUsage{namespace: namespace, quota: quota})
+	sort.SliceStable(t.namespaces, func(i, j int) bool {
+		return len(t.namespaces[i].namespace) > len(t.namespaces[j].namespace)
+	})
+	t.reassignNamespaces()
+}
+
+// reassignNamespaces rebuilds the namespace usage of all entries. It must be called while holding t.mu.
+func (t *usageTracker) reassignNamespaces() {
+	for _, ns := range t.namespaces {
+		ns.entries, ns.bytes = 0, 0
+		ns.heap = &entryHeap{lfu: t.lfu, namespace: true}
+	}
+	for _, meta := range t.entries {
+		meta.ns = nil
+		t.addToNamespace(meta)
+	}
+}
+
+// addToNamespace adds an entry to its namespace. It must be called while holding t.mu.
+func (t *usageTracker) addToNamespace(meta *entryMeta) {
+	for _, ns := range t.namespaces {
+		if strings.HasPrefix(meta.key, ns.namespace) {
+			meta.ns = ns
+			ns.entries++
+			ns.bytes += meta.size
+			heap.Push(ns.heap, meta)
+			return
+		}
+	}
+}
+
+// set records the size of an entry that has been written, the write counts as an access.
+func (t *usageTracker) set(key string, size int64) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	meta, ok := t.entries[key]
+	if !ok {
+		t.seq++
+		meta = &entryMeta{key: key, size: size, freq: 1, seq: t.seq}
+		t.entries[key] = meta
+		t.bytes += size
+		heap.Push(t.heap, meta)
+		t.addToNamespace(meta)
+		return
+	}
+	t.resize(meta, size-meta.size)
+	t.access(meta)
+}
+
+// add changes the size of an entry whose value has been modified in place, the write counts as an access.
+func (t *usageTracker) add(key string, delta int64) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if meta, ok := t.entries[key]; ok {
+		t.resize(meta, delta)
+		t.access(meta)
+	}
+}
+
+// touch records a read access of an entry.
+func (t *usageTracker) touch(key string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if meta, ok := t.entries[key]; ok {
+		t.access(meta)
+	}
+}
+
+// remove stops tracking an entry.
+func (t *usageTracker) remove(key string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if meta, ok := t.entries[key]; ok {
+		t.removeMeta(meta)
+	}
+}
+
+// totalBytes returns the estimated size of all entries.
+func (t *usageTracker) totalBytes() int64 {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.bytes
+}
+
+// victims removes and returns the keys to evict to meet the namespace quota of key and the global limits.
+// The entry of key is only selected if no other entry of the exceeded scope is left.
+func (t *usageTracker) victims(key string) []string {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entries := int64(len(t.entries))
+	current := t.entries[key]
+	var victims []string
+	// the namespace quota of the written entry
+	if current != nil && current.ns != nil {
+		ns := current.ns
+		for ns.quota.exceeded(ns.entries, ns.bytes) {
+			meta := t.pop(ns.heap, current)
+			if meta == nil {
+				break
+			}
+			victims = append(victims, meta.key)
+			entries--
+		}
+	}
+	for t.quota.exceeded(entries, t.bytes) {
+		meta := t.pop(t.heap, current)
+		if meta == nil {
+			break
+		}
+		victims = append(victims, meta.key)
+		entries--
+	}
+	return victims
+}
+
+// pop removes the entry with the highest eviction priority from h, preferring entries other than current.
+// It must be called while holding t.mu.
+func (t *usageTracker) pop(h *entryHeap, current *entryMeta) *entryMeta {
+	if h.Len() == 0 {
+		return nil
+	}
+	meta := h.entries[0]
+	if meta == current && h.Len() > 1 {
+		// the second highest priority is one of the children of the root
+		meta = h.entries[1]
+		if h.Len() > 2 && h.Less(2, 1) {
+			meta = h.entries[2]
+		}
+	}
+	t.removeMeta(meta)
+	return meta
+}
+
+// removeMeta stops tracking an entry. It must be called while holding t.mu.
+func (t *usageTracker) removeMeta(meta *entryMeta) {
+	delete(t.entries, meta.key)
+	t.bytes -= meta.size
+	heap.Remove(t.heap, meta.index)
+	if ns := meta.ns; ns != nil {
+		ns.entries--
+		ns.bytes -= meta.size
+		heap.Remove(ns.heap, meta.nsIndex)
+		meta.ns = nil
+	}
+}
+
+// resize changes the size of an entry. It must be called while holding t.mu.
+func (t *usageTracker) resize(meta *entryMeta, delta int64) {
+	meta.size += delta
+	t.bytes += delta
+	if meta.ns != nil {
+		meta.ns.bytes += delta
+	}
+}
+
+// access updates the recency and frequency of an entry. It must be called while holding t.mu.
+func (t *usageTracker) access(meta *entryMeta) {
+	t.seq++
+	meta.seq = t.seq
+	meta.freq++
+	heap.Fix(t.heap, t.heap.indexOf(meta))
+	if meta.ns != nil {
+		heap.Fix(meta.ns.heap, meta.ns.heap.indexOf(meta))
+	}
+}
+
+// entrySize estimates the size of an entry in bytes.
+func entrySize(key string, value interface{}) int64 {
+	return int64(len(key)) + valueSize(value) + entryOverhead
+}
+
+// valueSize estimates the size of a value in bytes.
+func valueSize(value interface{}) int64 {
+	switch v := value.(type) {
+	case nil:
+		return 0
+	case string:
+		return int64(len(v))
+	case []byte:
+		return int64(len(v))
+	case bool, int8, uint8:
+		return 1
+	case int16, uint16:
+		return 2
+	case int32, uint32, float32:
+		return 4
+	case int, int64, uint, uint64, float64:
+		return 8
+	case *listValue:
+		return listSize(v.items)
+	case []interface{}:
+		return listSize(v)
+	case setValue:
+		var size int64
+		for member := range v {
+			size += memberSize(member)
+		}
+		return size
+	case []string:
+		var size int64
+		for _, member := range v {
+			size += memberSize(member)
+		}
+		return size
+	case hashValue:
+		return mapSize(v)
+	case map[string]interface{}:
+		return mapSize(v)
+	case map[string]string:
+		var size int64
+		for field, fieldValue := range v {
+			size += int64(len(field)+len(fieldValue)) + elemOverhead
+		}
+		return size
+	default:
+		return int64(len(fmt.Sprint(v)))
+	}
+}
+
+func listSize(items []interface{}) int64 {
+	var size int64
+	for _, item := range items {
+		size += elemSize(item)
+	}
+	return size
+}
+
+func mapSize(m map[string]interface{}) int64 {
+	var size int64
+	for field, fieldValue := range m {
+		size += fieldSize(field, fieldValue)
+	}
+	return size
+}
+
+// elemSize estimates the size of a list element.
+func elemSize(value interface{}) int64 {
+	return valueSize(value) + elemOverhead
+}
+
+// memberSize estimates the size of a set member.
+func memberSize(member string) int64 {
+	return int64(len(member)) + elemOverhead
+}
+
+// fieldSize estimates the size of a hash field.
+func fieldSize(field string, value interface{}) int64 {
+	return int64(len(field)) + valueSize(value) + elemOverhead
+}
@@ -0,0 +1,197 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cache
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestMemoryCache_BoundLRU(t *testing.T) {
+	c := NewMemoryCache(time.Minute, WithMaxEntries(3))
+	var evicted []string
+	c.AddListener(func(event types.CacheEvent) {
+		if event.Type == types.CacheEventEvicted {
+			evicted = append(evicted, event.Key)
+		}
+	})
+	assert.Nil(t, c.Set("a", 1, ""))
+	assert.Nil(t, c.Set("b", 2, ""))
+	assert.Nil(t, c.Set("c", 3, ""))
+	//访问a，b成为最久未使用
+	assert.Equal(t, 1, c.Get("a"))
+	assert.Nil(t, c.Set("d", 4, ""))
+	assert.Equal(t, []string{"b"}, evicted)
+	assert.False(t, c.Has("b"))
+
+	//覆盖已存在的key不会淘汰
+	assert.Nil(t, c.Set("a", 10, ""))
+	assert.Equal(t, 1, len(evicted))
+
+	_, _ = c.Incr("counter", 1, "")
+	assert.Equal(t, []string{"b", "c"}, evicted)
+	_, _ = c.RPush("list", 1)
+	assert.Equal(t, []string{"b", "c", "d"}, evicted)
+	assert.Equal(t, 3, len(c.GetByPrefix("")))
+
+	m := c.Metrics().Get()
+	assert.Equal(t, int64(3), m.Entries)
+	assert.Equal(t, int64(3), m.Evictions)
+	assert.True(t, m.Bytes > 0)
+}
+
+func TestMemoryCache_BoundLFU(t *testing.T) {
+	c := NewMemoryCache(time.Minute, WithMaxEntries(3), WithEvictionPolicy(EvictionLFU))
+	assert.Nil(t, c.Set("a", 1, ""))
+	assert.Nil(t, c.Set("b", 2, ""))
+	assert.Nil(t, c.Set("c", 3, ""))
+	for i := 0; i < 3; i++ {
+		c.Get("a")
+		c.Get("c")
+	}
+	c.Get("b")
+	//新写入的key即使访问次数最少也不会被立即淘汰
+	assert.Nil(t, c.Set("d", 4, ""))
+	assert.False(t, c.Has("b"))
+	assert.True(t, c.Has("d"))
+	assert.Nil(t, c.Set("e", 5, ""))
+	assert.False(t, c.Has("d"))
+	assert.True(t, c.Has("a"))
+	assert.True(t, c.Has("c"))
+}
+
+func TestMemoryCache_BoundBytes(t *testing.T) {
+	value := strings.Repeat("x", 100)
+	size := entrySize("k0", value)
+	c := NewMemoryCache(time.Minute, WithMaxBytes(size*3))
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, c.Set(fmt.Sprintf("k%d", i), value, ""))
+	}
+	assert.Equal(t, 3, len(c.GetByPrefix("k")))
+	assert.True(t, c.Has("k4"))
+	assert.False(t, c.Has("k0"))
+	assert.Equal(t, size*3, c.Metrics().Get().Bytes)
+
+	//集合按元素累计大小
+	c = NewMemoryCache(time.Minute, WithMaxBytes(1000))
+	_, _ = c.RPush("list", value, value)
+	_, _ = c.SAdd("set", "m1", "m2")
+	assert.Nil(t, c.HSet("hash", "f1", value))
+	assert.Equal(t, entrySize("list", []interface{}{value, value})+entrySize("set", []string{"m1", "m2"})+
+		entrySize("hash", map[string]interface{}{"f1": value}), c.Metrics().Get().Bytes)
+	_, _ = c.RPop("list")
+	_, _ = c.SRem("set", "m1")
+	_, _ = c.HDel("hash", "f2")
+	assert.Nil(t, c.HSet("hash", "f1", "v"))
+	assert.Equal(t, entrySize("list", []interface{}{value})+entrySize("set", []string{"m2"})+
+		entrySize("hash", map[string]interface{}{"f1": "v"}), c.Metrics().Get().Bytes)
+	for i := 0; i < 10; i++ {
+		_, _ = c.RPush("list", value)
+	}
+	//超过限制时淘汰其他key，只剩当前key时保留
+	assert.False(t, c.Has("set"))
+	assert.False(t, c.Has("hash"))
+	assert.True(t, c.Has("list"))
+	_ = c.DeleteByPrefix("")
+	assert.Equal(t, int64(0), c.Metrics().Get().Bytes)
+	assert.Equal(t, int64(0), c.Metrics().Get().Entries)
+}
+
+func TestMemoryCache_NamespaceQuota(t *testing.T) {
+	c := NewMemoryCache(time.Minute, WithNamespaceQuota("chain01:", Quota{MaxEntries: 2}))
+	chainCache := NewNamespaceCache(c, "chain01:")
+	otherCache := NewNamespaceCache(c, "chain02:")
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, chainCache.Set(fmt.Sprintf("k%d", i), i, ""))
+		assert.Nil(t, otherCache.Set(fmt.Sprintf("k%d", i), i, ""))
+	}
+	assert.Equal(t, map[string]interface{}{"k3": 3, "k4": 4}, chainCache.GetByPrefix(""))
+	assert.Equal(t, 5, len(otherCache.GetByPrefix("")))
+
+	//运行时设置配额，下一次写入时生效，子命名空间使用最长匹配的配额
+	c.SetNamespaceQuota("chain02:", Quota{MaxEntries: 3})
+	c.SetNamespaceQuota("chain02:sub:", Quota{MaxEntries: 1})
+	assert.Nil(t, otherCache.Set("k5", 5, ""))
+	assert.Equal(t, 3, len(otherCache.GetByPrefix("")))
+	assert.Nil(t, otherCache.Set("sub:a", 1, ""))
+	assert.Nil(t, otherCache.Set("sub:b", 1, ""))
+	assert.Equal(t, 1, len(otherCache.GetByPrefix("sub:")))
+	assert.Equal(t, 4, len(otherCache.GetByPrefix("")))
+
+	//删除配额
+	c.SetNamespaceQuota("chain01:", Quota{})
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, chainCache.Set(fmt.Sprintf("k%d", i), i, ""))
+	}
+	assert.Equal(t, 5, len(chainCache.GetByPrefix("")))
+}
+
+func TestMemoryCache_Metrics(t *testing.T) {
+	c := NewMemoryCache(time.Minute)
+	assert.Nil(t, c.Set("a", 1, ""))
+	assert.Nil(t, c.Set("expired", 1, "1ms"))
+	time.Sleep(5 * time.Millisecond)
+	c.Get("a")
+	c.Get("a")
+	c.Get("notFound")
+	c.Get("expired")
+	m := c.Metrics().Get()
+	assert.Equal(t, int64(2), m.Hits)
+	assert.Equal(t, int64(2), m.Misses)
+	assert.Equal(t, int64(2), m.Entries)
+	//未设置限制时不统计大小
+	assert.Equal(t, int64(0), m.Bytes)
+
+	c.Metrics().Reset()
+	m = c.Metrics().Get()
+	assert.Equal(t, int64(0), m.Hits)
+	assert.Equal(t, int64(2), m.Entries)
+}
+
+func TestMemoryCache_BoundConcurrent(t *testing.T) {
+	c := NewMemoryCache(time.Minute, WithMaxEntries(50), WithNamespaceQuota("ns:", Quota{MaxEntries: 10}))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				key := fmt.Sprintf("%d:%d", i, j)
+				_ = c.Set(key, j, "")
+				_ = c.Set("ns:"+key, j, "")
+				c.Get(fmt.Sprintf("%d:%d", i, j/2))
+				_, _ = c.RPush("list", j)
+				_, _ = c.LPop("list")
+				if j%10 == 0 {
+					_ = c.Delete(key)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.True(t, len(c.GetByPrefix("")) <= 50)
+	assert.True(t, len(c.GetByPrefix("ns:")) <= 10)
+	c.mu.RLock()
+	assert.Equal(t, len(c.items), len(c.tracker.entries))
+	c.mu.RUnlock()
+}
@@ -0,0 +1,726 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cache
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+)
+
+// listValue is the internal representation of a list stored by LPush/RPush.
+type listValue struct {
+	items []interface{}
+}
+
+// setValue is the internal representation of a set stored by SAdd.
+type setValue map[string]struct{}
+
+// hashValue is the internal representation of a hash stored by HSet.
+type hashValue map[string]interface{}
+
+// exportValue converts internal collection values to plain values, so callers of Get
+// never share state with the cache:
+// - list: []interface{}
+// - set: sorted []string
+// - hash: map[string]interface{}
+func exportValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *listValue:
+		result := make([]interface{}, len(v.items))
+		copy(result, v.items)
+		return result
+	case setValue:
+		result := make([]string, 0, len(v))
+		for member := range v {
+			result = append(result, member)
+		}
+		sort.Strings(result)
+		return result
+	case hashValue:
+		result := make(map[string]interface{}, len(v))
+		for field, fieldValue := range v {
+			result[field] = fieldValue
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// toInt64 converts a cached value to int64, floats must be integral.
+func toInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		if v > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v), true
+	case float32:
+		return floatToInt64(float64(v))
+	case float64:
+		return floatToInt64(v)
+	case string:
+		i, err := strconv.ParseInt(v, 10, 64)
+		return i, err == nil
+	case []byte:
+		i, err := strconv.ParseInt(string(v), 10, 64)
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func floatToInt64(v float64) (int64, bool) {
+	if v != math.Trunc(v) || v > math.MaxInt64 || v < math.MinInt64 {
+		return 0, false
+	}
+	return int64(v), true
+}
+
+// ValuesEqual reports whether two values are deeply equal or have the same string form.
+func ValuesEqual(a, b interface{}) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	if a == nil || b == nil {
+		return false
+	}
+	return fmt.Sprint(a) == fmt.Sprint(b)
+}
+
+// liveItem returns the item of the key if it exists and has not expired.
+// It must be called while holding c.mu.
+func (c *MemoryCache) liveItem(key string, now int64) (item, bool) {
+	it, found := c.items[key]
+	if !found || (it.expiration > 0 && now > it.expiration) {
+		return item{}, false
+	}
+	c.tracker.touch(key)
+	return it, true
+}
+
+// takeLiveItem is like liveItem, but also removes an expired item and reports its expiry event,
+// so that the key can be recreated. It must be called while holding the write lock.
+func (c *MemoryCache) takeLiveItem(events []types.CacheEvent, key string, now int64) (item, bool, []types.CacheEvent) {
+	it, found := c.items[key]
+	if !found {
+		return item{}, false, events
+	}
+	if it.expiration > 0 && now > it.expiration {
+		events = c.appendEvent(events, types.CacheEventExpired, key, it.value)
+		c.removeItem(key)
+		return item{}, false, events
+	}
+	return it, true, events
+}
+
+// Incr atomically increments the integer value of a key by delta.
+// A missing or expired key is treated as 0 and created with the given ttl,
+// the expiration of an existing key is kept.
+func (c *MemoryCache) Incr(key string, delta int64, ttl string) (int64, error) {
+	expiration, err := parseExpiration(ttl)
+	if err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	it, found, events := c.takeLiveItem(nil, key, time.Now().UnixNano())
+	var value int64
+	if found {
+		var ok bool
+		if value, ok = toInt64(it.value); !ok {
+			c.mu.Unlock()
+			c.notify(events)
+			return 0, types.ErrCacheWrongType
+		}
+		expiration = it.expiration
+	}
+	value += delta
+	c.storeItem(key, item{value: value, expiration: expiration})
+	events = c.evict(events, key)
+	c.unlockAndNotify(events, expiration)
+	return value, nil
+}
+
+// Decr atomically decrements the integer value of a key by delta.
+func (c *MemoryCache) Decr(key string, delta int64, ttl string) (int64, error) {
+	return c.Incr(key, -delta, ttl)
+}
+
+// CompareAndSet atomically sets the value of a key only if its current value equals expected.
+// A nil expected value means the key must not exist.
+func (c *MemoryCache) CompareAndSet(key string, expected, value interface{}, ttl string) (bool, error) {
+	expiration, err := parseExpiration(ttl)
+	if err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	it, found, events := c.takeLiveItem(nil, key, time.Now().UnixNano())
+	var matched bool
+	if expected == nil {
+		matched = !found
+	} else {
+		matched = found && ValuesEqual(exportValue(it.value), expected)
+	}
+	if !matched {
+		c.mu.Unlock()
+		c.notify(events)
+		return false, nil
+	}
+	c.storeItem(key, item{value: value, expiration: expiration})
+	events = c.evict(events, key)
+	c.unlockAndNotify(events, expiration)
+	return true, nil
+}
+
+// SetIfAbsent atomically stores a key-value pair only if the key does not exist or has expired.
+func (c *MemoryCache) SetIfAbsent(key string, value interface{}, ttl string) (bool, error) {
+	return c.CompareAndSet(key, nil, value, ttl)
+}
+
+// Expire updates the time-to-live of an existing key, an empty or 0 ttl removes the expiration.
+func (c *MemoryCache) Expire(key string, ttl string) (bool, error) {
+	expiration, err := parseExpiration(ttl)
+	if err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	it, found, events := c.takeLiveItem(nil, key, time.Now().UnixNano())
+	if !found {
+		c.mu.Unlock()
+		c.notify(events)
+		return false, nil
+	}
+	it.expiration = expiration
+	c.items[key] = it
+	c.unlockAndNotify(events, expiration)
+	return true, nil
+}
+
+// TTL returns the remaining time-to-live of a key, 0 if the key never expires.
+func (c *MemoryCache) TTL(key string) (time.Duration, bool) {
+	now := time.Now().UnixNano()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	it, found := c.liveItem(key, now)
+	if !found {
+		return 0, false
+	}
+	if it.expiration == 0 {
+		return 0, true
+	}
+	return time.Duration(it.expiration - now), true
+}
+
+// getList returns the list stored at key, nil if the key does not exist.
+// It must be called while holding c.mu.
+func (c *MemoryCache) getList(key string, now int64) (*listValue, error) {
+	it, found := c.liveItem(key, now)
+	if !found {
+		return nil, nil
+	}
+	list, ok := it.value.(*listValue)
+	if !ok {
+		return nil, types.ErrCacheWrongType
+	}
+	return list, nil
+}
+
+// push adds values to the head or tail of the list stored at key.
+func (c *MemoryCache) push(key string, head bool, values []interface{}) (int64, error) {
+	c.mu.Lock()
+	it, found, events := c.takeLiveItem(nil, key, time.Now().UnixNano())
+	list := &listValue{}
+	if found {
+		var ok bool
+		if list, ok = it.value.(*listValue); !ok {
+			c.mu.Unlock()
+			c.notify(events)
+			return 0, types.ErrCacheWrongType
+		}
+	} else {
+		c.storeItem(key, item{value: list})
+	}
+	if head {
+		items := make([]interface{}, 0, len(values)+len(list.items))
+		for i := len(values) - 1; i >= 0; i-- {
+			items = append(items, values[i])
+		}
+		list.items = append(items, list.items...)
+	} else {
+		list.items = append(list.items, values...)
+	}
+	length := int64(len(list.items))
+	c.tracker.add(key, listSize(values))
+	events = c.evict(events, key)
+	c.mu.Unlock()
+	c.notify(events)
+	return length, nil
+}
+
+// pop removes and returns the first or last element of the list stored at key.
+func (c *MemoryCache) pop(key string, head bool) (interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	list, err := c.getList(key, time.Now().UnixNano())
+	if err != nil || list == nil || len(list.items) == 0 {
+		return nil, err
+	}
+	var value interface{}
+	if head {
+		value = list.items[0]
+		list.items[0] = nil
+		list.items = list.items[1:]
+	} else {
+		last := len(list.items) - 1
+		value = list.items[last]
+		list.items[last] = nil
+		list.items = list.items[:last]
+	}
+	if len(list.items) == 0 {
+		c.removeItem(key)
+	} else {
+		c.tracker.add(key, -elemSize(value))
+	}
+	return value, nil
+}
+
+// LPush inserts values at the head of the list stored at key.
+// Values are inserted one after another, so LPush(key, "a", "b") results in ["b", "a"].
+func (c *MemoryCache) LPush(key string, values ...interface{}) (int64, error) {
+	return c.push(key, true, values)
+}
+
+// RPush appends values to the tail of the list stored at key.
+func (c *MemoryCache) RPush(key string, values ...interface{}) (int64, error) {
+	return c.push(key, false, values)
+}
+
+// LPop removes and returns the first element of the list stored at key.
+func (c *MemoryCache) LPop(key string) (interface{}, error) {
+	return c.pop(key, true)
+}
+
+// RPop removes and returns the last element of the list stored at key.
+func (c *MemoryCache) RPop(key string) (interface{}, error) {
+	return c.pop(key, false)
+}
+
+// LRange returns the elements of the list between start and stop, both inclusive.
+// Negative indexes count from the tail, -1 is the last element.
+func (c *MemoryCache) LRange(key string, start, stop int64) ([]interface{}, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	list, err := c.getList(key, time.Now().UnixNano())
+	if err != nil || list == nil {
+		return nil, err
+	}
+	length := int64(len(list.items))
+	if start < 0 {
+		start += length
+	}
+	if stop < 0 {
+		stop += length
+	}
+	if start < 0 {
+		start = 0
+	}
+	if stop >= length {
+		stop = length - 1
+	}
+	if start > stop {
+		return []interface{}{}, nil
+	}
+	result := make([]interface{}, stop-start+1)
+	copy(result, list.items[start:stop+1])
+	return result, nil
+}
+
+// LLen returns the length of the list stored at key.
+func (c *MemoryCache) LLen(key string) (int64, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	list, err := c.getList(key, time.Now().UnixNano())
+	if err != nil || list == nil {
+		return 0, err
+	}
+	return int64(len(list.items)), nil
+}
+
+// getSet returns the set stored at key, nil if the key does not exist.
+// It must be called while holding c.mu.
+func (c *MemoryCache) getSet(key string, now int64) (setValue, error) {
+	it, found := c.liveItem(key, now)
+	if !found {
+		return nil, nil
+	}
+	set, ok := it.value.(setValue)
+	if !ok {
+		return nil, types.ErrCacheWrongType
+	}
+	return set, nil
+}
+
+// SAdd adds members to the set stored at key, returns the number of members that were added.
+func (c *MemoryCache) SAdd(key string, members ...string) (int64, error) {
+	c.mu.Lock()
+	it, found, events := c.takeLiveItem(nil, key, time.Now().UnixNano())
+	set := setValue{}
+	if found {
+		var ok bool
+		if set, ok = it.value.(setValue); !ok {
+			c.mu.Unlock()
+			c.notify(events)
+			return 0, types.ErrCacheWrongType
+		}
+	} else {
+		c.storeItem(key, item{value: set})
+	}
+	var added, size int64
+	for _, member := range members {
+		if _, ok := set[member]; !ok {
+			set[member] = struct{}{}
+			added++
+			size += memberSize(member)
+		}
+	}
+	c.tracker.add(key, size)
+	events = c.evict(events, key)
+	c.mu.Unlock()
+	c.notify(events)
+	return added, nil
+}
+
+// SRem removes members from the set stored at key, returns the number of members that were removed.
+func (c *MemoryCache) SRem(key string, members ...string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	set, err := c.getSet(key, time.Now().UnixNano())
+	if err != nil || set == nil {
+		return 0, err
+	}
+	var removed, size int64
+	for _, member := range members {
+		if _, ok := set[member]; ok {
+			delete(set, member)
+			removed++
+			size += memberSize(member)
+		}
+	}
+	if len(set) == 0 {
+		c.removeItem(key)
+	} else {
+		c.tracker.add(key, -size)
+	}
+	return removed, nil
+}
+
+// SMembers returns all members of the set stored at key, sorted.
+func (c *MemoryCache) SMembers(key string) ([]string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	set, err := c.getSet(key, time.Now().UnixNano())
+	if err != nil || set == nil {
+		return nil, err
+	}
+	return exportValue(set).([]string), nil
+}
+
+// SIsMember reports whether member belongs to the set stored at key.
+func (c *MemoryCache) SIsMember(key string, member string) (bool, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	set, err := c.getSet(key, time.Now().UnixNano())
+	if err != nil || set == nil {
+		return false, err
+	}
+	_, ok := set[member]
+	return ok, nil
+}
+
+// getHash returns the hash stored at key, nil if the key does not exist.
+// It must be called while holding c.mu.
+func (c *MemoryCache) getHash(key string, now int64) (hashValue, error) {
+	it, found := c.liveItem(key, now)
+	if !found {
+		return nil, nil
+	}
+	hash, ok := it.value.(hashValue)
+	if !ok {
+		return nil, types.ErrCacheWrongType
+	}
+	return hash, nil
+}
+
+// HSet sets a field of the hash stored at key.
+func (c *MemoryCache) HSet(key string, field string, value interface{}) error {
+	c.mu.Lock()
+	it, found, events := c.takeLiveItem(nil, key, time.Now().UnixNano())
+	hash := hashValue{}
+	if found {
+		var ok bool
+		if hash, ok = it.value.(hashValue); !ok {
+			c.mu.Unlock()
+			c.notify(events)
+			return types.ErrCacheWrongType
+		}
+	} else {
+		c.storeItem(key, item{value: hash})
+	}
+	size := fieldSize(field, value)
+	if old, ok := hash[field]; ok {
+		size -= fieldSize(field, old)
+	}
+	hash[field] = value
+	c.tracker.add(key, size)
+	events = c.evict(events, key)
+	c.mu.Unlock()
+	c.notify(events)
+	return nil
+}
+
+// HGet returns the value of a field of the hash stored at key.
+func (c *MemoryCache) HGet(key string, field string) (interface{}, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	hash, err := c.getHash(key, time.Now().UnixNano())
+	if err != nil || hash == nil {
+		return nil, err
+	}
+	return hash[field], nil
+}
+
+// HDel removes fields from the hash stored at key, returns the number of fields that were removed.
+func (c *MemoryCache) HDel(key string, fields ...string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hash, err := c.getHash(key, time.Now().UnixNano())
+	if err != nil || hash == nil {
+		return 0, err
+	}
+	var removed, size int64
+	for _, field := range fields {
+		if old, ok := hash[field]; ok {
+			delete(hash, field)
+			removed++
+			size += fieldSize(field, old)
+		}
+	}
+	if len(hash) == 0 {
+		c.removeItem(key)
+	} else {
+		c.tracker.add(key, -size)
+	}
+	return removed, nil
+}
+
+// HGetAll returns a copy of all fields and values of the hash stored at key.
+func (c *MemoryCache) HGetAll(key string) (map[string]interface{}, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	hash, err := c.getHash(key, time.Now().UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	if hash == nil {
+		return map[string]interface{}{}, nil
+	}
+	return exportValue(hash).(map[string]interface{}), nil
+}
+
+// Incr increments the integer value of a prefixed key
+func (c *NamespaceCache) Incr(key string, delta int64, ttl string) (int64, error) {
+	if c == nil || c.Cache == nil {
+		return 0, types.ErrCacheNotInitialized
+	}
+	return c.Cache.Incr(c.Namespace+key, delta, ttl)
+}
+
+// Decr decrements the integer value of a prefixed key
+func (c *NamespaceCache) Decr(key string, delta int64, ttl string) (int64, error) {
+	if c == nil || c.Cache == nil {
+		return 0, types.ErrCacheNotInitialized
+	}
+	return c.Cache.Decr(c.Namespace+key, delta, ttl)
+}
+
+// CompareAndSet sets the value of a prefixed key if its current value equals expected
+func (c *NamespaceCache) CompareAndSet(key string, expected, value interface{}, ttl string) (bool, error) {
+	if c == nil || c.Cache == nil {
+		return false, types.ErrCacheNotInitialized
+	}
+	return c.Cache.CompareAndSet(c.Namespace+key, expected, value, ttl)
+}
+
+// SetIfAbsent stores a prefixed key-value pair if the key does not exist
+func (c *NamespaceCache) SetIfAbsent(key string, value interface{}, ttl string) (bool, error) {
+	if c == nil || c.Cache == nil {
+		return false, types.ErrCacheNotInitialized
+	}
+	return c.Cache.SetIfAbsent(c.Namespace+key, value, ttl)
+}
+
+// Expire updates the time-to-live of a prefixed key
+func (c *NamespaceCache) Expire(key string, ttl string) (bool, error) {
+	if c == nil || c.Cache == nil {
+		return false, types.ErrCacheNotInitialized
+	}
+	return c.Cache.Expire(c.Namespace+key, ttl)
+}
+
+// TTL returns the remaining time-to-live of a prefixed key
+func (c *NamespaceCache) TTL(key string) (time.Duration, bool) {
+	if c == nil || c.Cache == nil {
+		return 0, false
+	}
+	return c.Cache.TTL(c.Namespace + key)
+}
+
+// LPush inserts values at the head of the list stored at a prefixed key
+func (c *NamespaceCache) LPush(key string, values ...interface{}) (int64, error) {
+	if c == nil || c.Cache == nil {
+		return 0, types.ErrCacheNotInitialized
+	}
+	return c.Cache.LPush(c.Namespace+key, values...)
+}
+
+// RPush appends values to the tail of the list stored at a prefixed key
+func (c *NamespaceCache) RPush(key string, values ...interface{}) (int64, error) {
+	if c == nil || c.Cache == nil {
+		return 0, types.ErrCacheNotInitialized
+	}
+	return c.Cache.RPush(c.Namespace+key, values...)
+}
+
+// LPop removes and returns the first element of the list stored at a prefixed key
+func (c *NamespaceCache) LPop(key string) (interface{}, error) {
+	if c == nil || c.Cache == nil {
+		return nil, types.ErrCacheNotInitialized
+	}
+	return c.Cache.LPop(c.Namespace + key)
+}
+
+// RPop removes and returns the last element of the list stored at a prefixed key
+func (c *NamespaceCache) RPop(key string) (interface{}, error) {
+	if c == nil || c.Cache == nil {
+		return nil, types.ErrCacheNotInitialized
+	}
+	return c.Cache.RPop(c.Namespace + key)
+}
+
+// LRange returns the elements of the list stored at a prefixed key
+func (c *NamespaceCache) LRange(key string, start, stop int64) ([]interface{}, error) {
+	if c == nil || c.Cache == nil {
+		return nil, types.ErrCacheNotInitialized
+	}
+	return c.Cache.LRange(c.Namespace+key, start, stop)
+}
+
+// LLen returns the length of the list stored at a prefixed key
+func (c *NamespaceCache) LLen(key string) (int64, error) {
+	if c == nil || c.Cache == nil {
+		return 0, types.ErrCacheNotInitialized
+	}
+	return c.Cache.LLen(c.Namespace + key)
+}
+
+// SAdd adds members to the set stored at a prefixed key
+func (c *NamespaceCache) SAdd(key string, members ...string) (int64, error) {
+	if c == nil || c.Cache == nil {
+		return 0, types.ErrCacheNotInitialized
+	}
+	return c.Cache.SAdd(c.Namespace+key, members...)
+}
+
+// SRem removes members from the set stored at a prefixed key
+func (c *NamespaceCache) SRem(key string, members ...string) (int64, error) {
+	if c == nil || c.Cache == nil {
+		return 0, types.ErrCacheNotInitialized
+	}
+	return c.Cache.SRem(c.Namespace+key, members...)
+}
+
+// SMembers returns all members of the set stored at a prefixed key
+func (c *NamespaceCache) SMembers(key string) ([]string, error) {
+	if c == nil || c.Cache == nil {
+		return nil, types.ErrCacheNotInitialized
+	}
+	return c.Cache.SMembers(c.Namespace + key)
+}
+
+// SIsMember reports whether member belongs to the set stored at a prefixed key
+func (c *NamespaceCache) SIsMember(key string, member string) (bool, error) {
+	if c == nil || c.Cache == nil {
+		return false, types.ErrCacheNotInitialized
+	}
+	return c.Cache.SIsMember(c.Namespace+key, member)
+}
+
+// HSet sets a field of the hash stored at a prefixed key
+func (c *NamespaceCache) HSet(key string, field string, value interface{}) error {
+	if c == nil || c.Cache == nil {
+		return types.ErrCacheNotInitialized
+	}
+	return c.Cache.HSet(c.Namespace+key, field, value)
+}
+
+// HGet returns the value of a field of the hash stored at a prefixed key
+func (c *NamespaceCache) HGet(key string, field string) (interface{}, error) {
+	if c == nil || c.Cache == nil {
+		return nil, types.ErrCacheNotInitialized
+	}
+	return c.Cache.HGet(c.Namespace+key, field)
+}
+
+// HDel removes fields from the hash stored at a prefixed key
+func (c *NamespaceCache) HDel(key string, fields ...string) (int64, error) {
+	if c == nil || c.Cache == nil {
+		return 0, types.ErrCacheNotInitialized
+	}
+	return c.Cache.HDel(c.Namespace+key, fields...)
+}
+
+// HGetAll returns all fields and values of the hash stored at a prefixed key
+func (c *NamespaceCache) HGetAll(key string) (map[string]interface{}, error) {
+	if c == nil || c.Cache == nil {
+		return nil, types.ErrCacheNotInitialized
+	}
+	return c.Cache.HGetAll(c.Namespace + key)
+}
@@ -0,0 +1,291 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestMemoryCache_Atomic(t *testing.T) {
+	c := NewMemoryCache(time.Minute)
+
+	t.Run("Incr", func(t *testing.T) {
+		v, err := c.Incr("counter", 1, "")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), v)
+		v, _ = c.Incr("counter", 5, "")
+		assert.Equal(t, int64(6), v)
+		v, _ = c.Decr("counter", 2, "")
+		assert.Equal(t, int64(4), v)
+		assert.Equal(t, int64(4), c.Get("counter"))
+
+		_ = c.Set("str", "10", "")
+		v, err = c.Incr("str", 1, "")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(11), v)
+		_ = c.Set("float", 1.5, "")
+		_, err = c.Incr("float", 1, "")
+		assert.Equal(t, types.ErrCacheWrongType, err)
+		_, err = c.Incr("counter", 1, "abc")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("IncrConcurrent", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 20; j++ {
+					_, _ = c.Incr("concurrent", 1, "")
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int64(1000), c.Get("concurrent"))
+	})
+
+	t.Run("IncrKeepTtl", func(t *testing.T) {
+		_, _ = c.Incr("window", 1, "100ms")
+		_, _ = c.Incr("window", 1, "1h")
+		ttl, ok := c.TTL("window")
+		assert.True(t, ok)
+		assert.True(t, ttl > 0 && ttl <= 100*time.Millisecond)
+		time.Sleep(150 * time.Millisecond)
+		v, _ := c.Incr("window", 1, "")
+		assert.Equal(t, int64(1), v)
+	})
+
+	t.Run("CompareAndSet", func(t *testing.T) {
+		ok, err := c.CompareAndSet("cas", nil, "a", "")
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		ok, _ = c.CompareAndSet("cas", nil, "b", "")
+		assert.False(t, ok)
+		ok, _ = c.CompareAndSet("cas", "b", "c", "")
+		assert.False(t, ok)
+		ok, _ = c.CompareAndSet("cas", "a", 1, "")
+		assert.True(t, ok)
+		ok, _ = c.CompareAndSet("cas", "1", 2, "")
+		assert.True(t, ok)
+		assert.Equal(t, 2, c.Get("cas"))
+		_, err = c.CompareAndSet("cas", 2, 3, "abc")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("SetIfAbsent", func(t *testing.T) {
+		ok, _ := c.SetIfAbsent("lock", "owner1", "50ms")
+		assert.True(t, ok)
+		ok, _ = c.SetIfAbsent("lock", "owner2", "50ms")
+		assert.False(t, ok)
+		time.Sleep(80 * time.Millisecond)
+		ok, _ = c.SetIfAbsent("lock", "owner2", "")
+		assert.True(t, ok)
+		assert.Equal(t, "owner2", c.Get("lock"))
+	})
+
+	t.Run("ExpireAndTTL", func(t *testing.T) {
+		_, ok := c.TTL("notFound")
+		assert.False(t, ok)
+		ok, _ = c.Expire("notFound", "1m")
+		assert.False(t, ok)
+
+		_ = c.Set("expire", "v", "")
+		ttl, ok := c.TTL("expire")
+		assert.True(t, ok)
+		assert.Equal(t, time.Duration(0), ttl)
+
+		ok, err := c.Expire("expire", "1m")
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		ttl, _ = c.TTL("expire")
+		assert.True(t, ttl > 50*time.Second)
+
+		ok, _ = c.Expire("expire", "")
+		assert.True(t, ok)
+		ttl, _ = c.TTL("expire")
+		assert.Equal(t, time.Duration(0), ttl)
+		_, err = c.Expire("expire", "abc")
+		assert.NotNil(t, err)
+	})
+}
+
+func TestMemoryCache_Collections(t *testing.T) {
+	c := NewMemoryCache(time.Minute)
+
+	t.Run("List", func(t *testing.T) {
+		n, err := c.RPush("list", "b", "c")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), n)
+		n, _ = c.LPush("list", "a", "z")
+		assert.Equal(t, int64(4), n)
+
+		items, _ := c.LRange("list", 0, -1)
+		assert.Equal(t, []interface{}{"z", "a", "b", "c"}, items)
+		items, _ = c.LRange("list", 1, 2)
+		assert.Equal(t, []interface{}{"a", "b"}, items)
+		items, _ = c.LRange("list", -2, 100)
+		assert.Equal(t, []interface{}{"b", "c"}, items)
+		items, _ = c.LRange("list", 3, 1)
+		assert.Equal(t, 0, len(items))
+
+		//Get 返回副本
+		value := c.Get("list").([]interface{})
+		value[0] = "changed"
+		first, _ := c.LPop("list")
+		assert.Equal(t, "z", first)
+		last, _ := c.RPop("list")
+		assert.Equal(t, "c", last)
+		length, _ := c.LLen("list")
+		assert.Equal(t, int64(2), length)
+
+		_, _ = c.LPop("list")
+		_, _ = c.LPop("list")
+		assert.False(t, c.Has("list"))
+		v, err := c.LPop("list")
+		assert.Nil(t, err)
+		assert.Nil(t, v)
+		length, _ = c.LLen("list")
+		assert.Equal(t, int64(0), length)
+	})
+
+	t.Run("Set", func(t *testing.T) {
+		n, err := c.SAdd("set", "a", "b", "a")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), n)
+		n, _ = c.SAdd("set", "b", "c")
+		assert.Equal(t, int64(1), n)
+		members, _ := c.SMembers("set")
+		assert.Equal(t, []string{"a", "b", "c"}, members)
+		assert.Equal(t, []string{"a", "b", "c"}, c.Get("set"))
+		ok, _ := c.SIsMember("set", "b")
+		assert.True(t, ok)
+		n, _ = c.SRem("set", "b", "d")
+		assert.Equal(t, int64(1), n)
+		ok, _ = c.SIsMember("set", "b")
+		assert.False(t, ok)
+		_, _ = c.SRem("set", "a", "c")
+		assert.False(t, c.Has("set"))
+	})
+
+	t.Run("Hash", func(t *testing.T) {
+		assert.Nil(t, c.HSet("hash", "name", "lala"))
+		assert.Nil(t, c.HSet("hash", "age", 18))
+		v, _ := c.HGet("hash", "name")
+		assert.Equal(t, "lala", v)
+		v, _ = c.HGet("hash", "notFound")
+		assert.Nil(t, v)
+		all, _ := c.HGetAll("hash")
+		assert.Equal(t, map[string]interface{}{"name": "lala", "age": 18}, all)
+		n, _ := c.HDel("hash", "name", "notFound")
+		assert.Equal(t, int64(1), n)
+		_, _ = c.HDel("hash", "age")
+		assert.False(t, c.Has("hash"))
+		all, err := c.HGetAll("hash")
+		assert.Nil(t, err)
+		assert.Equal(t, 0, len(all))
+	})
+
+	t.Run("WrongType", func(t *testing.T) {
+		_ = c.Set("string", "v", "")
+		_, err := c.RPush("string", "a")
+		assert.Equal(t, types.ErrCacheWrongType, err)
+		_, err = c.LRange("string", 0, -1)
+		assert.Equal(t, types.ErrCacheWrongType, err)
+		_, err = c.SAdd("string", "a")
+		assert.Equal(t, types.ErrCacheWrongType, err)
+		_, err = c.SIsMember("string", "a")
+		assert.Equal(t, types.ErrCacheWrongType, err)
+		assert.Equal(t, types.ErrCacheWrongType, c.HSet("string", "a", 1))
+		_, err = c.HGetAll("string")
+		assert.Equal(t, types.ErrCacheWrongType, err)
+		_, _ = c.RPush("list", "a")
+		_, err = c.Incr("list", 1, "")
+		assert.Equal(t, types.ErrCacheWrongType, err)
+	})
+
+	t.Run("Expire", func(t *testing.T) {
+		_, _ = c.RPush("expireList", "a")
+		ok, _ := c.Expire("expireList", "50ms")
+		assert.True(t, ok)
+		time.Sleep(80 * time.Millisecond)
+		length, _ := c.LLen("expireList")
+		assert.Equal(t, int64(0), length)
+		n, _ := c.RPush("expireList", "b")
+		assert.Equal(t, int64(1), n)
+	})
+}
+
+func TestNamespaceCache_Ops(t *testing.T) {
+	c := NewMemoryCache(time.Minute)
+	ns := NewNamespaceCache(c, "ns:")
+
+	v, _ := ns.Incr("counter", 2, "")
+	assert.Equal(t, int64(2), v)
+	v, _ = ns.Decr("counter", 1, "")
+	assert.Equal(t, int64(1), v)
+	assert.Equal(t, int64(1), c.Get("ns:counter"))
+
+	ok, _ := ns.SetIfAbsent("lock", 1, "")
+	assert.True(t, ok)
+	ok, _ = ns.CompareAndSet("lock", 1, 2, "")
+	assert.True(t, ok)
+	ok, _ = ns.Expire("lock", "1m")
+	assert.True(t, ok)
+	_, ok = ns.TTL("lock")
+	assert.True(t, ok)
+
+	_, _ = ns.RPush("list", "a")
+	_, _ = ns.LPush("list", "b")
+	items, _ := ns.LRange("list", 0, -1)
+	assert.Equal(t, []interface{}{"b", "a"}, items)
+	length, _ := ns.LLen("list")
+	assert.Equal(t, int64(2), length)
+	first, _ := ns.LPop("list")
+	assert.Equal(t, "b", first)
+	last, _ := ns.RPop("list")
+	assert.Equal(t, "a", last)
+
+	_, _ = ns.SAdd("set", "a")
+	ok, _ = ns.SIsMember("set", "a")
+	assert.True(t, ok)
+	members, _ := ns.SMembers("set")
+	assert.Equal(t, []string{"a"}, members)
+	n, _ := ns.SRem("set", "a")
+	assert.Equal(t, int64(1), n)
+
+	_ = ns.HSet("hash", "f", "v")
+	fieldValue, _ := ns.HGet("hash", "f")
+	assert.Equal(t, "v", fieldValue)
+	all, _ := c.HGetAll("ns:hash")
+	assert.Equal(t, map[string]interface{}{"f": "v"}, all)
+	all, _ = ns.HGetAll("hash")
+	assert.Equal(t, 1, len(all))
+	n, _ = ns.HDel("hash", "f")
+	assert.Equal(t, int64(1), n)
+
+	var nilCache *NamespaceCache
+	_, err := nilCache.Incr("a", 1, "")
+	assert.Equal(t, types.ErrCacheNotInitialized, err)
+	_, ok = nilCache.TTL("a")
+	assert.False(t, ok)
+}
@@ -18,9 +18,11 @@ package cache
 
 import (
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/rulego/rulego/api/types"
 	"github.com/rulego/rulego/test/assert"
 )
 
@@ -305,3 +307,82 @@ func TestNamespaceCache(t *testing.T) {
 	})
 
 }
+
+func TestMemoryCache_Events(t *testing.T) {
+	c := NewMemoryCache(time.Minute)
+	var mu sync.Mutex
+	var events []types.CacheEvent
+	id := c.AddListener(func(event types.CacheEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	})
+	getEvents := func() []types.CacheEvent {
+		mu.Lock()
+		defer mu.Unlock()
+		result := events
+		events = nil
+		return result
+	}
+
+	t.Run("Expired", func(t *testing.T) {
+		_ = c.Set("hb:d1", "v1", "50ms")
+		_ = c.Set("hb:d2", "v2", "1m")
+		time.Sleep(time.Millisecond * 100)
+		c.DeleteExpired()
+		result := getEvents()
+		assert.Equal(t, 1, len(result))
+		assert.Equal(t, types.CacheEventExpired, result[0].Type)
+		assert.Equal(t, "hb:d1", result[0].Key)
+		assert.Equal(t, "v1", result[0].Value)
+		assert.True(t, result[0].Ts > 0)
+	})
+
+	t.Run("OverwriteExpired", func(t *testing.T) {
+		_ = c.Set("hb:d3", "v1", "50ms")
+		time.Sleep(time.Millisecond * 100)
+		_ = c.Set("hb:d3", "v2", "1m")
+		result := getEvents()
+		assert.Equal(t, 1, len(result))
+		assert.Equal(t, types.CacheEventExpired, result[0].Type)
+		assert.Equal(t, "v1", result[0].Value)
+		//没有过期的key覆盖不产生事件
+		_ = c.Set("hb:d3", "v3", "1m")
+		assert.Equal(t, 0, len(getEvents()))
+	})
+
+	t.Run("Deleted", func(t *testing.T) {
+		_ = c.Delete("hb:d2")
+		_ = c.Delete("notFound")
+		result := getEvents()
+		assert.Equal(t, 1, len(result))
+		assert.Equal(t, types.CacheEventDeleted, result[0].Type)
+		assert.Equal(t, "hb:d2", result[0].Key)
+
+		_ = c.Set("other", "v", "")
+		_ = c.DeleteByPrefix("hb:")
+		result = getEvents()
+		assert.Equal(t, 1, len(result))
+		assert.Equal(t, "hb:d3", result[0].Key)
+	})
+
+	t.Run("Namespace", func(t *testing.T) {
+		ns := NewNamespaceCache(c, "chain1:")
+		nsId := ns.AddListener(func(event types.CacheEvent) {
+			assert.Equal(t, "key1", event.Key)
+		})
+		_ = ns.Set("key1", "v", "")
+		_ = c.Set("chain2:key1", "v", "")
+		_ = ns.Delete("key1")
+		_ = c.Delete("chain2:key1")
+		assert.Equal(t, 2, len(getEvents()))
+		ns.RemoveListener(nsId)
+	})
+
+	t.Run("RemoveListener", func(t *testing.T) {
+		c.RemoveListener(id)
+		_ = c.Set("key", "v", "")
+		_ = c.Delete("key")
+		assert.Equal(t, 0, len(getEvents()))
+	})
+}
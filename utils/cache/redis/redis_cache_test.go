@@ -0,0 +1,327 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package redis
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test/assert"
+	"github.com/rulego/rulego/utils/cache"
+)
+
+func newTestCache(t *testing.T) (*RedisCache, *miniredis.Miniredis) {
+	s := miniredis.RunT(t)
+	c, err := NewRedisCache(context.Background(), Config{Server: s.Addr()})
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		_ = c.Close()
+	})
+	return c, s
+}
+
+func TestNewRedisCache(t *testing.T) {
+	s := miniredis.RunT(t)
+	c, err := NewRedisCache(context.Background(), Config{Server: "redis://" + s.Addr() + "/1", PoolSize: 2})
+	assert.Nil(t, err)
+	assert.Nil(t, c.Set("a", 1, ""))
+	assert.Equal(t, int64(1), c.Get("a"))
+	s.Select(1)
+	assert.True(t, s.Exists("a"))
+	assert.Nil(t, c.Close())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	_, err = NewRedisCache(ctx, Config{Server: "127.0.0.1:1"})
+	assert.NotNil(t, err)
+	_, err = NewRedisCache(ctx, Config{Server: "redis://:abc"})
+	assert.NotNil(t, err)
+}
+
+func TestRedisCache(t *testing.T) {
+	c, s := newTestCache(t)
+
+	t.Run("SetAndGet", func(t *testing.T) {
+		assert.Nil(t, c.Set("str", "value1", ""))
+		assert.Nil(t, c.Set("int", 10, ""))
+		assert.Nil(t, c.Set("float", 1.5, ""))
+		assert.Nil(t, c.Set("map", map[string]interface{}{"a": 1, "b": "x"}, ""))
+		assert.Equal(t, "value1", c.Get("str"))
+		assert.Equal(t, int64(10), c.Get("int"))
+		assert.Equal(t, 1.5, c.Get("float"))
+		assert.Equal(t, map[string]interface{}{"a": int64(1), "b": "x"}, c.Get("map"))
+		assert.Nil(t, c.Get("notFound"))
+		assert.True(t, c.Has("str"))
+		assert.False(t, c.Has("notFound"))
+
+		//其他客户端写入的非JSON数据按字符串返回
+		_ = s.Set("raw", "hello world")
+		assert.Equal(t, "hello world", c.Get("raw"))
+
+		assert.NotNil(t, c.Set("ttl", 1, "abc"))
+		assert.Nil(t, c.Set("ttl", 1, "1m"))
+		assert.Equal(t, time.Minute, s.TTL("ttl"))
+		s.FastForward(2 * time.Minute)
+		assert.False(t, c.Has("ttl"))
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		_ = c.Set("del", 1, "")
+		assert.Nil(t, c.Delete("del"))
+		assert.False(t, c.Has("del"))
+	})
+
+	t.Run("Prefix", func(t *testing.T) {
+		for i := 0; i < 1200; i++ {
+			_ = c.Set("bulk:"+string(rune('a'+i%26))+":"+time.Duration(i).String(), i, "")
+		}
+		_ = c.Set("p*x:1", "a", "")
+		_ = c.Set("p*x:2", "b", "")
+		_ = c.Set("pyx:1", "c", "")
+		_, _ = c.RPush("p*x:list", "a")
+
+		values := c.GetByPrefix("p*x:")
+		assert.Equal(t, map[string]interface{}{"p*x:1": "a", "p*x:2": "b"}, values)
+		assert.Equal(t, 1200, len(c.GetByPrefix("bulk:")))
+
+		assert.Nil(t, c.DeleteByPrefix("bulk:"))
+		assert.Equal(t, 0, len(c.GetByPrefix("bulk:")))
+		assert.Nil(t, c.DeleteByPrefix("p*x:"))
+		assert.False(t, c.Has("p*x:list"))
+		assert.True(t, c.Has("pyx:1"))
+	})
+}
+
+func TestRedisCache_Atomic(t *testing.T) {
+	c, s := newTestCache(t)
+
+	t.Run("Incr", func(t *testing.T) {
+		v, err := c.Incr("counter", 2, "1m")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), v)
+		s.FastForward(30 * time.Second)
+		v, _ = c.Incr("counter", 3, "1h")
+		assert.Equal(t, int64(5), v)
+		assert.Equal(t, 30*time.Second, s.TTL("counter"))
+		v, _ = c.Decr("counter", 1, "")
+		assert.Equal(t, int64(4), v)
+		assert.Equal(t, int64(4), c.Get("counter"))
+
+		_ = c.Set("str", "abc", "")
+		_, err = c.Incr("str", 1, "")
+		assert.Equal(t, types.ErrCacheWrongType, err)
+		_, err = c.Incr("counter", 1, "abc")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("IncrConcurrent", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < 10; j++ {
+					_, _ = c.Incr("concurrent", 1, "")
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int64(200), c.Get("concurrent"))
+	})
+
+	t.Run("CompareAndSet", func(t *testing.T) {
+		ok, err := c.CompareAndSet("cas", nil, "a", "")
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		ok, _ = c.CompareAndSet("cas", nil, "b", "")
+		assert.False(t, ok)
+		ok, _ = c.CompareAndSet("cas", "b", "c", "")
+		assert.False(t, ok)
+		ok, _ = c.CompareAndSet("cas", "a", 1, "1m")
+		assert.True(t, ok)
+		ok, _ = c.CompareAndSet("cas", "1", 2, "")
+		assert.True(t, ok)
+		assert.Equal(t, int64(2), c.Get("cas"))
+		ok, _ = c.CompareAndSet("notFound", 1, 2, "")
+		assert.False(t, ok)
+		_, err = c.CompareAndSet("cas", 2, 3, "abc")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("CompareAndSetConcurrent", func(t *testing.T) {
+		_ = c.Set("version", 0, "")
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var success int
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if ok, _ := c.CompareAndSet("version", 0, 1, ""); ok {
+					mu.Lock()
+					success++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 1, success)
+	})
+
+	t.Run("SetIfAbsent", func(t *testing.T) {
+		ok, _ := c.SetIfAbsent("lock", "owner1", "10s")
+		assert.True(t, ok)
+		ok, _ = c.SetIfAbsent("lock", "owner2", "10s")
+		assert.False(t, ok)
+		s.FastForward(11 * time.Second)
+		ok, _ = c.SetIfAbsent("lock", "owner2", "")
+		assert.True(t, ok)
+		assert.Equal(t, "owner2", c.Get("lock"))
+	})
+
+	t.Run("ExpireAndTTL", func(t *testing.T) {
+		_, ok := c.TTL("notFound")
+		assert.False(t, ok)
+		ok, _ = c.Expire("notFound", "1m")
+		assert.False(t, ok)
+		ok, _ = c.Expire("notFound", "")
+		assert.False(t, ok)
+
+		_ = c.Set("expire", "v", "")
+		ttl, ok := c.TTL("expire")
+		assert.True(t, ok)
+		assert.Equal(t, time.Duration(0), ttl)
+		ok, err := c.Expire("expire", "1m")
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		ttl, _ = c.TTL("expire")
+		assert.Equal(t, time.Minute, ttl)
+		ok, _ = c.Expire("expire", "")
+		assert.True(t, ok)
+		ok, _ = c.Expire("expire", "")
+		assert.True(t, ok)
+		ttl, _ = c.TTL("expire")
+		assert.Equal(t, time.Duration(0), ttl)
+		_, err = c.Expire("expire", "abc")
+		assert.NotNil(t, err)
+	})
+}
+
+func TestRedisCache_Collections(t *testing.T) {
+	c, _ := newTestCache(t)
+
+	t.Run("List", func(t *testing.T) {
+		n, err := c.RPush("list", "b", 1)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), n)
+		n, _ = c.LPush("list", "a", "z")
+		assert.Equal(t, int64(4), n)
+		items, _ := c.LRange("list", 0, -1)
+		assert.Equal(t, []interface{}{"z", "a", "b", int64(1)}, items)
+		items, _ = c.LRange("list", -2, 100)
+		assert.Equal(t, []interface{}{"b", int64(1)}, items)
+		first, _ := c.LPop("list")
+		assert.Equal(t, "z", first)
+		last, _ := c.RPop("list")
+		assert.Equal(t, int64(1), last)
+		length, _ := c.LLen("list")
+		assert.Equal(t, int64(2), length)
+		v, err := c.LPop("empty")
+		assert.Nil(t, err)
+		assert.Nil(t, v)
+	})
+
+	t.Run("Set", func(t *testing.T) {
+		n, _ := c.SAdd("set", "b", "a", "b")
+		assert.Equal(t, int64(2), n)
+		members, _ := c.SMembers("set")
+		assert.Equal(t, []string{"a", "b"}, members)
+		ok, _ := c.SIsMember("set", "a")
+		assert.True(t, ok)
+		n, _ = c.SRem("set", "a", "c")
+		assert.Equal(t, int64(1), n)
+		ok, _ = c.SIsMember("set", "a")
+		assert.False(t, ok)
+	})
+
+	t.Run("Hash", func(t *testing.T) {
+		assert.Nil(t, c.HSet("hash", "name", "lala"))
+		assert.Nil(t, c.HSet("hash", "age", 18))
+		v, _ := c.HGet("hash", "age")
+		assert.Equal(t, int64(18), v)
+		v, err := c.HGet("hash", "notFound")
+		assert.Nil(t, err)
+		assert.Nil(t, v)
+		all, _ := c.HGetAll("hash")
+		assert.Equal(t, map[string]interface{}{"name": "lala", "age": int64(18)}, all)
+		n, _ := c.HDel("hash", "name", "notFound")
+		assert.Equal(t, int64(1), n)
+		all, _ = c.HGetAll("notFound")
+		assert.Equal(t, 0, len(all))
+	})
+
+	t.Run("WrongType", func(t *testing.T) {
+		_ = c.Set("string", "v", "")
+		_, err := c.RPush("string", "a")
+		assert.Equal(t, types.ErrCacheWrongType, err)
+		_, err = c.SAdd("string", "a")
+		assert.Equal(t, types.ErrCacheWrongType, err)
+		assert.Equal(t, types.ErrCacheWrongType, c.HSet("string", "a", 1))
+		_, err = c.Incr("list", 1, "")
+		assert.Equal(t, types.ErrCacheWrongType, err)
+		assert.Nil(t, c.Get("list"))
+	})
+}
+
+func TestRedisCache_Namespace(t *testing.T) {
+	c, s := newTestCache(t)
+	chain := c.WithNamespace("chain1:")
+	wrapped := cache.NewNamespaceCache(c, "chain1:")
+
+	_ = chain.Set("k1", "v1", "")
+	assert.Equal(t, "v1", wrapped.Get("k1"))
+	assert.True(t, s.Exists("chain1:k1"))
+	_ = wrapped.Set("k2", "v2", "")
+	assert.Equal(t, "v2", chain.Get("k2"))
+
+	_ = c.Set("chain2:k1", "other", "")
+	assert.Equal(t, map[string]interface{}{"k1": "v1", "k2": "v2"}, chain.GetByPrefix(""))
+	assert.Equal(t, chain.GetByPrefix(""), wrapped.GetByPrefix(""))
+
+	v, _ := chain.Incr("count", 1, "")
+	assert.Equal(t, int64(1), v)
+	v, _ = wrapped.Incr("count", 1, "")
+	assert.Equal(t, int64(2), v)
+
+	//销毁规则链时删除命名空间下所有key
+	assert.Nil(t, chain.DeleteByPrefix(""))
+	assert.False(t, s.Exists("chain1:k1"))
+	assert.True(t, s.Exists("chain2:k1"))
+
+	//命名空间共享连接，关闭不影响原缓存
+	assert.Nil(t, chain.Close())
+	assert.True(t, c.Has("chain2:k1"))
+
+	other := NewRedisCacheWithClient(c.Client())
+	assert.Equal(t, "other", other.Get("chain2:k1"))
+	assert.Nil(t, other.Close())
+	assert.True(t, c.Has("chain2:k1"))
+}
@@ -0,0 +1,236 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lua
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+var lValueType = reflect.TypeOf((*lua.LValue)(nil)).Elem()
+
+// ToLuaValue 把go值转换成lua值，map和slice转换成table
+func ToLuaValue(L *lua.LState, v interface{}) lua.LValue {
+	switch val := v.(type) {
+	case nil:
+		return lua.LNil
+	case lua.LValue:
+		return val
+	case bool:
+		return lua.LBool(val)
+	case string:
+		return lua.LString(val)
+	case []byte:
+		return lua.LString(val)
+	case int:
+		return lua.LNumber(val)
+	case int64:
+		return lua.LNumber(val)
+	case float64:
+		return lua.LNumber(val)
+	case map[string]interface{}:
+		t := L.CreateTable(0, len(val))
+		for k, item := range val {
+			t.RawSetString(k, ToLuaValue(L, item))
+		}
+		return t
+	case map[string]string:
+		t := L.CreateTable(0, len(val))
+		for k, item := range val {
+			t.RawSetString(k, lua.LString(item))
+		}
+		return t
+	case []interface{}:
+		t := L.CreateTable(len(val), 0)
+		for _, item := range val {
+			t.Append(ToLuaValue(L, item))
+		}
+		return t
+	case error:
+		return lua.LString(val.Error())
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return lua.LNumber(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return lua.LNumber(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return lua.LNumber(rv.Float())
+	case reflect.String:
+		return lua.LString(rv.String())
+	case reflect.Bool:
+		return lua.LBool(rv.Bool())
+	case reflect.Slice, reflect.Array:
+		t := L.CreateTable(rv.Len(), 0)
+		for i := 0; i < rv.Len(); i++ {
+			t.Append(ToLuaValue(L, rv.Index(i).Interface()))
+		}
+		return t
+	case reflect.Map:
+		t := L.CreateTable(0, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			t.RawSet(ToLuaValue(L, iter.Key().Interface()), ToLuaValue(L, iter.Value().Interface()))
+		}
+		return t
+	case reflect.Func:
+		return ToLuaFunction(L, v)
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return lua.LNil
+		}
+	}
+	ud := L.NewUserData()
+	ud.Value = v
+	return ud
+}
+
+// ToGoValue 把lua值转换成go值
+// 整数转换成int64，其他数值转换成float64；
+// 连续整数下标的table转换成[]interface{}，其他table转换成map[string]interface{}
+func ToGoValue(v lua.LValue) interface{} {
+	switch val := v.(type) {
+	case *lua.LNilType:
+		return nil
+	case lua.LBool:
+		return bool(val)
+	case lua.LString:
+		return string(val)
+	case lua.LNumber:
+		f := float64(val)
+		if f == math.Trunc(f) && f >= math.MinInt64 && f <= math.MaxInt64 {
+			return int64(f)
+		}
+		return f
+	case *lua.LTable:
+		if n := val.MaxN(); n > 0 && n == tableLen(val) {
+			arr := make([]interface{}, 0, n)
+			for i := 1; i <= n; i++ {
+				arr = append(arr, ToGoValue(val.RawGetInt(i)))
+			}
+			return arr
+		}
+		m := make(map[string]interface{})
+		val.ForEach(func(key lua.LValue, value lua.LValue) {
+			m[key.String()] = ToGoValue(value)
+		})
+		return m
+	case *lua.LUserData:
+		return val.Value
+	default:
+		return v
+	}
+}
+
+// ToLuaFunction 把go函数转换成lua函数，如果不是函数返回nil
+// 参数按照go函数的参数类型转换，返回值依次压栈，最后一个返回值如果是error并且不为空则抛出lua错误
+func ToLuaFunction(L *lua.LState, fn interface{}) *lua.LFunction {
+	switch f := fn.(type) {
+	case *lua.LFunction:
+		return f
+	case lua.LGFunction:
+		return L.NewFunction(f)
+	case func(*lua.LState) int:
+		return L.NewFunction(f)
+	}
+	rv := reflect.ValueOf(fn)
+	if rv.Kind() != reflect.Func {
+		return nil
+	}
+	ft := rv.Type()
+	return L.NewFunction(func(L *lua.LState) int {
+		top := L.GetTop()
+		numIn := ft.NumIn()
+		var args []reflect.Value
+		for i := 0; i < numIn; i++ {
+			if ft.IsVariadic() && i == numIn-1 {
+				elemType := ft.In(i).Elem()
+				for j := i + 1; j <= top; j++ {
+					args = append(args, toReflectValue(L, L.Get(j), elemType))
+				}
+				break
+			}
+			args = append(args, toReflectValue(L, L.Get(i+1), ft.In(i)))
+		}
+		results := rv.Call(args)
+		if n := len(results); n > 0 && ft.Out(n-1) == reflect.TypeOf((*error)(nil)).Elem() {
+			if err, _ := results[n-1].Interface().(error); err != nil {
+				L.RaiseError("%s", err.Error())
+				return 0
+			}
+			results = results[:n-1]
+		}
+		for _, r := range results {
+			L.Push(ToLuaValue(L, r.Interface()))
+		}
+		return len(results)
+	})
+}
+
+// toReflectValue 把lua值转换成指定类型的go值，无法转换则抛出lua错误
+func toReflectValue(L *lua.LState, v lua.LValue, t reflect.Type) reflect.Value {
+	if t.Implements(lValueType) && reflect.TypeOf(v).AssignableTo(t) {
+		return reflect.ValueOf(v)
+	}
+	goValue := ToGoValue(v)
+	if goValue == nil {
+		return reflect.Zero(t)
+	}
+	rv := reflect.ValueOf(goValue)
+	if rv.Type().AssignableTo(t) {
+		return rv
+	}
+	if t.Kind() == reflect.String {
+		return reflect.ValueOf(fmt.Sprint(goValue)).Convert(t)
+	}
+	if rv.Type().ConvertibleTo(t) && rv.Kind() != reflect.String {
+		return rv.Convert(t)
+	}
+	if t.Kind() == reflect.Slice {
+		if arr, ok := goValue.([]interface{}); ok {
+			s := reflect.MakeSlice(t, 0, len(arr))
+			for _, item := range arr {
+				s = reflect.Append(s, toReflectValue(L, ToLuaValue(L, item), t.Elem()))
+			}
+			return s
+		}
+	}
+	if t.Kind() == reflect.Map && t.Key().Kind() == reflect.String {
+		if m, ok := goValue.(map[string]interface{}); ok {
+			mv := reflect.MakeMapWithSize(t, len(m))
+			for k, item := range m {
+				mv.SetMapIndex(reflect.ValueOf(k).Convert(t.Key()), toReflectValue(L, ToLuaValue(L, item), t.Elem()))
+			}
+			return mv
+		}
+	}
+	L.RaiseError("cannot convert %s to %s", v.Type().String(), t.String())
+	return reflect.Zero(t)
+}
+
+// tableLen table元素个数
+func tableLen(t *lua.LTable) int {
+	count := 0
+	t.ForEach(func(lua.LValue, lua.LValue) {
+		count++
+	})
+	return count
+}
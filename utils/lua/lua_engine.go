@@ -0,0 +1,250 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package lua provides Lua execution capabilities for the RuleGo rule engine.
+//
+// This package implements a Lua engine using the pure Go gopher-lua library.
+// It mirrors the js package: scripts are compiled once, executed on pooled
+// Lua states, interrupted when ScriptMaxExecutionTime is exceeded and can
+// call user-defined functions registered in Config.Udf.
+//
+// Key components:
+// - LuaEngine: The main struct representing the Lua engine.
+// - NewLuaEngine: Function to create a new instance of the Lua engine.
+//
+// User-defined functions are registered for Lua when:
+// - types.Script{Type: types.Lua or types.AllScript, Content: string} – Lua source executed in every state.
+// - types.Script{Type: types.Lua or types.AllScript, Content: go func} – exposed as a global Lua function.
+// - a plain go func – exposed as a global Lua function.
+//
+// By default only the base, table, string and math libraries are opened.
+// If the rule config property `load_lua_libs` is "true", all gopher-lua
+// standard libraries (os, io, package, coroutine, channel, debug) are opened.
+package lua
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/rulego/rulego/api/types"
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+const (
+	//GlobalKey  global properties key,call them through the global.xx method
+	GlobalKey = "global"
+	// KeyLoadLuaLibs rule config property key, whether to load all lua standard libraries
+	KeyLoadLuaLibs = "load_lua_libs"
+)
+
+// LuaEngine gopher-lua engine
+type LuaEngine struct {
+	statePool   sync.Pool
+	config      types.Config
+	script      *lua.FunctionProto
+	udfProtos   map[string]*lua.FunctionProto
+	loadAllLibs bool
+	fromVars    map[string]interface{}
+}
+
+// NewLuaEngine Create a new instance of the Lua engine
+func NewLuaEngine(config types.Config, luaScript string, fromVars map[string]interface{}) (*LuaEngine, error) {
+	proto, err := compile("", luaScript)
+	if err != nil {
+		return nil, err
+	}
+	engine := &LuaEngine{
+		config:      config,
+		script:      proto,
+		fromVars:    fromVars,
+		loadAllLibs: config.Properties.GetValue(KeyLoadLuaLibs) == "true",
+	}
+	if err = engine.PreCompileLua(config); err != nil {
+		return nil, err
+	}
+	//Create a state up front, so script errors are reported at init
+	L, err := engine.NewState()
+	if err != nil {
+		return nil, err
+	}
+	engine.statePool.Put(L)
+	return engine, nil
+}
+
+// PreCompileLua Precompiled UDF Lua script
+func (e *LuaEngine) PreCompileLua(config types.Config) error {
+	var udfProtos = make(map[string]*lua.FunctionProto)
+	for k, v := range config.Udf {
+		if script, ok := v.(types.Script); ok && (script.Type == types.Lua || script.Type == types.AllScript) {
+			if c, ok := script.Content.(string); ok {
+				if p, err := compile(k, c); err != nil {
+					return err
+				} else {
+					udfProtos[k] = p
+				}
+			}
+		}
+	}
+	e.udfProtos = udfProtos
+	return nil
+}
+
+// NewState new a lua state,load udf,variables and script
+func (e *LuaEngine) NewState() (*lua.LState, error) {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	e.openLibs(L)
+
+	vars := make(map[string]interface{})
+	for k, v := range e.fromVars {
+		vars[k] = v
+	}
+	if len(e.config.Properties.Values()) != 0 {
+		//Add global properties to the lua state and call them through the global.xx method
+		vars[GlobalKey] = e.config.Properties.Values()
+	}
+	for k, v := range vars {
+		L.SetGlobal(k, ToLuaValue(L, v))
+	}
+	//Add global custom functions to the lua state
+	for k, v := range e.config.Udf {
+		if script, ok := v.(types.Script); ok {
+			if script.Type != types.Lua && script.Type != types.AllScript {
+				continue
+			}
+			if p, ok := e.udfProtos[k]; ok {
+				if err := doProto(L, p); err != nil {
+					e.config.Logger.Printf("parse lua script=" + k + " error,err:" + err.Error())
+				}
+			} else if fn := ToLuaFunction(L, script.Content); fn != nil {
+				funcName := strings.Replace(k, types.Lua+types.ScriptFuncSeparator, "", 1)
+				L.SetGlobal(funcName, fn)
+			}
+		} else if fn := ToLuaFunction(L, v); fn != nil {
+			// parse go func
+			L.SetGlobal(k, fn)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.config.ScriptMaxExecutionTime)
+	L.SetContext(ctx)
+	err := doProto(L, e.script)
+	cancel()
+	L.RemoveContext()
+	if err != nil {
+		L.Close()
+		return nil, err
+	}
+	return L, nil
+}
+
+// Execute Execute lua function
+func (e *LuaEngine) Execute(ctx types.RuleContext, functionName string, argumentList ...interface{}) (out interface{}, err error) {
+	defer func() {
+		if caught := recover(); caught != nil {
+			err = fmt.Errorf("%s", caught)
+		}
+	}()
+	L, err := e.getState()
+	if err != nil {
+		return nil, err
+	}
+	fn := L.GetGlobal(functionName)
+	if fn.Type() != lua.LTFunction {
+		e.statePool.Put(L)
+		return nil, errors.New(functionName + " is not a function")
+	}
+	var params []lua.LValue
+	for _, v := range argumentList {
+		params = append(params, ToLuaValue(L, v))
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), e.config.ScriptMaxExecutionTime)
+	defer cancel()
+	L.SetContext(timeoutCtx)
+	err = L.CallByParam(lua.P{
+		Fn:      fn,
+		NRet:    1,
+		Protect: true,
+	}, params...)
+	L.RemoveContext()
+	if err != nil {
+		//The state may be interrupted in an unknown stack position, discard it
+		L.Close()
+		if timeoutCtx.Err() != nil {
+			return nil, errors.New("execution timeout")
+		}
+		return nil, err
+	}
+	ret := L.Get(-1)
+	L.Pop(1)
+	out = ToGoValue(ret)
+	//Put back to the pool
+	e.statePool.Put(L)
+	return out, nil
+}
+
+// Stop pooled states are released by the garbage collector
+func (e *LuaEngine) Stop() {
+}
+
+func (e *LuaEngine) getState() (*lua.LState, error) {
+	if v := e.statePool.Get(); v != nil {
+		return v.(*lua.LState), nil
+	}
+	return e.NewState()
+}
+
+func (e *LuaEngine) openLibs(L *lua.LState) {
+	if e.loadAllLibs {
+		L.OpenLibs()
+		return
+	}
+	for _, pair := range []struct {
+		name string
+		fn   lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(pair.fn))
+		L.Push(lua.LString(pair.name))
+		L.Call(1, 0)
+	}
+	//Without load_lua_libs, scripts can not load files or modules.
+	//require and module are registered by the base library and need the package library, which is not opened
+	for _, name := range []string{"dofile", "loadfile", "require", "module"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+}
+
+func compile(name, source string) (*lua.FunctionProto, error) {
+	chunk, err := parse.Parse(strings.NewReader(source), name)
+	if err != nil {
+		return nil, err
+	}
+	return lua.Compile(chunk, name)
+}
+
+func doProto(L *lua.LState, proto *lua.FunctionProto) error {
+	L.Push(L.NewFunctionFromProto(proto))
+	return L.PCall(0, 0, nil)
+}
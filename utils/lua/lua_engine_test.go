@@ -0,0 +1,158 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package lua
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rulego/rulego/api/types"
+	"github.com/rulego/rulego/test/assert"
+)
+
+func TestLuaEngine(t *testing.T) {
+	var luaScript = `
+	function Filter(msg, metadata, msgType)
+		return msg.temperature > 50 and metadata.productType == 'test'
+	end
+	function Transform(msg, metadata, msgType)
+		msg.sum = add(1, 5)
+		msg.upper = toUpper(msgType)
+		msg.double = double(msg.temperature)
+		msg.region = vars.region
+		msg.name = global.name
+		msg.list = {1, 2, 3}
+		return msg
+	end
+	function CallError()
+		return mayFail(true)
+	end
+	function Join()
+		return join(',', 'a', 'b', 'c')
+	end
+	function Timeout()
+		while true do end
+	end
+	`
+	config := types.NewConfig()
+	config.ScriptMaxExecutionTime = time.Millisecond * 100
+	config.Properties.PutValue("name", "lala")
+	config.RegisterUdf("add", func(a, b int) int {
+		return a + b
+	})
+	config.RegisterUdf("toUpper", types.Script{
+		Type:    types.Lua,
+		Content: strings.ToUpper,
+	})
+	config.RegisterUdf("double", types.Script{
+		Type:    types.Lua,
+		Content: "function double(v) return v * 2 end",
+	})
+	config.RegisterUdf("mayFail", func(fail bool) (string, error) {
+		if fail {
+			return "", errors.New("udf error")
+		}
+		return "ok", nil
+	})
+	config.RegisterUdf("join", func(sep string, items ...string) string {
+		return strings.Join(items, sep)
+	})
+	//js脚本不会注册到lua
+	config.RegisterUdf("jsFunc", types.Script{
+		Type:    types.Js,
+		Content: "function jsFunc(){}",
+	})
+
+	engine, err := NewLuaEngine(config, luaScript, map[string]interface{}{
+		"vars": map[string]string{"region": "east"},
+	})
+	assert.Nil(t, err)
+	defer engine.Stop()
+
+	msg := map[string]interface{}{"temperature": 60}
+	out, err := engine.Execute(nil, "Filter", msg, map[string]string{"productType": "test"}, "TELEMETRY")
+	assert.Nil(t, err)
+	assert.Equal(t, true, out)
+
+	out, err = engine.Execute(nil, "Transform", msg, map[string]string{}, "telemetry")
+	assert.Nil(t, err)
+	result := out.(map[string]interface{})
+	assert.Equal(t, int64(6), result["sum"])
+	assert.Equal(t, "TELEMETRY", result["upper"])
+	assert.Equal(t, int64(120), result["double"])
+	assert.Equal(t, "east", result["region"])
+	assert.Equal(t, "lala", result["name"])
+	assert.Equal(t, []interface{}{int64(1), int64(2), int64(3)}, result["list"])
+
+	out, err = engine.Execute(nil, "Join")
+	assert.Nil(t, err)
+	assert.Equal(t, "a,b,c", out)
+
+	_, err = engine.Execute(nil, "CallError")
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "udf error"))
+
+	_, err = engine.Execute(nil, "NotFound")
+	assert.Equal(t, "NotFound is not a function", err.Error())
+
+	start := time.Now()
+	_, err = engine.Execute(nil, "Timeout")
+	assert.Equal(t, "execution timeout", err.Error())
+	assert.True(t, time.Since(start) < time.Second)
+
+	//超时后引擎仍可用
+	out, err = engine.Execute(nil, "Filter", msg, map[string]string{"productType": "test"}, "TELEMETRY")
+	assert.Nil(t, err)
+	assert.Equal(t, true, out)
+}
+
+func TestLuaEngineInitError(t *testing.T) {
+	config := types.NewConfig()
+	_, err := NewLuaEngine(config, "function Filter( end", nil)
+	assert.NotNil(t, err)
+
+	config.RegisterUdf("bad", types.Script{
+		Type:    types.Lua,
+		Content: "function bad( end",
+	})
+	_, err = NewLuaEngine(config, "function Filter() end", nil)
+	assert.NotNil(t, err)
+}
+
+func TestLuaEngineLibs(t *testing.T) {
+	config := types.NewConfig()
+	engine, err := NewLuaEngine(config, "function HasOs() return os ~= nil end", nil)
+	assert.Nil(t, err)
+	out, err := engine.Execute(nil, "HasOs")
+	assert.Nil(t, err)
+	assert.Equal(t, false, out)
+
+	//默认不能加载模块和文件
+	engine, err = NewLuaEngine(config, "function CanLoad() return package ~= nil or require ~= nil or dofile ~= nil or loadfile ~= nil end", nil)
+	assert.Nil(t, err)
+	out, err = engine.Execute(nil, "CanLoad")
+	assert.Nil(t, err)
+	assert.Equal(t, false, out)
+
+	config.Properties.PutValue(KeyLoadLuaLibs, "true")
+	engine, err = NewLuaEngine(config, "function HasOs() return os ~= nil end", nil)
+	assert.Nil(t, err)
+	out, err = engine.Execute(nil, "HasOs")
+	assert.Nil(t, err)
+	assert.Equal(t, true, out)
+}
@@ -0,0 +1,194 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package protoutil provides helpers for working with protobuf descriptors at runtime,
+// so that gRPC services can be served or called without generated code.
+//
+// Descriptors are usually loaded from a descriptor set produced by protoc:
+//
+//	protoc --include_imports --descriptor_set_out=service.pb service.proto
+//
+// Messages are created with dynamicpb and converted from and to JSON with protojson.
+package protoutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	//register the well-known types, so descriptor sets built without --include_imports can be resolved
+	_ "google.golang.org/protobuf/types/known/anypb"
+	_ "google.golang.org/protobuf/types/known/durationpb"
+	_ "google.golang.org/protobuf/types/known/emptypb"
+	_ "google.golang.org/protobuf/types/known/fieldmaskpb"
+	_ "google.golang.org/protobuf/types/known/structpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+	_ "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// LoadDescriptorSet reads one or more serialized FileDescriptorSet files and
+// builds a registry of their file descriptors.
+func LoadDescriptorSet(paths ...string) (*protoregistry.Files, error) {
+	var fds []*descriptorpb.FileDescriptorProto
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var set descriptorpb.FileDescriptorSet
+		if err = proto.Unmarshal(data, &set); err != nil {
+			return nil, fmt.Errorf("invalid descriptor set %s: %w", path, err)
+		}
+		fds = append(fds, set.GetFile()...)
+	}
+	return NewFiles(fds)
+}
+
+// NewFiles builds a registry from file descriptor protos given in any order.
+// Dependencies are resolved against the given files first and then against
+// protoregistry.GlobalFiles, so well-known types do not need to be included.
+func NewFiles(fds []*descriptorpb.FileDescriptorProto) (*protoregistry.Files, error) {
+	files := new(protoregistry.Files)
+	r := &resolver{files: files}
+	pending := fds
+	for len(pending) > 0 {
+		var next []*descriptorpb.FileDescriptorProto
+		var lastErr error
+		for _, fdp := range pending {
+			if _, err := files.FindFileByPath(fdp.GetName()); err == nil {
+				continue
+			}
+			fd, err := protodesc.NewFile(fdp, r)
+			if err != nil {
+				//dependencies may come later in the list
+				next = append(next, fdp)
+				lastErr = err
+				continue
+			}
+			if err = files.RegisterFile(fd); err != nil {
+				return nil, err
+			}
+		}
+		if len(next) == len(pending) {
+			return nil, lastErr
+		}
+		pending = next
+	}
+	return files, nil
+}
+
+// resolver looks up the files being built first, then the global registry.
+type resolver struct {
+	files *protoregistry.Files
+}
+
+func (r *resolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	if fd, err := r.files.FindFileByPath(path); err == nil {
+		return fd, nil
+	}
+	return protoregistry.GlobalFiles.FindFileByPath(path)
+}
+
+func (r *resolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if d, err := r.files.FindDescriptorByName(name); err == nil {
+		return d, nil
+	}
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
+}
+
+// DescriptorResolver finds descriptors by their full name, *protoregistry.Files satisfies it.
+type DescriptorResolver interface {
+	FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error)
+}
+
+// ParseMethod splits a full method name such as "/pkg.Service/Method" or
+// "pkg.Service/Method" into the service and method names.
+func ParseMethod(fullMethod string) (service string, method string, err error) {
+	fullMethod = strings.TrimPrefix(strings.TrimSpace(fullMethod), "/")
+	index := strings.LastIndex(fullMethod, "/")
+	if index <= 0 || index == len(fullMethod)-1 {
+		return "", "", fmt.Errorf("invalid method name: %s, the format is package.Service/Method", fullMethod)
+	}
+	return fullMethod[:index], fullMethod[index+1:], nil
+}
+
+// FindMethod finds the method descriptor of a full method name.
+func FindMethod(resolver DescriptorResolver, fullMethod string) (protoreflect.MethodDescriptor, error) {
+	service, method, err := ParseMethod(fullMethod)
+	if err != nil {
+		return nil, err
+	}
+	d, err := resolver.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("service %s not found: %w", service, err)
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", service)
+	}
+	md := sd.Methods().ByName(protoreflect.Name(method))
+	if md == nil {
+		return nil, fmt.Errorf("method %s not found in service %s", method, service)
+	}
+	return md, nil
+}
+
+// FullMethod returns the gRPC path of the method, for example: /pkg.Service/Method
+func FullMethod(md protoreflect.MethodDescriptor) string {
+	return "/" + string(md.Parent().FullName()) + "/" + string(md.Name())
+}
+
+// NewMessage creates a dynamic message of the given type from JSON, empty data creates an empty message.
+func NewMessage(md protoreflect.MessageDescriptor, data []byte) (*dynamicpb.Message, error) {
+	msg := dynamicpb.NewMessage(md)
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return msg, nil
+	}
+	if err := protojson.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// ToJson converts the message to compact JSON.
+// useProtoNames uses the field names in the proto file instead of lowerCamelCase names,
+// emitUnpopulated outputs fields with default values.
+func ToJson(msg proto.Message, useProtoNames, emitUnpopulated bool) ([]byte, error) {
+	if msg == nil {
+		return nil, errors.New("message is nil")
+	}
+	data, err := protojson.MarshalOptions{UseProtoNames: useProtoNames, EmitUnpopulated: emitUnpopulated}.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	//protojson output is deliberately unstable, it may contain random spaces
+	var buf bytes.Buffer
+	if err = json.Compact(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright 2025 The RuleGo Authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protoutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rulego/rulego/test/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const descriptorSet = "../../testdata/grpc/greeter.pb"
+
+func TestLoadDescriptorSet(t *testing.T) {
+	files, err := LoadDescriptorSet(descriptorSet)
+	assert.Nil(t, err)
+	md, err := FindMethod(files, "/rulego.test.Greeter/SayHello")
+	assert.Nil(t, err)
+	assert.Equal(t, "/rulego.test.Greeter/SayHello", FullMethod(md))
+	assert.False(t, md.IsStreamingServer())
+	md, err = FindMethod(files, "rulego.test.Greeter/StreamHello")
+	assert.Nil(t, err)
+	assert.True(t, md.IsStreamingServer())
+
+	for _, method := range []string{"", "Greeter", "rulego.test.Greeter/", "rulego.test.Nothing/SayHello", "rulego.test.Greeter/Nothing", "rulego.test.HelloRequest/SayHello"} {
+		_, err = FindMethod(files, method)
+		assert.NotNil(t, err)
+	}
+
+	_, err = LoadDescriptorSet("not_found.pb")
+	assert.NotNil(t, err)
+	invalid := filepath.Join(t.TempDir(), "invalid.pb")
+	assert.Nil(t, os.WriteFile(invalid, []byte("invalid"), 0644))
+	_, err = LoadDescriptorSet(invalid)
+	assert.NotNil(t, err)
+}
+
+func TestNewFiles(t *testing.T) {
+	data, err := os.ReadFile(descriptorSet)
+	assert.Nil(t, err)
+	var set descriptorpb.FileDescriptorSet
+	assert.Nil(t, proto.Unmarshal(data, &set))
+
+	//依赖的文件在后面，并且标准类型从全局注册表解析
+	var fds []*descriptorpb.FileDescriptorProto
+	for i := len(set.File) - 1; i >= 0; i-- {
+		if set.File[i].GetName() != "google/protobuf/timestamp.proto" {
+			fds = append(fds, set.File[i])
+		}
+	}
+	files, err := NewFiles(fds)
+	assert.Nil(t, err)
+	_, err = files.FindFileByPath("greeter.proto")
+	assert.Nil(t, err)
+
+	//依赖不存在
+	fdp := proto.Clone(set.File[len(set.File)-1]).(*descriptorpb.FileDescriptorProto)
+	fdp.Dependency = []string{"not_found.proto"}
+	_, err = NewFiles([]*descriptorpb.FileDescriptorProto{fdp})
+	assert.NotNil(t, err)
+}
+
+func TestJson(t *testing.T) {
+	files, err := LoadDescriptorSet(descriptorSet)
+	assert.Nil(t, err)
+	md, err := FindMethod(files, "rulego.test.Greeter/SayHello")
+	assert.Nil(t, err)
+
+	msg, err := NewMessage(md.Input(), []byte(`{"name":"lala","count":2}`))
+	assert.Nil(t, err)
+	data, err := ToJson(msg, false, false)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"name":"lala","count":2}`, string(data))
+
+	msg, err = NewMessage(md.Output(), nil)
+	assert.Nil(t, err)
+	data, err = ToJson(msg, true, true)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"message":"","index":0,"time":null}`, string(data))
+
+	msg, err = NewMessage(md.Output(), []byte(`{"message":"hi","time":"2025-01-01T00:00:00Z"}`))
+	assert.Nil(t, err)
+	data, err = ToJson(msg, false, false)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"message":"hi","time":"2025-01-01T00:00:00Z"}`, string(data))
+
+	_, err = NewMessage(md.Input(), []byte(`{"unknown":1}`))
+	assert.NotNil(t, err)
+	_, err = ToJson(nil, false, false)
+	assert.NotNil(t, err)
+}